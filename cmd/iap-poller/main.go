@@ -1,16 +1,24 @@
 package main
 
 import (
+	"context"
 	_ "embed"
 	"flag"
 	"fmt"
 	"github.com/FTChinese/go-rest/chrono"
 	"github.com/FTChinese/subscription-api/internal/app/poll"
+	"github.com/FTChinese/subscription-api/pkg/background"
 	"github.com/FTChinese/subscription-api/pkg/config"
 	"github.com/FTChinese/subscription-api/pkg/db"
+	"github.com/FTChinese/subscription-api/pkg/health"
+	"github.com/FTChinese/subscription-api/pkg/metrics"
 	"github.com/go-co-op/gocron"
+	"go.uber.org/zap"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 )
 
@@ -22,11 +30,13 @@ var (
 	build      string
 	production bool // Command line argument. Determine which db to use: true use production mysql, false use localhost.
 	run        bool
+	adminPort  string // Serves health checks if set.
 )
 
 func init() {
 	flag.BoolVar(&production, "production", false, "Connect to production MySQL database if present. Default to localhost.")
 	flag.BoolVar(&run, "run", false, "Run immediately")
+	flag.StringVar(&adminPort, "admin-port", "", "Serve /__health, /__ready and /metrics on this port if set")
 	var v = flag.Bool("v", false, "print current version")
 
 	flag.Parse()
@@ -39,7 +49,7 @@ func init() {
 	config.MustSetupViper([]byte(tomlConfig))
 }
 
-func task() {
+func task(ctx context.Context) {
 	log.Printf("Starting iap polling job at %s", time.Now().Format(time.RFC3339))
 
 	logger := config.MustGetLogger(production)
@@ -47,7 +57,7 @@ func task() {
 
 	poller := poll.NewIAPPoller(myDB, production, logger)
 
-	err := poller.Start(false)
+	err := poller.Start(ctx, false)
 	if err != nil {
 		log.Println(err)
 	}
@@ -56,22 +66,84 @@ func task() {
 }
 
 func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger := config.MustGetLogger(production)
+
 	log.Println("Launching IAP poller...")
+	var adminSrv *http.Server
+	if adminPort != "" {
+		adminSrv = serveHealth(db.MustNewMyDBs(), logger)
+	}
 
 	if run {
-		task()
+		task(ctx)
 		return
 	}
 
+	// Jobs run in the scheduler's goroutine. Track them so that
+	// shutdown waits for a poll in progress.
+	jobs := background.NewRunner(logger)
+
 	s := gocron.NewScheduler(chrono.TZShanghai)
 	_, err := s.Every(1).
 		Day().
 		At("00:00").
-		Do(task)
+		Do(func() {
+			jobs.Go(func() {
+				task(ctx)
+			})
+		})
 
 	if err != nil {
 		panic(err)
 	}
 
-	s.StartBlocking()
+	s.StartAsync()
+
+	<-ctx.Done()
+	stop()
+	log.Println("Shutting down...")
+	s.Stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.MustShutdownConfig().Timeout())
+	defer cancel()
+	if err := jobs.Wait(shutdownCtx); err != nil {
+		log.Printf("Polling job not finished: %s", err)
+	}
+	// Stopped last so that the job in progress is still
+	// observable while draining.
+	if adminSrv != nil {
+		_ = adminSrv.Shutdown(shutdownCtx)
+	}
+}
+
+// serveHealth exposes the same checks as the API server, and
+// poller counters, so that the scheduler process could be
+// monitored. The returned server should be shut down on exit.
+func serveHealth(myDBs db.ReadWriteMyDBs, logger *zap.Logger) *http.Server {
+	cfg := config.MustHealthConfig()
+	h := health.New(cfg.Timeout(), health.MySQL(myDBs)...).
+		With(health.Optional(cfg)...).
+		WithLogger(logger)
+
+	mux := http.NewServeMux()
+	mux.Handle("/", h.Handler())
+	mux.Handle("/metrics", metrics.Handler())
+
+	srv := &http.Server{
+		Addr:    ":" + adminPort,
+		Handler: mux,
+	}
+
+	go func() {
+		log.Printf("Health checks and metrics on port %s", adminPort)
+		err := srv.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			log.Println(err)
+		}
+	}()
+
+	return srv
 }
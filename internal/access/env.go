@@ -12,30 +12,65 @@ Authorization: Bearer <token>
 package access
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
 	"time"
 
+	"github.com/FTChinese/subscription-api/pkg/cachestore"
+	"github.com/FTChinese/subscription-api/pkg/config"
 	"github.com/FTChinese/subscription-api/pkg/db"
-	"github.com/patrickmn/go-cache"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 type Env struct {
-	cache   *cache.Cache
+	cache   cachestore.Store
 	gormDBs db.MultiGormDBs
+	// Scopes of tokens saved without any, keyed by token.
+	legacy map[string]Scopes
+	// How long a rotated token keeps working.
+	rotationGrace time.Duration
+	logger        *zap.Logger
 }
 
-func NewEnv(dbs db.MultiGormDBs) Env {
+// NewEnv uses the shared cache so that a token revoked on
+// one instance stops working on all of them.
+// It panics if a legacy token is granted unknown scopes.
+func NewEnv(dbs db.MultiGormDBs, c cachestore.Store, legacy []config.LegacyToken, rotation config.TokenRotationConfig, logger *zap.Logger) Env {
+	grants := make(map[string]Scopes, len(legacy))
+	for _, l := range legacy {
+		var scopes Scopes
+		for _, v := range l.Scopes {
+			scopes = append(scopes, Scope(v))
+		}
+		if v := scopes.Invalid(); v != "" {
+			panic("unknown scope " + string(v) + " granted to legacy token")
+		}
+		grants[strings.ToLower(l.Token)] = scopes
+	}
+
 	return Env{
-		// Default expiration 24 hours, and purges the expired items every hour.
-		cache:   cache.New(24*time.Hour, 1*time.Hour),
-		gormDBs: dbs,
+		cache:         c,
+		gormDBs:       dbs,
+		legacy:        grants,
+		rotationGrace: rotation.Grace(),
+		logger:        logger,
 	}
 }
 
+// tokenCacheKey does not keep tokens in plain text in Redis.
+func tokenCacheKey(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return "token:" + hex.EncodeToString(h[:])
+}
+
 // Load tries to load an access token from cache first, then
 // retrieve from db if not found in cache.
 func (env Env) Load(token string) (OAuth, error) {
 	if acc, ok := env.loadCachedToken(token); ok {
-		return acc, nil
+		return env.withLegacyScopes(token, acc), nil
 	}
 
 	acc, err := env.retrieveFromDB(token)
@@ -45,20 +80,31 @@ func (env Env) Load(token string) (OAuth, error) {
 
 	env.cacheToken(token, acc)
 
-	return acc, nil
+	return env.withLegacyScopes(token, acc), nil
 }
 
-func (env Env) loadCachedToken(token string) (OAuth, bool) {
-	x, found := env.cache.Get(token)
-	if !found {
-		return OAuth{}, false
+// withLegacyScopes grants configured scopes to a token
+// created before scopes were introduced.
+func (env Env) withLegacyScopes(token string, acc OAuth) OAuth {
+	if len(acc.Scopes) != 0 {
+		return acc
 	}
 
-	if access, ok := x.(OAuth); ok {
-		return access, true
+	if scopes, ok := env.legacy[strings.ToLower(token)]; ok {
+		acc.Scopes = scopes
+	}
+
+	return acc
+}
+
+func (env Env) loadCachedToken(token string) (OAuth, bool) {
+	var access OAuth
+	err := env.cache.Get(context.Background(), tokenCacheKey(token), &access)
+	if err != nil {
+		return OAuth{}, false
 	}
 
-	return OAuth{}, false
+	return access, true
 }
 
 func (env Env) retrieveFromDB(token string) (OAuth, error) {
@@ -74,5 +120,96 @@ func (env Env) retrieveFromDB(token string) (OAuth, error) {
 }
 
 func (env Env) cacheToken(token string, access OAuth) {
-	env.cache.Set(token, access, cache.DefaultExpiration)
+	err := env.cache.Set(context.Background(), tokenCacheKey(token), access)
+	if err != nil {
+		env.logger.Error("Failed to cache access token", zap.Error(err))
+	}
+}
+
+func (env Env) uncacheToken(token string) {
+	err := env.cache.Delete(context.Background(), tokenCacheKey(token))
+	if err != nil {
+		env.logger.Error("Failed to invalidate access token", zap.Error(err))
+	}
+}
+
+// List shows all tokens, newest first.
+func (env Env) List() ([]OAuth, error) {
+	var list []OAuth
+	err := env.gormDBs.Read.
+		Order("created_utc DESC").
+		Find(&list).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (env Env) RetrieveByID(id int64) (OAuth, error) {
+	var o OAuth
+	err := env.gormDBs.Read.First(&o, "id = ?", id).Error
+	if err != nil {
+		return o, err
+	}
+
+	return o, nil
+}
+
+func (env Env) Create(o OAuth) (OAuth, error) {
+	err := env.gormDBs.Write.Create(&o).Error
+	if err != nil {
+		return o, err
+	}
+
+	return o, nil
+}
+
+// Revoke deactivates a token.
+func (env Env) Revoke(o OAuth) error {
+	err := env.gormDBs.Write.
+		Model(&OAuth{}).
+		Where("id = ?", o.ID).
+		Updates(map[string]interface{}{
+			"is_active":   false,
+			"updated_utc": o.UpdatedAt,
+		}).
+		Error
+	if err != nil {
+		return err
+	}
+
+	env.uncacheToken(o.Token.String())
+
+	return nil
+}
+
+// Rotate saves the replacement token in a transaction with
+// the old one, which is kept working until the configured
+// grace period ends so that its client could switch over.
+func (env Env) Rotate(current OAuth, replacement OAuth) (OAuth, error) {
+	old := current.Retired(env.rotationGrace)
+
+	err := env.gormDBs.Write.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&replacement).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&OAuth{}).
+			Where("id = ?", old.ID).
+			Updates(map[string]interface{}{
+				"is_active":   old.Active,
+				"retires_utc": old.RetiresAt,
+				"updated_utc": old.UpdatedAt,
+			}).
+			Error
+	})
+	if err != nil {
+		return replacement, err
+	}
+
+	env.uncacheToken(old.Token.String())
+
+	return replacement, nil
 }
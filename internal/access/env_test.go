@@ -14,8 +14,10 @@ package access
 import (
 	"testing"
 
+	"github.com/FTChinese/subscription-api/pkg/cachestore"
+	"github.com/FTChinese/subscription-api/pkg/config"
 	"github.com/FTChinese/subscription-api/pkg/db"
-	"github.com/patrickmn/go-cache"
+	"go.uber.org/zap"
 )
 
 func TestEnv_retrieveFromDB(t *testing.T) {
@@ -23,7 +25,7 @@ func TestEnv_retrieveFromDB(t *testing.T) {
 
 	type fields struct {
 		dbs     db.ReadWriteMyDBs
-		cache   *cache.Cache
+		cache   cachestore.Store
 		gormDBs db.MultiGormDBs
 	}
 	type args struct {
@@ -66,3 +68,27 @@ func TestEnv_retrieveFromDB(t *testing.T) {
 		})
 	}
 }
+
+func TestEnv_withLegacyScopes(t *testing.T) {
+	env := NewEnv(db.MultiGormDBs{}, nil, []config.LegacyToken{
+		{
+			Token:  "9BCFAFE3C1CC3883F16008452D2A66E8F4A320F3",
+			Scopes: []string{"reader", "cms:token"},
+		},
+	}, config.TokenRotationConfig{}, zap.NewNop())
+
+	got := env.withLegacyScopes("9bcfafe3c1cc3883f16008452d2a66e8f4a320f3", OAuth{})
+	if !got.GrantedScopes().Has(ScopeTokenAdmin) {
+		t.Errorf("legacy token scopes = %v", got.Scopes)
+	}
+
+	got = env.withLegacyScopes("9bcfafe3c1cc3883f16008452d2a66e8f4a320f3", OAuth{Scopes: Scopes{ScopeReader}})
+	if got.GrantedScopes().Has(ScopeTokenAdmin) {
+		t.Error("saved scopes should not be overridden")
+	}
+
+	got = env.withLegacyScopes("unknown", OAuth{})
+	if !got.GrantedScopes().Has(ScopeReader) || got.GrantedScopes().Has(ScopeTokenAdmin) {
+		t.Errorf("unlisted token scopes = %v", got.Scopes)
+	}
+}
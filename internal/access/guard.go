@@ -1,22 +1,34 @@
 package access
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"log"
 	"net/http"
 
 	"github.com/FTChinese/go-rest/view"
-	"github.com/FTChinese/subscription-api/pkg/db"
 	"github.com/FTChinese/subscription-api/pkg/xhttp"
+	"gorm.io/gorm"
 )
 
+type ctxKey int
+
+const keyOAuth ctxKey = iota
+
+// FromContext gets the access token verified by CheckToken.
+func FromContext(ctx context.Context) (OAuth, bool) {
+	o, ok := ctx.Value(keyOAuth).(OAuth)
+	return o, ok
+}
+
 type Guard struct {
 	env Env
 }
 
-func NewGuard(dbs db.MultiGormDBs) Guard {
+func NewGuard(env Env) Guard {
 	return Guard{
-		env: NewEnv(dbs),
+		env: env,
 	}
 }
 
@@ -28,21 +40,19 @@ func (g Guard) CheckToken(next http.Handler) http.Handler {
 		}
 
 		token, err := xhttp.GetAccessToken(req)
-
 		if err != nil {
 			log.Printf("Token not found: %s", err)
-
 			_ = view.Render(w, view.NewForbidden("Invalid access token"))
 			return
 		}
 
 		access, err := g.env.Load(token)
-
 		if err != nil {
-			if err == sql.ErrNoRows {
+			if err == sql.ErrNoRows || errors.Is(err, gorm.ErrRecordNotFound) {
 				_ = view.Render(w, view.NewForbidden("Invalid access token"))
 				return
 			}
+
 			_ = view.Render(w, view.NewDBFailure(err))
 			return
 		}
@@ -53,8 +63,52 @@ func (g Guard) CheckToken(next http.Handler) http.Handler {
 			return
 		}
 
-		next.ServeHTTP(w, req)
+		next.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), keyOAuth, access)))
 	}
 
 	return http.HandlerFunc(fn)
 }
+
+// RequireScope denies a request if the access token does not
+// have the scope. It must be used after CheckToken.
+func (g Guard) RequireScope(scope Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, req *http.Request) {
+			if !hasScope(req, scope) {
+				_ = view.Render(w, view.NewForbidden("Access token does not have scope "+string(scope)))
+				return
+			}
+
+			next.ServeHTTP(w, req)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// RequireWriteScope is like RequireScope but only checks
+// methods that modify data, so that a route group could be read
+// with one scope and written with another.
+func (g Guard) RequireWriteScope(scope Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, req *http.Request) {
+			if !isReadOnly(req) && !hasScope(req, scope) {
+				_ = view.Render(w, view.NewForbidden("Access token does not have scope "+string(scope)))
+				return
+			}
+
+			next.ServeHTTP(w, req)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+func hasScope(req *http.Request, scope Scope) bool {
+	o, ok := FromContext(req.Context())
+	if !ok {
+		return false
+	}
+
+	return o.GrantedScopes().Has(scope)
+}
@@ -0,0 +1,77 @@
+package access
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGuard_RequireWriteScope(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	h := Guard{}.RequireWriteScope(ScopePaywallWrite)(ok)
+
+	tests := []struct {
+		name   string
+		method string
+		oauth  OAuth
+		want   int
+	}{
+		{
+			name:   "Read without write scope",
+			method: http.MethodGet,
+			oauth:  OAuth{Scopes: Scopes{ScopeReader}},
+			want:   http.StatusNoContent,
+		},
+		{
+			name:   "Write without write scope",
+			method: http.MethodPost,
+			oauth:  OAuth{Scopes: Scopes{ScopeReader}},
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "Legacy token write",
+			method: http.MethodPatch,
+			oauth:  OAuth{},
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "Write with write scope",
+			method: http.MethodDelete,
+			oauth:  OAuth{Scopes: Scopes{ScopeReader, ScopePaywallWrite}},
+			want:   http.StatusNoContent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/paywall/products", nil)
+			req = req.WithContext(context.WithValue(req.Context(), keyOAuth, tt.oauth))
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("RequireWriteScope() status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestScopes_Scan(t *testing.T) {
+	var s Scopes
+	if err := s.Scan([]byte("reader cms:membership")); err != nil {
+		t.Fatal(err)
+	}
+
+	if !s.Has(ScopeCMSMembership) || s.Has(ScopePaywallWrite) {
+		t.Errorf("Scan() = %v", s)
+	}
+
+	v, _ := s.Value()
+	if v != "reader cms:membership" {
+		t.Errorf("Value() = %v", v)
+	}
+}
@@ -3,6 +3,7 @@ package access
 import (
 	"time"
 
+	gorest "github.com/FTChinese/go-rest"
 	"github.com/FTChinese/go-rest/chrono"
 	"github.com/FTChinese/subscription-api/pkg/conv"
 	"github.com/guregu/null"
@@ -10,11 +11,47 @@ import (
 
 // OAuth contains the data related to an access token, used
 // either by human or machines.
+// A token is issued to a client and could only reach the
+// route groups permitted by its scopes.
 type OAuth struct {
-	Token     conv.HexBin `gorm:"column:access_token"`
-	Active    bool        `gorm:"column:is_active"`
-	ExpiresIn null.Int    `gorm:"column:expires_in"` // seconds
-	CreatedAt chrono.Time `gorm:"column:created_utc"`
+	ID          int64       `json:"id" gorm:"column:id;primaryKey"`
+	Token       conv.HexBin `json:"-" gorm:"column:access_token"`
+	ClientID    null.String `json:"clientId" gorm:"column:client_id"`
+	Scopes      Scopes      `json:"scopes" gorm:"column:scope"`
+	Description null.String `json:"description" gorm:"column:description"`
+	Active      bool        `json:"active" gorm:"column:is_active"`
+	ExpiresIn   null.Int    `json:"expiresIn" gorm:"column:expires_in"`   // seconds
+	RetiresAt   chrono.Time `json:"retiresUtc" gorm:"column:retires_utc"` // set upon rotation
+	CreatedBy   null.String `json:"createdBy" gorm:"column:created_by"`
+	CreatedAt   chrono.Time `json:"createdUtc" gorm:"column:created_utc"`
+	UpdatedAt   chrono.Time `json:"updatedUtc" gorm:"column:updated_utc"`
+}
+
+// NewOAuth creates a new token for a client.
+func NewOAuth(clientID string, scopes Scopes, desc null.String, expiresIn null.Int, staff string) (OAuth, error) {
+	t, err := gorest.RandomHex(20)
+	if err != nil {
+		return OAuth{}, err
+	}
+
+	token, err := conv.DecodeHexString(t)
+	if err != nil {
+		return OAuth{}, err
+	}
+
+	now := chrono.TimeNow()
+
+	return OAuth{
+		Token:       token,
+		ClientID:    null.StringFrom(clientID),
+		Scopes:      scopes,
+		Description: desc,
+		Active:      true,
+		ExpiresIn:   expiresIn,
+		CreatedBy:   null.StringFrom(staff),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}, nil
 }
 
 func (o OAuth) TableName() string {
@@ -22,6 +59,9 @@ func (o OAuth) TableName() string {
 }
 
 func (o OAuth) Expired() bool {
+	if !o.RetiresAt.IsZero() && o.RetiresAt.Before(time.Now()) {
+		return true
+	}
 
 	if o.ExpiresIn.IsZero() {
 		return false
@@ -31,3 +71,59 @@ func (o OAuth) Expired() bool {
 
 	return expireAt.Before(time.Now())
 }
+
+// Retiring tells whether the token has been rotated and is
+// kept valid only during the grace period.
+func (o OAuth) Retiring() bool {
+	return !o.RetiresAt.IsZero()
+}
+
+// GrantedScopes returns the token's scopes. Tokens created
+// before scopes were introduced have none set and are
+// limited to reader scope, unless granted in config
+// `legacy_tokens`.
+func (o OAuth) GrantedScopes() Scopes {
+	if len(o.Scopes) == 0 {
+		return Scopes{ScopeReader}
+	}
+
+	return o.Scopes
+}
+
+// Rotate creates a replacement token with the same client and
+// scopes.
+func (o OAuth) Rotate(staff string) (OAuth, error) {
+	return NewOAuth(o.ClientID.String, o.Scopes, o.Description, o.ExpiresIn, staff)
+}
+
+// Retired keeps a rotated token working for grace, or revokes
+// it at once if grace is zero.
+func (o OAuth) Retired(grace time.Duration) OAuth {
+	if grace <= 0 {
+		return o.Revoked()
+	}
+
+	o.UpdatedAt = chrono.TimeNow()
+	o.RetiresAt = chrono.TimeFrom(o.UpdatedAt.Add(grace))
+	return o
+}
+
+func (o OAuth) Revoked() OAuth {
+	o.Active = false
+	o.UpdatedAt = chrono.TimeNow()
+	return o
+}
+
+// Issued shows the token value. It is sent to client only
+// once upon creation.
+func (o OAuth) Issued() IssuedToken {
+	return IssuedToken{
+		OAuth: o,
+		Token: o.Token.String(),
+	}
+}
+
+type IssuedToken struct {
+	OAuth
+	Token string `json:"token"`
+}
@@ -0,0 +1,33 @@
+package access
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FTChinese/go-rest/chrono"
+	"github.com/guregu/null"
+)
+
+func TestOAuth_Retired(t *testing.T) {
+	o, err := NewOAuth("cms", Scopes{ScopeReader}, null.String{}, null.Int{}, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	retiring := o.Retired(time.Hour)
+	if !retiring.Active || retiring.Expired() {
+		t.Error("rotated token should keep working during grace period")
+	}
+	if !retiring.Retiring() {
+		t.Error("rotated token should be retiring")
+	}
+
+	retiring.RetiresAt = chrono.TimeFrom(time.Now().Add(-time.Second))
+	if !retiring.Expired() {
+		t.Error("rotated token should expire after grace period")
+	}
+
+	if o.Retired(0).Active {
+		t.Error("token should be revoked at once without grace period")
+	}
+}
@@ -0,0 +1,56 @@
+package access
+
+import (
+	"strings"
+
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/lib/validator"
+	"github.com/guregu/null"
+)
+
+// TokenParams is used by CMS to issue an access token
+// to a client.
+type TokenParams struct {
+	ClientID    string      `json:"clientId"`
+	Scopes      Scopes      `json:"scopes"`
+	Description null.String `json:"description"`
+	ExpiresIn   null.Int    `json:"expiresIn"` // seconds
+}
+
+func (p *TokenParams) Validate() *render.ValidationError {
+	p.ClientID = strings.TrimSpace(p.ClientID)
+
+	ve := validator.New("clientId").
+		Required().
+		MaxLen(64).
+		Validate(p.ClientID)
+	if ve != nil {
+		return ve
+	}
+
+	if len(p.Scopes) == 0 {
+		return &render.ValidationError{
+			Message: "At least one scope is required",
+			Field:   "scopes",
+			Code:    render.CodeMissingField,
+		}
+	}
+
+	if s := p.Scopes.Invalid(); s != "" {
+		return &render.ValidationError{
+			Message: "Unknown scope " + string(s),
+			Field:   "scopes",
+			Code:    render.CodeInvalid,
+		}
+	}
+
+	if p.ExpiresIn.Valid && p.ExpiresIn.Int64 <= 0 {
+		return &render.ValidationError{
+			Message: "expiresIn must be positive",
+			Field:   "expiresIn",
+			Code:    render.CodeInvalid,
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package access
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strings"
+)
+
+// Scope limits which route groups an access token could reach.
+type Scope string
+
+const (
+	// ScopeReader grants user-facing endpoints used by apps and web.
+	ScopeReader Scope = "reader"
+	// ScopePaywallWrite grants mutation of paywall, prices, discounts
+	// and stripe price/coupon metadata.
+	ScopePaywallWrite Scope = "paywall:write"
+	// ScopeCMSMembership grants customer support operations
+	// on readers' membership, orders, accounts and emails.
+	ScopeCMSMembership Scope = "cms:membership"
+	// ScopeCMSContent grants editing legal documents and app releases.
+	ScopeCMSContent Scope = "cms:content"
+	// ScopeTokenAdmin grants issuing, rotating and revoking access tokens.
+	ScopeTokenAdmin Scope = "cms:token"
+)
+
+var knownScopes = map[Scope]bool{
+	ScopeReader:        true,
+	ScopePaywallWrite:  true,
+	ScopeCMSMembership: true,
+	ScopeCMSContent:    true,
+	ScopeTokenAdmin:    true,
+}
+
+func (s Scope) IsValid() bool {
+	return knownScopes[s]
+}
+
+// Scopes is saved as a space-separated string.
+type Scopes []Scope
+
+func (x Scopes) Has(s Scope) bool {
+	for _, v := range x {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Invalid returns the first unknown scope, or empty string if
+// all are known.
+func (x Scopes) Invalid() Scope {
+	for _, v := range x {
+		if !v.IsValid() {
+			return v
+		}
+	}
+
+	return ""
+}
+
+func (x Scopes) String() string {
+	s := make([]string, 0, len(x))
+	for _, v := range x {
+		s = append(s, string(v))
+	}
+
+	return strings.Join(s, " ")
+}
+
+// Scan does not validate scopes so that a token with
+// scopes unknown to this version still loads.
+func (x *Scopes) Scan(src interface{}) error {
+	var str string
+	switch s := src.(type) {
+	case nil:
+		*x = nil
+		return nil
+	case []byte:
+		str = string(s)
+	case string:
+		str = s
+	default:
+		return errors.New("incompatible type to scan to Scopes")
+	}
+
+	var scopes Scopes
+	for _, v := range strings.Fields(str) {
+		scopes = append(scopes, Scope(v))
+	}
+	*x = scopes
+
+	return nil
+}
+
+func (x Scopes) Value() (driver.Value, error) {
+	if len(x) == 0 {
+		return nil, nil
+	}
+
+	return x.String(), nil
+}
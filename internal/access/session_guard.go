@@ -0,0 +1,133 @@
+package access
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/internal/repository/sessionrepo"
+	"github.com/FTChinese/subscription-api/pkg/config"
+	"github.com/FTChinese/subscription-api/pkg/ids"
+	"github.com/FTChinese/subscription-api/pkg/session"
+	"github.com/FTChinese/subscription-api/pkg/xhttp"
+	"github.com/guregu/null"
+)
+
+// SessionGuard derives user identity from the session token
+// in `X-Session-Token` header.
+// If the token is valid, `X-User-Id` and `X-Union-Id` headers
+// are overwritten by the values signed in the token so that
+// handlers reading those headers always get verified ids.
+// Without a session token, the identity headers are kept
+// only if allowed by the compatibility mode; otherwise they
+// are removed and routes requiring user id respond 401.
+type SessionGuard struct {
+	issuer  session.Issuer
+	repo    sessionrepo.Env
+	compat  config.SessionCompat
+	trusted map[string]bool
+}
+
+func NewSessionGuard(c config.SessionConfig, issuer session.Issuer, repo sessionrepo.Env) SessionGuard {
+	trusted := make(map[string]bool, len(c.TrustedTokens))
+	for _, t := range c.TrustedTokens {
+		trusted[t] = true
+	}
+
+	return SessionGuard{
+		issuer:  issuer,
+		repo:    repo,
+		compat:  c.Compat,
+		trusted: trusted,
+	}
+}
+
+// acceptHeaders checks whether identity headers could be used
+// as is when no session token is present.
+func (g SessionGuard) acceptHeaders(req *http.Request) bool {
+	switch g.compat {
+	case config.SessionCompatAll:
+		return true
+
+	case config.SessionCompatTrusted:
+		token, err := xhttp.GetAccessToken(req)
+		if err != nil {
+			return false
+		}
+		return g.trusted[token]
+
+	default:
+		return false
+	}
+}
+
+func (g SessionGuard) Authenticate(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		token := strings.TrimSpace(xhttp.GetSessionToken(req.Header))
+
+		if token == "" {
+			if !g.acceptHeaders(req) {
+				req.Header.Del(xhttp.XUserID)
+				req.Header.Del(xhttp.XUnionID)
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			ftcID := strings.TrimSpace(xhttp.GetFtcID(req.Header))
+			unionID := strings.TrimSpace(xhttp.GetUnionID(req.Header))
+			userIDs, err := ids.UserIDs{
+				FtcID:   null.NewString(ftcID, ftcID != ""),
+				UnionID: null.NewString(unionID, unionID != ""),
+			}.Normalize()
+			if err == nil {
+				req = req.WithContext(ids.WithUserIDs(req.Context(), userIDs))
+			}
+
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		claims, err := g.issuer.Verify(token)
+		if err != nil {
+			log.Printf("Invalid session token: %s", err)
+			_ = render.New(w).Unauthorized(err.Error())
+			return
+		}
+
+		s, err := g.repo.LoadSession(claims.SessionID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				_ = render.New(w).Unauthorized("Session not found")
+				return
+			}
+			_ = render.New(w).DBError(err)
+			return
+		}
+
+		if !s.IsActive() {
+			_ = render.New(w).Unauthorized("Session is revoked or expired")
+			return
+		}
+
+		userIDs, err := claims.UserIDs()
+		if err != nil {
+			_ = render.New(w).Unauthorized(err.Error())
+			return
+		}
+
+		req.Header.Del(xhttp.XUserID)
+		req.Header.Del(xhttp.XUnionID)
+		if userIDs.FtcID.Valid {
+			req.Header.Set(xhttp.XUserID, userIDs.FtcID.String)
+		}
+		if userIDs.UnionID.Valid {
+			req.Header.Set(xhttp.XUnionID, userIDs.UnionID.String)
+		}
+
+		next.ServeHTTP(w, req.WithContext(ids.WithUserIDs(req.Context(), userIDs)))
+	}
+
+	return http.HandlerFunc(fn)
+}
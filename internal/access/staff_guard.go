@@ -0,0 +1,217 @@
+package access
+
+import (
+	"bytes"
+	"database/sql"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/internal/repository/cmsrepo"
+	"github.com/FTChinese/subscription-api/pkg/background"
+	"github.com/FTChinese/subscription-api/pkg/cms"
+	"github.com/FTChinese/subscription-api/pkg/footprint"
+	"github.com/FTChinese/subscription-api/pkg/xhttp"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/patrickmn/go-cache"
+)
+
+// Request body larger than this is not kept in audit log.
+const maxAuditBody = 64 << 10
+
+// StaffGuard checks the role of the staff named in
+// `X-Staff-Name` header, and records what the staff
+// modified in audit log.
+type StaffGuard struct {
+	repo cmsrepo.Env
+	// Role changes take effect after cache expired.
+	cache *cache.Cache
+	tasks *background.Runner
+	// Staff acting as admin if no role is assigned in db, so
+	// that someone could assign the first roles.
+	admins map[string]bool
+}
+
+func NewStaffGuard(repo cmsrepo.Env, tasks *background.Runner, admins []string) StaffGuard {
+	m := make(map[string]bool, len(admins))
+	for _, name := range admins {
+		m[name] = true
+	}
+
+	return StaffGuard{
+		repo:   repo,
+		cache:  cache.New(time.Minute, 10*time.Minute),
+		tasks:  tasks,
+		admins: m,
+	}
+}
+
+// bootstrapRole grants admin to a staff listed in config.
+func (g StaffGuard) bootstrapRole(name string) (cms.StaffRole, bool) {
+	if !g.admins[name] {
+		return cms.StaffRole{}, false
+	}
+
+	return cms.NewStaffRole(name, cms.RoleAdmin, "config"), true
+}
+
+func (g StaffGuard) loadRole(name string) (cms.StaffRole, error) {
+	if x, ok := g.cache.Get(name); ok {
+		if s, ok := x.(cms.StaffRole); ok {
+			return s, nil
+		}
+	}
+
+	s, err := g.repo.RetrieveStaffRole(name)
+	if err == sql.ErrNoRows {
+		if b, ok := g.bootstrapRole(name); ok {
+			s, err = b, nil
+		}
+	}
+	if err != nil {
+		return s, err
+	}
+
+	g.cache.Set(name, s, cache.DefaultExpiration)
+
+	return s, nil
+}
+
+func (g StaffGuard) check(w http.ResponseWriter, req *http.Request, p cms.Permission) bool {
+	name := xhttp.GetStaffName(req.Header)
+	if name == "" {
+		_ = render.New(w).Unauthorized("Missing X-Staff-Name header")
+		return false
+	}
+
+	s, err := g.loadRole(name)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			_ = render.New(w).Forbidden("Staff " + name + " has no role assigned")
+			return false
+		}
+		_ = render.New(w).DBError(err)
+		return false
+	}
+
+	if !s.Role.Can(p) {
+		_ = render.New(w).Forbidden("Role " + string(s.Role) + " does not have permission " + string(p))
+		return false
+	}
+
+	cms.AuditTrailFrom(req.Context()).Staff = s
+
+	return true
+}
+
+// Require denies staff whose role does not grant the
+// permission. It must be used after xhttp.RequireStaffName.
+func (g StaffGuard) Require(p cms.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, req *http.Request) {
+			if !g.check(w, req, p) {
+				return
+			}
+
+			next.ServeHTTP(w, req)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// RequireWrite is like Require but only checks methods
+// modifying data, for route groups also read by apps.
+func (g StaffGuard) RequireWrite(p cms.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, req *http.Request) {
+			if isReadOnly(req) {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			if !g.check(w, req, p) {
+				return
+			}
+
+			next.ServeHTTP(w, req)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// Audit records every request modifying data after a staff
+// passed Require or RequireWrite. Handlers could add target
+// ids and before/after state via cms.AuditTrailFrom; target
+// ids default to url parameters.
+func (g StaffGuard) Audit(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		if isReadOnly(req) {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		var body []byte
+		if req.Body != nil {
+			b, err := io.ReadAll(io.LimitReader(req.Body, maxAuditBody+1))
+			if err != nil {
+				_ = render.New(w).BadRequest(err.Error())
+				return
+			}
+			req.Body = io.NopCloser(io.MultiReader(bytes.NewReader(b), req.Body))
+			if len(b) <= maxAuditBody {
+				body = b
+			}
+		}
+
+		ctx, trail := cms.WithAuditTrail(req.Context())
+		req = req.WithContext(ctx)
+		ww := middleware.NewWrapResponseWriter(w, req.ProtoMajor)
+
+		next.ServeHTTP(ww, req)
+
+		// Rejected before permission check.
+		if trail.Staff.StaffName == "" {
+			trail.Staff.StaffName = xhttp.GetStaffName(req.Header)
+		}
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		rctx := chi.RouteContext(req.Context())
+		// Handlers not naming targets modify the rows
+		// identified in path, e.g., /prices/{id}.
+		if len(trail.TargetIDs) == 0 {
+			trail.Target(rctx.URLParams.Values...)
+		}
+
+		entry := trail.Entry(
+			req.Method+" "+rctx.RoutePattern(),
+			body,
+			status,
+			footprint.NewClient(req).UserIP.String)
+
+		g.tasks.Go(func() {
+			if err := g.repo.SaveAuditEntry(entry); err != nil {
+				log.Printf("Failed to save audit log: %s", err)
+			}
+		})
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+func isReadOnly(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+
+	return false
+}
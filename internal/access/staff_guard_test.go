@@ -0,0 +1,21 @@
+package access
+
+import (
+	"testing"
+
+	"github.com/FTChinese/subscription-api/internal/repository/cmsrepo"
+	"github.com/FTChinese/subscription-api/pkg/cms"
+)
+
+func TestStaffGuard_bootstrapRole(t *testing.T) {
+	g := NewStaffGuard(cmsrepo.Env{}, nil, []string{"root"})
+
+	s, ok := g.bootstrapRole("root")
+	if !ok || s.Role != cms.RoleAdmin || !s.Role.Can(cms.PermStaff) {
+		t.Errorf("bootstrapRole(root) = %v, %t", s, ok)
+	}
+
+	if _, ok := g.bootstrapRole("guest"); ok {
+		t.Error("unlisted staff should not be admin")
+	}
+}
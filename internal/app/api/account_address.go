@@ -12,6 +12,7 @@ import (
 //
 //	GET /user/address
 func (router AccountRouter) LoadAddress(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
 	ftcID := xhttp.GetFtcID(req.Header)
 
 	addr, err := router.Repo.LoadAddress(ftcID)
@@ -36,6 +37,7 @@ func (router AccountRouter) LoadAddress(w http.ResponseWriter, req *http.Request
 // street?: string;
 // postcode?: string
 func (router AccountRouter) UpdateAddress(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
 	ftcID := xhttp.GetFtcID(req.Header)
 
 	var addr account.Address
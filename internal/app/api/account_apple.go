@@ -0,0 +1,187 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	gorest "github.com/FTChinese/go-rest"
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/internal/pkg/input"
+	"github.com/FTChinese/subscription-api/pkg/applelogin"
+	"github.com/FTChinese/subscription-api/pkg/xhttp"
+)
+
+// LoadAppleLink shows the Apple ID linked to current account.
+//
+//	GET /account/apple
+func (router AccountRouter) LoadAppleLink(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
+	defer router.Logger.Sync()
+	sugar := router.Logger.Sugar()
+
+	ftcID := xhttp.GetFtcID(req.Header)
+
+	link, err := router.Repo.AppleLinkByFtcID(ftcID)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	_ = render.New(w).OK(link)
+}
+
+// AppleLinkEmail links an email account to Apple ID.
+//
+//	POST /account/apple/link
+//
+// Input:
+// * identityToken: string.
+func (router AccountRouter) AppleLinkEmail(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
+	defer router.Logger.Sync()
+	sugar := router.Logger.Sugar()
+
+	ftcID := xhttp.GetFtcID(req.Header)
+
+	var params input.AppleLoginParams
+	if err := gorest.ParseJSON(req.Body, &params); err != nil {
+		sugar.Error(err)
+		_ = render.New(w).BadRequest(err.Error())
+		return
+	}
+
+	if ve := params.Validate(); ve != nil {
+		sugar.Error(ve)
+		_ = render.New(w).Unprocessable(ve)
+		return
+	}
+
+	claims, err := router.AppleSignIn.Verify(params.IdentityToken)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).Unauthorized(err.Error())
+		return
+	}
+
+	acnt, err := router.ReaderRepo.AccountByFtcID(ftcID)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	// The Apple ID might be linked to this account or another one.
+	existing, err := router.Repo.AppleLinkBySub(claims.Subject)
+	if err != nil && err != sql.ErrNoRows {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+	if err == nil {
+		if existing.FtcID == acnt.FtcID {
+			sugar.Info("Duplicate apple-email link")
+			_ = render.New(w).NoContent()
+			return
+		}
+
+		_ = render.New(w).Unprocessable(&render.ValidationError{
+			Message: "The Apple ID is already linked to another account",
+			Field:   "apple",
+			Code:    render.CodeAlreadyExists,
+		})
+		return
+	}
+
+	// One account could only link to one Apple ID.
+	_, err = router.Repo.AppleLinkByFtcID(acnt.FtcID)
+	if err != sql.ErrNoRows {
+		if err != nil {
+			sugar.Error(err)
+			_ = render.New(w).DBError(err)
+			return
+		}
+
+		_ = render.New(w).Unprocessable(&render.ValidationError{
+			Message: "The account is already linked to another Apple ID",
+			Field:   "account_link",
+			Code:    render.CodeAlreadyExists,
+		})
+		return
+	}
+
+	link := applelogin.NewLink(acnt.FtcID, claims)
+	err = router.Repo.LinkApple(link)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	router.revokeSessions(acnt.FtcID)
+
+	router.Tasks.Go(func() {
+		err := router.EmailService.SendAppleLink(acnt.BaseAccount, link.Email, true)
+		if err != nil {
+			sugar.Error(err)
+		}
+	})
+
+	_ = render.New(w).NoContent()
+}
+
+// AppleUnlinkEmail removes the Apple ID linked to an account.
+// An account created by Apple with a private relay email
+// could not be unlinked unless user changed the email.
+//
+//	POST /account/apple/unlink
+func (router AccountRouter) AppleUnlinkEmail(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
+	defer router.Logger.Sync()
+	sugar := router.Logger.Sugar()
+
+	ftcID := xhttp.GetFtcID(req.Header)
+
+	link, err := router.Repo.AppleLinkByFtcID(ftcID)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	acnt, err := router.ReaderRepo.AccountByFtcID(ftcID)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	if link.IsRelayLogin(acnt.Email) {
+		_ = render.New(w).Unprocessable(&render.ValidationError{
+			Message: "Please change the Apple private relay email before unlinking",
+			Field:   "email",
+			Code:    render.CodeInvalid,
+		})
+		return
+	}
+
+	err = router.Repo.UnlinkApple(ftcID)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	// Sessions started by Apple ID should no longer access
+	// this account.
+	router.revokeSessions(ftcID)
+
+	router.Tasks.Go(func() {
+		err := router.EmailService.SendAppleLink(acnt.BaseAccount, link.Email, false)
+		if err != nil {
+			sugar.Error(err)
+		}
+	})
+
+	_ = render.New(w).NoContent()
+}
@@ -4,6 +4,7 @@ import (
 	gorest "github.com/FTChinese/go-rest"
 	"github.com/FTChinese/go-rest/render"
 	"github.com/FTChinese/subscription-api/internal/pkg/input"
+	"github.com/FTChinese/subscription-api/pkg/account"
 	"github.com/FTChinese/subscription-api/pkg/db"
 	"github.com/FTChinese/subscription-api/pkg/footprint"
 	"github.com/FTChinese/subscription-api/pkg/xhttp"
@@ -11,12 +12,17 @@ import (
 	"net/http"
 )
 
-// UpdateEmail lets user to change email.
+// UpdateEmail starts changing email. The account keeps the
+// current email until the new address is confirmed.
+// A link, or a code for mobile apps, is sent to the new address.
 //
-//	PATCH /user/email
+//	PATCH /account/email
 //
 // Input {email: string, sourceUrl?: string}
+//
+// Responds `202 Accepted` with the pending change.
 func (router AccountRouter) UpdateEmail(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
 	defer router.Logger.Sync()
 	sugar := router.Logger.Sugar()
 
@@ -54,11 +60,158 @@ func (router AccountRouter) UpdateEmail(w http.ResponseWriter, req *http.Request
 		return
 	}
 
-	newAcnt := currAcnt.WithEmail(params.Email)
-	// Update email and record email change history.
-	err = router.Repo.UpdateEmail(newAcnt)
+	ok, err := router.Repo.EmailExists(params.Email)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+	if ok {
+		_ = render.New(w).Unprocessable(render.NewVEAlreadyExists("email"))
+		return
+	}
+
+	change, err := account.NewEmailChange(
+		currAcnt,
+		params,
+		footprint.NewClient(req).IsApp())
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).InternalServerError(err.Error())
+		return
+	}
+
+	err = router.Repo.SaveEmailChange(change)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	err = router.EmailService.SendEmailChangeConfirm(currAcnt, change)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).InternalServerError(err.Error())
+		return
+	}
+
+	// `202 Accepted` since email is not changed yet.
+	_ = render.New(w).JSON(http.StatusAccepted, change)
+}
+
+// LoadEmailChange shows the pending email change, if any.
+//
+//	GET /account/email/pending
+func (router AccountRouter) LoadEmailChange(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
+	defer router.Logger.Sync()
+	sugar := router.Logger.Sugar()
+
+	ftcID := xhttp.GetFtcID(req.Header)
+
+	change, err := router.Repo.PendingEmailChange(ftcID)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	_ = render.New(w).OK(change)
+}
+
+// CancelEmailChange drops the pending email change.
+//
+//	DELETE /account/email/pending
+func (router AccountRouter) CancelEmailChange(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
+	defer router.Logger.Sync()
+	sugar := router.Logger.Sugar()
+
+	ftcID := xhttp.GetFtcID(req.Header)
+
+	err := router.Repo.CancelEmailChanges(ftcID)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	_ = render.New(w).NoContent()
+}
+
+// ConfirmEmailChange switches account to the new email
+// after user proved owning it.
+// The old address is notified with a link to revert.
+//
+//	POST /auth/email/change/confirm
+//	POST /account/email/confirm
+//
+// Input {token?: string, code?: string}
+// The code could only be used together with the `X-User-Id` header.
+func (router AccountRouter) ConfirmEmailChange(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
+	defer router.Logger.Sync()
+	sugar := router.Logger.Sugar()
+
+	var params input.EmailChangeConfirmParams
+	if err := gorest.ParseJSON(req.Body, &params); err != nil {
+		sugar.Error(err)
+		_ = render.New(w).BadRequest(err.Error())
+		return
+	}
+	if ve := params.Validate(); ve != nil {
+		sugar.Error(ve)
+		_ = render.New(w).Unprocessable(ve)
+		return
+	}
+
+	var change account.EmailChange
+	var err error
+	if params.Token != "" {
+		change, err = router.Repo.EmailChangeByToken(params.Token)
+	} else {
+		ftcID := xhttp.GetFtcID(req.Header)
+		if ftcID == "" {
+			_ = render.New(w).Unauthorized("Login is required to confirm by code")
+			return
+		}
+		change, err = router.Repo.PendingEmailChange(ftcID)
+	}
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	if !change.CanConfirm() {
+		_ = render.New(w).Forbidden("The email change is expired or no longer valid")
+		return
+	}
+
+	if params.Token == "" && !change.MatchCode(params.Code) {
+		if err := router.Repo.IncEmailChangeAttempts(change.ID); err != nil {
+			sugar.Error(err)
+		}
+		_ = render.New(w).Forbidden("Incorrect verification code")
+		return
+	}
+
+	currAcnt, err := router.ReaderRepo.BaseAccountByUUID(change.FtcID)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
 
-	// `422 Unprocessable Entity`
+	change, err = change.Confirmed()
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).InternalServerError(err.Error())
+		return
+	}
+
+	// The new email might be taken after the change requested.
+	err = router.Repo.ConfirmEmailChange(change, currAcnt)
 	if err != nil {
 		sugar.Error(err)
 		if db.IsAlreadyExists(err) {
@@ -71,27 +224,120 @@ func (router AccountRouter) UpdateEmail(w http.ResponseWriter, req *http.Request
 		return
 	}
 
-	// Save user's current email address.
-	go func() {
-		if err := router.Repo.SaveEmailHistory(currAcnt); err != nil {
+	newAcnt := currAcnt.WithEmail(change.NewEmail)
+	newAcnt.IsVerified = true
+
+	router.Tasks.Go(func() {
+		err := router.EmailService.SendEmailChanged(newAcnt, change)
+		if err != nil {
 			sugar.Error(err)
 		}
-	}()
+	})
 
-	go func() {
-		_ = router.SendEmailVerification(newAcnt, params.SourceURL.String, false)
-	}()
+	router.syncStripeEmail(newAcnt)
 
-	// `204 No Content` if updated successfully.
 	_ = render.New(w).OK(newAcnt)
 }
 
+// RevertEmailChange restores the old email using the
+// link sent to it after the change is confirmed.
+//
+//	POST /auth/email/change/revert
+//
+// Input {token: string}
+func (router AccountRouter) RevertEmailChange(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
+	defer router.Logger.Sync()
+	sugar := router.Logger.Sugar()
+
+	var params input.EmailChangeConfirmParams
+	if err := gorest.ParseJSON(req.Body, &params); err != nil {
+		sugar.Error(err)
+		_ = render.New(w).BadRequest(err.Error())
+		return
+	}
+	if params.Token == "" {
+		_ = render.New(w).Unprocessable(&render.ValidationError{
+			Message: "Token is required",
+			Field:   "token",
+			Code:    render.CodeMissingField,
+		})
+		return
+	}
+
+	change, err := router.Repo.EmailChangeByRevertToken(params.Token)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	if !change.CanRevert() {
+		_ = render.New(w).Forbidden("The revert link is expired or already used")
+		return
+	}
+
+	currAcnt, err := router.ReaderRepo.BaseAccountByUUID(change.FtcID)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	// Email changed again after this one. Reverting it would
+	// overwrite a change not covered by this link.
+	if currAcnt.Email != change.NewEmail {
+		_ = render.New(w).Forbidden("The email has been changed again and could not be reverted")
+		return
+	}
+
+	change = change.Reverted()
+	err = router.Repo.RevertEmailChange(change, currAcnt)
+	if err != nil {
+		sugar.Error(err)
+		if db.IsAlreadyExists(err) {
+			_ = render.New(w).Unprocessable(
+				render.NewVEAlreadyExists("email"))
+			return
+		}
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	oldAcnt := currAcnt.WithEmail(change.OldEmail)
+	oldAcnt.IsVerified = change.OldVerified
+
+	router.syncStripeEmail(oldAcnt)
+	// Whoever changed the email should not stay logged in.
+	router.revokeSessions(oldAcnt.FtcID)
+
+	_ = render.New(w).OK(oldAcnt)
+}
+
+// syncStripeEmail updates Stripe customer's email in background.
+func (router AccountRouter) syncStripeEmail(a account.BaseAccount) {
+	if !a.StripeID.Valid {
+		return
+	}
+
+	router.Tasks.Go(func() {
+		defer router.Logger.Sync()
+		sugar := router.Logger.Sugar()
+
+		_, err := router.StripeClient.UpdateCustomerEmail(a.StripeID.String, a.Email)
+		if err != nil {
+			sugar.Error(err)
+		}
+	})
+}
+
 // RequestVerification sends user a verification letter when he explicitly ask so.
 // We need to tell user if email cannot be sent.
 //	POST /user/email/request-verification
 //
 // Input {sourceUrl?: string}
 func (router AccountRouter) RequestVerification(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
 	defer router.Logger.Sync()
 	sugar := router.Logger.Sugar()
 
@@ -131,12 +377,12 @@ func (router AccountRouter) RequestVerification(w http.ResponseWriter, req *http
 
 	fp := footprint.New(baseAccount.FtcID, footprint.NewClient(req)).FromVerification()
 
-	go func() {
+	router.Tasks.Go(func() {
 		err = router.Repo.SaveFootprint(fp)
 		if err != nil {
 			sugar.Error(err)
 		}
-	}()
+	})
 
 	_ = render.New(w).NoContent()
 }
@@ -8,7 +8,8 @@ import (
 )
 
 func (router AccountRouter) LoadMembership(w http.ResponseWriter, req *http.Request) {
-	userIDs := ids.UserIDsFromHeader(req.Header)
+	router = router.withRequest(req)
+	userIDs := ids.UserIDsFromRequest(req)
 
 	m, err := router.ReaderRepo.RetrieveMember(userIDs.CompoundID)
 	if err != nil {
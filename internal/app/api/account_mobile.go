@@ -17,6 +17,7 @@ import (
 // Input:
 // mobile: string;
 func (router AccountRouter) SMSToModifyMobile(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
 	defer router.Logger.Sync()
 	sugar := router.Logger.Sugar()
 
@@ -122,6 +123,7 @@ func (router AccountRouter) SMSToModifyMobile(w http.ResponseWriter, req *http.R
 // code: string;
 // deviceToken?: string.
 func (router AccountRouter) UpdateMobile(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
 	defer router.Logger.Sync()
 	sugar := router.Logger.Sugar()
 
@@ -157,10 +159,10 @@ func (router AccountRouter) UpdateMobile(w http.ResponseWriter, req *http.Reques
 	}
 
 	// Flag the verifier as used.
-	go func() {
+	router.Tasks.Go(func() {
 		err = router.Repo.SMSVerifierUsed(vrf.WithUsed())
 		sugar.Error(err)
-	}()
+	})
 
 	// Retrieve account for current id.
 	baseAccount, err := router.ReaderRepo.BaseAccountByUUID(ftcID)
@@ -209,6 +211,7 @@ func (router AccountRouter) UpdateMobile(w http.ResponseWriter, req *http.Reques
 // Input:
 // mobile: string;
 func (router AccountRouter) DeleteMobile(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
 	defer router.Logger.Sync()
 	sugar := router.Logger.Sugar()
 
@@ -16,6 +16,7 @@ import (
 // Input
 // userName: string
 func (router AccountRouter) UpdateName(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
 	ftcID := xhttp.GetFtcID(req.Header)
 
 	var params input.NameUpdateParams
@@ -19,6 +19,7 @@ import (
 // * currentPassword: string;
 // * newPassword: string.
 func (router AccountRouter) UpdatePassword(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
 	ftcID := xhttp.GetFtcID(req.Header)
 
 	var params input.PasswordUpdateParams
@@ -60,6 +61,10 @@ func (router AccountRouter) UpdatePassword(w http.ResponseWriter, req *http.Requ
 		return
 	}
 
+	// Every session, including current one, must log in again
+	// with the new password.
+	router.revokeSessions(ftcID)
+
 	// `204 No Content`
 	_ = render.New(w).NoContent()
 }
@@ -13,6 +13,7 @@ import (
 //
 //	 GET `/user/profile`
 func (router AccountRouter) LoadProfile(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
 	userID := xhttp.GetFtcID(req.Header)
 
 	p, err := router.Repo.LoadProfile(userID)
@@ -37,6 +38,7 @@ func (router AccountRouter) LoadProfile(w http.ResponseWriter, req *http.Request
 // birthday?: string;
 // gender?: M | F;
 func (router AccountRouter) UpdateProfile(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
 	ftcID := xhttp.GetFtcID(req.Header)
 
 	var input account.BaseProfile
@@ -5,6 +5,7 @@ import (
 	"github.com/FTChinese/go-rest/enum"
 	"github.com/FTChinese/go-rest/render"
 	"github.com/FTChinese/subscription-api/internal/pkg/input"
+	"github.com/FTChinese/subscription-api/internal/stripeclient"
 	"github.com/FTChinese/subscription-api/pkg/account"
 	"github.com/FTChinese/subscription-api/pkg/xhttp"
 	"net/http"
@@ -12,17 +13,27 @@ import (
 
 type AccountRouter struct {
 	UserShared
+	StripeClient stripeclient.Client // Sync customer email.
 }
 
-func NewAccountRouter(shared UserShared) AccountRouter {
+func NewAccountRouter(shared UserShared, sc stripeclient.Client) AccountRouter {
 	return AccountRouter{
-		UserShared: shared,
+		UserShared:   shared,
+		StripeClient: sc,
 	}
 }
 
+// withRequest logs with the request id of req.
+func (router AccountRouter) withRequest(req *http.Request) AccountRouter {
+	router.UserShared = router.UserShared.withRequest(req)
+	router.StripeClient = router.StripeClient.WithLogger(router.Logger)
+	return router
+}
+
 // LoadAccountByFtcID loads a user's full account data
 // by ftc id provided in request header.
 func (router AccountRouter) LoadAccountByFtcID(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
 	userID := xhttp.GetFtcID(req.Header)
 
 	acnt, err := router.ReaderRepo.AccountByFtcID(userID)
@@ -46,6 +57,7 @@ func (router AccountRouter) LoadAccountByFtcID(w http.ResponseWriter, req *http.
 //	GET /wx/account
 // Header `X-Union-Id: <wechat union id>`
 func (router AccountRouter) LoadAccountByWx(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
 	unionID := xhttp.GetUnionID(req.Header)
 
 	acnt, err := router.ReaderRepo.AccountByWxID(unionID)
@@ -68,6 +80,7 @@ func (router AccountRouter) LoadAccountByWx(w http.ResponseWriter, req *http.Req
 // * Email must match the email under this id - Unprocessable email_missing.
 // * This id should not have a valid subscription - Unprocessable subscription_already_exists.
 func (router AccountRouter) DeleteFtcAccount(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
 	defer router.Logger.Sync()
 	sugar := router.Logger.Sugar()
 
@@ -0,0 +1,328 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	gorest "github.com/FTChinese/go-rest"
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/internal/pkg/input"
+	"github.com/FTChinese/subscription-api/pkg/account"
+	"github.com/FTChinese/subscription-api/pkg/xhttp"
+)
+
+// LoadTwoFactor shows whether 2FA is enabled.
+//
+//	GET /account/2fa
+func (router AccountRouter) LoadTwoFactor(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
+	defer router.Logger.Sync()
+	sugar := router.Logger.Sugar()
+
+	ftcID := xhttp.GetFtcID(req.Header)
+
+	tf, err := router.Repo.RetrieveTwoFactor(ftcID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			_ = render.New(w).OK(account.TwoFactorStatus{})
+			return
+		}
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	n, err := router.Repo.CountRecoveryCodes(ftcID)
+	if err != nil {
+		sugar.Error(err)
+	}
+
+	_ = render.New(w).OK(account.TwoFactorStatus{
+		Enabled:           tf.Enabled,
+		ConfirmedUTC:      tf.ConfirmedUTC,
+		RecoveryCodesLeft: n,
+	})
+}
+
+// EnrollTwoFactor generates a new TOTP secret.
+// 2FA is not enabled until confirmed.
+//
+//	POST /account/2fa
+//
+// Returns account.TwoFactorEnrollment. Client should show the
+// uri as a QR code to be scanned by authenticator app.
+func (router AccountRouter) EnrollTwoFactor(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
+	defer router.Logger.Sync()
+	sugar := router.Logger.Sugar()
+
+	ftcID := xhttp.GetFtcID(req.Header)
+
+	ba, err := router.ReaderRepo.BaseAccountByUUID(ftcID)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	if ba.IsMobileEmail() {
+		_ = render.New(w).Forbidden("Two-factor authentication is only available to email accounts")
+		return
+	}
+
+	current, err := router.Repo.RetrieveTwoFactor(ftcID)
+	if err != nil && err != sql.ErrNoRows {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	if current.Enabled {
+		_ = render.New(w).Unprocessable(render.NewVEAlreadyExists("twoFactor"))
+		return
+	}
+
+	tf, err := account.NewTwoFactor(ftcID)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).InternalServerError(err.Error())
+		return
+	}
+
+	err = router.Repo.SaveTwoFactor(tf)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	_ = render.New(w).OK(tf.Enrollment(ba.Email))
+}
+
+// ConfirmTwoFactor enables 2FA after user proved the
+// secret is saved in authenticator app.
+//
+//	POST /account/2fa/confirm
+//
+// Input: {code: string}
+//
+// Returns recovery codes which are shown only once.
+func (router AccountRouter) ConfirmTwoFactor(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
+	defer router.Logger.Sync()
+	sugar := router.Logger.Sugar()
+
+	ftcID := xhttp.GetFtcID(req.Header)
+
+	var params input.TwoFactorCodeParams
+	if err := gorest.ParseJSON(req.Body, &params); err != nil {
+		sugar.Error(err)
+		_ = render.New(w).BadRequest(err.Error())
+		return
+	}
+
+	if ve := params.Validate(); ve != nil {
+		_ = render.New(w).Unprocessable(ve)
+		return
+	}
+
+	tf, err := router.Repo.RetrieveTwoFactor(ftcID)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	if tf.Enabled {
+		_ = render.New(w).Unprocessable(render.NewVEAlreadyExists("twoFactor"))
+		return
+	}
+
+	ok, err := router.verifyTOTP(tf, params.Code)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+	if !ok {
+		_ = render.New(w).Forbidden("Incorrect verification code")
+		return
+	}
+
+	tf = tf.Confirmed()
+	codes, hashed := account.NewRecoveryCodes(ftcID)
+
+	err = router.Repo.EnableTwoFactor(tf, hashed)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	router.Tasks.Go(func() {
+		ba, err := router.ReaderRepo.BaseAccountByUUID(ftcID)
+		if err != nil {
+			sugar.Error(err)
+			return
+		}
+		err = router.EmailService.SendTwoFactorChanged(ba, true, false)
+		if err != nil {
+			sugar.Error(err)
+		}
+	})
+
+	_ = render.New(w).OK(map[string][]string{
+		"recoveryCodes": codes,
+	})
+}
+
+// RegenerateRecoveryCodes invalidates all existing recovery
+// codes and creates a new set.
+//
+//	POST /account/2fa/recovery-codes
+//
+// Input: {code: string}
+func (router AccountRouter) RegenerateRecoveryCodes(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
+	defer router.Logger.Sync()
+	sugar := router.Logger.Sugar()
+
+	ftcID := xhttp.GetFtcID(req.Header)
+
+	var params input.TwoFactorCodeParams
+	if err := gorest.ParseJSON(req.Body, &params); err != nil {
+		sugar.Error(err)
+		_ = render.New(w).BadRequest(err.Error())
+		return
+	}
+
+	if ve := params.Validate(); ve != nil {
+		_ = render.New(w).Unprocessable(ve)
+		return
+	}
+
+	tf, err := router.Repo.RetrieveTwoFactor(ftcID)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	if !tf.Enabled {
+		_ = render.New(w).NotFound("Two-factor authentication is not enabled")
+		return
+	}
+
+	ok, err := router.verifyTOTP(tf, params.Code)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+	if !ok {
+		_ = render.New(w).Forbidden("Incorrect verification code")
+		return
+	}
+
+	codes, hashed := account.NewRecoveryCodes(ftcID)
+	err = router.Repo.ReplaceRecoveryCodes(ftcID, hashed)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	_ = render.New(w).OK(map[string][]string{
+		"recoveryCodes": codes,
+	})
+}
+
+// DisableTwoFactor turns off 2FA.
+// Both password and a second factor are required.
+//
+//	DELETE /account/2fa
+//
+// Input:
+// * password: string;
+// * code?: string;
+// * recoveryCode?: string.
+func (router AccountRouter) DisableTwoFactor(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
+	defer router.Logger.Sync()
+	sugar := router.Logger.Sugar()
+
+	ftcID := xhttp.GetFtcID(req.Header)
+
+	var params input.TwoFactorDisableParams
+	if err := gorest.ParseJSON(req.Body, &params); err != nil {
+		sugar.Error(err)
+		_ = render.New(w).BadRequest(err.Error())
+		return
+	}
+
+	if ve := params.Validate(); ve != nil {
+		_ = render.New(w).Unprocessable(ve)
+		return
+	}
+
+	authResult, err := router.Repo.VerifyIDPassword(account.IDCredentials{
+		FtcID:    ftcID,
+		Password: params.Password,
+	})
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	if !authResult.PasswordMatched {
+		_ = render.New(w).Forbidden("Current password incorrect")
+		return
+	}
+
+	tf, err := router.Repo.RetrieveTwoFactor(ftcID)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	// A second factor is required only if 2FA is enabled.
+	// An unconfirmed enrollment is simply removed.
+	if tf.Enabled {
+		method, err := router.checkSecondFactor(tf, params.Code, params.RecoveryCode)
+		if err != nil {
+			sugar.Error(err)
+			_ = render.New(w).DBError(err)
+			return
+		}
+
+		if method == account.TwoFactorNull {
+			_ = render.New(w).Forbidden("Incorrect verification code")
+			return
+		}
+	}
+
+	err = router.Repo.DisableTwoFactor(ftcID)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	if tf.Enabled {
+		router.Tasks.Go(func() {
+			ba, err := router.ReaderRepo.BaseAccountByUUID(ftcID)
+			if err != nil {
+				sugar.Error(err)
+				return
+			}
+			err = router.EmailService.SendTwoFactorChanged(ba, false, false)
+			if err != nil {
+				sugar.Error(err)
+			}
+		})
+	}
+
+	_ = render.New(w).NoContent()
+}
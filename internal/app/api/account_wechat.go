@@ -26,6 +26,7 @@ import (
 //
 // The footprint.Client headers are required.
 func (router AccountRouter) WxSignUp(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
 
 	defer router.Logger.Sync()
 	sugar := router.Logger.Sugar()
@@ -110,26 +111,26 @@ func (router AccountRouter) WxSignUp(w http.ResponseWriter, req *http.Request) {
 	// Check if wx side has membership. Ftc side must not have one since
 	// this is a new email account.
 	if !wxAccount.Membership.IsZero() {
-		go func() {
+		router.Tasks.Go(func() {
 			versioned := reader.NewMembershipVersioned(merged.Membership).
 				WithPriorVersion(wxAccount.Membership).
 				ArchivedBy(reader.NewArchiver().ByWechat().ActionLink())
 
 			_ = router.ReaderRepo.VersionMembership(versioned)
-		}()
+		})
 	}
 
 	fp := footprint.
 		New(merged.FtcID, footprint.NewClient(req)).
 		FromSignUp()
 
-	go func() {
+	router.Tasks.Go(func() {
 		_ = router.Repo.SaveFootprint(fp)
-	}()
+	})
 
 	// Send an email telling user that a new account is created with this email, wechat is bound to it, and in the future the email account is equal to wechat account.
 	// Add customer service information so that user could contact us for any other issues.
-	go func() {
+	router.Tasks.Go(func() {
 		sugar.Info("Sending wechat signup email...")
 		verifier, err := account.NewEmailVerifier(in.Email, in.SourceURL)
 		if err != nil {
@@ -145,7 +146,7 @@ func (router AccountRouter) WxSignUp(w http.ResponseWriter, req *http.Request) {
 		if err != nil {
 			return
 		}
-	}()
+	})
 
 	// Change login method to wechat so that when unlinked, client knows which side should be used.
 	merged.LoginMethod = enum.LoginMethodWx
@@ -162,6 +163,7 @@ func (router AccountRouter) WxSignUp(w http.ResponseWriter, req *http.Request) {
 // Input: input.LinkWxParams
 // * ftcId: string;
 func (router AccountRouter) WxLinkEmail(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
 	defer router.Logger.Sync()
 	sugar := router.Logger.Sugar()
 
@@ -229,7 +231,10 @@ func (router AccountRouter) WxLinkEmail(w http.ResponseWriter, req *http.Request
 		return
 	}
 
-	go func() {
+	// Sessions of either side carry only one of the ids.
+	router.revokeLinkedSessions(params.FtcID, params.UnionID)
+
+	router.Tasks.Go(func() {
 		if !result.FtcVersioned.IsZero() {
 			_ = router.ReaderRepo.VersionMembership(result.FtcVersioned)
 		}
@@ -237,16 +242,16 @@ func (router AccountRouter) WxLinkEmail(w http.ResponseWriter, req *http.Request
 		if !result.WxVersioned.IsZero() {
 			_ = router.ReaderRepo.VersionMembership(result.WxVersioned)
 		}
-	}()
+	})
 
 	// Send email telling user that the accounts are linked.
-	go func() {
+	router.Tasks.Go(func() {
 		sugar.Info("Sending wx-email linked letter")
 		err := router.EmailService.SendWxEmailLink(result)
 		if err != nil {
 			sugar.Error(err)
 		}
-	}()
+	})
 
 	_ = render.New(w).NoContent()
 }
@@ -265,6 +270,7 @@ func (router AccountRouter) WxLinkEmail(w http.ResponseWriter, req *http.Request
 // not expired, `anchor` field must be provided indicating
 // after accounts unlinked, with which side the membership should be kept.
 func (router AccountRouter) WxUnlinkEmail(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
 	defer router.Logger.Sync()
 	sugar := router.Logger.Sugar()
 
@@ -315,24 +321,27 @@ func (router AccountRouter) WxUnlinkEmail(w http.ResponseWriter, req *http.Reque
 		return
 	}
 
+	// Sessions started on either side still carry both ids.
+	router.revokeLinkedSessions(acnt.FtcID, params.UnionID)
+
 	result := reader.NewWxEmailUnlinkResult(acnt, params.Anchor)
 	if !result.Versioned.IsZero() {
-		go func() {
+		router.Tasks.Go(func() {
 			err := router.ReaderRepo.VersionMembership(result.Versioned)
 			if err != nil {
 				sugar.Error(err)
 			}
-		}()
+		})
 	}
 
-	go func() {
+	router.Tasks.Go(func() {
 		err := router.EmailService.SendWxEmailUnlink(
 			acnt,
 			params.Anchor)
 		if err != nil {
 			sugar.Error(err)
 		}
-	}()
+	})
 
 	_ = render.New(w).NoContent()
 }
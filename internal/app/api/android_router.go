@@ -6,9 +6,10 @@ import (
 	"github.com/FTChinese/subscription-api/internal/pkg/android"
 	"github.com/FTChinese/subscription-api/internal/repository"
 	"github.com/FTChinese/subscription-api/internal/repository/apprepo"
+	"github.com/FTChinese/subscription-api/pkg/cachestore"
+	"github.com/FTChinese/subscription-api/pkg/cms"
 	"github.com/FTChinese/subscription-api/pkg/db"
 	"github.com/FTChinese/subscription-api/pkg/xhttp"
-	"github.com/patrickmn/go-cache"
 	"go.uber.org/zap"
 	"net/http"
 )
@@ -19,7 +20,7 @@ type AndroidRouter struct {
 	logger    *zap.Logger
 }
 
-func NewAndroidRouter(dbs db.ReadWriteMyDBs, c *cache.Cache, logger *zap.Logger) AndroidRouter {
+func NewAndroidRouter(dbs db.ReadWriteMyDBs, c cachestore.Store, logger *zap.Logger) AndroidRouter {
 	return AndroidRouter{
 		dbRepo:    apprepo.New(dbs),
 		cacheRepo: repository.NewCacheRepo(c),
@@ -56,6 +57,10 @@ func (router AndroidRouter) CreateRelease(w http.ResponseWriter, req *http.Reque
 		return
 	}
 
+	cms.AuditTrailFrom(req.Context()).
+		Target(release.VersionName).
+		Change(nil, release)
+
 	_ = render.New(w).OK(release)
 }
 
@@ -98,6 +103,8 @@ func (router AndroidRouter) UpdateRelease(w http.ResponseWriter, req *http.Reque
 		return
 	}
 
+	cms.AuditTrailFrom(req.Context()).Change(current, updated)
+
 	_ = render.New(w).OK(updated)
 }
 
@@ -107,11 +114,19 @@ func (router AndroidRouter) UpdateRelease(w http.ResponseWriter, req *http.Reque
 func (router AndroidRouter) DeleteRelease(w http.ResponseWriter, req *http.Request) {
 	versionName, _ := xhttp.GetURLParam(req, "versionName").ToString()
 
+	current, err := router.dbRepo.RetrieveRelease(versionName)
+	if err != nil {
+		_ = render.New(w).DBError(err)
+		return
+	}
+
 	if err := router.dbRepo.DeleteRelease(versionName); err != nil {
 		_ = render.New(w).DBError(err)
 		return
 	}
 
+	cms.AuditTrailFrom(req.Context()).Change(current, nil)
+
 	_ = render.New(w).NoContent()
 }
 
@@ -135,7 +150,9 @@ func (router AndroidRouter) loadLatestRelease(refresh bool) (android.Release, er
 	}
 
 	// Cache it.
-	router.cacheRepo.AndroidLatest(release)
+	if err := router.cacheRepo.AndroidLatest(release); err != nil {
+		sugar.Error(err)
+	}
 
 	return release, nil
 }
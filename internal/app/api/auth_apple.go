@@ -0,0 +1,157 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	gorest "github.com/FTChinese/go-rest"
+	"github.com/FTChinese/go-rest/enum"
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/internal/pkg/input"
+	"github.com/FTChinese/subscription-api/pkg/account"
+	"github.com/FTChinese/subscription-api/pkg/applelogin"
+	"github.com/FTChinese/subscription-api/pkg/footprint"
+	"github.com/FTChinese/subscription-api/pkg/reader"
+)
+
+// AppleLogin logs in a user with Sign in with Apple.
+// If the Apple user is not linked to any account, a new
+// account is created with the email provided by Apple,
+// which might be a private relay address.
+// If the email is already taken, client should ask user
+// to log in with email and link to Apple under /account/apple/link.
+//
+//	POST /auth/apple/login
+//
+// Input:
+// * identityToken: string;
+// * fullName?: string; Only available on first authorization.
+// * deviceToken?: string. Required only for Android app.
+//
+// The footprint.Client headers are required.
+func (router AuthRouter) AppleLogin(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
+	defer router.Logger.Sync()
+	sugar := router.Logger.Sugar()
+
+	var params input.AppleLoginParams
+	if err := gorest.ParseJSON(req.Body, &params); err != nil {
+		sugar.Error(err)
+		_ = render.New(w).BadRequest(err.Error())
+		return
+	}
+
+	if ve := params.Validate(); ve != nil {
+		sugar.Error(ve)
+		_ = render.New(w).Unprocessable(ve)
+		return
+	}
+
+	claims, err := router.AppleSignIn.Verify(params.IdentityToken)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).Unauthorized(err.Error())
+		return
+	}
+
+	client := footprint.NewClient(req)
+
+	link, err := router.Repo.AppleLinkBySub(claims.Subject)
+	switch {
+	case err == nil:
+		acnt, err := router.ReaderRepo.AccountByFtcID(link.FtcID)
+		if err != nil {
+			sugar.Error(err)
+			_ = render.New(w).DBError(err)
+			return
+		}
+
+		// Apple ID only passes the first factor of the linked
+		// account, same as password or magic link.
+		if router.challengeTwoFactor(w, acnt.FtcID, footprint.AuthMethodApple, params.DeviceToken) {
+			return
+		}
+
+		fp := footprint.New(acnt.FtcID, client).
+			FromLogin().
+			WithAuthMethod(footprint.AuthMethodApple, params.DeviceToken)
+
+		router.Tasks.Go(func() {
+			err := router.Repo.SaveFootprint(fp)
+			if err != nil {
+				sugar.Error(err)
+			}
+		})
+
+		router.renderLoggedIn(w, req, acnt, footprint.AuthMethodApple)
+		return
+
+	case err != sql.ErrNoRows:
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	// First time login. Create a new account.
+	if claims.Email == "" {
+		_ = render.New(w).Unprocessable(&render.ValidationError{
+			Message: "Apple did not provide an email",
+			Field:   "email",
+			Code:    render.CodeMissing,
+		})
+		return
+	}
+
+	ok, err := router.Repo.EmailExists(claims.Email)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+	if ok {
+		_ = render.New(w).Unprocessable(&render.ValidationError{
+			Message: "Email already exists. Please log in with email and link your Apple ID",
+			Field:   "email",
+			Code:    render.CodeAlreadyExists,
+		})
+		return
+	}
+
+	baseAccount := account.NewAppleBaseAccount(
+		claims.Email,
+		params.FullName,
+		bool(claims.EmailVerified))
+
+	err = router.Repo.AppleSignUp(baseAccount, applelogin.NewLink(baseAccount.FtcID, claims))
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	if baseAccount.IsVerified {
+		router.Tasks.Go(func() {
+			if err := router.Repo.EmailVerified(baseAccount.FtcID); err != nil {
+				sugar.Error(err)
+			}
+		})
+	}
+
+	fp := footprint.New(baseAccount.FtcID, client).
+		FromSignUp().
+		WithAuthMethod(footprint.AuthMethodApple, params.DeviceToken)
+
+	router.Tasks.Go(func() {
+		err := router.Repo.SaveFootprint(fp)
+		if err != nil {
+			sugar.Error(err)
+		}
+	})
+
+	router.renderLoggedIn(w, req, reader.Account{
+		BaseAccount: baseAccount,
+		LoginMethod: enum.LoginMethodEmail,
+		Wechat:      account.Wechat{},
+		Membership:  reader.Membership{},
+	}, footprint.AuthMethodApple)
+}
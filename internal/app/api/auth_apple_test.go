@@ -0,0 +1,139 @@
+package api
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/FTChinese/go-rest/chrono"
+	"github.com/FTChinese/subscription-api/internal/repository/accounts"
+	"github.com/FTChinese/subscription-api/internal/repository/shared"
+	"github.com/FTChinese/subscription-api/pkg/account"
+	"github.com/FTChinese/subscription-api/pkg/applelogin"
+	"github.com/FTChinese/subscription-api/pkg/config"
+	"github.com/FTChinese/subscription-api/pkg/db"
+	"github.com/FTChinese/subscription-api/test"
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+// mockAppleIdentity signs identity tokens with a key served
+// from a local JWKS endpoint.
+type mockAppleIdentity struct {
+	key *rsa.PrivateKey
+	srv *httptest.Server
+}
+
+func newMockAppleIdentity(t *testing.T) mockAppleIdentity {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(applelogin.JWKSet{Keys: []applelogin.JWK{
+			{
+				Kty: "RSA",
+				Kid: "test-kid",
+				N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			},
+		}})
+	}))
+
+	return mockAppleIdentity{
+		key: key,
+		srv: srv,
+	}
+}
+
+func (m mockAppleIdentity) verifier() applelogin.Verifier {
+	return applelogin.NewVerifier(config.AppleSignIn{
+		JWKSURL:   m.srv.URL,
+		ClientIDs: []string{"com.ft.ftchinese.mobile"},
+	})
+}
+
+func (m mockAppleIdentity) token(t *testing.T, sub string, email string) string {
+	h, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "test-kid"})
+	c, _ := json.Marshal(map[string]interface{}{
+		"iss":            config.AppleIssuer,
+		"aud":            "com.ft.ftchinese.mobile",
+		"sub":            sub,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(10 * time.Minute).Unix(),
+		"email":          email,
+		"email_verified": "true",
+	})
+
+	signing := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+	digest := sha256.Sum256([]byte(signing))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, m.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signing + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestAuthRouter_AppleLogin_twoFactor(t *testing.T) {
+	apple := newMockAppleIdentity(t)
+	defer apple.srv.Close()
+
+	myDB := db.MockMySQL()
+	logger := zaptest.NewLogger(t)
+	repo := accounts.New(myDB, logger)
+	router := NewAuthRouter(UserShared{
+		Repo:        repo,
+		ReaderRepo:  shared.NewReaderCommon(myDB),
+		Logger:      logger,
+		AppleSignIn: apple.verifier(),
+	})
+
+	acnt := test.NewPersona().EmailOnlyAccount()
+	test.NewRepo().MustCreateFtcAccount(acnt)
+
+	tf, err := account.NewTwoFactor(acnt.FtcID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tf.Enabled = true
+	tf.ConfirmedUTC = chrono.TimeNow()
+	if err := repo.SaveTwoFactor(tf); err != nil {
+		t.Fatal(err)
+	}
+
+	sub := uuid.New().String()
+	err = repo.LinkApple(applelogin.Link{
+		Subject:    sub,
+		FtcID:      acnt.FtcID,
+		Email:      acnt.Email,
+		CreatedUTC: chrono.TimeNow(),
+		UpdatedUTC: chrono.TimeNow(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ := json.Marshal(map[string]string{
+		"identityToken": apple.token(t, sub, acnt.Email),
+	})
+	req := httptest.NewRequest(http.MethodPost, "/auth/apple/login", bytes.NewReader(body))
+	req.Header.Set("X-Client-Type", "ios")
+	w := httptest.NewRecorder()
+
+	router.AppleLogin(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Errorf("AppleLogin() want status %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+	}
+}
@@ -18,6 +18,7 @@ import (
 //
 //	GET /auth/email/exists?v={email}
 func (router AuthRouter) EmailExists(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
 	defer router.Logger.Sync()
 	sugar := router.Logger.Sugar()
 
@@ -53,8 +54,12 @@ func (router AuthRouter) EmailExists(w http.ResponseWriter, req *http.Request) {
 // * password: string
 // * deviceToken?: string. Required only for Android app.
 //
+// If user enabled 2FA, responds `202 Accepted` with
+// account.TwoFactorRequired instead of the account.
+//
 // The footprint.Client headers are required.
 func (router AuthRouter) EmailLogin(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
 	defer router.Logger.Sync()
 	sugar := router.Logger.Sugar()
 
@@ -85,23 +90,30 @@ func (router AuthRouter) EmailLogin(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	// If 2FA is enabled, client should answer the challenge
+	// at /auth/email/login/2fa to get the account.
+	if router.challengeTwoFactor(w, authResult.UserID, footprint.AuthMethodEmail, params.DeviceToken) {
+		return
+	}
+
 	// There shouldn't be any not found error.
 	acnt, err := router.ReaderRepo.AccountByFtcID(authResult.UserID)
 	if err != nil {
 		sugar.Error(err)
 		_ = render.New(w).DBError(err)
+		return
 	}
 
 	fp := footprint.New(acnt.FtcID, footprint.NewClient(req)).
 		FromLogin().
 		WithAuth(enum.LoginMethodEmail, params.DeviceToken)
 
-	go func() {
+	router.Tasks.Go(func() {
 		err := router.Repo.SaveFootprint(fp)
 		if err != nil {
 			sugar.Error(err)
 		}
-	}()
+	})
 
 	if acnt.IsMobileEmail() {
 		acnt.BaseAccount = acnt.SyncMobile()
@@ -109,7 +121,7 @@ func (router AuthRouter) EmailLogin(w http.ResponseWriter, req *http.Request) {
 		router.SyncMobile(acnt.BaseAccount)
 	}
 
-	_ = render.New(w).OK(acnt)
+	router.renderLoggedIn(w, req, acnt, footprint.AuthMethodEmail)
 }
 
 // EmailSignUp create a new account for a user.
@@ -125,6 +137,7 @@ func (router AuthRouter) EmailLogin(w http.ResponseWriter, req *http.Request) {
 //
 // The footprint.Client headers are required.
 func (router AuthRouter) EmailSignUp(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
 	defer router.Logger.Sync()
 	sugar := router.Logger.Sugar()
 
@@ -149,7 +162,7 @@ func (router AuthRouter) EmailSignUp(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	router.emailSignUp(w, params, clientApp)
+	router.emailSignUp(w, req, params)
 }
 
 // emailSignUp creates a new email account.
@@ -159,10 +172,12 @@ func (router AuthRouter) EmailSignUp(w http.ResponseWriter, req *http.Request) {
 // * mobile?: string; - Required only when mobile is linking to new account.
 // * deviceToken?: string; - Required for Android app.
 // * sourceUrl?: string; - Used to compose email verification link.
-func (router AuthRouter) emailSignUp(w http.ResponseWriter, params input.EmailSignUpParams, client footprint.Client) {
+func (router AuthRouter) emailSignUp(w http.ResponseWriter, req *http.Request, params input.EmailSignUpParams) {
 	defer router.Logger.Sync()
 	sugar := router.Logger.Sugar()
 
+	client := footprint.NewClient(req)
+
 	if ve := params.Validate(); ve != nil {
 		sugar.Error(ve)
 		_ = render.New(w).Unprocessable(ve)
@@ -189,34 +204,35 @@ func (router AuthRouter) emailSignUp(w http.ResponseWriter, params input.EmailSi
 		FromSignUp().
 		WithAuth(enum.LoginMethodMobile, params.DeviceToken)
 
-	go func() {
+	router.Tasks.Go(func() {
 		err := router.Repo.SaveFootprint(fp)
 		if err != nil {
 			sugar.Error()
 		}
-	}()
+	})
 
 	// Send verification email.
-	go func() {
+	router.Tasks.Go(func() {
 		_ = router.SendEmailVerification(
 			baseAccount,
 			params.SourceURL,
 			true)
-	}()
+	})
 
 	// Compose reader.Account instance.
-	_ = render.New(w).OK(reader.Account{
+	router.renderLoggedIn(w, req, reader.Account{
 		BaseAccount: baseAccount,
 		LoginMethod: enum.LoginMethodEmail,
 		Wechat:      account.Wechat{},
 		Membership:  reader.Membership{},
-	})
+	}, footprint.AuthMethodEmail)
 }
 
 // VerifyEmail verifies the authenticity of user's email.
 //
 //	POST /auth/email/verification/{token}
 func (router AuthRouter) VerifyEmail(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
 	defer router.Logger.Sync()
 	sugar := router.Logger.Sugar()
 
@@ -260,17 +276,17 @@ func (router AuthRouter) VerifyEmail(w http.ResponseWriter, req *http.Request) {
 		New(baseAccount.FtcID, footprint.NewClient(req)).
 		FromVerification()
 
-	go func() {
+	router.Tasks.Go(func() {
 		_ = router.Repo.SaveFootprint(fp)
-	}()
+	})
 
 	// Send a greeting letter.
-	go func() {
+	router.Tasks.Go(func() {
 		err := router.EmailService.SendGreeting(baseAccount)
 		if err != nil {
 			sugar.Error(err)
 		}
-	}()
+	})
 
 	_ = render.New(w).NoContent()
 }
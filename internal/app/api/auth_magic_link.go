@@ -0,0 +1,195 @@
+package api
+
+import (
+	"net/http"
+
+	gorest "github.com/FTChinese/go-rest"
+	"github.com/FTChinese/go-rest/enum"
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/internal/pkg/input"
+	"github.com/FTChinese/subscription-api/pkg/account"
+	"github.com/FTChinese/subscription-api/pkg/footprint"
+)
+
+// RequestMagicLink sends a passwordless login link,
+// or a 6-digit code for mobile apps, to an existing email account.
+//
+//	POST /auth/email/magic-link
+//
+// Input:
+// * email: string;
+// * useCode: boolean; - Send a code instead of a link.
+// * sourceUrl?: string; - Only applicable to web app. Must be under our own domains.
+//
+// The footprint.Client headers are required.
+func (router AuthRouter) RequestMagicLink(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
+	defer router.Logger.Sync()
+	sugar := router.Logger.Sugar()
+
+	var params input.MagicLinkParams
+	if err := gorest.ParseJSON(req.Body, &params); err != nil {
+		sugar.Error(err)
+		_ = render.New(w).BadRequest(err.Error())
+		return
+	}
+
+	client := footprint.NewClient(req)
+	if client.IsApp() && !params.UseCode {
+		params.UseCode = true
+	}
+
+	if ve := params.Validate(router.Live); ve != nil {
+		sugar.Error(ve)
+		_ = render.New(w).Unprocessable(ve)
+		return
+	}
+
+	limit, err := router.Repo.MagicLinkLimit(params.Email, client.UserIP.String)
+	if err != nil {
+		sugar.Error(err)
+	}
+	if limit.Exceeds() {
+		_ = render.New(w).TooManyRequests("Too many login requests within the past 1 hour.")
+		return
+	}
+
+	baseAccount, err := router.Repo.BaseAccountByEmail(params.Email)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	session, err := account.NewMagicLinkSession(params, baseAccount, client.UserIP)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).InternalServerError(err.Error())
+		return
+	}
+
+	if err := router.Repo.SaveMagicLink(session); err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	err = router.EmailService.SendMagicLink(baseAccount, session)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).InternalServerError(err.Error())
+		return
+	}
+
+	// `204 No Content`
+	_ = render.New(w).NoContent()
+}
+
+// VerifyMagicLink exchanges a magic link token, or email + code,
+// for the account. Each session could only be used once.
+// If 2FA is enabled, a challenge is returned just like EmailLogin.
+//
+//	POST /auth/email/magic-link/verify
+//
+// Input:
+// * token?: string; - From the link in email.
+// * email?: string; - Required if token is missing.
+// * code?: string; - Required if token is missing.
+// * deviceToken?: string. Required only for Android app.
+//
+// The footprint.Client headers are required.
+func (router AuthRouter) VerifyMagicLink(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
+	defer router.Logger.Sync()
+	sugar := router.Logger.Sugar()
+
+	var params input.MagicLinkVerifyParams
+	if err := gorest.ParseJSON(req.Body, &params); err != nil {
+		sugar.Error(err)
+		_ = render.New(w).BadRequest(err.Error())
+		return
+	}
+
+	if ve := params.Validate(); ve != nil {
+		sugar.Error(ve)
+		_ = render.New(w).Unprocessable(ve)
+		return
+	}
+
+	var session account.MagicLinkSession
+	var err error
+	if params.Token != "" {
+		session, err = router.Repo.MagicLinkByToken(params.Token)
+	} else {
+		session, err = router.Repo.MagicLinkByEmail(params.Email)
+	}
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	if !session.IsValid() {
+		_ = render.New(w).Forbidden("The login link or code is expired or already used")
+		return
+	}
+
+	if params.Token == "" && !session.MatchCode(params.Code) {
+		err := router.Repo.IncMagicLinkAttempts(session.Token)
+		if err != nil {
+			sugar.Error(err)
+		}
+		_ = render.New(w).Forbidden("Incorrect verification code")
+		return
+	}
+
+	ok, err := router.Repo.DisableMagicLink(session.Token)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+	if !ok {
+		_ = render.New(w).Forbidden("The login link or code is expired or already used")
+		return
+	}
+
+	acnt, err := router.ReaderRepo.AccountByFtcID(session.FtcID)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	// Receiving the letter proves ownership of the email.
+	if !acnt.IsVerified && acnt.Email == session.Email {
+		if err := router.Repo.EmailVerified(acnt.FtcID); err != nil {
+			sugar.Error(err)
+		} else {
+			acnt.IsVerified = true
+		}
+	}
+
+	if router.challengeTwoFactor(w, acnt.FtcID, footprint.AuthMethodEmailLink, params.DeviceToken) {
+		return
+	}
+
+	fp := footprint.New(acnt.FtcID, footprint.NewClient(req)).
+		FromLogin().
+		WithAuthMethod(footprint.AuthMethodEmailLink, params.DeviceToken)
+
+	router.Tasks.Go(func() {
+		err := router.Repo.SaveFootprint(fp)
+		if err != nil {
+			sugar.Error(err)
+		}
+	})
+
+	if acnt.IsMobileEmail() {
+		acnt.BaseAccount = acnt.SyncMobile()
+		acnt.LoginMethod = enum.LoginMethodMobile
+		router.SyncMobile(acnt.BaseAccount)
+	}
+
+	router.renderLoggedIn(w, req, acnt, footprint.AuthMethodEmailLink)
+}
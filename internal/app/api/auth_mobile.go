@@ -11,6 +11,7 @@ import (
 	"github.com/FTChinese/subscription-api/pkg/account"
 	"github.com/FTChinese/subscription-api/pkg/db"
 	"github.com/FTChinese/subscription-api/pkg/footprint"
+	"github.com/FTChinese/subscription-api/pkg/ids"
 	"github.com/FTChinese/subscription-api/pkg/reader"
 	"github.com/FTChinese/subscription-api/pkg/ztsms"
 	"github.com/guregu/null"
@@ -20,6 +21,7 @@ import (
 // Input:
 // mobile: string
 func (router AuthRouter) RequestSMSVerification(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
 	defer router.Logger.Sync()
 	sugar := router.Logger.Sugar()
 
@@ -89,7 +91,10 @@ func (router AuthRouter) RequestSMSVerification(w http.ResponseWriter, req *http
 // If user id is null, it indicates this mobile phone is used for the first time.
 // Client should ask user to enter email so that we could link this mobile to an email account;
 // otherwise client should use the user id to retrieve reader.Account.
+// If the account has 2FA enabled, 202 is returned with a
+// challenge to answer at /auth/email/login/2fa instead.
 func (router AuthRouter) VerifySMSCode(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
 	defer router.Logger.Sync()
 	sugar := router.Logger.Sugar()
 
@@ -115,12 +120,12 @@ func (router AuthRouter) VerifySMSCode(w http.ResponseWriter, req *http.Request)
 		return
 	}
 
-	go func() {
+	router.Tasks.Go(func() {
 		err := router.Repo.SMSVerifierUsed(vrf.WithUsed())
 		if err != nil {
 			sugar.Error(err)
 		}
-	}()
+	})
 
 	// If FtcID exists, it indicates this mobile is already
 	// linked to an email account. We treat it as a login
@@ -128,19 +133,25 @@ func (router AuthRouter) VerifySMSCode(w http.ResponseWriter, req *http.Request)
 	// otherwise the metadata should be recorded by link mobile
 	// or signup process.
 	if vrf.FtcID.Valid {
+		// SMS code only passes the first factor of the
+		// linked email account.
+		if router.challengeTwoFactor(w, vrf.FtcID.String, footprint.AuthMethodMobile, params.DeviceToken) {
+			return
+		}
+
 		fp := footprint.
 			New(vrf.FtcID.String, footprint.NewClient(req)).
 			FromLogin().
 			WithAuth(enum.LoginMethodMobile, params.DeviceToken)
 
-		go func() {
+		router.Tasks.Go(func() {
 			err := router.Repo.SaveFootprint(fp)
 			if err != nil {
 				sugar.Error(err)
 			}
-		}()
+		})
 
-		_ = render.New(w).OK(account.NewSearchResult(vrf.FtcID.String))
+		router.renderMobileFound(w, req, account.NewSearchResult(vrf.FtcID.String))
 		return
 	}
 
@@ -161,7 +172,11 @@ func (router AuthRouter) VerifySMSCode(w http.ResponseWriter, req *http.Request)
 	// and the mobile should be synced between tables, which is
 	// delayed till the account is fetched.
 	if result.ID.Valid {
-		go func() {
+		if router.challengeTwoFactor(w, result.ID.String, footprint.AuthMethodMobile, params.DeviceToken) {
+			return
+		}
+
+		router.Tasks.Go(func() {
 			fp := footprint.
 				New(result.ID.String, footprint.NewClient(req)).
 				FromLogin().
@@ -171,7 +186,10 @@ func (router AuthRouter) VerifySMSCode(w http.ResponseWriter, req *http.Request)
 			if err != nil {
 				sugar.Error(err)
 			}
-		}()
+		})
+
+		router.renderMobileFound(w, req, result)
+		return
 	}
 
 	_ = render.New(w).OK(result)
@@ -188,13 +206,15 @@ func (router AuthRouter) VerifySMSCode(w http.ResponseWriter, req *http.Request)
 //
 // Require header footprint.Client.
 //
-// Returns reader.Account.
+// Returns reader.Account, or a 2FA challenge with 202 if
+// enabled for the email account.
 // Possible cases:
 // * The link target does not exist in profile table. Insert.
 // * The link target present in profile table but mobile column missing. Update.
 // * The link target present in profile table and mobile column is taken by another mobile number. Deny.
 // * The link target present in profile and mobile column is this mobile number. No action.
 func (router AuthRouter) MobileLinkExistingEmail(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
 	defer router.Logger.Sync()
 	sugar := router.Logger.Sugar()
 
@@ -242,7 +262,10 @@ func (router AuthRouter) MobileLinkExistingEmail(w http.ResponseWriter, req *htt
 	if currentMobile != "" {
 		// Mobile already set. Return the account immediately.
 		if currentMobile == params.Mobile {
-			_ = render.New(w).OK(acnt)
+			if router.challengeTwoFactor(w, acnt.FtcID, footprint.AuthMethodMobile, params.DeviceToken) {
+				return
+			}
+			router.renderLoggedIn(w, req, acnt, footprint.AuthMethodMobile)
 			return
 		}
 
@@ -305,14 +328,29 @@ func (router AuthRouter) MobileLinkExistingEmail(w http.ResponseWriter, req *htt
 		FromSignUp().
 		WithAuth(enum.LoginMethodMobile, params.DeviceToken)
 
-	go func() {
+	router.Tasks.Go(func() {
 		err := router.Repo.SaveFootprint(fp)
 		if err != nil {
 			sugar.Error()
 		}
-	}()
+	})
+
+	// Revoked before a new session is started for the linked
+	// account so that the new one is kept.
+	err = router.Sessions.Repo.RevokeUserSessions(ids.UserIDs{
+		FtcID: null.StringFrom(acnt.FtcID),
+	})
+	if err != nil {
+		sugar.Error(err)
+	}
 
-	_ = render.New(w).OK(acnt)
+	// Mobile is linked with password only. Login still
+	// requires the second factor.
+	if router.challengeTwoFactor(w, acnt.FtcID, footprint.AuthMethodMobile, params.DeviceToken) {
+		return
+	}
+
+	router.renderLoggedIn(w, req, acnt, footprint.AuthMethodMobile)
 }
 
 // MobileSignUp creates a new mobile account.
@@ -322,6 +360,7 @@ func (router AuthRouter) MobileLinkExistingEmail(w http.ResponseWriter, req *htt
 // - mobile: string;
 // - deviceToken?: string; - Required for Android app.
 func (router AuthRouter) MobileSignUp(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
 	defer router.Logger.Sync()
 	sugar := router.Logger.Sugar()
 
@@ -336,12 +375,12 @@ func (router AuthRouter) MobileSignUp(w http.ResponseWriter, req *http.Request)
 	// used to create a new email account when mobile is
 	// used to log in for the first time.
 	if params.HasCredentials() {
-		router.emailSignUp(w, input.EmailSignUpParams{
+		router.emailSignUp(w, req, input.EmailSignUpParams{
 			EmailCredentials: params.EmailCredentials,
 			Mobile:           null.StringFrom(params.Mobile),
 			DeviceToken:      params.DeviceToken,
 			SourceURL:        params.SourceURL,
-		}, footprint.NewClient(req))
+		})
 		return
 	}
 
@@ -374,17 +413,17 @@ func (router AuthRouter) MobileSignUp(w http.ResponseWriter, req *http.Request)
 		FromSignUp().
 		WithAuth(enum.LoginMethodMobile, params.DeviceToken)
 
-	go func() {
+	router.Tasks.Go(func() {
 		err := router.Repo.SaveFootprint(fp)
 		if err != nil {
 			sugar.Error()
 		}
-	}()
+	})
 
-	_ = render.New(w).OK(reader.Account{
+	router.renderLoggedIn(w, req, reader.Account{
 		BaseAccount: baseAccount,
 		LoginMethod: enum.LoginMethodMobile,
 		Wechat:      account.Wechat{},
 		Membership:  reader.Membership{},
-	})
+	}, footprint.AuthMethodMobile)
 }
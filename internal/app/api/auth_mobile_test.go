@@ -8,6 +8,7 @@ import (
 	"github.com/FTChinese/subscription-api/faker"
 	"github.com/FTChinese/subscription-api/internal/pkg/letter"
 	"github.com/FTChinese/subscription-api/internal/repository/accounts"
+	"github.com/FTChinese/subscription-api/internal/repository/mailrepo"
 	"github.com/FTChinese/subscription-api/internal/repository/shared"
 	"github.com/FTChinese/subscription-api/pkg/db"
 	"github.com/FTChinese/subscription-api/pkg/ztsms"
@@ -23,7 +24,7 @@ func newMockAuthRouter(t *testing.T) AuthRouter {
 		Repo:         accounts.New(myDB, logger),
 		ReaderRepo:   shared.NewReaderCommon(myDB),
 		SMSClient:    ztsms.NewClient(logger),
-		EmailService: letter.NewService(logger),
+		EmailService: letter.NewService(mailrepo.New(myDB, logger), logger),
 	})
 }
 
@@ -22,6 +22,7 @@ import (
 //
 // The footprint.Client headers are required.
 func (router AuthRouter) ForgotPassword(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
 	defer router.Logger.Sync()
 	sugar := router.Logger.Sugar()
 
@@ -72,9 +73,9 @@ func (router AuthRouter) ForgotPassword(w http.ResponseWriter, req *http.Request
 	fp := footprint.New(baseAccount.FtcID, client).
 		FromPwReset()
 
-	go func() {
+	router.Tasks.Go(func() {
 		_ = router.Repo.SaveFootprint(fp)
-	}()
+	})
 
 	// Compose email
 	err = router.EmailService.SendPasswordReset(baseAccount, session)
@@ -92,6 +93,7 @@ func (router AuthRouter) ForgotPassword(w http.ResponseWriter, req *http.Request
 //
 // 	GET /auth/password-reset/tokens/{token}
 func (router AuthRouter) VerifyResetToken(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
 	defer router.Logger.Sync()
 	sugar := router.Logger.Sugar()
 
@@ -124,6 +126,7 @@ func (router AuthRouter) VerifyResetToken(w http.ResponseWriter, req *http.Reque
 //
 // GET /users/password-reset/codes?email=xxx&code=xxx
 func (router AuthRouter) VerifyResetCode(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
 	defer router.Logger.Sync()
 	sugar := router.Logger.Sugar()
 
@@ -176,6 +179,7 @@ func (router AuthRouter) VerifyResetCode(w http.ResponseWriter, req *http.Reques
 // * token: string;
 // * password: string.
 func (router AuthRouter) ResetPassword(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
 	defer router.Logger.Sync()
 	sugar := router.Logger.Sugar()
 
@@ -222,9 +226,12 @@ func (router AuthRouter) ResetPassword(w http.ResponseWriter, req *http.Request)
 	}
 
 	// Invalidate token.
-	go func() {
+	router.Tasks.Go(func() {
 		_ = router.Repo.DisablePasswordReset(params.Token)
-	}()
+	})
+
+	// Log out everywhere.
+	router.revokeSessions(baseAccount.FtcID)
 
 	// `204 No Content`
 	_ = render.New(w).NoContent()
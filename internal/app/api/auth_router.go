@@ -1,5 +1,7 @@
 package api
 
+import "net/http"
+
 type AuthRouter struct {
 	UserShared
 }
@@ -9,3 +11,9 @@ func NewAuthRouter(shared UserShared) AuthRouter {
 		UserShared: shared,
 	}
 }
+
+// withRequest logs with the request id of req.
+func (router AuthRouter) withRequest(req *http.Request) AuthRouter {
+	router.UserShared = router.UserShared.withRequest(req)
+	return router
+}
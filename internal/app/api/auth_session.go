@@ -0,0 +1,266 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+
+	gorest "github.com/FTChinese/go-rest"
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/internal/pkg/input"
+	"github.com/FTChinese/subscription-api/internal/repository/sessionrepo"
+	"github.com/FTChinese/subscription-api/pkg/account"
+	"github.com/FTChinese/subscription-api/pkg/footprint"
+	"github.com/FTChinese/subscription-api/pkg/ids"
+	"github.com/FTChinese/subscription-api/pkg/reader"
+	"github.com/FTChinese/subscription-api/pkg/session"
+	"github.com/FTChinese/subscription-api/pkg/wxlogin"
+	"github.com/FTChinese/subscription-api/pkg/xhttp"
+	"github.com/guregu/null"
+)
+
+// SessionStarter issues signed session tokens upon login
+// and persists the session so that it could be refreshed
+// or revoked later.
+type SessionStarter struct {
+	Issuer session.Issuer
+	Repo   sessionrepo.Env
+}
+
+func (s SessionStarter) Start(req *http.Request, userIDs ids.UserIDs, method footprint.AuthMethod) (session.Credentials, error) {
+	sess, cred, err := s.Issuer.Start(userIDs, method, footprint.NewClient(req))
+	if err != nil {
+		return session.Credentials{}, err
+	}
+
+	err = s.Repo.SaveSession(sess)
+	if err != nil {
+		return session.Credentials{}, err
+	}
+
+	return cred, nil
+}
+
+// accountSession is returned from login endpoints.
+// Account fields stay at the top level so that clients
+// ignoring the session keep working.
+type accountSession struct {
+	reader.Account
+	Credentials session.Credentials `json:"session"`
+}
+
+// searchSession is returned when mobile login finds an
+// existing account.
+type searchSession struct {
+	account.SearchResult
+	Credentials session.Credentials `json:"session"`
+}
+
+// wxSession is returned from wechat login.
+type wxSession struct {
+	wxlogin.Session
+	Credentials session.Credentials `json:"session"`
+}
+
+// renderLoggedIn starts a session for a logged-in account
+// and sends the account together with session credentials.
+func (us UserShared) renderLoggedIn(w http.ResponseWriter, req *http.Request, a reader.Account, method footprint.AuthMethod) {
+	defer us.Logger.Sync()
+	sugar := us.Logger.Sugar()
+
+	cred, err := us.Sessions.Start(req, a.CompoundIDs(), method)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	_ = render.New(w).OK(accountSession{
+		Account:     a,
+		Credentials: cred,
+	})
+}
+
+// renderMobileFound starts a session when a mobile login
+// finds an existing account.
+func (us UserShared) renderMobileFound(w http.ResponseWriter, req *http.Request, result account.SearchResult) {
+	defer us.Logger.Sync()
+	sugar := us.Logger.Sugar()
+
+	cred, err := us.Sessions.Start(req, ids.UserIDs{FtcID: result.ID}, footprint.AuthMethodMobile)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	_ = render.New(w).OK(searchSession{
+		SearchResult: result,
+		Credentials:  cred,
+	})
+}
+
+// revokeSessions logs a user out of all devices, e.g., after
+// password changed.
+func (us UserShared) revokeSessions(ftcID string) {
+	us.revokeUserSessions(ids.UserIDs{
+		FtcID: null.StringFrom(ftcID),
+	})
+}
+
+// revokeLinkedSessions logs out both sides after accounts are
+// linked or unlinked, since user ids carried by a session are
+// fixed upon login.
+func (us UserShared) revokeLinkedSessions(ftcID string, unionID string) {
+	us.revokeUserSessions(ids.UserIDs{
+		FtcID:   null.NewString(ftcID, ftcID != ""),
+		UnionID: null.NewString(unionID, unionID != ""),
+	})
+}
+
+func (us UserShared) revokeUserSessions(userIDs ids.UserIDs) {
+	us.Tasks.Go(func() {
+		defer us.Logger.Sync()
+		sugar := us.Logger.Sugar()
+
+		err := us.Sessions.Repo.RevokeUserSessions(userIDs)
+		if err != nil {
+			sugar.Error(err)
+		}
+	})
+}
+
+// RefreshSession exchanges a refresh token for a new access
+// token. The refresh token is rotated on each call. Presenting
+// a refresh token that has already been rotated revokes the
+// whole session since it is likely stolen.
+//
+//	POST /auth/session/refresh
+//
+// Input:
+// * refreshToken: string.
+//
+// Response: session.Credentials.
+func (router AuthRouter) RefreshSession(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
+	defer router.Logger.Sync()
+	sugar := router.Logger.Sugar()
+
+	var params input.SessionRefreshParams
+	if err := gorest.ParseJSON(req.Body, &params); err != nil {
+		sugar.Error(err)
+		_ = render.New(w).BadRequest(err.Error())
+		return
+	}
+
+	if ve := params.Validate(); ve != nil {
+		_ = render.New(w).Unprocessable(ve)
+		return
+	}
+
+	s, err := router.Sessions.Repo.SessionByRefresh(params.RefreshToken)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			_ = render.New(w).Unauthorized("Invalid refresh token")
+			return
+		}
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	if s.RefreshHash != session.HashRefreshToken(params.RefreshToken) {
+		sugar.Infof("Refresh token reused for session %s", s.ID)
+		if err := router.Sessions.Repo.RevokeSession(s.ID); err != nil {
+			sugar.Error(err)
+		}
+		_ = render.New(w).Unauthorized("Refresh token already used")
+		return
+	}
+
+	if !s.IsActive() {
+		_ = render.New(w).Unauthorized("Session is revoked or expired")
+		return
+	}
+
+	s, cred, err := router.Sessions.Issuer.Refresh(s)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).InternalServerError(err.Error())
+		return
+	}
+
+	ok, err := router.Sessions.Repo.RefreshSession(s)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+	// Another request presenting the same refresh token won
+	// the race. The session is kept since the winner holds
+	// the new refresh token.
+	if !ok {
+		sugar.Infof("Refresh token reused concurrently for session %s", s.ID)
+		_ = render.New(w).Unauthorized("Refresh token already used")
+		return
+	}
+
+	_ = render.New(w).OK(cred)
+}
+
+// Logout revokes current session. The session is identified
+// by `X-Session-Token` header, or by refresh token in body if
+// access token is already expired.
+//
+//	POST /auth/session/logout
+//
+// Input:
+// * refreshToken?: string.
+func (router AuthRouter) Logout(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
+	defer router.Logger.Sync()
+	sugar := router.Logger.Sugar()
+
+	var sessionID string
+	if token := strings.TrimSpace(xhttp.GetSessionToken(req.Header)); token != "" {
+		claims, err := router.Sessions.Issuer.Verify(token)
+		if err == nil {
+			sessionID = claims.SessionID
+		}
+	}
+
+	if sessionID == "" {
+		var params input.SessionRefreshParams
+		if err := gorest.ParseJSON(req.Body, &params); err != nil {
+			sugar.Error(err)
+			_ = render.New(w).BadRequest(err.Error())
+			return
+		}
+
+		if ve := params.Validate(); ve != nil {
+			_ = render.New(w).Unprocessable(ve)
+			return
+		}
+
+		s, err := router.Sessions.Repo.SessionByRefresh(params.RefreshToken)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				_ = render.New(w).NoContent()
+				return
+			}
+			sugar.Error(err)
+			_ = render.New(w).DBError(err)
+			return
+		}
+		sessionID = s.ID
+	}
+
+	err := router.Sessions.Repo.RevokeSession(sessionID)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	_ = render.New(w).NoContent()
+}
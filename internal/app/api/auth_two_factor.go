@@ -0,0 +1,173 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	gorest "github.com/FTChinese/go-rest"
+	"github.com/FTChinese/go-rest/enum"
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/internal/pkg/input"
+	"github.com/FTChinese/subscription-api/pkg/account"
+	"github.com/FTChinese/subscription-api/pkg/footprint"
+	"github.com/guregu/null"
+)
+
+// challengeTwoFactor responds with a challenge if 2FA is
+// enabled for the account, after the first factor passed.
+// The first factor's method is kept on the challenge so that
+// the login is recorded as it is once the challenge is answered.
+// Returns true if a response is already written so that
+// caller should stop.
+func (router AuthRouter) challengeTwoFactor(w http.ResponseWriter, ftcID string, method footprint.AuthMethod, deviceToken null.String) bool {
+	sugar := router.Logger.Sugar()
+
+	tf, err := router.Repo.RetrieveTwoFactor(ftcID)
+	if err != nil && err != sql.ErrNoRows {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return true
+	}
+	if !tf.Enabled {
+		return false
+	}
+
+	challenge, err := account.NewTwoFactorChallenge(ftcID, string(method), deviceToken)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).InternalServerError(err.Error())
+		return true
+	}
+
+	err = router.Repo.SaveTwoFactorChallenge(challenge)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return true
+	}
+
+	// `202 Accepted` tells client that login is not finished yet.
+	_ = render.New(w).JSON(http.StatusAccepted, account.NewTwoFactorRequired(challenge))
+	return true
+}
+
+// EmailLoginTwoFactor finishes login for accounts with 2FA
+// enabled by answering the challenge returned from EmailLogin.
+//
+//	POST /auth/email/login/2fa
+//
+// Input:
+// * challenge: string;
+// * code?: string. The 6-digit code from authenticator app;
+// * recoveryCode?: string. Used if code is not provided;
+// * deviceToken?: string. Required only for Android app.
+//
+// The footprint.Client headers are required.
+func (router AuthRouter) EmailLoginTwoFactor(w http.ResponseWriter, req *http.Request) {
+	router = router.withRequest(req)
+	defer router.Logger.Sync()
+	sugar := router.Logger.Sugar()
+
+	var params input.TwoFactorLoginParams
+	if err := gorest.ParseJSON(req.Body, &params); err != nil {
+		sugar.Error(err)
+		_ = render.New(w).BadRequest(err.Error())
+		return
+	}
+
+	if ve := params.Validate(); ve != nil {
+		sugar.Error(ve)
+		_ = render.New(w).Unprocessable(ve)
+		return
+	}
+
+	challenge, err := router.Repo.RetrieveTwoFactorChallenge(params.Challenge)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	if !challenge.IsValid() {
+		_ = render.New(w).Forbidden("The challenge is expired or already used. Please login again.")
+		return
+	}
+
+	tf, err := router.Repo.RetrieveTwoFactor(challenge.FtcID)
+	if err != nil {
+		sugar.Error(err)
+		// 2FA is disabled after the challenge issued.
+		if err == sql.ErrNoRows {
+			_ = render.New(w).Forbidden("Two-factor authentication is not enabled")
+			return
+		}
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	method, err := router.checkSecondFactor(tf, params.Code, params.RecoveryCode)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	if method == account.TwoFactorNull {
+		err := router.Repo.IncChallengeAttempts(challenge.Token)
+		if err != nil {
+			sugar.Error(err)
+		}
+		_ = render.New(w).Forbidden("Incorrect verification code")
+		return
+	}
+
+	ok, err := router.Repo.DisableTwoFactorChallenge(challenge.Token)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+	if !ok {
+		_ = render.New(w).Forbidden("The challenge is expired or already used. Please login again.")
+		return
+	}
+
+	acnt, err := router.ReaderRepo.AccountByFtcID(challenge.FtcID)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	deviceToken := params.DeviceToken
+	if !deviceToken.Valid {
+		deviceToken = challenge.DeviceToken
+	}
+
+	authMethod := footprint.AuthMethod(challenge.AuthMethod)
+	if authMethod == footprint.AuthMethodNull {
+		authMethod = footprint.AuthMethodEmail
+	}
+
+	fp := footprint.New(acnt.FtcID, footprint.NewClient(req)).
+		FromLogin().
+		WithAuthMethod(authMethod, deviceToken).
+		WithTwoFactor(method)
+
+	router.Tasks.Go(func() {
+		err := router.Repo.SaveFootprint(fp)
+		if err != nil {
+			sugar.Error(err)
+		}
+	})
+
+	if acnt.IsMobileEmail() {
+		acnt.BaseAccount = acnt.SyncMobile()
+		acnt.LoginMethod = enum.LoginMethodMobile
+		router.SyncMobile(acnt.BaseAccount)
+	} else if authMethod == footprint.AuthMethodMobile {
+		acnt.LoginMethod = enum.LoginMethodMobile
+	}
+
+	router.renderLoggedIn(w, req, acnt, authMethod)
+}
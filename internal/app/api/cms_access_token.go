@@ -0,0 +1,183 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	gorest "github.com/FTChinese/go-rest"
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/internal/access"
+	"github.com/FTChinese/subscription-api/pkg/cms"
+	"github.com/FTChinese/subscription-api/pkg/xhttp"
+)
+
+// ListAccessTokens shows all tokens without the token value.
+//
+//	GET /cms/access-tokens
+func (router CMSRouter) ListAccessTokens(w http.ResponseWriter, req *http.Request) {
+	defer router.logger.Sync()
+	sugar := router.logger.Sugar()
+
+	list, err := router.tokenRepo.List()
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	_ = render.New(w).OK(list)
+}
+
+// IssueAccessToken creates a token for a client.
+// The token value is only visible in this response.
+//
+//	POST /cms/access-tokens
+//
+// Input: access.TokenParams
+// * clientId: string;
+// * scopes: string[];
+// * description?: string;
+// * expiresIn?: number - seconds.
+func (router CMSRouter) IssueAccessToken(w http.ResponseWriter, req *http.Request) {
+	defer router.logger.Sync()
+	sugar := router.logger.Sugar()
+
+	var params access.TokenParams
+	if err := gorest.ParseJSON(req.Body, &params); err != nil {
+		sugar.Error(err)
+		_ = render.New(w).BadRequest(err.Error())
+		return
+	}
+
+	if ve := params.Validate(); ve != nil {
+		_ = render.New(w).Unprocessable(ve)
+		return
+	}
+
+	staffName := xhttp.GetStaffName(req.Header)
+
+	o, err := access.NewOAuth(
+		params.ClientID,
+		params.Scopes,
+		params.Description,
+		params.ExpiresIn,
+		staffName)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).InternalServerError(err.Error())
+		return
+	}
+
+	o, err = router.tokenRepo.Create(o)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	sugar.Infof("Access token %d for %s issued by %s", o.ID, params.ClientID, staffName)
+	cms.AuditTrailFrom(req.Context()).
+		Target(strconv.FormatInt(o.ID, 10)).
+		Change(nil, o)
+
+	_ = render.New(w).OK(o.Issued())
+}
+
+// RotateAccessToken replaces a token with a new one carrying
+// the same client and scopes. The old one keeps working until
+// the grace period in `token_rotation` ends.
+//
+//	POST /cms/access-tokens/{id}/rotate
+func (router CMSRouter) RotateAccessToken(w http.ResponseWriter, req *http.Request) {
+	defer router.logger.Sync()
+	sugar := router.logger.Sugar()
+
+	id, err := xhttp.GetURLParam(req, "id").ToInt()
+	if err != nil {
+		_ = render.New(w).BadRequest(err.Error())
+		return
+	}
+
+	staffName := xhttp.GetStaffName(req.Header)
+
+	current, err := router.tokenRepo.RetrieveByID(id)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	if !current.Active {
+		_ = render.New(w).Unprocessable(&render.ValidationError{
+			Message: "Revoked token cannot be rotated",
+			Field:   "isActive",
+			Code:    render.CodeInvalid,
+		})
+		return
+	}
+
+	if current.Retiring() {
+		_ = render.New(w).Unprocessable(&render.ValidationError{
+			Message: "Token is already rotated",
+			Field:   "retiresUtc",
+			Code:    render.CodeAlreadyExists,
+		})
+		return
+	}
+
+	replacement, err := current.Rotate(staffName)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).InternalServerError(err.Error())
+		return
+	}
+
+	replacement, err = router.tokenRepo.Rotate(current, replacement)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	sugar.Infof("Access token %d rotated to %d by %s", current.ID, replacement.ID, staffName)
+	cms.AuditTrailFrom(req.Context()).
+		Target(strconv.FormatInt(current.ID, 10), strconv.FormatInt(replacement.ID, 10)).
+		Change(current, replacement)
+
+	_ = render.New(w).OK(replacement.Issued())
+}
+
+// RevokeAccessToken deactivates a token.
+//
+//	DELETE /cms/access-tokens/{id}
+func (router CMSRouter) RevokeAccessToken(w http.ResponseWriter, req *http.Request) {
+	defer router.logger.Sync()
+	sugar := router.logger.Sugar()
+
+	id, err := xhttp.GetURLParam(req, "id").ToInt()
+	if err != nil {
+		_ = render.New(w).BadRequest(err.Error())
+		return
+	}
+
+	current, err := router.tokenRepo.RetrieveByID(id)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	err = router.tokenRepo.Revoke(current.Revoked())
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	sugar.Infof("Access token %d revoked by %s", id, xhttp.GetStaffName(req.Header))
+	cms.AuditTrailFrom(req.Context()).
+		Target(strconv.FormatInt(id, 10)).
+		Change(current, current.Revoked())
+
+	_ = render.New(w).NoContent()
+}
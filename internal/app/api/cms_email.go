@@ -0,0 +1,87 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	gorest "github.com/FTChinese/go-rest"
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/pkg/xhttp"
+)
+
+// ListEmails shows the outbox of a user, including letters
+// failed to be delivered.
+//
+//	GET /cms/emails?ftc_id=<uuid>&page=<int>&per_page=<int>
+func (router CMSRouter) ListEmails(w http.ResponseWriter, req *http.Request) {
+	defer router.logger.Sync()
+	sugar := router.logger.Sugar()
+
+	ftcID := strings.TrimSpace(req.Form.Get("ftc_id"))
+	if ftcID == "" {
+		_ = render.New(w).BadRequest("Missing ftc_id in query parameters")
+		return
+	}
+
+	p := gorest.GetPagination(req)
+
+	// Letters like email verification are linked by email only.
+	ba, err := router.readerRepo.BaseAccountByUUID(ftcID)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	list, err := router.mailRepo.ListEnvelopes(ba.FtcID, ba.Email, p)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	_ = render.New(w).OK(list)
+}
+
+// LoadEmail shows a single letter in the outbox.
+//
+//	GET /cms/emails/{id}
+func (router CMSRouter) LoadEmail(w http.ResponseWriter, req *http.Request) {
+	id, _ := xhttp.GetURLParam(req, "id").ToString()
+
+	e, err := router.mailRepo.RetrieveEnvelope(id)
+	if err != nil {
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	_ = render.New(w).OK(e)
+}
+
+// ResendEmail puts a copy of an existing letter into the
+// outbox. The original one is kept as history.
+//
+//	POST /cms/emails/{id}/resend
+func (router CMSRouter) ResendEmail(w http.ResponseWriter, req *http.Request) {
+	defer router.logger.Sync()
+	sugar := router.logger.Sugar()
+
+	id, _ := xhttp.GetURLParam(req, "id").ToString()
+
+	e, err := router.mailRepo.RetrieveEnvelope(id)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	resent := e.Resend()
+	err = router.mailRepo.Enqueue(resent)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	_ = render.New(w).OK(resent)
+}
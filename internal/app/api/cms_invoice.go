@@ -3,6 +3,7 @@ package api
 import (
 	gorest "github.com/FTChinese/go-rest"
 	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/pkg/cms"
 	"github.com/FTChinese/subscription-api/pkg/invoice"
 	"net/http"
 )
@@ -32,12 +33,16 @@ func (router CMSRouter) CreateAddOn(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	go func() {
+	cms.AuditTrailFrom(req.Context()).
+		Target(result.Invoice.ID, result.Membership.CompoundID).
+		Change(result.Versioned.AnteChange, result.Membership)
+
+	router.tasks.Go(func() {
 		err := router.readerRepo.VersionMembership(result.Versioned)
 		if err != nil {
 			sugar.Error(err)
 		}
-	}()
+	})
 
 	_ = render.New(w).OK(result)
 }
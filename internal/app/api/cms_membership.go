@@ -5,6 +5,7 @@ import (
 	"github.com/FTChinese/go-rest"
 	"github.com/FTChinese/go-rest/render"
 	"github.com/FTChinese/subscription-api/internal/pkg/input"
+	"github.com/FTChinese/subscription-api/pkg/cms"
 	"github.com/FTChinese/subscription-api/pkg/ids"
 	"github.com/FTChinese/subscription-api/pkg/reader"
 	"github.com/FTChinese/subscription-api/pkg/xhttp"
@@ -70,15 +71,19 @@ func (router CMSRouter) UpsertMembership(w http.ResponseWriter, req *http.Reques
 		return
 	}
 
+	cms.AuditTrailFrom(req.Context()).
+		Target(newMmb.FtcID.String, newMmb.UnionID.String).
+		Change(versioned.AnteChange, newMmb)
+
 	// TODO: send email to this user.
 
 	if !versioned.AnteChange.IsZero() {
-		go func() {
+		router.tasks.Go(func() {
 			err := router.readerRepo.VersionMembership(versioned)
 			if err != nil {
 				sugar.Error(err)
 			}
-		}()
+		})
 	}
 
 	_ = render.New(w).OK(newMmb)
@@ -96,12 +101,16 @@ func (router CMSRouter) DeleteMembership(w http.ResponseWriter, req *http.Reques
 		return
 	}
 
+	cms.AuditTrailFrom(req.Context()).
+		Target(id).
+		Change(m, nil)
+
 	if !m.IsZero() {
-		go func() {
+		router.tasks.Go(func() {
 			v := m.Deleted().
 				ArchivedBy(reader.NewArchiver().By(staffName).ActionDelete())
 			_ = router.readerRepo.VersionMembership(v)
-		}()
+		})
 	}
 
 	_ = render.New(w).NoContent()
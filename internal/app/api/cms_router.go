@@ -1,29 +1,43 @@
 package api
 
 import (
+	"github.com/FTChinese/subscription-api/internal/access"
 	"github.com/FTChinese/subscription-api/internal/pkg/letter"
 	"github.com/FTChinese/subscription-api/internal/repository"
+	"github.com/FTChinese/subscription-api/internal/repository/accounts"
 	"github.com/FTChinese/subscription-api/internal/repository/cmsrepo"
+	"github.com/FTChinese/subscription-api/internal/repository/mailrepo"
 	"github.com/FTChinese/subscription-api/internal/repository/shared"
+	"github.com/FTChinese/subscription-api/pkg/background"
 	"github.com/FTChinese/subscription-api/pkg/db"
 	"go.uber.org/zap"
 )
 
 type CMSRouter struct {
 	repo         cmsrepo.Env
+	accountRepo  accounts.Env
 	readerRepo   shared.ReaderCommon
 	paywallRepo  repository.PaywallRepo
+	mailRepo     mailrepo.Env
+	tokenRepo    access.Env
 	emailService letter.Service
+	tasks        *background.Runner
 	logger       *zap.Logger
 	live         bool
 }
 
-func NewCMSRouter(dbs db.ReadWriteMyDBs, live bool, logger *zap.Logger) CMSRouter {
+func NewCMSRouter(dbs db.ReadWriteMyDBs, tokenRepo access.Env, tasks *background.Runner, live bool, logger *zap.Logger) CMSRouter {
+	mailRepo := mailrepo.New(dbs, logger)
+
 	return CMSRouter{
 		repo:         cmsrepo.New(dbs, logger),
+		accountRepo:  accounts.New(dbs, logger),
 		readerRepo:   shared.NewReaderCommon(dbs),
 		paywallRepo:  repository.NewPaywallRepo(dbs),
-		emailService: letter.NewService(logger),
+		mailRepo:     mailRepo,
+		tokenRepo:    tokenRepo,
+		emailService: letter.NewService(mailRepo, logger),
+		tasks:        tasks,
 		live:         live,
 		logger:       logger,
 	}
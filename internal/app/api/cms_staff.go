@@ -0,0 +1,133 @@
+package api
+
+import (
+	"net/http"
+
+	gorest "github.com/FTChinese/go-rest"
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/internal/pkg/input"
+	"github.com/FTChinese/subscription-api/pkg/cms"
+	"github.com/FTChinese/subscription-api/pkg/xhttp"
+)
+
+// ListStaffRoles shows all staff having a role.
+//
+//	GET /cms/staff
+func (router CMSRouter) ListStaffRoles(w http.ResponseWriter, req *http.Request) {
+	defer router.logger.Sync()
+	sugar := router.logger.Sugar()
+
+	list, err := router.repo.ListStaffRoles()
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	_ = render.New(w).OK(list)
+}
+
+// AssignStaffRole grants or changes a staff's role.
+// It takes effect within a minute.
+//
+//	PUT /cms/staff/{name}
+//
+// Input:
+// * role: support | finance | product | admin.
+func (router CMSRouter) AssignStaffRole(w http.ResponseWriter, req *http.Request) {
+	defer router.logger.Sync()
+	sugar := router.logger.Sugar()
+
+	name, err := xhttp.GetURLParam(req, "name").ToString()
+	if err != nil {
+		_ = render.New(w).BadRequest(err.Error())
+		return
+	}
+
+	var params input.StaffRoleParams
+	if err := gorest.ParseJSON(req.Body, &params); err != nil {
+		sugar.Error(err)
+		_ = render.New(w).BadRequest(err.Error())
+		return
+	}
+
+	if ve := params.Validate(); ve != nil {
+		_ = render.New(w).Unprocessable(ve)
+		return
+	}
+
+	current, _ := router.repo.RetrieveStaffRole(name)
+
+	s := cms.NewStaffRole(
+		name,
+		params.Role,
+		xhttp.GetStaffName(req.Header))
+
+	err = router.repo.UpsertStaffRole(s)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	cms.AuditTrailFrom(req.Context()).
+		Target(name).
+		Change(current, s)
+
+	_ = render.New(w).OK(s)
+}
+
+// RemoveStaffRole revokes a staff's access to CMS.
+//
+//	DELETE /cms/staff/{name}
+func (router CMSRouter) RemoveStaffRole(w http.ResponseWriter, req *http.Request) {
+	defer router.logger.Sync()
+	sugar := router.logger.Sugar()
+
+	name, err := xhttp.GetURLParam(req, "name").ToString()
+	if err != nil {
+		_ = render.New(w).BadRequest(err.Error())
+		return
+	}
+
+	current, err := router.repo.RetrieveStaffRole(name)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	err = router.repo.DeleteStaffRole(name)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	cms.AuditTrailFrom(req.Context()).
+		Target(name).
+		Change(current, nil)
+
+	_ = render.New(w).NoContent()
+}
+
+// ListAuditLog queries operations performed by staff.
+//
+//	GET /cms/audit-logs?staff=<name>&action=<prefix>&target_id=<id>&since=<YYYY-MM-DD>&until=<YYYY-MM-DD>&page=<int>&per_page=<int>
+//
+// Action is matched by prefix, e.g., `POST /cms/memberships`.
+func (router CMSRouter) ListAuditLog(w http.ResponseWriter, req *http.Request) {
+	defer router.logger.Sync()
+	sugar := router.logger.Sugar()
+
+	p := gorest.GetPagination(req)
+
+	list, err := router.repo.ListAuditEntries(cms.NewAuditFilter(req.Form), p)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	_ = render.New(w).OK(list)
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/pkg/cms"
+	"github.com/FTChinese/subscription-api/pkg/xhttp"
+)
+
+// ResetTwoFactor turns off 2FA on behalf of a user who lost
+// both the authenticator and recovery codes.
+// User is notified by email.
+//
+//	DELETE /cms/accounts/{id}/2fa
+func (router CMSRouter) ResetTwoFactor(w http.ResponseWriter, req *http.Request) {
+	defer router.logger.Sync()
+	sugar := router.logger.Sugar()
+
+	ftcID, _ := xhttp.GetURLParam(req, "id").ToString()
+	staffName := xhttp.GetStaffName(req.Header)
+
+	ba, err := router.readerRepo.BaseAccountByUUID(ftcID)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	tf, err := router.accountRepo.RetrieveTwoFactor(ftcID)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	err = router.accountRepo.DisableTwoFactor(ftcID)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	sugar.Infof("2FA of %s reset by %s", ftcID, staffName)
+	cms.AuditTrailFrom(req.Context()).
+		Target(ftcID).
+		Change(tf, nil)
+
+	if tf.Enabled {
+		router.tasks.Go(func() {
+			err := router.emailService.SendTwoFactorChanged(ba, false, true)
+			if err != nil {
+				sugar.Error(err)
+			}
+		})
+	}
+
+	_ = render.New(w).NoContent()
+}
@@ -13,7 +13,8 @@ import (
 // TODO: when claiming addon for an expired b2b, we
 // revoke the linked licence automatically.
 func (routes FtcPayRoutes) ClaimAddOn(w http.ResponseWriter, req *http.Request) {
-	readerIDs := ids.UserIDsFromHeader(req.Header)
+	routes = routes.withRequest(req)
+	readerIDs := ids.UserIDsFromRequest(req)
 
 	result, err := routes.AddOnRepo.ClaimAddOn(readerIDs)
 	if err != nil {
@@ -22,9 +23,9 @@ func (routes FtcPayRoutes) ClaimAddOn(w http.ResponseWriter, req *http.Request)
 	}
 
 	if !result.Versioned.IsZero() {
-		go func() {
+		routes.Tasks.Go(func() {
 			_ = routes.ReaderRepo.VersionMembership(result.Versioned)
-		}()
+		})
 	}
 
 	_ = render.New(w).OK(result.Membership)
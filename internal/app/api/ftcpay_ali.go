@@ -11,6 +11,7 @@ import (
 	"github.com/FTChinese/subscription-api/pkg/config"
 	"github.com/FTChinese/subscription-api/pkg/footprint"
 	"github.com/FTChinese/subscription-api/pkg/ids"
+	"github.com/FTChinese/subscription-api/pkg/metrics"
 	"github.com/FTChinese/subscription-api/pkg/reader"
 	"github.com/FTChinese/subscription-api/pkg/xhttp"
 )
@@ -33,13 +34,14 @@ func (routes FtcPayRoutes) AliPay(kind ali.EntryKind) http.HandlerFunc {
 		enum.PayMethodAli)
 
 	return func(w http.ResponseWriter, req *http.Request) {
+		routes := routes.withRequest(req)
 		defer routes.Logger.Sync()
 		sugar := routes.Logger.Sugar()
 
 		// Collect client metadata from header.
 		clientApp := footprint.NewClient(req)
 		// Get user compound ids from header.
-		readerIDs := ids.UserIDsFromHeader(req.Header)
+		readerIDs := ids.UserIDsFromRequest(req)
 
 		sugar.Infof("Alipay from app for %v", readerIDs)
 
@@ -126,12 +128,12 @@ func (routes FtcPayRoutes) AliPay(kind ali.EntryKind) http.HandlerFunc {
 			return
 		}
 
-		go func() {
+		routes.Tasks.Go(func() {
 			err := routes.SubsRepo.SavePaymentIntent(alipayIntent.Schema())
 			if err != nil {
 				sugar.Error(err)
 			}
-		}()
+		})
 
 		_ = render.New(w).OK(alipayIntent)
 	}
@@ -141,12 +143,15 @@ func (routes FtcPayRoutes) AliPay(kind ali.EntryKind) http.HandlerFunc {
 // See https://opendocs.alipay.com/open/204/105301
 // POST /webhook/alipay
 func (routes FtcPayRoutes) AliWebHook(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.Logger.Sync()
 	sugar := routes.Logger.Sugar()
 
+	event := metrics.WebhookEventFrom(req.Context())
 	var send = func(ok bool) {
 		var err error
 		if !ok {
+			event.Outcome = metrics.WebhookRetry
 			_, err = w.Write([]byte("fail"))
 		} else {
 			_, err = w.Write([]byte("success"))
@@ -176,14 +181,16 @@ func (routes FtcPayRoutes) AliWebHook(w http.ResponseWriter, req *http.Request)
 		return
 	}
 
-	go func() {
+	event.Type = payload.TradeStatus
+
+	routes.Tasks.Go(func() {
 		sugar.Infof("Saving alipay webhook payload...")
 		err := routes.SubsRepo.SaveAliWebhookPayload(
 			ali.NewWebhookPayload(payload))
 		if err != nil {
 			sugar.Error(err)
 		}
-	}()
+	})
 
 	sugar.Info("Start processing ali webhook")
 	payResult, err := ftcpay.NewAliWebhookResult(payload)
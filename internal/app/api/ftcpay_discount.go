@@ -9,9 +9,10 @@ import (
 )
 
 func (routes FtcPayRoutes) LoadDiscountRedeemed(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	id, _ := xhttp.GetURLParam(req, "id").ToString()
 
-	userIDs := ids.UserIDsFromHeader(req.Header)
+	userIDs := ids.UserIDsFromRequest(req)
 
 	redeemed, err := routes.SubsRepo.RetrieveDiscountRedeemed(
 		userIDs,
@@ -10,13 +10,14 @@ import (
 )
 
 func (routes FtcPayRoutes) ListInvoices(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	if err := req.ParseForm(); err != nil {
 		_ = render.New(w).BadRequest(err.Error())
 		return
 	}
 
 	p := gorest.GetPagination(req)
-	userIDs := ids.UserIDsFromHeader(req.Header)
+	userIDs := ids.UserIDsFromRequest(req)
 
 	list, err := routes.AddOnRepo.ListInvoices(
 		userIDs,
@@ -31,7 +32,8 @@ func (routes FtcPayRoutes) ListInvoices(w http.ResponseWriter, req *http.Request
 }
 
 func (routes FtcPayRoutes) LoadInvoice(w http.ResponseWriter, req *http.Request) {
-	userIDs := ids.UserIDsFromHeader(req.Header)
+	routes = routes.withRequest(req)
+	userIDs := ids.UserIDsFromRequest(req)
 
 	invID, err := xhttp.GetURLParam(req, "id").ToString()
 	if err != nil {
@@ -16,9 +16,10 @@ import (
 // Pagination support by adding query parameter:
 // page=<int>&per_page=<int>
 func (routes FtcPayRoutes) ListOrders(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 
 	p := gorest.GetPagination(req)
-	userIDs := ids.UserIDsFromHeader(req.Header)
+	userIDs := ids.UserIDsFromRequest(req)
 
 	list, err := routes.SubsRepo.ListOrders(userIDs, p)
 	if err != nil {
@@ -33,6 +34,7 @@ func (routes FtcPayRoutes) ListOrders(w http.ResponseWriter, req *http.Request)
 // The only difference from ListOrder is that user ids
 // is set in query parameter rather than header.
 func (routes FtcPayRoutes) CMSListOrders(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	p := gorest.GetPagination(req)
 	userIDs := ids.UserIDsFromQuery(req.Form)
 
@@ -46,7 +48,8 @@ func (routes FtcPayRoutes) CMSListOrders(w http.ResponseWriter, req *http.Reques
 }
 
 func (routes FtcPayRoutes) LoadOrder(w http.ResponseWriter, req *http.Request) {
-	userIDs := ids.UserIDsFromHeader(req.Header)
+	routes = routes.withRequest(req)
+	userIDs := ids.UserIDsFromRequest(req)
 
 	orderID, err := xhttp.GetURLParam(req, "id").ToString()
 	if err != nil {
@@ -69,6 +72,7 @@ func (routes FtcPayRoutes) LoadOrder(w http.ResponseWriter, req *http.Request) {
 }
 
 func (routes FtcPayRoutes) CMSFindOrder(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 
 	orderID, err := xhttp.
 		GetURLParam(req, "id").
@@ -90,6 +94,7 @@ func (routes FtcPayRoutes) CMSFindOrder(w http.ResponseWriter, req *http.Request
 // RawPaymentResult fetch data from wxpay or alipay order query endpoints and transfer the data as is.
 // The response data formats are not always the same one.
 func (routes FtcPayRoutes) RawPaymentResult(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.Logger.Sync()
 	sugar := routes.Logger.Sugar()
 
@@ -147,6 +152,7 @@ func (routes FtcPayRoutes) RawPaymentResult(w http.ResponseWriter, req *http.Req
 // the payment result of an order.
 // POST /orders/{id}/verify-payment
 func (routes FtcPayRoutes) VerifyPayment(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.Logger.Sync()
 	sugar := routes.Logger.Sugar()
 
@@ -185,12 +191,12 @@ func (routes FtcPayRoutes) VerifyPayment(w http.ResponseWriter, req *http.Reques
 		return
 	}
 
-	go func() {
+	routes.Tasks.Go(func() {
 		err := routes.SubsRepo.SavePayResult(payResult)
 		if err != nil {
 			sugar.Error(err)
 		}
-	}()
+	})
 
 	if !payResult.IsOrderPaid() {
 		sugar.Info("Order is either not paid or already confirmed")
@@ -9,10 +9,13 @@ import (
 	"github.com/FTChinese/subscription-api/internal/app/paybase"
 	"github.com/FTChinese/subscription-api/internal/pkg/ftcpay"
 	"github.com/FTChinese/subscription-api/internal/repository"
+	"github.com/FTChinese/subscription-api/pkg/background"
+	"github.com/FTChinese/subscription-api/pkg/cachestore"
 	"github.com/FTChinese/subscription-api/pkg/db"
 	"github.com/FTChinese/subscription-api/pkg/footprint"
 	"github.com/FTChinese/subscription-api/pkg/reader"
-	"github.com/patrickmn/go-cache"
+	"github.com/FTChinese/subscription-api/pkg/tracing"
+	"github.com/FTChinese/subscription-api/pkg/xhttp"
 	"go.uber.org/zap"
 )
 
@@ -26,18 +29,28 @@ type FtcPayRoutes struct {
 
 func NewFtcPayRoutes(
 	dbs db.ReadWriteMyDBs,
-	c *cache.Cache,
+	c cachestore.Store,
+	tasks *background.Runner,
 	logger *zap.Logger,
 	live bool,
 ) FtcPayRoutes {
 	return FtcPayRoutes{
-		FtcPayBase:  paybase.NewFtcPay(dbs, logger),
+		FtcPayBase:  paybase.NewFtcPay(dbs, tasks, logger),
 		paywallRepo: repository.NewPaywallRepo(dbs),
 		cacheRepo:   repository.NewCacheRepo(c),
 		live:        live,
 	}
 }
 
+// withRequest logs with the request id of req, and traces
+// SQL statements as part of the request.
+func (routes FtcPayRoutes) withRequest(req *http.Request) FtcPayRoutes {
+	routes.FtcPayBase = routes.FtcPayBase.
+		WithLogger(xhttp.LoggerFrom(req.Context(), routes.Logger)).
+		WithContext(tracing.Detach(req.Context()))
+	return routes
+}
+
 // Centralized error handling after order creation.
 // It handles the errors propagated from Membership.AliWxSubsKind(),
 func (routes FtcPayRoutes) handleOrderErr(w http.ResponseWriter, err error) {
@@ -54,7 +67,7 @@ func (routes FtcPayRoutes) postOrderCreation(order ftcpay.Order, client footprin
 	defer routes.Logger.Sync()
 	sugar := routes.Logger.Sugar()
 
-	go func() {
+	routes.Tasks.Go(func() {
 		err := routes.SubsRepo.SaveOrderMeta(footprint.OrderClient{
 			OrderID: order.ID,
 			Client:  client,
@@ -62,7 +75,7 @@ func (routes FtcPayRoutes) postOrderCreation(order ftcpay.Order, client footprin
 		if err != nil {
 			sugar.Error(err)
 		}
-	}()
+	})
 
 	return nil
 }
@@ -73,12 +86,12 @@ func (routes FtcPayRoutes) processWebhookResult(result ftcpay.PaymentResult) (ft
 
 	sugar.Infof("Webhook Payment result %v", result)
 
-	go func() {
+	routes.Tasks.Go(func() {
 		err := routes.SubsRepo.SavePayResult(result)
 		if err != nil {
 			sugar.Error(err)
 		}
-	}()
+	})
 
 	if result.ShouldRetry() {
 		msg := fmt.Sprintf("payment status %s", result.PaymentState)
@@ -100,7 +113,7 @@ func (routes FtcPayRoutes) processWebhookResult(result ftcpay.PaymentResult) (ft
 		return ftcpay.ConfirmationResult{}, cfmErr
 	}
 
-	go func() {
+	routes.Tasks.Go(func() {
 		// Create a discount consumption history.
 		pi, err := routes.SubsRepo.RetrievePaymentIntent(order.ID)
 		if err != nil {
@@ -120,7 +133,7 @@ func (routes FtcPayRoutes) processWebhookResult(result ftcpay.PaymentResult) (ft
 		if err != nil {
 			sugar.Error(err)
 		}
-	}()
+	})
 
 	return confirmed, nil
 }
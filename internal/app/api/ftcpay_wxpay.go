@@ -10,6 +10,7 @@ import (
 	"github.com/FTChinese/subscription-api/pkg/config"
 	"github.com/FTChinese/subscription-api/pkg/footprint"
 	"github.com/FTChinese/subscription-api/pkg/ids"
+	"github.com/FTChinese/subscription-api/pkg/metrics"
 	"github.com/FTChinese/subscription-api/pkg/reader"
 	"github.com/FTChinese/subscription-api/pkg/wechat"
 	"github.com/FTChinese/subscription-api/pkg/xhttp"
@@ -35,13 +36,14 @@ func (routes FtcPayRoutes) WxPay(tradeType wechat.TradeType) http.HandlerFunc {
 	}
 
 	return func(w http.ResponseWriter, req *http.Request) {
+		routes := routes.withRequest(req)
 		defer routes.Logger.Sync()
 		sugar := routes.Logger.Sugar()
 
 		sugar.Info("Start creating a wechat order")
 
 		clientMeta := footprint.NewClient(req)
-		readerIDs := ids.UserIDsFromHeader(req.Header)
+		readerIDs := ids.UserIDsFromRequest(req)
 
 		// Find user account.
 		acnt, err := routes.ReaderRepo.FindBaseAccount(readerIDs)
@@ -109,7 +111,7 @@ func (routes FtcPayRoutes) WxPay(tradeType wechat.TradeType) http.HandlerFunc {
 		orderPayload, err := payClient.CreateOrder(orderReq)
 
 		// Save raw response.
-		go func() {
+		routes.Tasks.Go(func() {
 			err := routes.SubsRepo.SaveWxPayload(
 				wechat.NewPayloadSchema(
 					pi.Order.ID,
@@ -119,7 +121,7 @@ func (routes FtcPayRoutes) WxPay(tradeType wechat.TradeType) http.HandlerFunc {
 			if err != nil {
 				sugar.Error(err)
 			}
-		}()
+		})
 
 		if err != nil {
 			sugar.Error(err)
@@ -146,12 +148,12 @@ func (routes FtcPayRoutes) WxPay(tradeType wechat.TradeType) http.HandlerFunc {
 
 		payIntent := ftcpay.NewWxPaymentIntent(pi, payParams)
 
-		go func() {
+		routes.Tasks.Go(func() {
 			err := routes.SubsRepo.SavePaymentIntent(payIntent.Schema())
 			if err != nil {
 				sugar.Error(err)
 			}
-		}()
+		})
 
 		_ = render.New(w).OK(payIntent)
 	}
@@ -160,6 +162,7 @@ func (routes FtcPayRoutes) WxPay(tradeType wechat.TradeType) http.HandlerFunc {
 // WxWebHook implements 支付结果通知
 // https://pay.weixin.qq.com/wiki/doc/api/app/app.php?chapter=9_7&index=3
 func (routes FtcPayRoutes) WxWebHook(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.Logger.Sync()
 	sugar := routes.Logger.Sugar()
 
@@ -167,9 +170,11 @@ func (routes FtcPayRoutes) WxWebHook(w http.ResponseWriter, req *http.Request) {
 
 	resp := wxpay.Notifies{}
 
+	event := metrics.WebhookEventFrom(req.Context())
 	var send = func(err error) {
 		var e error
 		if err != nil {
+			event.Outcome = metrics.WebhookRetry
 			_, e = w.Write([]byte(resp.NotOK(err.Error())))
 		} else {
 			_, e = w.Write([]byte(resp.OK()))
@@ -212,7 +217,7 @@ func (routes FtcPayRoutes) WxWebHook(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	go func() {
+	routes.Tasks.Go(func() {
 		sugar.Info("Saving wxpay webhook raw payload")
 		err := routes.SubsRepo.SaveWxPayload(
 			wechat.NewPayloadSchema(
@@ -223,9 +228,10 @@ func (routes FtcPayRoutes) WxWebHook(w http.ResponseWriter, req *http.Request) {
 		if err != nil {
 			sugar.Error(err)
 		}
-	}()
+	})
 
 	payResult := ftcpay.NewWxWebhookResult(wechat.NewWebhookParams(rawPayload))
+	event.Type = payResult.PaymentState
 
 	sugar.Info("Start processing wx webhook")
 	_, cfmErr := routes.processWebhookResult(payResult)
@@ -59,12 +59,12 @@ func (router IAPRouter) Link(w http.ResponseWriter, req *http.Request) {
 		ve, ok := apple.ConvertLinkErr(err)
 		if ok {
 			// Archive possible cheating.
-			go func() {
+			router.Tasks.Go(func() {
 				err := router.Repo.ArchiveLinkCheating(input)
 				if err != nil {
 					sugar.Error(err)
 				}
-			}()
+			})
 
 			_ = render.New(w).Unprocessable(ve)
 			return
@@ -128,7 +128,7 @@ func (router IAPRouter) Link(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	go func() {
+	router.Tasks.Go(func() {
 		// Backup previous membership
 		if !result.Versioned.IsZero() {
 			err := router.ReaderRepo.VersionMembership(result.Versioned)
@@ -145,7 +145,7 @@ func (router IAPRouter) Link(w http.ResponseWriter, req *http.Request) {
 				return
 			}
 		}
-	}()
+	})
 
 	_ = render.New(w).OK(result.Member)
 }
@@ -185,7 +185,7 @@ func (router IAPRouter) Unlink(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	go func() {
+	router.Tasks.Go(func() {
 		if !result.Versioned.IsZero() {
 			err := router.ReaderRepo.VersionMembership(result.Versioned)
 			if err != nil {
@@ -207,7 +207,7 @@ func (router IAPRouter) Unlink(w http.ResponseWriter, req *http.Request) {
 		if err != nil {
 			return
 		}
-	}()
+	})
 
 	_ = render.New(w).NoContent()
 }
@@ -13,6 +13,8 @@ import (
 	"github.com/FTChinese/go-rest/render"
 	"github.com/FTChinese/subscription-api/internal/pkg/apple"
 	"github.com/FTChinese/subscription-api/internal/repository/iaprepo"
+	"github.com/FTChinese/subscription-api/pkg/background"
+	"github.com/FTChinese/subscription-api/pkg/metrics"
 )
 
 type IAPRouter struct {
@@ -20,6 +22,7 @@ type IAPRouter struct {
 	Client       iaprepo.Client
 	ReaderRepo   shared.ReaderCommon
 	EmailService letter.Service
+	Tasks        *background.Runner
 	Logger       *zap.Logger
 	Live         bool
 }
@@ -47,11 +50,11 @@ func (router IAPRouter) doVerification(receipt string) (apple.VerificationResp,
 	}
 
 	// Save the decoded receipt as a session of verification
-	go func() {
+	router.Tasks.Go(func() {
 		_ = router.Repo.SaveDecodedReceipt(
 			resp.ReceiptSchema(),
 		)
-	}()
+	})
 
 	// Dissect and save other fields in the verification response.
 	router.Repo.SaveUnifiedReceipt(resp.UnifiedReceipt)
@@ -100,7 +103,7 @@ func (router IAPRouter) VerifyReceipt(w http.ResponseWriter, req *http.Request)
 
 	// Update subscription and possible membership in background since this step is irrelevant to verification.
 	if err == nil {
-		go func() {
+		router.Tasks.Go(func() {
 
 			result, err := router.Repo.SaveSubs(sub)
 			if err != nil {
@@ -116,7 +119,7 @@ func (router IAPRouter) VerifyReceipt(w http.ResponseWriter, req *http.Request)
 			if err != nil {
 				sugar.Error(err)
 			}
-		}()
+		})
 	}
 
 	_ = render.New(w).OK(resp)
@@ -142,6 +145,8 @@ func (router IAPRouter) WebHook(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	metrics.WebhookEventFrom(req.Context()).Type = string(wh.NotificationType)
+
 	err = router.Repo.SaveWebhook(apple.NewWebHookSchema(wh))
 	if err != nil {
 		sugar.Error(err)
@@ -184,12 +189,12 @@ func (router IAPRouter) WebHook(w http.ResponseWriter, req *http.Request) {
 
 	// Snapshot might be empty is this subscription is linked to ftc account yet.
 	if !result.Versioned.IsZero() {
-		go func() {
+		router.Tasks.Go(func() {
 			err := router.ReaderRepo.VersionMembership(result.Versioned)
 			if err != nil {
 				sugar.Error(err)
 			}
-		}()
+		})
 	}
 
 	_ = render.New(w).OK(nil)
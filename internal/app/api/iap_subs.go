@@ -68,12 +68,12 @@ func (router IAPRouter) UpsertSubs(w http.ResponseWriter, req *http.Request) {
 	}
 
 	if !result.Versioned.IsZero() {
-		go func() {
+		router.Tasks.Go(func() {
 			err := router.ReaderRepo.VersionMembership(result.Versioned)
 			if err != nil {
 				sugar.Error(err)
 			}
-		}()
+		})
 	}
 
 	_ = render.New(w).OK(sub)
@@ -157,12 +157,12 @@ func (router IAPRouter) RefreshSubs(w http.ResponseWriter, req *http.Request) {
 	}
 
 	if !result.Versioned.IsZero() {
-		go func() {
+		router.Tasks.Go(func() {
 			err := router.ReaderRepo.VersionMembership(result.Versioned)
 			if err != nil {
 				sugar.Error(err)
 			}
-		}()
+		})
 	}
 
 	_ = render.New(w).OK(result)
@@ -5,6 +5,7 @@ import (
 	"github.com/FTChinese/go-rest/render"
 	"github.com/FTChinese/subscription-api/internal/pkg/legal"
 	"github.com/FTChinese/subscription-api/internal/repository"
+	"github.com/FTChinese/subscription-api/pkg/cms"
 	"github.com/FTChinese/subscription-api/pkg/db"
 	"github.com/FTChinese/subscription-api/pkg/xhttp"
 	"go.uber.org/zap"
@@ -75,6 +76,10 @@ func (routes LegalRoutes) Create(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	cms.AuditTrailFrom(req.Context()).
+		Target(legalDoc.HashID).
+		Change(nil, legalDoc)
+
 	_ = render.New(w).OK(legalDoc)
 }
 
@@ -93,6 +98,7 @@ func (routes LegalRoutes) Update(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	before := legalDoc
 	legalDoc = legalDoc.Update(params)
 	err = routes.repo.UpdateContent(legalDoc)
 	if err != nil {
@@ -100,6 +106,8 @@ func (routes LegalRoutes) Update(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	cms.AuditTrailFrom(req.Context()).Change(before, legalDoc)
+
 	_ = render.New(w).OK(legalDoc)
 }
 
@@ -118,6 +126,7 @@ func (routes LegalRoutes) Publish(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	before := legalDoc
 	legalDoc = legalDoc.Publish(params.Publish)
 	err = routes.repo.UpdateStatus(legalDoc)
 	if err != nil {
@@ -125,5 +134,7 @@ func (routes LegalRoutes) Publish(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	cms.AuditTrailFrom(req.Context()).Change(before, legalDoc)
+
 	_ = render.New(w).OK(legalDoc)
 }
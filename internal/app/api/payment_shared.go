@@ -4,9 +4,10 @@ import (
 	"github.com/FTChinese/subscription-api/internal/repository"
 	"github.com/FTChinese/subscription-api/internal/repository/stripeenv"
 	"github.com/FTChinese/subscription-api/internal/stripeclient"
+	"github.com/FTChinese/subscription-api/pkg/background"
+	"github.com/FTChinese/subscription-api/pkg/cachestore"
 	"github.com/FTChinese/subscription-api/pkg/db"
 	"github.com/FTChinese/subscription-api/pkg/reader"
-	"github.com/patrickmn/go-cache"
 	"go.uber.org/zap"
 )
 
@@ -14,13 +15,15 @@ type PaymentShared struct {
 	stripeRepo  stripeenv.Env
 	paywallRepo repository.PaywallRepo
 	cacheRepo   repository.CacheRepo
+	tasks       *background.Runner
 	logger      *zap.Logger
 	live        bool
 }
 
 func NewPaymentShared(
 	dbs db.ReadWriteMyDBs,
-	c *cache.Cache,
+	c cachestore.Store,
+	tasks *background.Runner,
 	logger *zap.Logger,
 	live bool,
 ) PaymentShared {
@@ -31,6 +34,7 @@ func NewPaymentShared(
 		),
 		paywallRepo: repository.NewPaywallRepo(dbs),
 		cacheRepo:   repository.NewCacheRepo(c),
+		tasks:       tasks,
 		logger:      logger,
 		live:        live,
 	}
@@ -55,7 +59,9 @@ func (ps PaymentShared) LoadCachedPaywall(refresh bool) (reader.Paywall, error)
 		return reader.Paywall{}, err
 	}
 
-	ps.cacheRepo.CachePaywall(paywall, ps.live)
+	if err := ps.cacheRepo.CachePaywall(paywall, ps.live); err != nil {
+		sugar.Error(err)
+	}
 
 	return paywall, nil
 }
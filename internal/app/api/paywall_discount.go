@@ -3,6 +3,7 @@ package api
 import (
 	"github.com/FTChinese/go-rest"
 	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/pkg/cms"
 	"github.com/FTChinese/subscription-api/pkg/price"
 	"github.com/FTChinese/subscription-api/pkg/xhttp"
 	"net/http"
@@ -45,6 +46,10 @@ func (router PaywallRouter) CreateDiscount(w http.ResponseWriter, req *http.Requ
 		return
 	}
 
+	cms.AuditTrailFrom(req.Context()).
+		Target(discount.ID, discount.PriceID).
+		Change(nil, discount)
+
 	_ = render.New(w).OK(discount)
 }
 
@@ -94,13 +99,18 @@ func (router PaywallRouter) DropDiscount(w http.ResponseWriter, req *http.Reques
 		return
 	}
 
-	discount = discount.Cancel()
-	err = router.productRepo.UpdateDiscount(discount)
+	cancelled := discount.Cancel()
+	err = router.productRepo.UpdateDiscount(cancelled)
 	if err != nil {
 		_ = render.New(w).DBError(err)
 		return
 	}
 
+	cms.AuditTrailFrom(req.Context()).
+		Target(id, discount.PriceID).
+		Change(discount, cancelled)
+	discount = cancelled
+
 	ftcPrice, err := router.paywallRepo.RetrievePaywallPrice(discount.PriceID, router.live)
 	if err != nil {
 		_ = render.New(w).DBError(err)
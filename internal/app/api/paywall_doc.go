@@ -4,8 +4,10 @@ import (
 	"database/sql"
 	gorest "github.com/FTChinese/go-rest"
 	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/pkg/cms"
 	"github.com/FTChinese/subscription-api/pkg/reader"
 	"net/http"
+	"strconv"
 )
 
 // SaveBanner saves a new banner. It actually creates a new
@@ -40,6 +42,7 @@ func (router PaywallRouter) SaveBanner(w http.ResponseWriter, req *http.Request)
 		pwb = reader.NewPaywallDoc(router.live)
 	}
 
+	prev := pwb
 	pwb = pwb.WithBanner(banner)
 
 	id, err := router.productRepo.CreatePaywallDoc(pwb)
@@ -50,6 +53,10 @@ func (router PaywallRouter) SaveBanner(w http.ResponseWriter, req *http.Request)
 
 	pwb.ID = id
 
+	cms.AuditTrailFrom(req.Context()).
+		Target(strconv.FormatInt(id, 10)).
+		Change(prev, pwb)
+
 	_ = render.New(w).OK(pwb)
 }
 
@@ -84,6 +91,7 @@ func (router PaywallRouter) SavePromo(w http.ResponseWriter, req *http.Request)
 		}
 	}
 
+	prev := pwb
 	pwb = pwb.WithPromo(promo)
 
 	id, err := router.productRepo.CreatePaywallDoc(pwb)
@@ -94,6 +102,10 @@ func (router PaywallRouter) SavePromo(w http.ResponseWriter, req *http.Request)
 
 	pwb.ID = id
 
+	cms.AuditTrailFrom(req.Context()).
+		Target(strconv.FormatInt(id, 10)).
+		Change(prev, pwb)
+
 	_ = render.New(w).OK(pwb)
 }
 
@@ -106,6 +118,7 @@ func (router PaywallRouter) DropPromo(w http.ResponseWriter, req *http.Request)
 		}
 	}
 
+	prev := pwb
 	pwb = pwb.DropPromo()
 
 	// Save a new version
@@ -118,5 +131,9 @@ func (router PaywallRouter) DropPromo(w http.ResponseWriter, req *http.Request)
 	// Change id to latest.
 	pwb.ID = id
 
+	cms.AuditTrailFrom(req.Context()).
+		Target(strconv.FormatInt(id, 10)).
+		Change(prev, pwb)
+
 	_ = render.New(w).OK(pwb)
 }
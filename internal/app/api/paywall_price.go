@@ -5,6 +5,7 @@ import (
 
 	gorest "github.com/FTChinese/go-rest"
 	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/pkg/cms"
 	"github.com/FTChinese/subscription-api/pkg/price"
 	"github.com/FTChinese/subscription-api/pkg/xhttp"
 )
@@ -116,22 +117,26 @@ func (router PaywallRouter) CreatePrice(w http.ResponseWriter, req *http.Request
 		return
 	}
 
+	cms.AuditTrailFrom(req.Context()).
+		Target(p.ID, p.ProductID).
+		Change(nil, p)
+
 	// Sync stripe price metadata
 	// TODO: remove this.
 	if p.StripePriceID != "" {
-		go func() {
+		router.tasks.Go(func() {
 			_, _ = router.updateStripPriceMeta(p)
-		}()
+		})
 	}
 
 	// Sync legacy table.
 	if p.IsRecurring() {
-		go func() {
+		router.tasks.Go(func() {
 			err := router.productRepo.CreatePlan(price.NewPlan(p))
 			if err != nil {
 				sugar.Error(err)
 			}
-		}()
+		})
 	}
 
 	_ = render.New(w).OK(p)
@@ -187,11 +192,13 @@ func (router PaywallRouter) UpdatePrice(w http.ResponseWriter, req *http.Request
 		return
 	}
 
+	cms.AuditTrailFrom(req.Context()).Change(ftcPrice.FtcPrice, updated)
+
 	// TODO: remove this.
 	if params.StripePriceID != "" {
-		go func() {
+		router.tasks.Go(func() {
 			_, _ = router.updateStripPriceMeta(updated)
-		}()
+		})
 	}
 
 	ftcPrice.FtcPrice = updated
@@ -221,12 +228,15 @@ func (router PaywallRouter) RefreshPriceOffers(w http.ResponseWriter, req *http.
 	}
 
 	// Update offers
-	ftcPrice, err = router.productRepo.RefreshPriceOffers(ftcPrice)
+	refreshed, err := router.productRepo.RefreshPriceOffers(ftcPrice)
 	if err != nil {
 		_ = render.New(w).DBError(err)
 		return
 	}
 
+	cms.AuditTrailFrom(req.Context()).Change(ftcPrice.Offers, refreshed.Offers)
+	ftcPrice = refreshed
+
 	_ = render.New(w).OK(ftcPrice)
 }
 
@@ -268,6 +278,8 @@ func (router PaywallRouter) ActivatePrice(w http.ResponseWriter, req *http.Reque
 		return
 	}
 
+	cms.AuditTrailFrom(req.Context()).Change(pwPrice.FtcPrice, activated)
+
 	pwPrice.FtcPrice = activated
 	// If the price is a one_time price,
 	_ = render.New(w).OK(pwPrice)
@@ -306,6 +318,8 @@ func (router PaywallRouter) DeactivateOrArchivePrice(archive bool) http.HandlerF
 			return
 		}
 
+		cms.AuditTrailFrom(req.Context()).Change(pwPrice.FtcPrice, deactivated)
+
 		pwPrice.FtcPrice = deactivated
 
 		// If this router is also used for archive.
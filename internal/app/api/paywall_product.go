@@ -5,6 +5,7 @@ import (
 
 	gorest "github.com/FTChinese/go-rest"
 	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/pkg/cms"
 	"github.com/FTChinese/subscription-api/pkg/reader"
 	"github.com/FTChinese/subscription-api/pkg/xhttp"
 )
@@ -51,6 +52,10 @@ func (router PaywallRouter) CreateProduct(w http.ResponseWriter, req *http.Reque
 		return
 	}
 
+	cms.AuditTrailFrom(req.Context()).
+		Target(p.ID).
+		Change(nil, p)
+
 	_ = render.New(w).OK(p)
 }
 
@@ -116,6 +121,8 @@ func (router PaywallRouter) UpdateProduct(w http.ResponseWriter, req *http.Reque
 		return
 	}
 
+	cms.AuditTrailFrom(req.Context()).Change(prod, updated)
+
 	_ = render.New(w).OK(updated)
 }
 
@@ -132,13 +139,16 @@ func (router PaywallRouter) ActivateProduct(w http.ResponseWriter, req *http.Req
 		return
 	}
 
-	prod = prod.Activate()
-	err = router.productRepo.SetProductOnPaywall(prod)
+	activated := prod.Activate()
+	err = router.productRepo.SetProductOnPaywall(activated)
 	if err != nil {
 		_ = render.New(w).DBError(err)
 		return
 	}
 
+	cms.AuditTrailFrom(req.Context()).Change(prod, activated)
+	prod = activated
+
 	_ = render.New(w).OK(prod)
 }
 
@@ -204,6 +214,7 @@ func (router PaywallRouter) AttachIntroPrice(w http.ResponseWriter, req *http.Re
 		return
 	}
 
+	before := prod
 	prod = prod.WithIntroPrice(activated)
 
 	err = router.productRepo.SetProductIntro(prod)
@@ -213,10 +224,14 @@ func (router PaywallRouter) AttachIntroPrice(w http.ResponseWriter, req *http.Re
 		return
 	}
 
+	cms.AuditTrailFrom(req.Context()).
+		Target(id, activated.ID).
+		Change(before, prod)
+
 	if pwPrice.StripePriceID != "" {
-		go func() {
+		router.tasks.Go(func() {
 			_, _ = router.updateStripPriceMeta(pwPrice.FtcPrice)
-		}()
+		})
 	}
 
 	_ = render.New(w).OK(prod)
@@ -248,6 +263,7 @@ func (router PaywallRouter) DropIntroPrice(w http.ResponseWriter, req *http.Requ
 		return
 	}
 
+	before := prod
 	prod = prod.DropIntroPrice()
 	err = router.productRepo.SetProductIntro(prod)
 	if err != nil {
@@ -256,5 +272,7 @@ func (router PaywallRouter) DropIntroPrice(w http.ResponseWriter, req *http.Requ
 		return
 	}
 
+	cms.AuditTrailFrom(req.Context()).Change(before, prod)
+
 	_ = render.New(w).OK(prod)
 }
@@ -5,9 +5,11 @@ import (
 
 	"github.com/FTChinese/go-rest/render"
 	"github.com/FTChinese/subscription-api/internal/repository/products"
+	"github.com/FTChinese/subscription-api/pkg/background"
+	"github.com/FTChinese/subscription-api/pkg/cachestore"
 	"github.com/FTChinese/subscription-api/pkg/db"
 	"github.com/FTChinese/subscription-api/pkg/xhttp"
-	"github.com/patrickmn/go-cache"
+	"github.com/go-chi/chi/v5/middleware"
 	"go.uber.org/zap"
 )
 
@@ -19,7 +21,8 @@ type PaywallRouter struct {
 
 func NewPaywallRouter(
 	dbs db.ReadWriteMyDBs,
-	c *cache.Cache,
+	c cachestore.Store,
+	tasks *background.Runner,
 	logger *zap.Logger,
 	live bool,
 ) PaywallRouter {
@@ -28,11 +31,39 @@ func NewPaywallRouter(
 		PaymentShared: NewPaymentShared(
 			dbs,
 			c,
+			tasks,
 			logger,
 			live),
 	}
 }
 
+// InvalidateCache drops cached paywall on all instances
+// after a request modifying products, prices, discounts or
+// banner succeeded.
+func (router PaywallRouter) InvalidateCache(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		ww := middleware.NewWrapResponseWriter(w, req.ProtoMajor)
+		next.ServeHTTP(ww, req)
+
+		if ww.Status() >= http.StatusBadRequest {
+			return
+		}
+
+		if err := router.cacheRepo.InvalidatePaywall(); err != nil {
+			defer router.logger.Sync()
+			router.logger.Sugar().Error(err)
+		}
+	}
+
+	return http.HandlerFunc(fn)
+}
+
 // LoadPaywall loads paywall data from db or cache.
 // Query parameter:
 // ?fresh=<bool>
@@ -49,7 +80,7 @@ func (router PaywallRouter) LoadPaywall(w http.ResponseWriter, req *http.Request
 
 	// Save stripe prices if any of them is fetched from
 	// Stripe API.
-	go func() {
+	router.tasks.Go(func() {
 		defer router.logger.Sync()
 		sugar := router.logger.Sugar()
 
@@ -70,7 +101,7 @@ func (router PaywallRouter) LoadPaywall(w http.ResponseWriter, req *http.Request
 				}
 			}
 		}
-	}()
+	})
 
 	_ = render.New(w).JSON(http.StatusOK, paywall)
 }
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/internal/pkg/stripe"
+	"github.com/FTChinese/subscription-api/pkg/xhttp"
+)
+
+// AcceptRetentionOffer reactivates a subscription canceled
+// at period end, with the coupon offered upon cancellation
+// deducted from the next invoice.
+func (routes StripeRoutes) AcceptRetentionOffer(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
+	defer routes.logger.Sync()
+	sugar := routes.logger.Sugar()
+
+	ftcID := xhttp.GetFtcID(req.Header)
+	subsID, err := xhttp.GetURLParam(req, "id").ToString()
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).BadRequest(err.Error())
+		return
+	}
+
+	result, err := routes.stripeRepo.AcceptRetentionOffer(ftcID, subsID)
+	if err != nil {
+		sugar.Error(err)
+		_ = xhttp.HandleSubsErr(w, err)
+		return
+	}
+
+	routes.tasks.Go(func() {
+		routes.handleSubsResult(result)
+	})
+
+	_ = render.New(w).OK(result)
+}
+
+// CancellationReport counts cancellations by tier, tenure
+// and reason, together with how many accepted retention offer.
+//
+//	GET /cms/cancellations/report?since=<YYYY-MM-DD>&until=<YYYY-MM-DD>
+//
+// Defaults to the last 90 days.
+func (routes StripeRoutes) CancellationReport(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
+	defer routes.logger.Sync()
+	sugar := routes.logger.Sugar()
+
+	params, ve := stripe.ParseCancellationReportParams(
+		req.FormValue("since"),
+		req.FormValue("until"))
+	if ve != nil {
+		_ = render.New(w).Unprocessable(ve)
+		return
+	}
+
+	report, err := routes.stripeRepo.CancellationReport(params, routes.live)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	_ = render.New(w).OK(report)
+}
@@ -0,0 +1,252 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	gorest "github.com/FTChinese/go-rest"
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/internal/pkg/stripe"
+	"github.com/FTChinese/subscription-api/pkg/account"
+	"github.com/FTChinese/subscription-api/pkg/idempotency"
+	"github.com/FTChinese/subscription-api/pkg/reader"
+	"github.com/FTChinese/subscription-api/pkg/xhttp"
+	sdk "github.com/stripe/stripe-go/v72"
+)
+
+// CreateCheckoutSession creates a Stripe-hosted checkout page
+// for a new subscription. Client redirects user to the url
+// in response.
+// Input:
+// - priceId: string
+// - introductoryPriceId?: string
+// - coupon?: string
+// - promotionCode?: string; Exclusive with introductoryPriceId and coupon.
+// - successUrl: string
+// - cancelUrl: string
+// - idempotency?: string; Defaults to Idempotency-Key header.
+//
+// Membership is linked upon webhook receiving
+// checkout.session.completed, not here.
+// Updating an existing subscription should use the
+// /stripe/subs/{id} endpoint.
+func (routes StripeRoutes) CreateCheckoutSession(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
+	defer routes.logger.Sync()
+	sugar := routes.logger.Sugar()
+
+	ftcID := xhttp.GetFtcID(req.Header)
+	var params stripe.CheckoutSessionParams
+	if err := gorest.ParseJSON(req.Body, &params); err != nil {
+		sugar.Error(err)
+		_ = render.New(w).BadRequest(err.Error())
+		return
+	}
+	if params.IdempotencyKey == "" {
+		params.IdempotencyKey = req.Header.Get(idempotency.Header)
+	}
+	if ve := params.Validate(routes.live); ve != nil {
+		_ = render.New(w).Unprocessable(ve)
+		return
+	}
+
+	acnt, err := routes.readerRepo.BaseAccountByUUID(ftcID)
+	if err != nil {
+		_ = render.New(w).DBError(err)
+		return
+	}
+	if acnt.StripeID.IsZero() {
+		_ = render.New(w).NotFound("Must be a stripe customer prior to subscription")
+		return
+	}
+
+	item, err := routes.findCartItem(params.SubsParams)
+	if err != nil {
+		sugar.Error(err)
+		_ = xhttp.HandleSubsErr(w, err)
+		return
+	}
+
+	if ve := item.Validate(); ve != nil {
+		_ = render.New(w).Unprocessable(ve)
+		return
+	}
+
+	if ve := item.ValidatePromoCode(acnt.StripeID.String); ve != nil {
+		_ = render.New(w).Unprocessable(ve)
+		return
+	}
+
+	mmb, err := routes.readerRepo.RetrieveMember(acnt.CompoundID())
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	cart, err := reader.NewShoppingCart(acnt).
+		WithStripeItem(item).
+		WithMember(mmb)
+	if err != nil {
+		_ = xhttp.HandleSubsErr(w, reader.ConvertIntentError(err))
+		return
+	}
+
+	if !cart.Intent.Kind.IsNewSubs() {
+		_ = render.New(w).Unprocessable(&render.ValidationError{
+			Message: "Checkout only permits creating a new subscription. Update the existing one instead.",
+			Field:   "intent",
+			Code:    render.CodeInvalid,
+		})
+		return
+	}
+
+	cart, err = routes.stripeRepo.WithTrial(cart)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	cs, err := routes.stripeRepo.Client.NewCheckoutSession(
+		params.NewSessionParams(acnt.FtcID, acnt.StripeID.String, cart.StripeItem))
+	if err != nil {
+		sugar.Error(err)
+		_ = xhttp.HandleSubsErr(w, err)
+		return
+	}
+
+	sugar.Infof("Checkout session %s created for %s", cs.ID, acnt.FtcID)
+
+	_ = render.New(w).OK(stripe.NewCheckoutSession(cs))
+}
+
+// CreatePortalSession opens the Stripe billing portal for
+// an existing customer.
+// Input:
+// - returnUrl: string
+func (routes StripeRoutes) CreatePortalSession(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
+	defer routes.logger.Sync()
+	sugar := routes.logger.Sugar()
+
+	ftcID := xhttp.GetFtcID(req.Header)
+	var params stripe.PortalSessionParams
+	if err := gorest.ParseJSON(req.Body, &params); err != nil {
+		sugar.Error(err)
+		_ = render.New(w).BadRequest(err.Error())
+		return
+	}
+	if ve := params.Validate(routes.live); ve != nil {
+		_ = render.New(w).Unprocessable(ve)
+		return
+	}
+
+	acnt, err := routes.readerRepo.BaseAccountByUUID(ftcID)
+	if err != nil {
+		_ = render.New(w).DBError(err)
+		return
+	}
+	if acnt.StripeID.IsZero() {
+		_ = render.New(w).NotFound("Stripe customer not found")
+		return
+	}
+
+	ps, err := routes.stripeRepo.Client.NewPortalSession(acnt.StripeID.String, params.ReturnURL)
+	if err != nil {
+		sugar.Error(err)
+		_ = xhttp.HandleSubsErr(w, err)
+		return
+	}
+
+	_ = render.New(w).OK(stripe.NewPortalSession(ps))
+}
+
+// eventCheckoutCompleted links the subscription created on
+// a checkout page to the ftc account that opened it.
+// customer.subscription.created might arrive before or after
+// this event; syncing is idempotent so the order does not matter.
+func (routes StripeRoutes) eventCheckoutCompleted(cs sdk.CheckoutSession) error {
+	defer routes.logger.Sync()
+	sugar := routes.logger.Sugar()
+
+	if cs.Mode != sdk.CheckoutSessionModeSubscription || cs.Subscription == nil {
+		return nil
+	}
+
+	acnt, err := routes.checkoutAccount(cs)
+	if err != nil {
+		sugar.Error(err)
+		return err
+	}
+
+	ss, err := routes.stripeRepo.Client.FetchSubs(cs.Subscription.ID, true)
+	if err != nil {
+		sugar.Error(err)
+		return err
+	}
+
+	if !acnt.IsStripeCustomer(ss.Customer.ID) {
+		sugar.Errorf("checkout session %s: customer %s does not belong to %s", cs.ID, ss.Customer.ID, acnt.FtcID)
+		return errors.New("customer id mismatched")
+	}
+
+	// Membership prior to syncing is kept in shopping session.
+	mmb, err := routes.readerRepo.RetrieveMember(acnt.CompoundID())
+	if err != nil {
+		sugar.Error(err)
+		return err
+	}
+
+	result, err := routes.stripeRepo.SyncSubs(
+		acnt.CompoundIDs(),
+		stripe.NewSubs(acnt.FtcID, ss),
+		reader.NewArchiver().ByStripe().ActionWebhook())
+	if err != nil {
+		sugar.Error(err)
+
+		var whe stripe.WebhookError
+		if errors.As(err, &whe) {
+			err := routes.stripeRepo.SaveWebhookError(whe)
+			if err != nil {
+				sugar.Error(err)
+			}
+		}
+
+		return err
+	}
+
+	routes.handleSubsResult(result)
+
+	params := stripe.SubsParamsFromMetadata(cs.Metadata)
+	item, err := routes.findCartItem(params)
+	if err != nil {
+		sugar.Error(err)
+		return nil
+	}
+
+	// Intent error is ignored as the subscription already exists.
+	cart, _ := reader.NewShoppingCart(acnt).
+		WithStripeItem(item).
+		WithMember(mmb)
+
+	routes.saveShoppingSession(stripe.NewShoppingSession(cart, params).
+		WithSubs(result.Subs))
+
+	return nil
+}
+
+// checkoutAccount finds the account by client_reference_id,
+// or by customer if it is missing.
+func (routes StripeRoutes) checkoutAccount(cs sdk.CheckoutSession) (account.BaseAccount, error) {
+	if cs.ClientReferenceID != "" {
+		return routes.readerRepo.BaseAccountByUUID(cs.ClientReferenceID)
+	}
+
+	if cs.Customer != nil {
+		return routes.readerRepo.BaseAccountByStripeID(cs.Customer.ID)
+	}
+
+	return account.BaseAccount{}, sql.ErrNoRows
+}
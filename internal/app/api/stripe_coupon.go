@@ -3,12 +3,14 @@ package api
 import (
 	"github.com/FTChinese/go-rest"
 	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/pkg/cms"
 	"github.com/FTChinese/subscription-api/pkg/price"
 	"github.com/FTChinese/subscription-api/pkg/xhttp"
 	"net/http"
 )
 
 func (routes StripeRoutes) ListPriceCoupons(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.logger.Sync()
 	sugar := routes.logger.Sugar()
 	activeOnly := xhttp.ParseQueryBool(req, "active_only")
@@ -29,6 +31,7 @@ func (routes StripeRoutes) ListPriceCoupons(w http.ResponseWriter, req *http.Req
 // Query parameters:
 // - refresh=true to force refresh db data.
 func (routes StripeRoutes) LoadStripeCoupon(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.logger.Sync()
 	sugar := routes.logger.Sugar()
 
@@ -43,12 +46,12 @@ func (routes StripeRoutes) LoadStripeCoupon(w http.ResponseWriter, req *http.Req
 	}
 
 	if c.IsFromStripe {
-		go func() {
+		routes.tasks.Go(func() {
 			err := routes.stripeRepo.UpsertCoupon(c)
 			if err != nil {
 				sugar.Error(err)
 			}
-		}()
+		})
 	}
 
 	_ = render.New(w).OK(c)
@@ -65,6 +68,7 @@ func (routes StripeRoutes) LoadStripeCoupon(w http.ResponseWriter, req *http.Req
 // * startUtc: string;
 // * endUtc: string;
 func (routes StripeRoutes) UpdateStripeCoupon(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.logger.Sync()
 	sugar := routes.logger.Sugar()
 
@@ -81,17 +85,30 @@ func (routes StripeRoutes) UpdateStripeCoupon(w http.ResponseWriter, req *http.R
 		return
 	}
 
+	before, err := routes.stripeRepo.LoadOrFetchCoupon(id, false, routes.live)
+	if err != nil {
+		sugar.Error(err)
+		_ = xhttp.HandleSubsErr(w, err)
+		return
+	}
+
 	// Modify the coupon against Stripe API, then upsert it in database.
 	c, err := routes.stripeRepo.ModifyCoupon(id, params)
 	if err != nil {
 		sugar.Error(err)
 		_ = xhttp.HandleSubsErr(w, err)
+		return
 	}
 
+	cms.AuditTrailFrom(req.Context()).
+		Target(c.PriceID.String).
+		Change(before, c)
+
 	_ = render.New(w).OK(c)
 }
 
 func (routes StripeRoutes) ActivateCoupon(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.logger.Sync()
 	sugar := routes.logger.Sugar()
 
@@ -104,18 +121,23 @@ func (routes StripeRoutes) ActivateCoupon(w http.ResponseWriter, req *http.Reque
 		return
 	}
 
+	before := c
 	c = c.Activate()
 
 	err = routes.stripeRepo.UpdateCouponStatus(c)
 	if err != nil {
 		sugar.Error(err)
 		_ = render.New(w).DBError(err)
+		return
 	}
 
+	cms.AuditTrailFrom(req.Context()).Change(before, c)
+
 	_ = render.New(w).OK(c)
 }
 
 func (routes StripeRoutes) PauseCoupon(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.logger.Sync()
 	sugar := routes.logger.Sugar()
 
@@ -128,20 +150,25 @@ func (routes StripeRoutes) PauseCoupon(w http.ResponseWriter, req *http.Request)
 		return
 	}
 
+	before := c
 	c = c.Pause()
 
 	err = routes.stripeRepo.UpdateCouponStatus(c)
 	if err != nil {
 		sugar.Error(err)
 		_ = render.New(w).DBError(err)
+		return
 	}
 
+	cms.AuditTrailFrom(req.Context()).Change(before, c)
+
 	_ = render.New(w).OK(c)
 }
 
 // DeleteCoupon flags a stripe coupon as invalid.
 // Limited only to internal usage.
 func (routes StripeRoutes) DeleteCoupon(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.logger.Sync()
 	sugar := routes.logger.Sugar()
 
@@ -154,13 +181,17 @@ func (routes StripeRoutes) DeleteCoupon(w http.ResponseWriter, req *http.Request
 		return
 	}
 
+	before := c
 	c = c.Cancelled()
 
 	err = routes.stripeRepo.UpdateCouponStatus(c)
 	if err != nil {
 		sugar.Error(err)
 		_ = render.New(w).DBError(err)
+		return
 	}
 
+	cms.AuditTrailFrom(req.Context()).Change(before, c)
+
 	_ = render.New(w).OK(c)
 }
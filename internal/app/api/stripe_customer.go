@@ -21,6 +21,7 @@ import (
 // Request: empty.
 // Response: stripe.Customer
 func (routes StripeRoutes) CreateCustomer(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.logger.Sync()
 	sugar := routes.logger.Sugar()
 
@@ -35,18 +36,19 @@ func (routes StripeRoutes) CreateCustomer(w http.ResponseWriter, req *http.Reque
 	}
 
 	if cus.IsFromStripe {
-		go func() {
+		routes.tasks.Go(func() {
 			err := routes.stripeRepo.UpsertCustomer(cus)
 			if err != nil {
 				sugar.Error(err)
 			}
-		}()
+		})
 	}
 
 	_ = render.New(w).OK(cus)
 }
 
 func (routes StripeRoutes) GetCustomer(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.logger.Sync()
 	sugar := routes.logger.Sugar()
 
@@ -97,12 +99,12 @@ func (routes StripeRoutes) loadCustomer(id string, refresh bool) (stripe.Custome
 
 	cus = cus.WithFtcID(ba.FtcID)
 
-	go func() {
+	routes.tasks.Go(func() {
 		err := routes.stripeRepo.UpsertCustomer(cus)
 		if err != nil {
 			sugar.Error(err)
 		}
-	}()
+	})
 
 	return cus, nil
 }
@@ -110,6 +112,7 @@ func (routes StripeRoutes) loadCustomer(id string, refresh bool) (stripe.Custome
 // GetCusDefaultPaymentMethod load the payment method details
 // which is set as a customer's default payment method.
 func (routes StripeRoutes) GetCusDefaultPaymentMethod(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.logger.Sync()
 	sugar := routes.logger.Sugar()
 
@@ -151,6 +154,7 @@ func (routes StripeRoutes) GetCusDefaultPaymentMethod(w http.ResponseWriter, req
 }
 
 func (routes StripeRoutes) UpdateCusDefaultPaymentMethod(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.logger.Sync()
 	sugar := routes.logger.Sugar()
 
@@ -200,7 +204,7 @@ func (routes StripeRoutes) UpdateCusDefaultPaymentMethod(w http.ResponseWriter,
 	// Update payment method in db.
 	// We do not update customer here since the webhook
 	// will handle it.
-	go func() {
+	routes.tasks.Go(func() {
 		// Save updated customer
 		err := routes.stripeRepo.UpsertCustomer(cus)
 		if err != nil {
@@ -219,12 +223,13 @@ func (routes StripeRoutes) UpdateCusDefaultPaymentMethod(w http.ResponseWriter,
 		if err != nil {
 			sugar.Error(err)
 		}
-	}()
+	})
 
 	_ = render.New(w).OK(cus)
 }
 
 func (routes StripeRoutes) ListCusPaymentMethods(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.logger.Sync()
 	sugar := routes.logger.Sugar()
 
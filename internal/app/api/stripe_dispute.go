@@ -0,0 +1,129 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	gorest "github.com/FTChinese/go-rest"
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/internal/pkg/stripe"
+	sdk "github.com/stripe/stripe-go/v72"
+)
+
+// ListOpenDisputes shows disputes still awaiting response
+// or review, those with the earliest evidence due date first.
+//
+//	GET /cms/disputes?page=<int>&per_page=<int>
+func (routes StripeRoutes) ListOpenDisputes(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
+	defer routes.logger.Sync()
+	sugar := routes.logger.Sugar()
+
+	p := gorest.GetPagination(req)
+
+	list, err := routes.stripeRepo.ListOpenDisputes(routes.live, p)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	_ = render.New(w).OK(list)
+}
+
+// eventDispute handles charge.dispute.* events.
+// Every event saves the dispute. In addition:
+// - charge.dispute.created flags the subscription and
+// membership, suspending access if configured;
+// - charge.dispute.closed restores access of a won dispute
+// by syncing membership from subscription again.
+// Finance staff are notified of both.
+func (routes StripeRoutes) eventDispute(raw sdk.Dispute, eventType string) error {
+	defer routes.logger.Sync()
+	sugar := routes.logger.Sugar()
+
+	d, err := routes.stripeRepo.FetchDispute(&raw)
+	if err != nil {
+		sugar.Error(err)
+		return err
+	}
+
+	if d.CustomerID.Valid {
+		acnt, err := routes.readerRepo.BaseAccountByStripeID(d.CustomerID.String)
+		if err != nil {
+			sugar.Error(err)
+			if err != sql.ErrNoRows {
+				return err
+			}
+		}
+		d = d.WithFtcID(acnt.FtcID)
+	}
+
+	err = routes.stripeRepo.UpsertDispute(d)
+	if err != nil {
+		sugar.Error(err)
+		return err
+	}
+
+	switch eventType {
+	case "charge.dispute.created":
+		v, err := routes.stripeRepo.FlagDispute(d, routes.disputeConfig.SuspendAccess)
+		if err != nil {
+			sugar.Error(err)
+			return err
+		}
+
+		if !v.IsZero() {
+			err := routes.readerRepo.VersionMembership(v)
+			if err != nil {
+				sugar.Error(err)
+			}
+		}
+
+		routes.notifyDispute(d, !v.IsZero())
+
+	case "charge.dispute.closed":
+		if d.IsWon() && d.SubsID.Valid {
+			err := routes.restoreDisputed(d.SubsID.String)
+			if err != nil {
+				sugar.Error(err)
+				return err
+			}
+		}
+
+		routes.notifyDispute(d, false)
+	}
+
+	return nil
+}
+
+// restoreDisputed clears the dispute flag of a subscription
+// and recalculates membership from the latest subscription.
+func (routes StripeRoutes) restoreDisputed(subsID string) error {
+	err := routes.stripeRepo.ClearDispute(subsID)
+	if err != nil {
+		// Nothing to restore if membership is not found.
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+
+	ss, err := routes.stripeRepo.Client.FetchSubs(subsID, true)
+	if err != nil {
+		return err
+	}
+
+	return routes.eventSubscription(ss)
+}
+
+func (routes StripeRoutes) notifyDispute(d stripe.Dispute, suspended bool) {
+	sugar := routes.logger.Sugar()
+
+	for _, to := range routes.disputeConfig.Notify {
+		err := routes.emailService.SendDisputeNotice(to, d, suspended)
+		if err != nil {
+			sugar.Error(err)
+		}
+	}
+}
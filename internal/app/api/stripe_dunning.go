@@ -0,0 +1,164 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	gorest "github.com/FTChinese/go-rest"
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/internal/pkg/stripe"
+	"github.com/FTChinese/subscription-api/pkg/idempotency"
+	"github.com/FTChinese/subscription-api/pkg/reader"
+	"github.com/FTChinese/subscription-api/pkg/xhttp"
+	sdk "github.com/stripe/stripe-go/v72"
+)
+
+// RetryPayment pays the open latest invoice of a subscription
+// in dunning with another payment method, instead of waiting
+// for Stripe's next retry.
+// Input:
+// - paymentMethod: string. Must be attached to the customer.
+// - idempotency?: string; Defaults to Idempotency-Key header.
+//
+// If the card requires authentication, the response carries
+// the payment intent for client to confirm.
+// The payment method is set as subscription's default upon
+// webhook receiving invoice.payment_succeeded.
+func (routes StripeRoutes) RetryPayment(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
+	defer routes.logger.Sync()
+	sugar := routes.logger.Sugar()
+
+	ftcID := xhttp.GetFtcID(req.Header)
+	subsID, err := xhttp.GetURLParam(req, "id").ToString()
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).BadRequest(err.Error())
+		return
+	}
+
+	var params stripe.RetryPaymentParams
+	if err := gorest.ParseJSON(req.Body, &params); err != nil {
+		sugar.Error(err)
+		_ = render.New(w).BadRequest(err.Error())
+		return
+	}
+	if params.IdempotencyKey == "" {
+		params.IdempotencyKey = req.Header.Get(idempotency.Header)
+	}
+	if ve := params.Validate(); ve != nil {
+		_ = render.New(w).Unprocessable(ve)
+		return
+	}
+
+	acnt, err := routes.readerRepo.BaseAccountByUUID(ftcID)
+	if err != nil {
+		_ = render.New(w).DBError(err)
+		return
+	}
+	if acnt.StripeID.IsZero() {
+		_ = render.New(w).NotFound("Stripe customer not found")
+		return
+	}
+
+	result, err := routes.stripeRepo.RetryPayment(
+		acnt.StripeID.String,
+		subsID,
+		params)
+	if err != nil {
+		sugar.Error(err)
+		_ = xhttp.HandleSubsErr(w, err)
+		return
+	}
+
+	synced, err := routes.stripeRepo.SyncSubs(
+		acnt.CompoundIDs(),
+		result.Subs.WithFtcID(acnt.FtcID),
+		reader.NewArchiver().ByStripe().ActionRefresh())
+	// Payment is already made. Membership will be synced
+	// again by webhook.
+	if err != nil {
+		sugar.Error(err)
+	} else {
+		result.Subs = synced.Subs
+		routes.tasks.Go(func() {
+			routes.handleSubsResult(synced)
+		})
+	}
+
+	_ = render.New(w).OK(result)
+}
+
+// eventPaymentFailed handles invoice.payment_failed and
+// invoice.payment_action_required.
+// Renewal invoices enter dunning: each failed attempt is
+// recorded, user is emailed the hosted invoice page, and
+// the membership is synced to the past_due subscription.
+func (routes StripeRoutes) eventPaymentFailed(rawInvoice sdk.Invoice, actionRequired bool) error {
+	defer routes.logger.Sync()
+	sugar := routes.logger.Sugar()
+
+	inv := stripe.NewInvoice(&rawInvoice)
+	err := routes.stripeRepo.UpsertInvoice(inv)
+	if err != nil {
+		sugar.Error(err)
+	}
+
+	if !inv.RequiresDunning() {
+		return nil
+	}
+
+	d, isNew, err := routes.stripeRepo.RecordDunning(inv, actionRequired)
+	if err != nil {
+		sugar.Error(err)
+		return err
+	}
+
+	acnt, err := routes.readerRepo.BaseAccountByStripeID(inv.CustomerID)
+	if err != nil {
+		sugar.Error(err)
+		// Nobody to notify.
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+
+	if isNew && acnt.Email != "" {
+		sugar.Infof("Sending dunning email of invoice %s, attempt %d", d.InvoiceID, d.AttemptCount)
+		err := routes.emailService.SendStripeDunning(acnt, d)
+		if err != nil {
+			sugar.Error(err)
+		}
+	}
+
+	// Webhook might arrive before customer.subscription.updated.
+	ss, err := routes.stripeRepo.Client.FetchSubs(d.SubsID, true)
+	if err != nil {
+		sugar.Error(err)
+		return err
+	}
+
+	result, err := routes.stripeRepo.SyncSubs(
+		acnt.CompoundIDs(),
+		stripe.NewSubs(acnt.FtcID, ss),
+		reader.NewArchiver().ByStripe().ActionWebhook())
+	if err != nil {
+		sugar.Error(err)
+
+		var whe stripe.WebhookError
+		if errors.As(err, &whe) {
+			err := routes.stripeRepo.SaveWebhookError(whe)
+			if err != nil {
+				sugar.Error(err)
+			}
+		}
+
+		return err
+	}
+
+	routes.handleSubsResult(result)
+
+	return nil
+}
@@ -14,6 +14,7 @@ import (
 // POST /stripe/customers/{id}/ephemeral-keys?api_version=<version>
 // Kept for android app < 6.2.0
 func (routes StripeRoutes) IssueKey(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.logger.Sync()
 	sugar := routes.logger.Sugar()
 
@@ -49,6 +50,7 @@ func (routes StripeRoutes) IssueKey(w http.ResponseWriter, req *http.Request) {
 }
 
 func (routes StripeRoutes) SetupWithEphemeral(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.logger.Sync()
 	sugar := routes.logger.Sugar()
 
@@ -84,12 +86,12 @@ func (routes StripeRoutes) SetupWithEphemeral(w http.ResponseWriter, req *http.R
 
 	si := stripe.NewSetupIntent(rawSI)
 
-	go func() {
+	routes.tasks.Go(func() {
 		err := routes.stripeRepo.UpsertSetupIntent(si)
 		if err != nil {
 			sugar.Error(err)
 		}
-	}()
+	})
 
 	_ = render.New(w).OK(paymentSheet)
 }
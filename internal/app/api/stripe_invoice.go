@@ -18,18 +18,19 @@ func (routes StripeRoutes) loadInvoice(id string, refresh bool) (stripe.Invoice,
 	}
 
 	if inv.IsFromStripe {
-		go func() {
+		routes.tasks.Go(func() {
 			err := routes.stripeRepo.UpsertInvoice(inv)
 			if err != nil {
 				sugar.Error(err)
 			}
-		}()
+		})
 	}
 
 	return inv, nil
 }
 
 func (routes StripeRoutes) LoadLatestInvoice(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.logger.Sync()
 	sugar := routes.logger.Sugar()
 
@@ -58,6 +59,7 @@ func (routes StripeRoutes) LoadLatestInvoice(w http.ResponseWriter, req *http.Re
 }
 
 func (routes StripeRoutes) LoadInvoice(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.logger.Sync()
 	sugar := routes.logger.Sugar()
 
@@ -81,6 +83,7 @@ func (routes StripeRoutes) LoadInvoice(w http.ResponseWriter, req *http.Request)
 }
 
 func (routes StripeRoutes) CouponOfLatestInvoice(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.logger.Sync()
 	sugar := routes.logger.Sugar()
 
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"net/http"
+
+	gorest "github.com/FTChinese/go-rest"
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/internal/pkg/stripe"
+	"github.com/FTChinese/subscription-api/pkg/xhttp"
+)
+
+// PauseSubs pauses payment collection of a subscription,
+// e.g., when user is travelling.
+// Input:
+// - behavior?: void | keep_as_draft; Defaults to void.
+// - resumesAt?: string; ISO8601 time within 90 days. Omit to resume manually.
+//
+// Membership expires at the end of the period already paid.
+// See https://stripe.com/docs/billing/subscriptions/pause
+func (routes StripeRoutes) PauseSubs(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
+	defer routes.logger.Sync()
+	sugar := routes.logger.Sugar()
+
+	ftcID := xhttp.GetFtcID(req.Header)
+	subsID, err := xhttp.GetURLParam(req, "id").ToString()
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).BadRequest(err.Error())
+		return
+	}
+
+	var params stripe.PauseParams
+	if err := gorest.ParseJSON(req.Body, &params); err != nil {
+		sugar.Error(err)
+		_ = render.New(w).BadRequest(err.Error())
+		return
+	}
+	if ve := params.Validate(); ve != nil {
+		_ = render.New(w).Unprocessable(ve)
+		return
+	}
+
+	result, err := routes.stripeRepo.PauseSubscription(ftcID, subsID, params)
+	if err != nil {
+		sugar.Error(err)
+		_ = xhttp.HandleSubsErr(w, err)
+		return
+	}
+
+	routes.tasks.Go(func() {
+		routes.handleSubsResult(result)
+	})
+
+	_ = render.New(w).OK(result)
+}
+
+// ResumeSubs resumes payment collection of a paused
+// subscription before its resume date.
+// If the period paid is already over, a new billing cycle
+// starts now and is charged immediately.
+func (routes StripeRoutes) ResumeSubs(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
+	defer routes.logger.Sync()
+	sugar := routes.logger.Sugar()
+
+	ftcID := xhttp.GetFtcID(req.Header)
+	subsID, err := xhttp.GetURLParam(req, "id").ToString()
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).BadRequest(err.Error())
+		return
+	}
+
+	result, err := routes.stripeRepo.ResumeSubscription(ftcID, subsID)
+	if err != nil {
+		sugar.Error(err)
+		_ = xhttp.HandleSubsErr(w, err)
+		return
+	}
+
+	routes.tasks.Go(func() {
+		routes.handleSubsResult(result)
+	})
+
+	_ = render.New(w).OK(result)
+}
@@ -12,6 +12,7 @@ import (
 // If query parameter ?refresh=true is passed,
 // it will bypass local db and use Stripe API directly.
 func (routes StripeRoutes) LoadPaymentMethod(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.logger.Sync()
 	sugar := routes.logger.Sugar()
 
@@ -46,12 +47,12 @@ func (routes StripeRoutes) loadPaymentMethod(pmID string, refresh bool) (stripe.
 
 	// Save it if not save in our db yet.
 	if pm.IsFromStripe {
-		go func() {
+		routes.tasks.Go(func() {
 			err := routes.stripeRepo.UpsertPaymentMethod(pm)
 			if err != nil {
 				sugar.Error(err)
 			}
-		}()
+		})
 	}
 
 	return pm, nil
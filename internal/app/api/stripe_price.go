@@ -5,6 +5,7 @@ import (
 
 	gorest "github.com/FTChinese/go-rest"
 	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/pkg/cms"
 	"github.com/FTChinese/subscription-api/pkg/price"
 	"github.com/FTChinese/subscription-api/pkg/xhttp"
 )
@@ -12,6 +13,7 @@ import (
 // ListPaywallPrices retrieves all prices defined in Stripe.
 // Deprecated
 func (routes StripeRoutes) ListPaywallPrices(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	refresh := xhttp.ParseQueryRefresh(req)
 
 	prices, err := routes.stripeRepo.LoadOrFetchPaywallPrices(refresh, routes.live)
@@ -33,6 +35,7 @@ func (routes StripeRoutes) ListPaywallPrices(w http.ResponseWriter, req *http.Re
 // Query parameter:
 // ?page=<int>&per_page=<int>
 func (routes StripeRoutes) ListPricesPaged(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	p := gorest.GetPagination(req)
 
 	prices, err := routes.stripeRepo.ListPricesPaged(routes.live, p)
@@ -50,6 +53,7 @@ func (routes StripeRoutes) ListPricesPaged(w http.ResponseWriter, req *http.Requ
 // Query parameters:
 // - refresh=true
 func (routes StripeRoutes) LoadPrice(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.logger.Sync()
 	sugar := routes.logger.Sugar()
 
@@ -85,10 +89,12 @@ func (routes StripeRoutes) LoadPrice(w http.ResponseWriter, req *http.Request) {
 // For recurring price, you only provide:
 // - introductory: false
 // - tier: string
+// - trialDays?: number; Free trial for new subscribers.
 // Since periodCount fields could be deduced from
 // stripe price fields in such case,
 // We'd better not touch it to avoid any data inconsistency.
 func (routes StripeRoutes) SetPriceMeta(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.logger.Sync()
 	sugar := routes.logger.Sugar()
 
@@ -131,14 +137,16 @@ func (routes StripeRoutes) SetPriceMeta(w http.ResponseWriter, req *http.Request
 
 	// Refresh db with updated data.
 	newPrice := price.NewStripePrice(rawPrice)
-	go func() {
+	cms.AuditTrailFrom(req.Context()).Change(sp, newPrice)
+
+	routes.tasks.Go(func() {
 		err := routes.stripeRepo.UpsertPrice(sp)
 		if err != nil {
 			defer routes.logger.Sync()
 			sugar := routes.logger.Sugar()
 			sugar.Error(err)
 		}
-	}()
+	})
 
 	newPrice.OnPaywall = sp.OnPaywall
 
@@ -146,6 +154,7 @@ func (routes StripeRoutes) SetPriceMeta(w http.ResponseWriter, req *http.Request
 }
 
 func (routes StripeRoutes) ActivatePrice(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.logger.Sync()
 	sugar := routes.logger.Sugar()
 
@@ -169,10 +178,14 @@ func (routes StripeRoutes) ActivatePrice(w http.ResponseWriter, req *http.Reques
 		return
 	}
 
-	_ = render.New(w).OK(sp.Activate())
+	activated := sp.Activate()
+	cms.AuditTrailFrom(req.Context()).Change(sp, activated)
+
+	_ = render.New(w).OK(activated)
 }
 
 func (routes StripeRoutes) DeactivatePrice(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.logger.Sync()
 	sugar := routes.logger.Sugar()
 
@@ -196,5 +209,8 @@ func (routes StripeRoutes) DeactivatePrice(w http.ResponseWriter, req *http.Requ
 		return
 	}
 
-	_ = render.New(w).OK(sp.Deactivate())
+	deactivated := sp.Deactivate()
+	cms.AuditTrailFrom(req.Context()).Change(sp, deactivated)
+
+	_ = render.New(w).OK(deactivated)
 }
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"net/http"
+
+	gorest "github.com/FTChinese/go-rest"
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/internal/pkg/stripe"
+	"github.com/FTChinese/subscription-api/pkg/reader"
+	"github.com/FTChinese/subscription-api/pkg/xhttp"
+)
+
+// ValidatePromoCode checks whether a promotion code could be
+// redeemed by current user on a price before subscribing.
+// Input:
+// - priceId: string
+// - promotionCode: string
+//
+// Returns the promotion code and the coupon it redeems so
+// that client could show the discounted amount.
+func (routes StripeRoutes) ValidatePromoCode(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
+	defer routes.logger.Sync()
+	sugar := routes.logger.Sugar()
+
+	ftcID := xhttp.GetFtcID(req.Header)
+	var params stripe.SubsParams
+	if err := gorest.ParseJSON(req.Body, &params); err != nil {
+		_ = render.New(w).BadRequest(err.Error())
+		return
+	}
+	if ve := params.Validate(); ve != nil {
+		_ = render.New(w).Unprocessable(ve)
+		return
+	}
+	if !params.PromotionCode.Valid || params.PromotionCode.String == "" {
+		_ = render.New(w).Unprocessable(&render.ValidationError{
+			Message: "Promotion code is required",
+			Field:   "promotionCode",
+			Code:    render.CodeMissingField,
+		})
+		return
+	}
+
+	acnt, err := routes.readerRepo.BaseAccountByUUID(ftcID)
+	if err != nil {
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	item, err := routes.findCartItem(params)
+	if err != nil {
+		sugar.Error(err)
+		_ = xhttp.HandleSubsErr(w, err)
+		return
+	}
+
+	if ve := item.ValidatePromoCode(acnt.StripeID.String); ve != nil {
+		_ = render.New(w).Unprocessable(ve)
+		return
+	}
+
+	mmb, err := routes.readerRepo.RetrieveMember(acnt.CompoundID())
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
+	// Restrictions depending on user's purchase history.
+	_, err = reader.NewShoppingCart(acnt).
+		WithStripeItem(item).
+		WithMember(mmb)
+	if err != nil {
+		_ = xhttp.HandleSubsErr(w, reader.ConvertIntentError(err))
+		return
+	}
+
+	_ = render.New(w).OK(item.PromoCode)
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"net/http"
+
+	gorest "github.com/FTChinese/go-rest"
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/internal/pkg/stripe"
+	"github.com/FTChinese/subscription-api/pkg/cms"
+	"github.com/FTChinese/subscription-api/pkg/idempotency"
+	"github.com/FTChinese/subscription-api/pkg/xhttp"
+)
+
+// RefundInvoice refunds a Stripe invoice on behalf of staff.
+//
+//	POST /cms/refunds/stripe/{id}
+//
+// Input:
+// - amount?: number. In minor unit. Defaults to all remaining.
+// - reason?: duplicate | fraudulent | requested_by_customer;
+// - cancelSubs?: boolean. Cancel subscription immediately;
+// - expire?: now | prorated. Omit to keep membership.
+// - idempotency?: string; Defaults to Idempotency-Key header.
+func (routes StripeRoutes) RefundInvoice(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
+	defer routes.logger.Sync()
+	sugar := routes.logger.Sugar()
+
+	invID, err := xhttp.GetURLParam(req, "id").ToString()
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).BadRequest(err.Error())
+		return
+	}
+	staffName := xhttp.GetStaffName(req.Header)
+
+	var params stripe.RefundParams
+	if err := gorest.ParseJSON(req.Body, &params); err != nil {
+		sugar.Error(err)
+		_ = render.New(w).BadRequest(err.Error())
+		return
+	}
+	if params.IdempotencyKey == "" {
+		params.IdempotencyKey = req.Header.Get(idempotency.Header)
+	}
+	if ve := params.Validate(); ve != nil {
+		_ = render.New(w).Unprocessable(ve)
+		return
+	}
+
+	result, err := routes.stripeRepo.RefundInvoice(invID, params, staffName)
+	if err != nil {
+		sugar.Error(err)
+		_ = xhttp.HandleSubsErr(w, err)
+		return
+	}
+
+	trail := cms.AuditTrailFrom(req.Context()).
+		Target(result.Refund.FtcUserID.String, invID)
+	if !result.Versioned.IsZero() {
+		trail.Change(result.Versioned.AnteChange.Membership, result.Member)
+	}
+
+	// Money is refunded while adjustment failed. Staff see
+	// adjustPending in response and stop it in Stripe.
+	if result.Refund.AdjustPending {
+		sugar.Warnf("Refund %s of invoice %s pending adjustment", result.Refund.ID, invID)
+	}
+
+	if params.AdjustsSubs() && !result.Refund.AdjustPending {
+		routes.tasks.Go(func() {
+			routes.handleSubsResult(stripe.SubsResult{
+				Modified:  !result.Versioned.IsZero(),
+				Subs:      result.Subs,
+				Member:    result.Member,
+				Versioned: result.Versioned,
+			})
+		})
+	}
+
+	_ = render.New(w).OK(result)
+}
@@ -4,15 +4,20 @@ import (
 	"net/http"
 
 	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/internal/pkg/letter"
 	"github.com/FTChinese/subscription-api/internal/pkg/stripe"
 	"github.com/FTChinese/subscription-api/internal/repository"
+	"github.com/FTChinese/subscription-api/internal/repository/mailrepo"
 	"github.com/FTChinese/subscription-api/internal/repository/shared"
 	"github.com/FTChinese/subscription-api/internal/repository/stripeenv"
 	"github.com/FTChinese/subscription-api/internal/stripeclient"
+	"github.com/FTChinese/subscription-api/pkg/background"
+	"github.com/FTChinese/subscription-api/pkg/cachestore"
 	"github.com/FTChinese/subscription-api/pkg/config"
 	"github.com/FTChinese/subscription-api/pkg/db"
 	"github.com/FTChinese/subscription-api/pkg/reader"
-	"github.com/patrickmn/go-cache"
+	"github.com/FTChinese/subscription-api/pkg/tracing"
+	"github.com/FTChinese/subscription-api/pkg/xhttp"
 	"go.uber.org/zap"
 )
 
@@ -27,13 +32,17 @@ type StripeRoutes struct {
 	readerRepo     shared.ReaderCommon
 	stripeRepo     stripeenv.Env
 	cacheRepo      repository.CacheRepo
+	emailService   letter.Service
+	disputeConfig  config.StripeDisputeConfig
+	tasks          *background.Runner
 	logger         *zap.Logger
 	live           bool
 }
 
 func NewStripeRoutes(
 	dbs db.ReadWriteMyDBs,
-	c *cache.Cache,
+	c cachestore.Store,
+	tasks *background.Runner,
 	logger *zap.Logger,
 	live bool,
 ) StripeRoutes {
@@ -47,13 +56,28 @@ func NewStripeRoutes(
 			stripeclient.New(live, logger),
 			repository.NewStripeRepo(dbs, logger),
 		),
-		cacheRepo: repository.NewCacheRepo(c),
-		logger:    logger,
-		live:      live,
+		cacheRepo:     repository.NewCacheRepo(c),
+		emailService:  letter.NewService(mailrepo.New(dbs, logger), logger),
+		disputeConfig: config.MustStripeDisputeConfig(),
+		tasks:         tasks,
+		logger:        logger,
+		live:          live,
 	}
 }
 
+// withRequest logs with the request id of req, and traces
+// SQL statements as part of the request.
+func (routes StripeRoutes) withRequest(req *http.Request) StripeRoutes {
+	l := xhttp.LoggerFrom(req.Context(), routes.logger)
+	routes.stripeRepo = routes.stripeRepo.
+		WithLogger(l).
+		WithContext(tracing.Detach(req.Context()))
+	routes.logger = l
+	return routes
+}
+
 func (routes StripeRoutes) PublishableKey(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	_ = render.New(w).OK(PublishableKey{
 		Key:  routes.publishableKey,
 		Live: routes.live,
@@ -97,7 +121,26 @@ func (routes StripeRoutes) handleSubsResult(result stripe.SubsResult) {
 	}
 }
 
+// findCartItem builds the items user is paying for, resolving
+// the promotion code if entered. Restrictions of the code are
+// not checked here.
 func (routes StripeRoutes) findCartItem(params stripe.SubsParams) (reader.CartItemStripe, error) {
+	item, err := routes.findPaywallItem(params)
+	if err != nil {
+		return reader.CartItemStripe{}, err
+	}
+
+	if params.PromotionCode.Valid {
+		item.PromoCode, err = routes.stripeRepo.FindPromoCode(params.PromotionCode.String)
+		if err != nil {
+			return reader.CartItemStripe{}, err
+		}
+	}
+
+	return item, nil
+}
+
+func (routes StripeRoutes) findPaywallItem(params stripe.SubsParams) (reader.CartItemStripe, error) {
 	// Get paywall from cache
 	paywall, err := routes.cacheRepo.LoadPaywall(routes.live)
 	// If paywall data is found in cache.
@@ -118,7 +161,7 @@ func (routes StripeRoutes) findCartItem(params stripe.SubsParams) (reader.CartIt
 	// In case item in cart is fetched from Stripe API,
 	// save it to db.
 	if item.AnyFromStripe() {
-		go func() {
+		routes.tasks.Go(func() {
 			defer routes.logger.Sync()
 			sugar := routes.logger.Sugar()
 
@@ -142,7 +185,7 @@ func (routes StripeRoutes) findCartItem(params stripe.SubsParams) (reader.CartIt
 					sugar.Error(err)
 				}
 			}
-		}()
+		})
 	}
 
 	return item, nil
@@ -9,6 +9,7 @@ import (
 )
 
 func (routes StripeRoutes) CreateSetupIntent(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.logger.Sync()
 	sugar := routes.logger.Sugar()
 
@@ -34,17 +35,18 @@ func (routes StripeRoutes) CreateSetupIntent(w http.ResponseWriter, req *http.Re
 
 	si := stripe.NewSetupIntent(rawSI)
 
-	go func() {
+	routes.tasks.Go(func() {
 		err := routes.stripeRepo.UpsertSetupIntent(si)
 		if err != nil {
 			sugar.Error(err)
 		}
-	}()
+	})
 
 	_ = render.New(w).OK(si)
 }
 
 func (routes StripeRoutes) GetSetupIntent(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.logger.Sync()
 	sugar := routes.logger.Sugar()
 
@@ -66,18 +68,19 @@ func (routes StripeRoutes) GetSetupIntent(w http.ResponseWriter, req *http.Reque
 	}
 
 	if si.IsFromStripe {
-		go func() {
+		routes.tasks.Go(func() {
 			err := routes.stripeRepo.UpsertSetupIntent(si)
 			if err != nil {
 				sugar.Error(err)
 			}
-		}()
+		})
 	}
 
 	_ = render.New(w).OK(si)
 }
 
 func (routes StripeRoutes) GetSetupPaymentMethod(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.logger.Sync()
 	sugar := routes.logger.Sugar()
 
@@ -109,12 +112,12 @@ func (routes StripeRoutes) loadSetupPaymentMethod(w http.ResponseWriter, setupID
 	}
 
 	if si.IsFromStripe {
-		go func() {
+		routes.tasks.Go(func() {
 			err := routes.stripeRepo.UpsertSetupIntent(si)
 			if err != nil {
 				sugar.Error(err)
 			}
-		}()
+		})
 	}
 
 	if si.PaymentMethodID.IsZero() {
@@ -142,12 +145,12 @@ func (routes StripeRoutes) refreshSetupPaymentMethod(w http.ResponseWriter, setu
 	}
 
 	si := stripe.NewSetupIntent(rawSI)
-	go func() {
+	routes.tasks.Go(func() {
 		err := routes.stripeRepo.UpsertSetupIntent(si)
 		if err != nil {
 			sugar.Error(err)
 		}
-	}()
+	})
 
 	if rawSI.PaymentMethod == nil || rawSI.PaymentMethod.ID == "" {
 		_ = render.New(w).NotFound("Payment method not set")
@@ -156,12 +159,12 @@ func (routes StripeRoutes) refreshSetupPaymentMethod(w http.ResponseWriter, setu
 
 	pm := stripe.NewPaymentMethod(rawSI.PaymentMethod)
 
-	go func() {
+	routes.tasks.Go(func() {
 		err := routes.stripeRepo.UpsertPaymentMethod(pm)
 		if err != nil {
 			sugar.Error(err)
 		}
-	}()
+	})
 
 	_ = render.New(w).OK(pm)
 }
@@ -1,11 +1,13 @@
 package api
 
 import (
+	"io"
 	"net/http"
 
 	gorest "github.com/FTChinese/go-rest"
 	"github.com/FTChinese/go-rest/render"
 	"github.com/FTChinese/subscription-api/internal/pkg/stripe"
+	"github.com/FTChinese/subscription-api/pkg/idempotency"
 	"github.com/FTChinese/subscription-api/pkg/reader"
 	"github.com/FTChinese/subscription-api/pkg/xhttp"
 )
@@ -15,8 +17,9 @@ import (
 // - priceId: string - The stripe price id to subscribe
 // - introductoryPriceId: string - A one-time stripe price id to create an extra invoice
 // - coupon?: string;
+// - promotionCode?: string; Customer-facing code. Exclusive with introductoryPriceId and coupon.
 // - defaultPaymentMethod?: string;
-// - idempotency?: string;
+// - idempotency?: string; Defaults to Idempotency-Key header.
 //
 // PITFALLS:
 // If you create a plan in CNY, and a customer is subscribed to
@@ -35,6 +38,7 @@ import (
 // in case user already linked wechat.
 // Notification email is sent upon webhook receiving data, not here.
 func (routes StripeRoutes) CreateSubs(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.logger.Sync()
 	sugar := routes.logger.Sugar()
 
@@ -46,6 +50,10 @@ func (routes StripeRoutes) CreateSubs(w http.ResponseWriter, req *http.Request)
 		_ = render.New(w).BadRequest(err.Error())
 		return
 	}
+	// Let Stripe dedupe retries too.
+	if params.IdempotencyKey == "" {
+		params.IdempotencyKey = req.Header.Get(idempotency.Header)
+	}
 	// Validate params data.
 	if err := params.Validate(); err != nil {
 		sugar.Error(err)
@@ -77,6 +85,11 @@ func (routes StripeRoutes) CreateSubs(w http.ResponseWriter, req *http.Request)
 		return
 	}
 
+	if ve := item.ValidatePromoCode(acnt.StripeID.String); ve != nil {
+		_ = render.New(w).Unprocessable(ve)
+		return
+	}
+
 	cart := reader.NewShoppingCart(acnt).
 		WithStripeItem(item)
 
@@ -90,19 +103,19 @@ func (routes StripeRoutes) CreateSubs(w http.ResponseWriter, req *http.Request)
 		sugar.Error(err)
 		_ = xhttp.HandleSubsErr(w, reader.ConvertIntentError(err))
 
-		go func() {
+		routes.tasks.Go(func() {
 			routes.saveShoppingSession(session)
-		}()
+		})
 
 		return
 	}
 
 	// Save ftc id to stripe subscription id mapping.
 	// Backup previous membership if exists.
-	go func() {
+	routes.tasks.Go(func() {
 		routes.handleSubsResult(result)
 		routes.saveShoppingSession(session.WithSubs(result.Subs))
-	}()
+	})
 
 	_ = render.New(w).OK(result)
 }
@@ -113,6 +126,7 @@ func (routes StripeRoutes) CreateSubs(w http.ResponseWriter, req *http.Request)
 // `refresh=true` query parameter since it involves syncing
 // membership.
 func (routes StripeRoutes) LoadSubs(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 
 	defer routes.logger.Sync()
 	sugar := routes.logger.Sugar()
@@ -146,9 +160,13 @@ func (routes StripeRoutes) LoadSubs(w http.ResponseWriter, req *http.Request) {
 
 // UpdateSubs updates a stripe subscription:
 // User could switch cycle of the same tier, or upgrading to a higher tier.
+// Upgrading takes effect immediately with proration, while
+// downgrading and switching cycle are scheduled to current
+// period end and returned in the `pendingChange` field.
 // Input:
 // * priceId: string - The price to change to.
 // * coupon?: "",
+// * promotionCode?: "",
 // * defaultPaymentMethod?: ""
 // * idempotency?: string
 //
@@ -159,6 +177,7 @@ func (routes StripeRoutes) LoadSubs(w http.ResponseWriter, req *http.Request) {
 // one is standard and another if premium.
 // So we cannot rely on this field to find FTC plan.
 func (routes StripeRoutes) UpdateSubs(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.logger.Sync()
 	sugar := routes.logger.Sugar()
 
@@ -202,6 +221,11 @@ func (routes StripeRoutes) UpdateSubs(w http.ResponseWriter, req *http.Request)
 		return
 	}
 
+	if ve := item.ValidatePromoCode(account.StripeID.String); ve != nil {
+		_ = render.New(w).Unprocessable(ve)
+		return
+	}
+
 	cart := reader.NewShoppingCart(account).WithStripeItem(item)
 	cart, result, err := routes.stripeRepo.UpdateSubscription(
 		subsID,
@@ -213,18 +237,21 @@ func (routes StripeRoutes) UpdateSubs(w http.ResponseWriter, req *http.Request)
 	if err != nil {
 		sugar.Error(err)
 		_ = xhttp.HandleSubsErr(w, reader.ConvertIntentError(err))
-		go func() {
+		routes.tasks.Go(func() {
 			routes.saveShoppingSession(session)
-		}()
+		})
 		return
 	}
 
 	// Remember uuid to stripe subscription mapping;
 	// Backup previous membership.
-	go func() {
-		routes.handleSubsResult(result)
+	// A scheduled change modifies nothing until period end.
+	routes.tasks.Go(func() {
+		if !cart.Intent.Kind.IsScheduled() {
+			routes.handleSubsResult(result)
+		}
 		routes.saveShoppingSession(session.WithSubs(result.Subs))
-	}()
+	})
 
 	// When a user in trial period is redeeming a coupon, there's no payment intent under latest invoice.
 	// We should not check payment intent in such case.
@@ -238,6 +265,7 @@ func (routes StripeRoutes) UpdateSubs(w http.ResponseWriter, req *http.Request)
 
 // RefreshSubs get the latest data of a subscription if user manually requested it.
 func (routes StripeRoutes) RefreshSubs(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.logger.Sync()
 	sugar := routes.logger.Sugar()
 
@@ -287,16 +315,24 @@ func (routes StripeRoutes) RefreshSubs(w http.ResponseWriter, req *http.Request)
 	}
 
 	// Only update subs and snapshot if actually modified.
-	go func() {
+	routes.tasks.Go(func() {
 		routes.handleSubsResult(refreshed)
-	}()
+	})
 
 	_ = render.New(w).OK(refreshed)
 }
 
 // CancelSubs cancels a stripe subscription at period end.
 // See https://stripe.com/docs/billing/subscriptions/cancel
+// Input, optional:
+// - reason?: too_expensive | not_using | missing_content | technical_issues | switching | temporary | other;
+// - feedback?: string; Required if reason is other.
+//
+// The response carries a retentionOffer field if user is
+// eligible for a coupon, which could be accepted by
+// POST /stripe/subs/{id}/retention-offer.
 func (routes StripeRoutes) CancelSubs(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.logger.Sync()
 	sugar := routes.logger.Sugar()
 
@@ -309,11 +345,24 @@ func (routes StripeRoutes) CancelSubs(w http.ResponseWriter, req *http.Request)
 		return
 	}
 
-	result, err := routes.stripeRepo.CancelSubscription(stripe.CancelParams{
+	var input stripe.CancelInput
+	if err := gorest.ParseJSON(req.Body, &input); err != nil {
+		// Ignore empty body for backward-compatibility.
+		if err != io.EOF {
+			_ = render.New(w).BadRequest(err.Error())
+			return
+		}
+	}
+	if ve := input.Validate(); ve != nil {
+		_ = render.New(w).Unprocessable(ve)
+		return
+	}
+
+	result, err := routes.stripeRepo.CancelWithReason(stripe.CancelParams{
 		FtcID:  ftcID,
 		SubID:  subsID,
 		Cancel: true,
-	})
+	}, input)
 
 	if err != nil {
 		sugar.Error(err)
@@ -323,15 +372,18 @@ func (routes StripeRoutes) CancelSubs(w http.ResponseWriter, req *http.Request)
 
 	// Remember uuid to stripe subscription mapping;
 	// Backup previous membership.
-	go func() {
-		routes.handleSubsResult(result)
-	}()
+	if result.Modified {
+		routes.tasks.Go(func() {
+			routes.handleSubsResult(result.SubsResult)
+		})
+	}
 
 	_ = render.New(w).OK(result)
 }
 
 // ReactivateSubscription undo subscription cancellation before period ends.
 func (routes StripeRoutes) ReactivateSubscription(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.logger.Sync()
 	sugar := routes.logger.Sugar()
 
@@ -358,15 +410,41 @@ func (routes StripeRoutes) ReactivateSubscription(w http.ResponseWriter, req *ht
 	// Remember uuid to stripe subscription mapping;
 	// Backup previous membership.
 	if result.Modified {
-		go func() {
+		routes.tasks.Go(func() {
 			routes.handleSubsResult(result)
-		}()
+		})
+	}
+
+	_ = render.New(w).OK(result)
+}
+
+// CancelPendingChange drops a scheduled downgrade or cycle
+// switch so that the subscription renews with current price.
+func (routes StripeRoutes) CancelPendingChange(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
+	defer routes.logger.Sync()
+	sugar := routes.logger.Sugar()
+
+	ftcID := xhttp.GetFtcID(req.Header)
+
+	subsID, err := xhttp.GetURLParam(req, "id").ToString()
+	if err != nil {
+		_ = render.New(w).BadRequest(err.Error())
+		return
+	}
+
+	result, err := routes.stripeRepo.CancelPendingChange(ftcID, subsID)
+	if err != nil {
+		sugar.Error(err)
+		_ = xhttp.HandleSubsErr(w, err)
+		return
 	}
 
 	_ = render.New(w).OK(result)
 }
 
 func (routes StripeRoutes) GetSubsDefaultPaymentMethod(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.logger.Sync()
 	sugar := routes.logger.Sugar()
 
@@ -402,6 +480,7 @@ func (routes StripeRoutes) GetSubsDefaultPaymentMethod(w http.ResponseWriter, re
 }
 
 func (routes StripeRoutes) UpdateSubsDefaultPayMethod(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.logger.Sync()
 	sugar := routes.logger.Sugar()
 
@@ -447,12 +526,12 @@ func (routes StripeRoutes) UpdateSubsDefaultPayMethod(w http.ResponseWriter, req
 
 	subs = stripe.NewSubs(subs.FtcUserID.String, rawSubs)
 
-	go func() {
+	routes.tasks.Go(func() {
 		err := routes.stripeRepo.UpsertSubs(subs, false)
 		if err != nil {
 			sugar.Error(err)
 		}
-	}()
+	})
 
 	_ = render.New(w).OK(subs)
 }
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"database/sql"
+
+	"github.com/FTChinese/subscription-api/internal/pkg/stripe"
+	sdk "github.com/stripe/stripe-go/v72"
+)
+
+// eventTrialWillEnd handles customer.subscription.trial_will_end,
+// which Stripe sends 3 days before a trial ends, or immediately
+// if the trial is shorter.
+// Membership is untouched; the status changes upon
+// customer.subscription.updated after trial ends.
+func (routes StripeRoutes) eventTrialWillEnd(ss *sdk.Subscription) error {
+	defer routes.logger.Sync()
+	sugar := routes.logger.Sugar()
+
+	// Trial ended early, e.g., user upgraded or canceled.
+	if ss.Status != sdk.SubscriptionStatusTrialing {
+		sugar.Infof("Subscription %s is no longer trialing", ss.ID)
+		return nil
+	}
+
+	acnt, err := routes.readerRepo.BaseAccountByStripeID(ss.Customer.ID)
+	if err != nil {
+		sugar.Error(err)
+		// Nobody to notify.
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+
+	if acnt.Email == "" {
+		return nil
+	}
+
+	subs := stripe.NewSubs(acnt.FtcID, ss)
+	sugar.Infof("Sending trial ending email of subscription %s", subs.ID)
+
+	err = routes.emailService.SendTrialEnding(acnt, subs)
+	if err != nil {
+		sugar.Error(err)
+		return err
+	}
+
+	return nil
+}
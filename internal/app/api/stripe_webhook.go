@@ -8,6 +8,7 @@ import (
 	"net/http"
 
 	"github.com/FTChinese/subscription-api/internal/pkg/stripe"
+	"github.com/FTChinese/subscription-api/pkg/metrics"
 	"github.com/FTChinese/subscription-api/pkg/price"
 	"github.com/FTChinese/subscription-api/pkg/reader"
 	sdk "github.com/stripe/stripe-go/v72"
@@ -19,14 +20,18 @@ import (
 // - customer.subscription.created
 // - customer.subscription.updated
 // - customer.subscription.deleted
+// - checkout.session.completed
+// - subscription_schedule.*
 // - invoice.created
 // - invoice.finalized
 // - invoice.payment_action_required
 // - invoice.payment_failed
 // - invoice.payment_succeeded
 // - invoice.upcoming
+// - charge.dispute.*
 // See https://stripe.com/docs/api/events/types
 func (routes StripeRoutes) WebHook(w http.ResponseWriter, req *http.Request) {
+	routes = routes.withRequest(req)
 	defer routes.logger.Sugar()
 	sugar := routes.logger.Sugar()
 
@@ -45,6 +50,7 @@ func (routes StripeRoutes) WebHook(w http.ResponseWriter, req *http.Request) {
 	}
 
 	sugar.Infof("Stripe event received: %s", event.Type)
+	metrics.WebhookEventFrom(req.Context()).Type = event.Type
 	sugar.Infof("Stripe event raw data: %s", event.Data.Raw)
 
 	switch event.Type {
@@ -56,9 +62,9 @@ func (routes StripeRoutes) WebHook(w http.ResponseWriter, req *http.Request) {
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
-		go func() {
+		routes.tasks.Go(func() {
 			_ = routes.eventCustomer(rawCus)
-		}()
+		})
 		w.WriteHeader(http.StatusOK)
 
 		// create occurs whenever a customer is signed up for a new plan.
@@ -71,12 +77,59 @@ func (routes StripeRoutes) WebHook(w http.ResponseWriter, req *http.Request) {
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
-		go func() {
+		routes.tasks.Go(func() {
 			err := routes.eventSubscription(&s)
 			if err != nil {
 				sugar.Error(err)
 			}
-		}()
+		})
+		w.WriteHeader(http.StatusOK)
+
+	// Remind user before the first charge after free trial.
+	case "customer.subscription.trial_will_end":
+		s := sdk.Subscription{}
+		if err := json.Unmarshal(event.Data.Raw, &s); err != nil {
+			sugar.Error(err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		routes.tasks.Go(func() {
+			_ = routes.eventTrialWillEnd(&s)
+		})
+		w.WriteHeader(http.StatusOK)
+
+	// A downgrade or cycle switch scheduled, started or dropped.
+	case "subscription_schedule.created",
+		"subscription_schedule.updated",
+		"subscription_schedule.released",
+		"subscription_schedule.canceled",
+		"subscription_schedule.completed",
+		"subscription_schedule.aborted":
+		var sched sdk.SubscriptionSchedule
+		if err := json.Unmarshal(event.Data.Raw, &sched); err != nil {
+			sugar.Error(err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		routes.tasks.Go(func() {
+			_, err := routes.stripeRepo.SyncSchedule(&sched)
+			if err != nil {
+				sugar.Error(err)
+			}
+		})
+		w.WriteHeader(http.StatusOK)
+
+	// Subscription created on a Stripe-hosted checkout page.
+	case "checkout.session.completed":
+		var cs sdk.CheckoutSession
+		if err := json.Unmarshal(event.Data.Raw, &cs); err != nil {
+			sugar.Error(err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		routes.tasks.Go(func() {
+			_ = routes.eventCheckoutCompleted(cs)
+		})
 		w.WriteHeader(http.StatusOK)
 
 	case "coupon.created", "coupon.updated", "coupon.deleted":
@@ -86,9 +139,9 @@ func (routes StripeRoutes) WebHook(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 
-		go func() {
+		routes.tasks.Go(func() {
 			_ = routes.eventCoupon(c)
-		}()
+		})
 
 		w.WriteHeader(http.StatusOK)
 
@@ -98,9 +151,9 @@ func (routes StripeRoutes) WebHook(w http.ResponseWriter, req *http.Request) {
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
-		go func() {
+		routes.tasks.Go(func() {
 			_ = routes.eventSetupIntent(si)
-		}()
+		})
 		w.WriteHeader(http.StatusOK)
 
 	case "setup_intent.canceled":
@@ -109,18 +162,32 @@ func (routes StripeRoutes) WebHook(w http.ResponseWriter, req *http.Request) {
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
-		go func() {
+		routes.tasks.Go(func() {
 			err := routes.stripeRepo.UpsertSetupIntent(stripe.NewSetupIntent(&si))
 			if err != nil {
 				sugar.Error(err)
 			}
-		}()
+		})
+		w.WriteHeader(http.StatusOK)
+
+	// Renewal payment failed or requires authentication.
+	// Stripe retries it according to the dunning settings.
+	case "invoice.payment_failed",
+		"invoice.payment_action_required":
+		var i sdk.Invoice
+		if err := json.Unmarshal(event.Data.Raw, &i); err != nil {
+			sugar.Error(err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		actionRequired := event.Type == "invoice.payment_action_required"
+		routes.tasks.Go(func() {
+			_ = routes.eventPaymentFailed(i, actionRequired)
+		})
 		w.WriteHeader(http.StatusOK)
 
 	// A few days prior to renewal, your site receives an invoice.upcoming event at the webhook endpoint.
 	case "invoice.created",
-		"invoice.payment_failed",
-		"invoice.payment_action_required",
 		"invoice.upcoming",
 		"invoice.finalized":
 		// Stripe waits an hour after receiving a successful response to the invoice.created event before attempting payment.
@@ -132,12 +199,12 @@ func (routes StripeRoutes) WebHook(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 		sugar.Infof("invoice: %v", i)
-		go func() {
+		routes.tasks.Go(func() {
 			err := routes.stripeRepo.UpsertInvoice(stripe.NewInvoice(&i))
 			if err != nil {
 				sugar.Error(err)
 			}
-		}()
+		})
 		w.WriteHeader(http.StatusOK)
 
 	// Set default payment method after payment succeeded.
@@ -152,9 +219,9 @@ func (routes StripeRoutes) WebHook(w http.ResponseWriter, req *http.Request) {
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
-		go func() {
+		routes.tasks.Go(func() {
 			_ = routes.eventPaymentSucceeded(invoice)
-		}()
+		})
 		w.WriteHeader(http.StatusOK)
 
 	case "payment_method.attached",
@@ -168,9 +235,27 @@ func (routes StripeRoutes) WebHook(w http.ResponseWriter, req *http.Request) {
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
-		go func() {
+		routes.tasks.Go(func() {
 			_ = routes.eventPaymentMethod(rawPM)
-		}()
+		})
+		w.WriteHeader(http.StatusOK)
+
+	// Card holder disputed a charge with the issuer.
+	case "charge.dispute.created",
+		"charge.dispute.updated",
+		"charge.dispute.closed",
+		"charge.dispute.funds_withdrawn",
+		"charge.dispute.funds_reinstated":
+		var rawDispute sdk.Dispute
+		if err := json.Unmarshal(event.Data.Raw, &rawDispute); err != nil {
+			sugar.Error(err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		eventType := event.Type
+		routes.tasks.Go(func() {
+			_ = routes.eventDispute(rawDispute, eventType)
+		})
 		w.WriteHeader(http.StatusOK)
 
 	case "price.created", "price.deleted", "price.updated":
@@ -182,9 +267,9 @@ func (routes StripeRoutes) WebHook(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 
-		go func() {
+		routes.tasks.Go(func() {
 			_ = routes.eventPrice(rawPrice)
-		}()
+		})
 
 		w.WriteHeader(http.StatusOK)
 
@@ -313,6 +398,11 @@ func (routes StripeRoutes) eventPaymentSucceeded(rawInvoice sdk.Invoice) error {
 	defer routes.logger.Sync()
 	sugar := routes.logger.Sugar()
 
+	err := routes.stripeRepo.RecoverDunning(rawInvoice.ID)
+	if err != nil {
+		sugar.Error(err)
+	}
+
 	pi, err := routes.stripeRepo.Client.FetchPaymentIntent(
 		rawInvoice.PaymentIntent.ID)
 
@@ -402,6 +492,25 @@ func (routes StripeRoutes) eventSubscription(ss *sdk.Subscription) error {
 		return err
 	}
 
+	// Payload does not expand latest invoice, which is needed
+	// to tell whether it is canceled after dunning failed,
+	// or which period is paid before pausing.
+	if isDunningEnd(ss.Status) || ss.PauseCollection.Behavior != "" {
+		expanded, err := routes.stripeRepo.Client.FetchSubs(ss.ID, true)
+		if err != nil {
+			sugar.Error(err)
+			return err
+		}
+		ss = expanded
+	}
+
+	// Collection resumed by Stripe upon resume date.
+	ss, err = routes.stripeRepo.RestartResumedCycle(ss)
+	if err != nil {
+		sugar.Error(err)
+		return err
+	}
+
 	// stripe.Subs could always be created regardless of user account present or not.
 	subs := stripe.NewSubs("", ss)
 
@@ -425,5 +534,19 @@ func (routes StripeRoutes) eventSubscription(ss *sdk.Subscription) error {
 
 	routes.handleSubsResult(result)
 
+	if isDunningEnd(ss.Status) {
+		err := routes.stripeRepo.ExhaustDunning(ss.ID)
+		if err != nil {
+			sugar.Error(err)
+		}
+	}
+
 	return nil
 }
+
+// isDunningEnd checks whether Stripe stopped collecting
+// payment of a subscription.
+func isDunningEnd(s sdk.SubscriptionStatus) bool {
+	return s == sdk.SubscriptionStatusCanceled ||
+		s == sdk.SubscriptionStatusUnpaid
+}
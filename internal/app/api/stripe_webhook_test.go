@@ -0,0 +1,102 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/FTChinese/subscription-api/internal/repository"
+	"github.com/FTChinese/subscription-api/internal/repository/stripeenv"
+	"github.com/FTChinese/subscription-api/internal/stripeclient"
+	"github.com/FTChinese/subscription-api/internal/stripeclient/stripetest"
+	"github.com/FTChinese/subscription-api/pkg/background"
+	"github.com/stretchr/testify/assert"
+	"github.com/stripe/stripe-go/v72"
+	"go.uber.org/zap/zaptest"
+)
+
+const testSigningKey = "whsec_test"
+
+// newWebhookTestRoutes creates routes whose Stripe client
+// talks to an in-repo fake. No db is attached, so events are
+// chosen not to reach handlers saving data.
+func newWebhookTestRoutes(t *testing.T) (StripeRoutes, *stripetest.Server) {
+	s := stripetest.NewServer()
+	t.Cleanup(s.Close)
+
+	logger := zaptest.NewLogger(t)
+
+	return StripeRoutes{
+		signingKey: testSigningKey,
+		stripeRepo: stripeenv.New(
+			stripeclient.NewWithBackend("sk_test_123", s.URL, logger),
+			repository.StripeRepo{},
+		),
+		tasks:  background.NewRunner(logger),
+		logger: logger,
+	}, s
+}
+
+func TestStripeRoutes_WebHook(t *testing.T) {
+	routes, s := newWebhookTestRoutes(t)
+
+	c := stripeclient.NewWithBackend("sk_test_123", s.URL, routes.logger)
+	cus, err := c.CreateCustomer("webhook@example.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed := func(eventType string, id string) *http.Request {
+		req, err := s.NewWebhookRequest("/webhook/stripe", testSigningKey, eventType, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+
+	tampered := signed("customer.updated", cus.ID)
+	tampered.Header.Set("Stripe-Signature", stripetest.SignPayload([]byte("{}"), testSigningKey, time.Now()))
+
+	payload, err := stripetest.NewEvent("customer.updated", cus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale := httptest.NewRequest(http.MethodPost, "/webhook/stripe", bytes.NewReader(payload))
+	stale.Header.Set("Stripe-Signature", stripetest.SignPayload(payload, testSigningKey, time.Now().Add(-time.Hour)))
+
+	unknown, err := stripetest.NewWebhookRequest("/webhook/stripe", testSigningKey, "account.updated", &stripe.Account{ID: "acct_test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	malformed, err := stripetest.NewWebhookRequest("/webhook/stripe", testSigningKey, "invoice.created", []int{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		want int
+	}{
+		{"Object from fake", signed("customer.source.updated", cus.ID), http.StatusOK},
+		{"Unhandled event", unknown, http.StatusOK},
+		{"Malformed event", malformed, http.StatusBadRequest},
+		{"Signature mismatch", tampered, http.StatusBadRequest},
+		{"Stale signature", stale, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			routes.WebHook(w, tt.req)
+
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+
+	_ = routes.tasks.Wait(context.Background())
+}
@@ -1,10 +1,15 @@
 package api
 
 import (
+	"net/http"
+
 	"github.com/FTChinese/subscription-api/internal/pkg/letter"
 	"github.com/FTChinese/subscription-api/internal/repository/accounts"
 	"github.com/FTChinese/subscription-api/internal/repository/shared"
 	"github.com/FTChinese/subscription-api/pkg/account"
+	"github.com/FTChinese/subscription-api/pkg/applelogin"
+	"github.com/FTChinese/subscription-api/pkg/background"
+	"github.com/FTChinese/subscription-api/pkg/xhttp"
 	"github.com/FTChinese/subscription-api/pkg/ztsms"
 	"go.uber.org/zap"
 )
@@ -16,6 +21,18 @@ type UserShared struct {
 	SMSClient    ztsms.Client
 	Logger       *zap.Logger
 	EmailService letter.Service
+	AppleSignIn  applelogin.Verifier
+	Sessions     SessionStarter
+	Tasks        *background.Runner
+	Live         bool
+}
+
+// withRequest returns a copy whose logger and account repo
+// log with the request id of req.
+func (us UserShared) withRequest(req *http.Request) UserShared {
+	us.Logger = xhttp.LoggerFrom(req.Context(), us.Logger)
+	us.Repo = us.Repo.WithLogger(us.Logger)
+	return us
 }
 
 // SendEmailVerification sends an email to user to verify email.
@@ -59,7 +76,7 @@ func (us UserShared) SyncMobile(a account.BaseAccount) {
 	if !a.Mobile.Valid {
 		return
 	}
-	go func() {
+	us.Tasks.Go(func() {
 		defer us.Logger.Sync()
 		sugar := us.Logger.Sugar()
 
@@ -71,5 +88,45 @@ func (us UserShared) SyncMobile(a account.BaseAccount) {
 		if err != nil {
 			sugar.Error(err)
 		}
-	}()
+	})
+}
+
+// verifyTOTP checks a code from authenticator app and
+// consumes its time step so that it could not be used again.
+func (us UserShared) verifyTOTP(f account.TwoFactor, code string) (bool, error) {
+	f, ok := f.Verify(code)
+	if !ok {
+		return false, nil
+	}
+
+	return us.Repo.UseTOTPStep(f)
+}
+
+// checkSecondFactor verifies a TOTP code, or consumes a
+// recovery code if code is empty.
+// Returns TwoFactorNull if neither passed.
+func (us UserShared) checkSecondFactor(f account.TwoFactor, code string, recoveryCode string) (account.TwoFactorMethod, error) {
+	if code != "" {
+		ok, err := us.verifyTOTP(f, code)
+		if err != nil {
+			return account.TwoFactorNull, err
+		}
+
+		if ok {
+			return account.TwoFactorTOTP, nil
+		}
+
+		return account.TwoFactorNull, nil
+	}
+
+	ok, err := us.Repo.UseRecoveryCode(f.FtcID, recoveryCode)
+	if err != nil {
+		return account.TwoFactorNull, err
+	}
+
+	if !ok {
+		return account.TwoFactorNull, nil
+	}
+
+	return account.TwoFactorRecovery, nil
 }
@@ -5,7 +5,9 @@ import (
 	"github.com/FTChinese/go-rest/render"
 	"github.com/FTChinese/subscription-api/internal/pkg/input"
 	"github.com/FTChinese/subscription-api/pkg/footprint"
+	"github.com/FTChinese/subscription-api/pkg/ids"
 	"github.com/FTChinese/subscription-api/pkg/wxlogin"
+	"github.com/guregu/null"
 	"net/http"
 )
 
@@ -92,11 +94,11 @@ func (router WxAuthRouter) Login(w http.ResponseWriter, req *http.Request) {
 		footprint.NewClient(req))
 
 	// Save access token
-	go func() {
+	router.tasks.Go(func() {
 		if err := router.wxRepo.SaveWxAccess(tokenSchema); err != nil {
 			sugar.Error(err)
 		}
-	}()
+	})
 
 	// 3. 通过access_token进行接口调用，获取用户基本数据资源或帮助用户实现基本操作。
 	infoSchema, err := router.getUserInfo(
@@ -108,7 +110,19 @@ func (router WxAuthRouter) Login(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	cred, err := router.sessions.Start(
+		req,
+		ids.UserIDs{UnionID: null.StringFrom(infoSchema.UnionID)},
+		footprint.AuthMethodWechat)
+	if err != nil {
+		sugar.Error(err)
+		_ = render.New(w).DBError(err)
+		return
+	}
+
 	// Send session data to client.
-	_ = render.New(w).OK(
-		wxlogin.NewSession(tokenSchema, infoSchema.UnionID))
+	_ = render.New(w).OK(wxSession{
+		Session:     wxlogin.NewSession(tokenSchema, infoSchema.UnionID),
+		Credentials: cred,
+	})
 }
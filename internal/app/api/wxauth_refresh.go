@@ -78,9 +78,9 @@ func (router WxAuthRouter) Refresh(w http.ResponseWriter, req *http.Request) {
 		// Refresh current access token schema.
 		currentAccessSchema = currentAccessSchema.WithAccessToken(newAccessResp.AccessToken)
 		// Update access token.
-		go func() {
+		router.tasks.Go(func() {
 			_ = router.wxRepo.UpdateWxAccess(currentAccessSchema)
-		}()
+		})
 	}
 
 	_, err = router.getUserInfo(
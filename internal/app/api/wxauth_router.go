@@ -4,6 +4,7 @@ import (
 	"errors"
 	"github.com/FTChinese/go-rest/render"
 	"github.com/FTChinese/subscription-api/internal/repository/wxoauth"
+	"github.com/FTChinese/subscription-api/pkg/background"
 	"github.com/FTChinese/subscription-api/pkg/config"
 	"github.com/FTChinese/subscription-api/pkg/db"
 	"github.com/FTChinese/subscription-api/pkg/wxlogin"
@@ -17,17 +18,21 @@ import (
 // Wechat never said you should do this.
 // But when combining their messy documentation, you must do it this way.
 type WxAuthRouter struct {
-	apps   map[string]config.WechatApp
-	wxRepo wxoauth.Env
-	logger *zap.Logger
+	apps     map[string]config.WechatApp
+	wxRepo   wxoauth.Env
+	sessions SessionStarter
+	tasks    *background.Runner
+	logger   *zap.Logger
 }
 
 // NewWxAuth creates a new WxLoginRouter instance.
-func NewWxAuth(dbs db.ReadWriteMyDBs, logger *zap.Logger) WxAuthRouter {
+func NewWxAuth(dbs db.ReadWriteMyDBs, sessions SessionStarter, tasks *background.Runner, logger *zap.Logger) WxAuthRouter {
 	return WxAuthRouter{
-		apps:   config.MustGetWechatApps(),
-		wxRepo: wxoauth.NewEnv(dbs),
-		logger: logger,
+		apps:     config.MustGetWechatApps(),
+		wxRepo:   wxoauth.NewEnv(dbs),
+		sessions: sessions,
+		tasks:    tasks,
+		logger:   logger,
 	}
 }
 
@@ -48,12 +53,12 @@ func (router WxAuthRouter) handleWxApiErr(w http.ResponseWriter, rs wxlogin.Resp
 
 	sugar.Error(rs.Message)
 
-	go func() {
+	router.tasks.Go(func() {
 		err := router.wxRepo.SaveWxStatus(rs)
 		if err != nil {
 			sugar.Error(err)
 		}
-	}()
+	})
 
 	_ = render.New(w).Unprocessable(rs.GetInvalidity())
 }
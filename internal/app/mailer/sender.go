@@ -0,0 +1,105 @@
+package mailer
+
+import (
+	"context"
+	"time"
+
+	"github.com/FTChinese/subscription-api/internal/repository/mailrepo"
+	"github.com/FTChinese/subscription-api/pkg/db"
+	"github.com/FTChinese/subscription-api/pkg/postman"
+	"go.uber.org/zap"
+)
+
+// batchSize is the max number of envelopes sent in each round.
+const batchSize = 50
+
+// Sender polls the outbox and delivers due envelopes.
+// Failed deliveries are retried with exponential backoff
+// until the envelope's attempts are exhausted.
+type Sender struct {
+	repo      mailrepo.Env
+	transport postman.Transport
+	logger    *zap.Logger
+}
+
+func NewSender(dbs db.ReadWriteMyDBs, t postman.Transport, logger *zap.Logger) Sender {
+	return Sender{
+		repo:      mailrepo.New(dbs, logger),
+		transport: t,
+		logger:    logger,
+	}
+}
+
+// Run sends due envelopes every interval until ctx is done.
+func (s Sender) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.Flush()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Flush sends one batch of due envelopes.
+// Returns the number of envelopes delivered.
+func (s Sender) Flush() int {
+	defer s.logger.Sync()
+	sugar := s.logger.Sugar()
+
+	list, err := s.repo.ListDue(batchSize)
+	if err != nil {
+		sugar.Error(err)
+		return 0
+	}
+
+	var n int
+	for _, e := range list {
+		if s.Send(e) {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Send claims an envelope and tries to deliver it.
+func (s Sender) Send(e postman.Envelope) bool {
+	defer s.logger.Sync()
+	sugar := s.logger.Sugar().With("envelope", e.ID)
+
+	e, ok, err := s.repo.Claim(e)
+	if err != nil {
+		sugar.Error(err)
+		return false
+	}
+	// Claimed by another instance.
+	if !ok {
+		return false
+	}
+
+	err = s.transport.Deliver(e.Parcel)
+	if err != nil {
+		sugar.Error(err)
+		e = e.Failed(s.transport.Name(), err)
+		if e.Status == postman.DeliveryDead {
+			sugar.Warnf("Envelope moved to dead letters after %d attempts", e.Attempts)
+		}
+	} else {
+		e = e.Delivered(s.transport.Name())
+	}
+
+	saved, err := s.repo.SaveDelivery(e)
+	if err != nil {
+		sugar.Error(err)
+	} else if !saved {
+		sugar.Warn("Envelope claimed by another sender before result saved")
+	}
+
+	return e.Status == postman.DeliverySent
+}
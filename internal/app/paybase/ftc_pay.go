@@ -1,13 +1,17 @@
 package paybase
 
 import (
+	"context"
+
 	"github.com/FTChinese/go-rest/enum"
 	"github.com/FTChinese/subscription-api/internal/pkg/ftcpay"
 	"github.com/FTChinese/subscription-api/internal/pkg/letter"
 	"github.com/FTChinese/subscription-api/internal/repository/addons"
+	"github.com/FTChinese/subscription-api/internal/repository/mailrepo"
 	"github.com/FTChinese/subscription-api/internal/repository/shared"
 	"github.com/FTChinese/subscription-api/internal/repository/subrepo"
 	"github.com/FTChinese/subscription-api/pkg/ali"
+	"github.com/FTChinese/subscription-api/pkg/background"
 	"github.com/FTChinese/subscription-api/pkg/db"
 	"github.com/FTChinese/subscription-api/pkg/wechat"
 	"go.uber.org/zap"
@@ -21,11 +25,13 @@ type FtcPayBase struct {
 	AliPayClient ali.PayClient
 	WxPayClients wechat.WxPayClientStore
 	EmailService letter.Service
+	Tasks        *background.Runner
 	Logger       *zap.Logger
 }
 
 func NewFtcPay(
 	dbs db.ReadWriteMyDBs,
+	tasks *background.Runner,
 	logger *zap.Logger,
 ) FtcPayBase {
 	return FtcPayBase{
@@ -34,11 +40,27 @@ func NewFtcPay(
 		AddOnRepo:    addons.New(dbs, logger),
 		AliPayClient: ali.NewPayClient(ali.MustInitApp(), logger),
 		WxPayClients: wechat.NewWxClientStore(wechat.MustGetPayApps(), logger),
-		EmailService: letter.NewService(logger),
+		EmailService: letter.NewService(mailrepo.New(dbs, logger), logger),
+		Tasks:        tasks,
 		Logger:       logger,
 	}
 }
 
+// WithLogger returns a copy whose logger and order repo
+// log with l, usually the request-scoped logger.
+func (pay FtcPayBase) WithLogger(l *zap.Logger) FtcPayBase {
+	pay.SubsRepo = pay.SubsRepo.WithLogger(l)
+	pay.Logger = l
+	return pay
+}
+
+// WithContext returns a copy whose order repo runs
+// statements within ctx.
+func (pay FtcPayBase) WithContext(ctx context.Context) FtcPayBase {
+	pay.SubsRepo = pay.SubsRepo.WithContext(ctx)
+	return pay
+}
+
 // SendConfirmEmail sends an email to user after an order is confirmed.
 func (pay FtcPayBase) SendConfirmEmail(result ftcpay.ConfirmationResult) error {
 	defer pay.Logger.Sync()
@@ -80,16 +102,16 @@ func (pay FtcPayBase) ConfirmOrder(result ftcpay.PaymentResult, order ftcpay.Ord
 
 	confirmed, cfmErr := pay.SubsRepo.ConfirmOrder(result, order)
 	if cfmErr != nil {
-		go func() {
+		pay.Tasks.Go(func() {
 			err := pay.SubsRepo.SaveConfirmErr(cfmErr)
 			if err != nil {
 				sugar.Error(err)
 			}
-		}()
+		})
 		return confirmed, cfmErr
 	}
 
-	go func() {
+	pay.Tasks.Go(func() {
 		// Save membership change history.
 		if !confirmed.Versioned.IsZero() {
 			err := pay.ReaderRepo.VersionMembership(confirmed.Versioned)
@@ -113,7 +135,7 @@ func (pay FtcPayBase) ConfirmOrder(result ftcpay.PaymentResult, order ftcpay.Ord
 				sugar.Error(err)
 			}
 		}
-	}()
+	})
 
 	return confirmed, nil
 }
@@ -154,14 +176,14 @@ func (pay FtcPayBase) verifyAliOrder(order ftcpay.Order) (ftcpay.PaymentResult,
 
 	sugar.Infof("Alipay raw order: %+v", aliOrder)
 
-	go func() {
+	pay.Tasks.Go(func() {
 		err := pay.SubsRepo.SaveAliOrderQueryPayload(
 			ali.NewOrderQueryPayload(aliOrder))
 
 		if err != nil {
 			sugar.Error(err)
 		}
-	}()
+	})
 
 	return ftcpay.NewAliPayResult(aliOrder), nil
 }
@@ -184,7 +206,7 @@ func (pay FtcPayBase) verifyWxOrder(order ftcpay.Order) (ftcpay.PaymentResult, e
 
 	sugar.Infof("Wxpay raw order %+v", payload)
 
-	go func() {
+	pay.Tasks.Go(func() {
 		err := pay.SubsRepo.SaveWxPayload(
 			wechat.NewPayloadSchema(
 				order.ID,
@@ -194,7 +216,7 @@ func (pay FtcPayBase) verifyWxOrder(order ftcpay.Order) (ftcpay.PaymentResult, e
 		if err != nil {
 			sugar.Error(err)
 		}
-	}()
+	})
 
 	return ftcpay.NewWxPayResult(wechat.NewOrderQueryResp(payload)), nil
 }
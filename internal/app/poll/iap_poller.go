@@ -155,19 +155,24 @@ func (p IAPPoller) verify(s apple.BaseSchema) error {
 	return nil
 }
 
-func (p IAPPoller) Start(dryRun bool) error {
+// Start verifies subscriptions until all are processed or
+// ctx is cancelled. Those already dispatched are waited for.
+func (p IAPPoller) Start(ctx context.Context, dryRun bool) error {
 	defer p.logger.Sync()
 	sugar := p.logger.Sugar()
-	ctx := context.Background()
 
 	subCh := p.retrieveSubs()
 
 	pollerLog := poller.NewLog(poller.AppNameIAP)
 
 	for sub := range subCh {
+		// Keep reading so that the query goroutine could exit.
+		if ctx.Err() != nil {
+			continue
+		}
 		if err := iapSem.Acquire(ctx, 1); err != nil {
 			sugar.Errorf("Failed to acquire semaphore: %v", err)
-			break
+			continue
 		}
 
 		go func(s apple.BaseSchema) {
@@ -185,17 +190,16 @@ func (p IAPPoller) Start(dryRun bool) error {
 			} else {
 				pollerLog.IncSuccess()
 			}
-			iapSem.Release(1)
 		}(sub)
 	}
 
 	// Acquire all of the tokens to wait for any remaining workers to finish.
-	//
-	// If you are already waiting for the workers by some other means (such as an
-	// errgroup.Group), you can omit this final Acquire call.
-	if err := orderSem.Acquire(ctx, int64(maxWorkers)); err != nil {
-		sugar.Infof("Failed to acquire semaphore: %v", err)
-		return nil
+	// Not bound to ctx since dispatched subscriptions must finish.
+	_ = iapSem.Acquire(context.Background(), int64(maxWorkers))
+	iapSem.Release(int64(maxWorkers))
+
+	if ctx.Err() != nil {
+		sugar.Info("Polling interrupted by shutdown")
 	}
 
 	pollerLog.EndUTC = chrono.TimeNow()
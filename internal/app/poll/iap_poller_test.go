@@ -1,6 +1,7 @@
 package poll
 
 import (
+	"context"
 	"github.com/FTChinese/subscription-api/test"
 	"go.uber.org/zap/zaptest"
 	"testing"
@@ -19,7 +20,7 @@ func TestIAPPoller_retrieveSubs(t *testing.T) {
 func TestIAPPoller_Start(t *testing.T) {
 	p := NewIAPPoller(test.SplitDB, false, zaptest.NewLogger(t))
 
-	err := p.Start(true)
+	err := p.Start(context.Background(), true)
 
 	if err != nil {
 		t.Error(err)
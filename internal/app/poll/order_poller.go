@@ -7,9 +7,11 @@ import (
 	"github.com/FTChinese/subscription-api/internal/pkg/ftcpay"
 	"github.com/FTChinese/subscription-api/internal/pkg/letter"
 	"github.com/FTChinese/subscription-api/internal/repository/addons"
+	"github.com/FTChinese/subscription-api/internal/repository/mailrepo"
 	"github.com/FTChinese/subscription-api/internal/repository/shared"
 	"github.com/FTChinese/subscription-api/internal/repository/subrepo"
 	"github.com/FTChinese/subscription-api/pkg/ali"
+	"github.com/FTChinese/subscription-api/pkg/background"
 	"github.com/FTChinese/subscription-api/pkg/db"
 	"github.com/FTChinese/subscription-api/pkg/poller"
 	"github.com/FTChinese/subscription-api/pkg/wechat"
@@ -49,7 +51,8 @@ func NewOrderPoller(myDBs db.ReadWriteMyDBs, logger *zap.Logger) OrderPoller {
 			AddOnRepo:    addons.New(myDBs, logger),
 			AliPayClient: ali.NewPayClient(ali.MustInitApp(), logger),
 			WxPayClients: wechat.NewWxClientStore(wechat.MustGetPayApps(), logger),
-			EmailService: letter.NewService(logger),
+			EmailService: letter.NewService(mailrepo.New(myDBs, logger), logger),
+			Tasks:        background.NewRunner(logger),
 			Logger:       logger,
 		},
 	}
@@ -152,19 +155,25 @@ func (p OrderPoller) saveLog(l *poller.Log) error {
 	return nil
 }
 
-func (p OrderPoller) Start(dryRun bool) error {
+// Start verifies unconfirmed orders until all are processed
+// or ctx is cancelled. Orders already dispatched, and emails
+// or history they queued, are waited for either way.
+func (p OrderPoller) Start(ctx context.Context, dryRun bool) error {
 	defer p.Logger.Sync()
 	sugar := p.Logger.Sugar()
-	ctx := context.Background()
 
 	orderCh := p.retrieveOrders()
 
 	pollerLog := poller.NewLog(poller.AppNameFtc)
 
 	for order := range orderCh {
+		// Keep reading so that the query goroutine could exit.
+		if ctx.Err() != nil {
+			continue
+		}
 		if err := orderSem.Acquire(ctx, 1); err != nil {
 			sugar.Errorf("Failed to acquire semaphore: %v", err)
-			break
+			continue
 		}
 
 		go func(o ftcpay.Order) {
@@ -182,17 +191,19 @@ func (p OrderPoller) Start(dryRun bool) error {
 			} else {
 				pollerLog.IncSuccess()
 			}
-			orderSem.Release(1)
 		}(order)
 	}
 
 	// Acquire all of the tokens to wait for any remaining workers to finish.
-	//
-	// If you are already waiting for the workers by some other means (such as an
-	// errgroup.Group), you can omit this final Acquire call.
-	if err := orderSem.Acquire(ctx, int64(maxWorkers)); err != nil {
-		sugar.Infof("Failed to acquire semaphore: %v", err)
-		return nil
+	// Not bound to ctx since dispatched orders must finish.
+	_ = orderSem.Acquire(context.Background(), int64(maxWorkers))
+	orderSem.Release(int64(maxWorkers))
+
+	if err := p.Tasks.Wait(context.Background()); err != nil {
+		sugar.Error(err)
+	}
+	if ctx.Err() != nil {
+		sugar.Info("Polling interrupted by shutdown")
 	}
 
 	pollerLog.EndUTC = chrono.TimeNow()
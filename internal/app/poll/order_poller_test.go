@@ -1,6 +1,7 @@
 package poll
 
 import (
+	"context"
 	"github.com/FTChinese/go-rest/chrono"
 	"github.com/FTChinese/subscription-api/internal/pkg/ftcpay"
 	"github.com/FTChinese/subscription-api/pkg/db"
@@ -66,7 +67,7 @@ func TestOrderPoller_saveLog(t *testing.T) {
 func TestOrderPoller_Start(t *testing.T) {
 	p := NewOrderPoller(db.MockMySQL(), zaptest.NewLogger(t))
 
-	err := p.Start(false)
+	err := p.Start(context.Background(), false)
 
 	if err != nil {
 		t.Error(err)
@@ -122,3 +122,25 @@ type UnlinkWxParams struct {
 	LinkWxParams
 	Anchor enum.AccountKind `json:"anchor"`
 }
+
+// EmailChangeConfirmParams confirms a pending email change
+// either by the token in the link, or by the code for apps.
+type EmailChangeConfirmParams struct {
+	Token string `json:"token"`
+	Code  string `json:"code"`
+}
+
+func (p *EmailChangeConfirmParams) Validate() *render.ValidationError {
+	p.Token = strings.TrimSpace(p.Token)
+	p.Code = strings.TrimSpace(p.Code)
+
+	if p.Token == "" && p.Code == "" {
+		return &render.ValidationError{
+			Message: "Either token or code is required",
+			Field:   "token",
+			Code:    render.CodeMissingField,
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,23 @@
+package input
+
+import (
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/pkg/cms"
+)
+
+// StaffRoleParams is used by admin to assign a role.
+type StaffRoleParams struct {
+	Role cms.Role `json:"role"`
+}
+
+func (p StaffRoleParams) Validate() *render.ValidationError {
+	if !p.Role.IsValid() {
+		return &render.ValidationError{
+			Message: "Role must be one of support, finance, product or admin",
+			Field:   "role",
+			Code:    render.CodeInvalid,
+		}
+	}
+
+	return nil
+}
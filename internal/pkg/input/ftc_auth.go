@@ -163,3 +163,90 @@ func (i *PasswordResetParams) Validate() *render.ValidationError {
 
 	return validator.EnsurePassword(i.Password)
 }
+
+// MagicLinkParams is used to request a passwordless login
+// link or code sent to email.
+type MagicLinkParams struct {
+	Email     string      `json:"email"`
+	UseCode   bool        `json:"useCode"`   // Used by mobile apps
+	SourceURL null.String `json:"sourceUrl"` // Used by web apps
+}
+
+// Validate checks email, and the url the link is built from
+// so that a login token is never sent to sites of others.
+// Urls on localhost are only accepted in sandbox mode.
+func (p *MagicLinkParams) Validate(live bool) *render.ValidationError {
+	p.Email = strings.TrimSpace(p.Email)
+	src := strings.TrimSpace(p.SourceURL.String)
+	p.SourceURL = null.NewString(src, src != "")
+
+	if ve := validator.EnsureEmail(p.Email); ve != nil {
+		return ve
+	}
+
+	if p.UseCode || !p.SourceURL.Valid {
+		return nil
+	}
+
+	return validator.EnsureRedirectURL("sourceUrl", p.SourceURL.String, live)
+}
+
+// MagicLinkVerifyParams exchanges either the token in the
+// link, or email + code, for the account.
+type MagicLinkVerifyParams struct {
+	Token       string      `json:"token"`
+	Email       string      `json:"email"`
+	Code        string      `json:"code"`
+	DeviceToken null.String `json:"deviceToken"` // Required only for android.
+}
+
+func (p *MagicLinkVerifyParams) Validate() *render.ValidationError {
+	p.Token = strings.TrimSpace(p.Token)
+	p.Email = strings.TrimSpace(p.Email)
+	p.Code = strings.TrimSpace(p.Code)
+
+	if p.Token != "" {
+		return nil
+	}
+
+	if ve := validator.EnsureEmail(p.Email); ve != nil {
+		return ve
+	}
+
+	return validator.New("code").
+		Required().
+		Range(6, 6).
+		Validate(p.Code)
+}
+
+// AppleLoginParams carries the identity token returned by
+// Sign in with Apple.
+// FullName is only available on the first authorization.
+type AppleLoginParams struct {
+	IdentityToken string      `json:"identityToken"`
+	FullName      null.String `json:"fullName"`
+	DeviceToken   null.String `json:"deviceToken"` // Required only for android.
+}
+
+func (p *AppleLoginParams) Validate() *render.ValidationError {
+	p.IdentityToken = strings.TrimSpace(p.IdentityToken)
+
+	return validator.New("identityToken").
+		Required().
+		Validate(p.IdentityToken)
+}
+
+// SessionRefreshParams carries the refresh token issued
+// together with a session access token.
+type SessionRefreshParams struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+func (p *SessionRefreshParams) Validate() *render.ValidationError {
+	p.RefreshToken = strings.TrimSpace(p.RefreshToken)
+
+	return validator.
+		New("refreshToken").
+		Required().
+		Validate(p.RefreshToken)
+}
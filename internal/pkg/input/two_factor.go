@@ -0,0 +1,83 @@
+package input
+
+import (
+	"strings"
+
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/lib/validator"
+	"github.com/guregu/null"
+)
+
+// TwoFactorCodeParams carries a code generated by
+// authenticator app.
+type TwoFactorCodeParams struct {
+	Code string `json:"code"`
+}
+
+func (p *TwoFactorCodeParams) Validate() *render.ValidationError {
+	p.Code = strings.TrimSpace(p.Code)
+
+	return validator.New("code").
+		Required().
+		Range(6, 6).
+		Validate(p.Code)
+}
+
+// TwoFactorDisableParams requires both password and a second
+// factor to turn off 2FA. Either code or recoveryCode should
+// be provided.
+type TwoFactorDisableParams struct {
+	Password     string `json:"password"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recoveryCode"`
+}
+
+func (p *TwoFactorDisableParams) Validate() *render.ValidationError {
+	p.Code = strings.TrimSpace(p.Code)
+	p.RecoveryCode = strings.TrimSpace(p.RecoveryCode)
+
+	ve := validator.EnsurePassword(p.Password)
+	if ve != nil {
+		return ve
+	}
+
+	if p.Code == "" && p.RecoveryCode == "" {
+		return &render.ValidationError{
+			Message: "Either code or recovery code is required",
+			Field:   "code",
+			Code:    render.CodeMissingField,
+		}
+	}
+
+	return nil
+}
+
+// TwoFactorLoginParams answers the challenge issued after
+// password is verified.
+type TwoFactorLoginParams struct {
+	Challenge    string      `json:"challenge"`
+	Code         string      `json:"code"`
+	RecoveryCode string      `json:"recoveryCode"`
+	DeviceToken  null.String `json:"deviceToken"` // Required only for android.
+}
+
+func (p *TwoFactorLoginParams) Validate() *render.ValidationError {
+	p.Challenge = strings.TrimSpace(p.Challenge)
+	p.Code = strings.TrimSpace(p.Code)
+	p.RecoveryCode = strings.TrimSpace(p.RecoveryCode)
+
+	ve := validator.New("challenge").Required().Validate(p.Challenge)
+	if ve != nil {
+		return ve
+	}
+
+	if p.Code == "" && p.RecoveryCode == "" {
+		return &render.ValidationError{
+			Message: "Either code or recovery code is required",
+			Field:   "code",
+			Code:    render.CodeMissingField,
+		}
+	}
+
+	return nil
+}
@@ -28,6 +28,18 @@ const (
 	keyAddOn       = "addOn"
 	keyIAPLinked   = "iapLinked"
 	keyIAPUnlinked = "iapUnlinked"
+
+	keyTwoFactor = "twoFactor"
+	keyMagicLink = "magicLink"
+	keyAppleLink = "appleLink"
+
+	keyEmailChangeConfirm = "emailChangeConfirm"
+	keyEmailChanged       = "emailChanged"
+
+	keyStripeDunning = "stripeDunning"
+	keyStripeDispute = "stripeDispute"
+
+	keyStripeTrialEnding = "stripeTrialEnding"
 )
 
 var funcMap = template.FuncMap{
@@ -176,3 +188,120 @@ type CtxAccountUnlink struct {
 func (ctx CtxAccountUnlink) Render() (string, error) {
 	return Render(keyUnlinkWx, ctx)
 }
+
+// CtxTwoFactor notifies user of 2FA setting changes.
+type CtxTwoFactor struct {
+	UserName string
+	Email    string
+	Enabled  bool
+	ByStaff  bool // Disabled by customer service.
+}
+
+func (ctx CtxTwoFactor) Render() (string, error) {
+	return Render(keyTwoFactor, ctx)
+}
+
+// CtxMagicLink renders passwordless login letter.
+// Either URL or AppCode is present.
+type CtxMagicLink struct {
+	UserName string
+	URL      string
+	AppCode  string
+	Duration string
+}
+
+func (ctx CtxMagicLink) Render() (string, error) {
+	return Render(keyMagicLink, ctx)
+}
+
+// CtxAppleLink notifies user that Sign in with Apple is
+// linked to or unlinked from the FTC account.
+type CtxAppleLink struct {
+	UserName   string
+	Email      string
+	AppleEmail string
+	Linked     bool
+}
+
+func (ctx CtxAppleLink) Render() (string, error) {
+	return Render(keyAppleLink, ctx)
+}
+
+// CtxEmailChangeConfirm is sent to the new address to
+// confirm an email change. Either URL or AppCode is present.
+type CtxEmailChangeConfirm struct {
+	UserName string
+	OldEmail string
+	NewEmail string
+	URL      string
+	AppCode  string
+}
+
+func (ctx CtxEmailChangeConfirm) Render() (string, error) {
+	return Render(keyEmailChangeConfirm, ctx)
+}
+
+// CtxEmailChanged is sent to the old address after a change
+// is confirmed, with a link to undo it.
+type CtxEmailChanged struct {
+	UserName  string
+	OldEmail  string
+	NewEmail  string
+	RevertURL string
+	Days      int64
+}
+
+func (ctx CtxEmailChanged) Render() (string, error) {
+	return Render(keyEmailChanged, ctx)
+}
+
+// CtxStripeDunning asks user to complete a Stripe renewal
+// payment that failed or requires authentication.
+type CtxStripeDunning struct {
+	UserName       string
+	Number         string
+	Amount         string
+	AttemptCount   int64
+	NextAttempt    chrono.Time // Zero if Stripe won't retry.
+	URL            string      // Stripe-hosted invoice page.
+	ActionRequired bool
+}
+
+func (ctx CtxStripeDunning) Render() (string, error) {
+	return Render(keyStripeDunning, ctx)
+}
+
+// CtxStripeDispute notifies finance staff that a Stripe
+// charge is disputed, or the dispute is closed.
+type CtxStripeDispute struct {
+	DisputeID     string
+	Amount        string
+	Reason        string
+	Status        string
+	EvidenceDueBy chrono.Time // Zero if response is not allowed.
+	CustomerID    string
+	FtcID         string
+	SubsID        string
+	Suspended     bool
+	Closed        bool
+	Won           bool
+	URL           string // Stripe dashboard.
+}
+
+func (ctx CtxStripeDispute) Render() (string, error) {
+	return Render(keyStripeDispute, ctx)
+}
+
+// CtxStripeTrialEnding reminds user that the free trial is
+// ending and the first payment will be charged.
+type CtxStripeTrialEnding struct {
+	UserName         string
+	Edition          string
+	TrialEnd         chrono.Time
+	Amount           string
+	HasPaymentMethod bool
+}
+
+func (ctx CtxStripeTrialEnding) Render() (string, error) {
+	return Render(keyStripeTrialEnding, ctx)
+}
@@ -335,3 +335,267 @@ func TestCtxSubs_Render(t *testing.T) {
 		})
 	}
 }
+
+func TestCtxTwoFactor_Render(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  CtxTwoFactor
+		wantErr bool
+	}{
+		{
+			name: "2FA enabled",
+			fields: CtxTwoFactor{
+				UserName: gofakeit.Username(),
+				Email:    gofakeit.Email(),
+				Enabled:  true,
+			},
+		},
+		{
+			name: "2FA reset by staff",
+			fields: CtxTwoFactor{
+				UserName: gofakeit.Username(),
+				Email:    gofakeit.Email(),
+				ByStaff:  true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.fields.Render()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Render() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			t.Logf("%s", got)
+		})
+	}
+}
+
+func TestCtxMagicLink_Render(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  CtxMagicLink
+		wantErr bool
+	}{
+		{
+			name: "Magic link in browser",
+			fields: CtxMagicLink{
+				UserName: gofakeit.Username(),
+				URL:      gofakeit.URL(),
+				Duration: "15分钟",
+			},
+		},
+		{
+			name: "Login code in app",
+			fields: CtxMagicLink{
+				UserName: gofakeit.Username(),
+				AppCode:  "123456",
+				Duration: "10分钟",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.fields.Render()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Render() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			t.Logf("%s", got)
+		})
+	}
+}
+
+func TestCtxAppleLink_Render(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  CtxAppleLink
+		wantErr bool
+	}{
+		{
+			name: "Linked",
+			fields: CtxAppleLink{
+				UserName:   gofakeit.Username(),
+				Email:      gofakeit.Email(),
+				AppleEmail: "abc@privaterelay.appleid.com",
+				Linked:     true,
+			},
+		},
+		{
+			name: "Unlinked",
+			fields: CtxAppleLink{
+				UserName:   gofakeit.Username(),
+				Email:      gofakeit.Email(),
+				AppleEmail: gofakeit.Email(),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.fields.Render()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Render() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			t.Logf("%s", got)
+		})
+	}
+}
+
+func TestCtxEmailChange_Render(t *testing.T) {
+	got, err := CtxEmailChangeConfirm{
+		UserName: gofakeit.Username(),
+		OldEmail: gofakeit.Email(),
+		NewEmail: gofakeit.Email(),
+		AppCode:  "123456",
+	}.Render()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	t.Logf("%s", got)
+
+	got, err = CtxEmailChanged{
+		UserName:  gofakeit.Username(),
+		OldEmail:  gofakeit.Email(),
+		NewEmail:  gofakeit.Email(),
+		RevertURL: gofakeit.URL(),
+		Days:      7,
+	}.Render()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	t.Logf("%s", got)
+}
+
+func TestCtxStripeDunning_Render(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  CtxStripeDunning
+		wantErr bool
+	}{
+		{
+			name: "Payment failed",
+			fields: CtxStripeDunning{
+				UserName:     gofakeit.Username(),
+				Number:       "ABCDEF-0002",
+				Amount:       "£39.00",
+				AttemptCount: 2,
+				NextAttempt:  chrono.TimeNow(),
+				URL:          gofakeit.URL(),
+			},
+		},
+		{
+			name: "Action required on last attempt",
+			fields: CtxStripeDunning{
+				UserName:       gofakeit.Username(),
+				Number:         "ABCDEF-0002",
+				Amount:         "£39.00",
+				AttemptCount:   4,
+				URL:            gofakeit.URL(),
+				ActionRequired: true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.fields.Render()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Render() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			t.Logf("%s", got)
+		})
+	}
+}
+
+func TestCtxStripeDispute_Render(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  CtxStripeDispute
+		wantErr bool
+	}{
+		{
+			name: "Dispute created",
+			fields: CtxStripeDispute{
+				DisputeID:     "dp_1JY2Qb2eZvKYlo2C1fGy2d8X",
+				Amount:        "£39.00",
+				Reason:        "fraudulent",
+				Status:        "needs_response",
+				EvidenceDueBy: chrono.TimeNow(),
+				CustomerID:    "cus_IXp31Fk2jYJmU3",
+				FtcID:         gofakeit.UUID(),
+				SubsID:        "sub_IY75arTimVigIr",
+				Suspended:     true,
+				URL:           "https://dashboard.stripe.com/test/disputes/dp_1JY2Qb2eZvKYlo2C1fGy2d8X",
+			},
+		},
+		{
+			name: "Dispute won",
+			fields: CtxStripeDispute{
+				DisputeID: "dp_1JY2Qb2eZvKYlo2C1fGy2d8X",
+				Amount:    "£39.00",
+				Reason:    "fraudulent",
+				Status:    "won",
+				Closed:    true,
+				Won:       true,
+				URL:       "https://dashboard.stripe.com/test/disputes/dp_1JY2Qb2eZvKYlo2C1fGy2d8X",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.fields.Render()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Render() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			t.Logf("%s", got)
+		})
+	}
+}
+
+func TestCtxStripeTrialEnding_Render(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  CtxStripeTrialEnding
+		wantErr bool
+	}{
+		{
+			name: "With payment method",
+			fields: CtxStripeTrialEnding{
+				UserName:         gofakeit.Username(),
+				Edition:          "标准会员/年",
+				TrialEnd:         chrono.TimeNow(),
+				Amount:           "£39.00",
+				HasPaymentMethod: true,
+			},
+		},
+		{
+			name: "Without payment method",
+			fields: CtxStripeTrialEnding{
+				UserName: gofakeit.Username(),
+				Edition:  "标准会员/年",
+				TrialEnd: chrono.TimeNow(),
+				Amount:   "£39.00",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.fields.Render()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Render() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			t.Logf("%s", got)
+		})
+	}
+}
@@ -5,8 +5,8 @@ import (
 	"github.com/FTChinese/go-rest/enum"
 	"github.com/FTChinese/subscription-api/internal/pkg/apple"
 	"github.com/FTChinese/subscription-api/internal/pkg/ftcpay"
+	"github.com/FTChinese/subscription-api/internal/pkg/stripe"
 	"github.com/FTChinese/subscription-api/pkg/account"
-	"github.com/FTChinese/subscription-api/pkg/config"
 	"github.com/FTChinese/subscription-api/pkg/postman"
 	"github.com/FTChinese/subscription-api/pkg/reader"
 	"go.uber.org/zap"
@@ -26,18 +26,32 @@ var accountKindCN = map[enum.AccountKind]string{
 	enum.AccountKindWx:  "微信",
 }
 
+// Outbox queues parcels to be delivered by a sender.
+type Outbox interface {
+	Enqueue(e postman.Envelope) error
+}
+
+// Service renders letters and puts them into the outbox.
+// Letters are not sent immediately so that they survive
+// transient failures of the mail server.
 type Service struct {
-	postman postman.Postman
-	logger  *zap.Logger
+	outbox Outbox
+	logger *zap.Logger
 }
 
-func NewService(logger *zap.Logger) Service {
+func NewService(outbox Outbox, logger *zap.Logger) Service {
 	return Service{
-		postman: postman.New(config.MustGetHanqiConn()),
-		logger:  logger,
+		outbox: outbox,
+		logger: logger,
 	}
 }
 
+// enqueue puts a parcel into the outbox. The optional ftcID
+// links the parcel to a user.
+func (s Service) enqueue(p postman.Parcel, ftcID string) error {
+	return s.outbox.Enqueue(postman.NewEnvelope(p).WithFtcID(ftcID))
+}
+
 // SendVerification generates the email body for verification letter from text template.
 func (s Service) SendVerification(ctx CtxVerification) error {
 	defer s.logger.Sync()
@@ -60,7 +74,7 @@ func (s Service) SendVerification(ctx CtxVerification) error {
 
 	sugar.Info(parcel)
 
-	return s.postman.Deliver(parcel)
+	return s.enqueue(parcel, "")
 }
 
 // SendGreeting creates a parcel to be delivered after email is verified.
@@ -88,7 +102,7 @@ func (s Service) SendGreeting(a account.BaseAccount) error {
 
 	sugar.Info(parcel)
 
-	return s.postman.Deliver(parcel)
+	return s.enqueue(parcel, a.FtcID)
 }
 
 // SendPasswordReset generates the email body for password reset.
@@ -119,7 +133,36 @@ func (s Service) SendPasswordReset(a account.BaseAccount, session account.PwRese
 
 	sugar.Info(parcel)
 
-	return s.postman.Deliver(parcel)
+	return s.enqueue(parcel, a.FtcID)
+}
+
+// SendMagicLink sends a passwordless login link or code.
+func (s Service) SendMagicLink(a account.BaseAccount, session account.MagicLinkSession) error {
+	defer s.logger.Sync()
+	sugar := s.logger.Sugar()
+
+	body, err := CtxMagicLink{
+		UserName: a.NormalizeName(),
+		URL:      session.BuildURL(),
+		AppCode:  session.AppCode.String,
+		Duration: session.FormatDuration(),
+	}.Render()
+
+	if err != nil {
+		sugar.Error(err)
+		return err
+	}
+
+	parcel := postman.Parcel{
+		FromAddress: fromAddress,
+		FromName:    "FT中文网",
+		ToAddress:   a.Email,
+		ToName:      a.NormalizeName(),
+		Subject:     "[FT中文网]登录链接",
+		Body:        body,
+	}
+
+	return s.enqueue(parcel, a.FtcID)
 }
 
 // SendWxSignUp sends an email after wechat-user linked to a new
@@ -153,7 +196,7 @@ func (s Service) SendWxSignUp(a reader.Account, v account.EmailVerifier) error {
 
 	sugar.Info(parcel)
 
-	return s.postman.Deliver(parcel)
+	return s.enqueue(parcel, a.FtcID)
 }
 
 // SendWxEmailLink sends an email to user after
@@ -190,7 +233,7 @@ func (s Service) SendWxEmailLink(linkResult reader.WxEmailLinkResult) error {
 
 	sugar.Info(parcel)
 
-	return s.postman.Deliver(parcel)
+	return s.enqueue(parcel, linkResult.Account.FtcID)
 }
 
 // SendWxEmailUnlink builds an email parcel after a linked
@@ -223,7 +266,104 @@ func (s Service) SendWxEmailUnlink(a reader.Account, anchor enum.AccountKind) er
 		Body:        body,
 	}
 
-	return s.postman.Deliver(parcel)
+	return s.enqueue(parcel, a.FtcID)
+}
+
+// SendAppleLink sends a letter after Sign in with Apple is
+// linked to or unlinked from an account.
+func (s Service) SendAppleLink(a account.BaseAccount, appleEmail string, linked bool) error {
+	defer s.logger.Sync()
+	sugar := s.logger.Sugar()
+
+	body, err := CtxAppleLink{
+		UserName:   a.NormalizeName(),
+		Email:      a.Email,
+		AppleEmail: appleEmail,
+		Linked:     linked,
+	}.Render()
+
+	if err != nil {
+		sugar.Error(err)
+		return err
+	}
+
+	subject := "关联Apple ID"
+	if !linked {
+		subject = "解除Apple ID关联"
+	}
+
+	parcel := postman.Parcel{
+		FromAddress: fromAddress,
+		FromName:    "FT中文网",
+		ToAddress:   a.Email,
+		ToName:      a.NormalizeName(),
+		Subject:     subject,
+		Body:        body,
+	}
+
+	return s.enqueue(parcel, a.FtcID)
+}
+
+// SendEmailChangeConfirm sends the confirmation link or
+// code to the new address.
+func (s Service) SendEmailChangeConfirm(a account.BaseAccount, c account.EmailChange) error {
+	defer s.logger.Sync()
+	sugar := s.logger.Sugar()
+
+	body, err := CtxEmailChangeConfirm{
+		UserName: a.NormalizeName(),
+		OldEmail: c.OldEmail,
+		NewEmail: c.NewEmail,
+		URL:      c.ConfirmURL(),
+		AppCode:  c.AppCode.String,
+	}.Render()
+
+	if err != nil {
+		sugar.Error(err)
+		return err
+	}
+
+	parcel := postman.Parcel{
+		FromAddress: fromAddress,
+		FromName:    "FT中文网",
+		ToAddress:   c.NewEmail,
+		ToName:      a.NormalizeName(),
+		Subject:     "[FT中文网]确认新邮箱",
+		Body:        body,
+	}
+
+	return s.enqueue(parcel, a.FtcID)
+}
+
+// SendEmailChanged notifies the old address after email
+// changed, with a link to revert it.
+func (s Service) SendEmailChanged(a account.BaseAccount, c account.EmailChange) error {
+	defer s.logger.Sync()
+	sugar := s.logger.Sugar()
+
+	body, err := CtxEmailChanged{
+		UserName:  a.NormalizeName(),
+		OldEmail:  c.OldEmail,
+		NewEmail:  c.NewEmail,
+		RevertURL: c.RevertURL(),
+		Days:      c.RevertDays(),
+	}.Render()
+
+	if err != nil {
+		sugar.Error(err)
+		return err
+	}
+
+	parcel := postman.Parcel{
+		FromAddress: fromAddress,
+		FromName:    "FT中文网",
+		ToAddress:   c.OldEmail,
+		ToName:      a.NormalizeName(),
+		Subject:     "[FT中文网]登录邮箱已更改",
+		Body:        body,
+	}
+
+	return s.enqueue(parcel, a.FtcID)
 }
 
 // SendOneTimePurchase sends an email after user made a
@@ -251,7 +391,7 @@ func (s Service) SendOneTimePurchase(a account.BaseAccount, invs ftcpay.Invoices
 		Body:        body,
 	}
 
-	return s.postman.Deliver(parcel)
+	return s.enqueue(parcel, a.FtcID)
 }
 
 func (s Service) SendIAPLinked(a account.BaseAccount, m reader.Membership) error {
@@ -279,7 +419,7 @@ func (s Service) SendIAPLinked(a account.BaseAccount, m reader.Membership) error
 		Body:        body,
 	}
 
-	return s.postman.Deliver(parcel)
+	return s.enqueue(parcel, a.FtcID)
 }
 
 func (s Service) SendIAPUnlinked(a account.BaseAccount, m apple.Subscription) error {
@@ -307,7 +447,153 @@ func (s Service) SendIAPUnlinked(a account.BaseAccount, m apple.Subscription) er
 		Body:        body,
 	}
 
-	return s.postman.Deliver(parcel)
+	return s.enqueue(parcel, a.FtcID)
+}
+
+// SendTwoFactorChanged notifies user that 2FA is turned on or off.
+func (s Service) SendTwoFactorChanged(a account.BaseAccount, enabled bool, byStaff bool) error {
+	defer s.logger.Sync()
+	sugar := s.logger.Sugar()
+
+	body, err := CtxTwoFactor{
+		UserName: a.NormalizeName(),
+		Email:    a.Email,
+		Enabled:  enabled,
+		ByStaff:  byStaff,
+	}.Render()
+
+	if err != nil {
+		sugar.Error(err)
+		return err
+	}
+
+	subject := "已关闭两步验证"
+	if enabled {
+		subject = "已开启两步验证"
+	}
+
+	parcel := postman.Parcel{
+		FromAddress: fromAddress,
+		FromName:    "FT中文网",
+		ToAddress:   a.Email,
+		ToName:      a.NormalizeName(),
+		Subject:     subject,
+		Body:        body,
+	}
+
+	return s.enqueue(parcel, a.FtcID)
+}
+
+// SendStripeDunning asks user to pay a renewal invoice
+// Stripe failed to charge.
+func (s Service) SendStripeDunning(a account.BaseAccount, d stripe.Dunning) error {
+	defer s.logger.Sync()
+	sugar := s.logger.Sugar()
+
+	body, err := CtxStripeDunning{
+		UserName:       a.NormalizeName(),
+		Number:         d.Number,
+		Amount:         d.ReadableAmount(),
+		AttemptCount:   d.AttemptCount,
+		NextAttempt:    d.NextAttemptUTC,
+		URL:            d.HostedInvoiceURL,
+		ActionRequired: d.ActionRequired,
+	}.Render()
+
+	if err != nil {
+		sugar.Error(err)
+		return err
+	}
+
+	subject := "Stripe支付失败"
+	if d.ActionRequired {
+		subject = "Stripe支付尚未完成"
+	}
+
+	parcel := postman.Parcel{
+		FromAddress: fromAddress,
+		FromName:    "FT中文网会员订阅",
+		ToAddress:   a.Email,
+		ToName:      a.NormalizeName(),
+		Subject:     subject,
+		Body:        body,
+	}
+
+	return s.enqueue(parcel, a.FtcID)
+}
+
+// SendDisputeNotice notifies finance staff of a dispute
+// created or closed.
+func (s Service) SendDisputeNotice(to string, d stripe.Dispute, suspended bool) error {
+	defer s.logger.Sync()
+	sugar := s.logger.Sugar()
+
+	body, err := CtxStripeDispute{
+		DisputeID:     d.ID,
+		Amount:        d.ReadableAmount(),
+		Reason:        d.Reason,
+		Status:        d.Status,
+		EvidenceDueBy: d.EvidenceDueBy,
+		CustomerID:    d.CustomerID.String,
+		FtcID:         d.FtcUserID.String,
+		SubsID:        d.SubsID.String,
+		Suspended:     suspended,
+		Closed:        d.IsClosed(),
+		Won:           d.IsWon(),
+		URL:           d.DashboardURL(),
+	}.Render()
+
+	if err != nil {
+		sugar.Error(err)
+		return err
+	}
+
+	subject := "Stripe付款争议 " + d.ID
+	if d.IsClosed() {
+		subject = "Stripe付款争议已结束 " + d.ID
+	}
+
+	parcel := postman.Parcel{
+		FromAddress: fromAddress,
+		FromName:    "FT中文网会员订阅",
+		ToAddress:   to,
+		ToName:      to,
+		Subject:     subject,
+		Body:        body,
+	}
+
+	return s.enqueue(parcel, "")
+}
+
+// SendTrialEnding reminds user that Stripe will charge the
+// first payment when the free trial ends.
+func (s Service) SendTrialEnding(a account.BaseAccount, subs stripe.Subs) error {
+	defer s.logger.Sync()
+	sugar := s.logger.Sugar()
+
+	body, err := CtxStripeTrialEnding{
+		UserName:         a.NormalizeName(),
+		Edition:          subs.Edition.StringCN(),
+		TrialEnd:         subs.TrialEndUTC,
+		Amount:           subs.ReadableRenewalAmount(),
+		HasPaymentMethod: subs.DefaultPaymentMethodID.Valid,
+	}.Render()
+
+	if err != nil {
+		sugar.Error(err)
+		return err
+	}
+
+	parcel := postman.Parcel{
+		FromAddress: fromAddress,
+		FromName:    "FT中文网会员订阅",
+		ToAddress:   a.Email,
+		ToName:      a.NormalizeName(),
+		Subject:     "FT中文网会员试用即将结束",
+		Body:        body,
+	}
+
+	return s.enqueue(parcel, a.FtcID)
 }
 
 //func (a Account) StripeSubParcel(s *stripe.Subscription) (postoffice.Parcel, error) {
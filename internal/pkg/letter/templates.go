@@ -188,6 +188,152 @@ FT中文网用户 {{.UserName}},
 您可以在使用该订阅的苹果设备登录FT中文网账号后可以重新绑定。
 
 感谢您对FT中文网的支持。如需帮助，请联系客服：subscriber.service@ftchinese.com。`,
+	keyTwoFactor: `
+FT中文网用户 {{.UserName}}，你好！
+{{if .Enabled}}
+您的FT中文网账号 {{.Email}} 已开启两步验证。此后使用邮箱和密码登录时，需要输入身份验证器App生成的验证码。
+
+请妥善保存恢复码。如果无法使用身份验证器，可以使用恢复码登录，每个恢复码只能使用一次。
+{{else if .ByStaff}}
+应您的要求，客服已经关闭了您的FT中文网账号 {{.Email}} 的两步验证。如需继续使用，请登录后重新开启。
+{{else}}
+您的FT中文网账号 {{.Email}} 已关闭两步验证。
+{{end}}
+如果您没有进行此操作，请立即修改密码并联系客服：subscriber.service@ftchinese.com。
+
+本邮件由系统自动生成，请勿回复。
+
+FT中文网`,
+	keyMagicLink: `
+FT中文网用户 {{.UserName}}，你好！
+{{if .URL}}
+点击以下链接即可登录FT中文网，无需输入密码：
+
+{{.URL}}
+
+如果上述链接无法点击，可以复制粘贴到浏览器地址栏。本链接{{.Duration}}内有效，且只能使用一次。
+{{else if .AppCode}}
+请在App中输入以下验证码登录FT中文网：
+
+{{.AppCode}}
+
+验证码{{.Duration}}内有效，且只能使用一次。
+{{end}}
+如果您没有请求登录，请忽略此邮件，您的账号仍然安全。
+
+本邮件由系统自动生成，请勿回复。
+
+FT中文网`,
+	keyAppleLink: `
+FT中文网用户 {{.UserName}}，你好！
+{{if .Linked}}
+您的FT中文网账号 {{.Email}} 已经关联了Apple ID（{{.AppleEmail}}）。此后您可以在App或网页上通过Apple登录FT中文网，会员信息相同。
+{{else}}
+您的FT中文网账号 {{.Email}} 已经解除了与Apple ID（{{.AppleEmail}}）的关联，此后无法再通过Apple登录该账号。
+{{end}}
+如果您本人没有执行此操作，请注意账号安全并联系客服：customer.service@ftchinese.com。
+
+本邮件由系统自动生成，请勿回复。
+
+FT中文网`,
+	keyEmailChangeConfirm: `
+FT中文网用户 {{.UserName}}，你好！
+
+您申请将FT中文网账号的登录邮箱从 {{.OldEmail}} 更改为 {{.NewEmail}}。
+{{if .URL}}
+请点击以下链接确认新邮箱，如果链接无法点击，可以复制粘贴到浏览器地址栏：
+
+{{.URL}}
+{{else if .AppCode}}
+请在App中输入以下验证码确认新邮箱：
+
+{{.AppCode}}
+{{end}}
+确认前您的登录邮箱不会改变。本{{if .URL}}链接{{else}}验证码{{end}}24小时内有效。
+
+如果您没有进行此操作，请忽略此邮件。
+
+本邮件由系统自动生成，请勿回复。
+
+FT中文网`,
+	keyEmailChanged: `
+FT中文网用户 {{.UserName}}，你好！
+
+您的FT中文网账号登录邮箱已经从 {{.OldEmail}} 更改为 {{.NewEmail}}。
+
+如果这不是您本人的操作，请在{{.Days}}天内点击以下链接恢复原邮箱，并尽快修改密码：
+
+{{.RevertURL}}
+
+如需帮助，请联系客服：customer.service@ftchinese.com。
+
+本邮件由系统自动生成，请勿回复。
+
+FT中文网`,
+	keyStripeDunning: `
+FT中文网用户 {{.UserName}}，你好！
+{{if .ActionRequired}}
+您通过Stripe续订FT中文网会员的付款尚未完成，您的发卡行需要进行安全验证。
+{{else}}
+您通过Stripe续订FT中文网会员的付款未能成功，这是第{{.AttemptCount}}次扣款失败。
+{{end}}
+发票号 {{.Number}}
+应付金额 {{.Amount}}
+
+请点击以下链接完成支付，或在App中更换支付方式后重新支付。如果链接无法点击，可以复制粘贴到浏览器地址栏：
+
+{{.URL}}
+{{if not .NextAttempt.IsZero}}
+如果您没有处理，我们将于 {{.NextAttempt.StringCN}} 再次尝试扣款。
+{{else}}
+我们不会再自动扣款。如果仍未支付，您的会员将在本计费周期开始时失效。
+{{end}}
+目前FT中文网的Stripe支付以英镑结算，不支持银联(UnionPay)等人民币信用卡。您可以使用有带有Visa、Mastercard、American Express、Discover、Diners Club等标志的卡片。
+
+如有疑问，请联系客服：subscriber.service@ftchinese.com。
+
+本邮件由系统自动生成，请勿回复。
+
+FT中文网`,
+	keyStripeDispute: `
+{{if .Closed}}Stripe争议 {{.DisputeID}} 已结束，结果：{{if .Won}}胜诉{{else}}败诉{{end}}（{{.Status}}）。
+{{if .Won}}
+如该会员因争议被暂停，已根据订阅状态恢复。
+{{end}}{{else}}一笔Stripe付款被持卡人提出争议(chargeback)。
+{{end}}
+争议ID {{.DisputeID}}
+金额 {{.Amount}}
+原因 {{.Reason}}
+状态 {{.Status}}
+Stripe客户 {{.CustomerID}}
+FT中文网用户 {{.FtcID}}
+订阅 {{.SubsID}}
+{{if not .Closed}}{{if .Suspended}}
+该会员已被暂停，争议胜诉后将自动恢复。
+{{end}}{{if not .EvidenceDueBy.IsZero}}
+请于 {{.EvidenceDueBy.StringCN}} 之前在Stripe后台提交证据：
+{{else}}
+该争议无需提交证据，详情请查看Stripe后台：
+{{end}}{{else}}
+详情请查看Stripe后台：
+{{end}}
+{{.URL}}
+
+本邮件由系统自动生成，请勿回复。`,
+	keyStripeTrialEnding: `
+FT中文网用户 {{.UserName}}，你好！
+
+感谢您通过Stripe免费试用FT中文网{{.Edition}}，您的试用期将于 {{.TrialEnd.StringCN}} 结束。
+{{if .HasPaymentMethod}}
+试用期结束后，我们将自动扣款 {{.Amount}}，您的会员将自动续订。如果您不希望继续订阅，请在试用期结束前在App或网站中取消自动续订。
+{{else}}
+您尚未设置支付方式。请在试用期结束前在App或网站中添加支付方式，以免会员在试用期结束后失效。续订价格为 {{.Amount}}。
+{{end}}
+如有疑问，请联系客服：subscriber.service@ftchinese.com。
+
+本邮件由系统自动生成，请勿回复。
+
+FT中文网`,
 }
 
 // Data used to compile this template:
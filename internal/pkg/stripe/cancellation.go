@@ -0,0 +1,327 @@
+package stripe
+
+import (
+	"strings"
+	"time"
+
+	"github.com/FTChinese/go-rest/chrono"
+	"github.com/FTChinese/go-rest/enum"
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/lib/validator"
+	"github.com/FTChinese/subscription-api/pkg/price"
+	"github.com/guregu/null"
+	"github.com/stripe/stripe-go/v72"
+)
+
+// CancelReason is the reason code user picked when canceling
+// a subscription.
+type CancelReason string
+
+const (
+	CancelReasonNull            CancelReason = ""
+	CancelReasonTooExpensive    CancelReason = "too_expensive"
+	CancelReasonNotUsing        CancelReason = "not_using"
+	CancelReasonMissingContent  CancelReason = "missing_content"
+	CancelReasonTechnicalIssues CancelReason = "technical_issues"
+	CancelReasonSwitching       CancelReason = "switching" // Subscribing elsewhere, e.g., via app store.
+	CancelReasonTemporary       CancelReason = "temporary" // Consider pausing instead.
+	CancelReasonOther           CancelReason = "other"
+)
+
+func (r CancelReason) IsValid() bool {
+	switch r {
+	case CancelReasonNull,
+		CancelReasonTooExpensive,
+		CancelReasonNotUsing,
+		CancelReasonMissingContent,
+		CancelReasonTechnicalIssues,
+		CancelReasonSwitching,
+		CancelReasonTemporary,
+		CancelReasonOther:
+		return true
+	}
+
+	return false
+}
+
+const maxFeedbackLen = 1024
+
+// CancelInput is the optional request body when user cancels
+// a subscription. Old clients send no body at all.
+type CancelInput struct {
+	Reason   CancelReason `json:"reason"`
+	Feedback null.String  `json:"feedback"`
+}
+
+func (i *CancelInput) Validate() *render.ValidationError {
+	if !i.Reason.IsValid() {
+		return &render.ValidationError{
+			Message: "Unknown cancellation reason",
+			Field:   "reason",
+			Code:    render.CodeInvalid,
+		}
+	}
+
+	feedback := strings.TrimSpace(i.Feedback.String)
+	i.Feedback = null.NewString(feedback, feedback != "")
+
+	if i.Reason == CancelReasonOther && !i.Feedback.Valid {
+		return &render.ValidationError{
+			Message: "Please tell us why you are leaving",
+			Field:   "feedback",
+			Code:    render.CodeMissingField,
+		}
+	}
+
+	return validator.New("feedback").MaxLen(maxFeedbackLen).Validate(feedback)
+}
+
+// Tenure groups subscriptions by how long they lasted
+// before cancellation.
+type Tenure string
+
+const (
+	TenureUnderOneMonth   Tenure = "0-1m"
+	TenureOneToThree      Tenure = "1-3m"
+	TenureThreeToSix      Tenure = "3-6m"
+	TenureSixToTwelve     Tenure = "6-12m"
+	TenureOverTwelveMonth Tenure = "12m+"
+)
+
+func NewTenure(days int64) Tenure {
+	switch {
+	case days < 30:
+		return TenureUnderOneMonth
+	case days < 90:
+		return TenureOneToThree
+	case days < 180:
+		return TenureThreeToSix
+	case days < 365:
+		return TenureSixToTwelve
+	default:
+		return TenureOverTwelveMonth
+	}
+}
+
+// Cancellation records why a subscription is canceled and the
+// retention offer presented, if any.
+// Saved in premium.stripe_cancellation, one row per subscription.
+// Canceling again after reactivation overwrites reason and
+// offer, while an offer accepted is kept so that a subscription
+// is retained with a coupon only once.
+type Cancellation struct {
+	SubsID    string `json:"subsId" db:"subs_id"`
+	FtcUserID string `json:"ftcUserId" db:"ftc_user_id"`
+	price.Edition
+	TenureDays    int64        `json:"tenureDays" db:"tenure_days"`
+	Tenure        Tenure       `json:"tenure" db:"tenure"`
+	Reason        CancelReason `json:"reason" db:"cancel_reason"`
+	Feedback      null.String  `json:"feedback" db:"feedback"`
+	OfferCouponID null.String  `json:"offerCouponId" db:"offer_coupon_id"`
+	OfferAccepted bool         `json:"offerAccepted" db:"offer_accepted"`
+	LiveMode      bool         `json:"liveMode" db:"live_mode"`
+	CreatedUTC    chrono.Time  `json:"createdUtc" db:"created_utc"`
+	UpdatedUTC    chrono.Time  `json:"updatedUtc" db:"updated_utc"`
+}
+
+// NewCancellation counts tenure from the date subscription
+// started, or current period if Stripe did not provide it.
+func NewCancellation(s Subs, input CancelInput) Cancellation {
+	start := s.StartDateUTC.Time
+	if start.IsZero() {
+		start = s.CurrentPeriodStart.Time
+	}
+
+	var days int64
+	if !start.IsZero() {
+		days = int64(time.Since(start).Hours() / 24)
+	}
+
+	now := chrono.TimeNow()
+
+	return Cancellation{
+		SubsID:     s.ID,
+		FtcUserID:  s.FtcUserID.String,
+		Edition:    s.Edition,
+		TenureDays: days,
+		Tenure:     NewTenure(days),
+		Reason:     input.Reason,
+		Feedback:   input.Feedback,
+		LiveMode:   s.LiveMode,
+		CreatedUTC: now,
+		UpdatedUTC: now,
+	}
+}
+
+func (c Cancellation) IsZero() bool {
+	return c.SubsID == ""
+}
+
+// WithOffer remembers the coupon offered so that user could
+// only accept what is presented.
+func (c Cancellation) WithOffer(coupon price.StripeCoupon) Cancellation {
+	c.OfferCouponID = null.NewString(coupon.ID, coupon.ID != "")
+	return c
+}
+
+// HasOffer tells whether an offer is presented and still
+// waiting for acceptance.
+func (c Cancellation) HasOffer() bool {
+	return c.OfferCouponID.Valid && !c.OfferAccepted
+}
+
+func (c Cancellation) Accepted() Cancellation {
+	c.OfferAccepted = true
+	c.UpdatedUTC = chrono.TimeNow()
+	return c
+}
+
+// RetentionOffer picks the retention coupon of the subscribed
+// price that deducts the most from it, to win back a user who
+// is canceling. A Stripe coupon is the counterpart of ftc
+// price's retention discount.
+// Coupons not marked as retention are shown on paywall and
+// never offered here.
+// Nothing is offered if:
+// - subscription is trialing, or already discounted;
+// - a coupon is already redeemed in current billing cycle;
+// - an offer was accepted upon a previous cancellation.
+func RetentionOffer(
+	s Subs,
+	coupons []price.StripeCoupon,
+	redeemed CouponRedeemed,
+	prior Cancellation,
+) price.StripeCoupon {
+	if len(s.Items) == 0 {
+		return price.StripeCoupon{}
+	}
+
+	if s.IsTrialing() || s.Discount.ID != "" {
+		return price.StripeCoupon{}
+	}
+
+	if !redeemed.IsZero() || prior.OfferAccepted {
+		return price.StripeCoupon{}
+	}
+
+	p := s.Items[0].Price
+
+	var offer price.StripeCoupon
+	var maxOff int64
+	for _, c := range coupons {
+		if !c.IsRetention() || !c.IsValid() {
+			continue
+		}
+
+		off := c.DiscountOf(p)
+		if off > maxOff {
+			offer = c
+			maxOff = off
+		}
+	}
+
+	return offer
+}
+
+// RetentionParams reactivates a subscription canceled at
+// period end with the coupon deducted from the next invoice.
+func (c Cancellation) RetentionParams() *stripe.SubscriptionParams {
+	return &stripe.SubscriptionParams{
+		CancelAtPeriodEnd: stripe.Bool(false),
+		Coupon:            stripe.String(c.OfferCouponID.String),
+	}
+}
+
+// CouponRedeemed attributes the coupon accepted to current
+// billing cycle so that no other coupon could be applied to it.
+func (c Cancellation) CouponRedeemed(s Subs) CouponRedeemed {
+	if !c.OfferCouponID.Valid || s.LatestInvoiceID == "" {
+		return CouponRedeemed{}
+	}
+
+	return CouponRedeemed{
+		FtcID:       c.FtcUserID,
+		InvoiceID:   s.LatestInvoiceID,
+		LiveMode:    s.LiveMode,
+		SubsID:      s.ID,
+		CouponID:    c.OfferCouponID.String,
+		CreatedUTC:  chrono.TimeNow(),
+		RedeemedUTC: chrono.TimeNow(),
+	}
+}
+
+// CancelResult is returned after user canceled a subscription.
+// RetentionOffer is null if user is not eligible for any.
+type CancelResult struct {
+	SubsResult
+	RetentionOffer *price.StripeCoupon `json:"retentionOffer"`
+}
+
+// CancellationReportParams limits the report to cancellations
+// created in [Since, Until).
+type CancellationReportParams struct {
+	Since time.Time `json:"since"`
+	Until time.Time `json:"until"`
+}
+
+// defaultReportDays is used when the start date is omitted.
+const defaultReportDays = 90
+
+// ParseCancellationReportParams parses dates in the form of
+// 2006-01-02. Until defaults to today and is inclusive.
+func ParseCancellationReportParams(since, until string) (CancellationReportParams, *render.ValidationError) {
+	end := time.Now().UTC().Truncate(24 * time.Hour)
+	if until != "" {
+		t, err := time.Parse(chrono.SQLDate, until)
+		if err != nil {
+			return CancellationReportParams{}, &render.ValidationError{
+				Message: "Date must be in the form of YYYY-MM-DD",
+				Field:   "until",
+				Code:    render.CodeInvalid,
+			}
+		}
+		end = t
+	}
+	end = end.AddDate(0, 0, 1)
+
+	start := end.AddDate(0, 0, -defaultReportDays)
+	if since != "" {
+		t, err := time.Parse(chrono.SQLDate, since)
+		if err != nil {
+			return CancellationReportParams{}, &render.ValidationError{
+				Message: "Date must be in the form of YYYY-MM-DD",
+				Field:   "since",
+				Code:    render.CodeInvalid,
+			}
+		}
+		start = t
+	}
+
+	if !start.Before(end) {
+		return CancellationReportParams{}, &render.ValidationError{
+			Message: "Start date must not be later than end date",
+			Field:   "since",
+			Code:    render.CodeInvalid,
+		}
+	}
+
+	return CancellationReportParams{
+		Since: start,
+		Until: end,
+	}, nil
+}
+
+// CancellationStats counts cancellations of the same tier,
+// tenure and reason.
+type CancellationStats struct {
+	Tier          enum.Tier    `json:"tier" db:"tier"`
+	Tenure        Tenure       `json:"tenure" db:"tenure"`
+	Reason        CancelReason `json:"reason" db:"cancel_reason"`
+	Count         int64        `json:"count" db:"cancel_count"`
+	OfferAccepted int64        `json:"offerAccepted" db:"accepted_count"`
+}
+
+type CancellationReport struct {
+	CancellationReportParams
+	Stats []CancellationStats `json:"stats"`
+}
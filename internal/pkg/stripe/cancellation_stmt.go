@@ -0,0 +1,68 @@
+package stripe
+
+// StmtUpsertCancellation keeps offer_accepted and created_utc
+// of an existing row.
+const StmtUpsertCancellation = `
+INSERT INTO premium.stripe_cancellation
+SET subs_id = :subs_id,
+	ftc_user_id = :ftc_user_id,
+	tier = :tier,
+	cycle = :cycle,
+	tenure_days = :tenure_days,
+	tenure = :tenure,
+	cancel_reason = :cancel_reason,
+	feedback = :feedback,
+	offer_coupon_id = :offer_coupon_id,
+	offer_accepted = :offer_accepted,
+	live_mode = :live_mode,
+	created_utc = :created_utc,
+	updated_utc = :updated_utc
+ON DUPLICATE KEY UPDATE
+	tier = :tier,
+	cycle = :cycle,
+	tenure_days = :tenure_days,
+	tenure = :tenure,
+	cancel_reason = :cancel_reason,
+	feedback = :feedback,
+	offer_coupon_id = :offer_coupon_id,
+	updated_utc = :updated_utc`
+
+const StmtRetrieveCancellation = `
+SELECT subs_id,
+	ftc_user_id,
+	tier,
+	cycle,
+	tenure_days,
+	tenure,
+	cancel_reason,
+	feedback,
+	offer_coupon_id,
+	offer_accepted,
+	live_mode,
+	created_utc,
+	updated_utc
+FROM premium.stripe_cancellation
+WHERE subs_id = ?
+LIMIT 1`
+
+const StmtAcceptRetentionOffer = `
+UPDATE premium.stripe_cancellation
+SET offer_accepted = :offer_accepted,
+	updated_utc = :updated_utc
+WHERE subs_id = :subs_id
+LIMIT 1`
+
+// StmtCancellationStats groups cancellations by tier, tenure
+// and reason, most frequent reason first.
+const StmtCancellationStats = `
+SELECT tier,
+	tenure,
+	cancel_reason,
+	COUNT(*) AS cancel_count,
+	SUM(offer_accepted) AS accepted_count
+FROM premium.stripe_cancellation
+WHERE live_mode = ?
+	AND created_utc >= ?
+	AND created_utc < ?
+GROUP BY tier, tenure, cancel_reason
+ORDER BY tier, tenure, cancel_count DESC`
@@ -0,0 +1,173 @@
+package stripe
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FTChinese/go-rest/enum"
+	"github.com/FTChinese/subscription-api/pkg/price"
+	"github.com/brianvoe/gofakeit/v5"
+	"github.com/guregu/null"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCancelInput_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   CancelInput
+		wantErr bool
+	}{
+		{"empty body", CancelInput{}, false},
+		{"reason only", CancelInput{Reason: CancelReasonTooExpensive}, false},
+		{"unknown reason", CancelInput{Reason: "bored"}, true},
+		{"other without feedback", CancelInput{Reason: CancelReasonOther, Feedback: null.StringFrom("  ")}, true},
+		{"other with feedback", CancelInput{Reason: CancelReasonOther, Feedback: null.StringFrom("Moving abroad")}, false},
+		{"feedback too long", CancelInput{Feedback: null.StringFrom(strings.Repeat("a", maxFeedbackLen+1))}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.input.Validate()
+			assert.Equal(t, tt.wantErr, got != nil)
+		})
+	}
+}
+
+func TestNewTenure(t *testing.T) {
+	assert.Equal(t, TenureUnderOneMonth, NewTenure(0))
+	assert.Equal(t, TenureOneToThree, NewTenure(30))
+	assert.Equal(t, TenureThreeToSix, NewTenure(120))
+	assert.Equal(t, TenureSixToTwelve, NewTenure(364))
+	assert.Equal(t, TenureOverTwelveMonth, NewTenure(365))
+}
+
+func TestNewCancellation(t *testing.T) {
+	s := NewMockSubsBuilder(gofakeit.UUID()).Build()
+	s.StartDateUTC.Time = time.Now().AddDate(0, -4, 0)
+
+	c := NewCancellation(s, CancelInput{Reason: CancelReasonNotUsing})
+
+	assert.Equal(t, s.ID, c.SubsID)
+	assert.Equal(t, TenureThreeToSix, c.Tenure)
+	assert.Equal(t, CancelReasonNotUsing, c.Reason)
+	assert.False(t, c.HasOffer())
+}
+
+func mockCoupon(id string, amountOff int64, status price.DiscountStatus) price.StripeCoupon {
+	return price.StripeCoupon{
+		ID:        id,
+		AmountOff: amountOff,
+		Currency:  "gbp",
+		StripeCouponMeta: price.StripeCouponMeta{
+			Kind: price.OfferKindRetention,
+		},
+		Status: status,
+	}
+}
+
+func TestRetentionOffer(t *testing.T) {
+	paywall := mockCoupon("paywall", 1000, price.DiscountStatusActive)
+	paywall.Kind = price.OfferKindNull
+
+	coupons := []price.StripeCoupon{
+		mockCoupon("small", 100, price.DiscountStatusActive),
+		mockCoupon("large", 500, price.DiscountStatusActive),
+		mockCoupon("cancelled", 900, price.DiscountStatusCancelled),
+		paywall,
+	}
+
+	subs := NewMockSubsBuilder(gofakeit.UUID()).Build()
+
+	tests := []struct {
+		name     string
+		subs     Subs
+		redeemed CouponRedeemed
+		prior    Cancellation
+		want     string
+	}{
+		{
+			name: "largest valid coupon",
+			subs: subs,
+			want: "large",
+		},
+		{
+			name: "trialing",
+			subs: NewMockSubsBuilder(gofakeit.UUID()).WithStatus(enum.SubsStatusTrialing).Build(),
+			want: "",
+		},
+		{
+			name: "already discounted",
+			subs: NewMockSubsBuilder(gofakeit.UUID()).WithDiscount().Build(),
+			want: "",
+		},
+		{
+			name:     "redeemed in current cycle",
+			subs:     subs,
+			redeemed: MockCouponRedeemed(),
+			want:     "",
+		},
+		{
+			name:  "accepted before",
+			subs:  subs,
+			prior: Cancellation{SubsID: subs.ID, OfferAccepted: true},
+			want:  "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RetentionOffer(tt.subs, coupons, tt.redeemed, tt.prior)
+			assert.Equal(t, tt.want, got.ID)
+		})
+	}
+}
+
+func TestRetentionOffer_percentOff(t *testing.T) {
+	// Price of mock subscription is 3900.
+	percent := mockCoupon("percent", 0, price.DiscountStatusActive)
+	percent.PercentOff = 20
+
+	usd := mockCoupon("usd", 1000, price.DiscountStatusActive)
+	usd.Currency = "usd"
+
+	coupons := []price.StripeCoupon{
+		mockCoupon("large", 500, price.DiscountStatusActive),
+		percent,
+		usd,
+	}
+
+	subs := NewMockSubsBuilder(gofakeit.UUID()).Build()
+
+	got := RetentionOffer(subs, coupons, CouponRedeemed{}, Cancellation{})
+	assert.Equal(t, "percent", got.ID)
+}
+
+func TestCancellation_CouponRedeemed(t *testing.T) {
+	subs := NewMockSubsBuilder(gofakeit.UUID()).Build()
+	c := NewCancellation(subs, CancelInput{}).
+		WithOffer(mockCoupon("large", 500, price.DiscountStatusActive))
+
+	assert.True(t, c.HasOffer())
+
+	r := c.Accepted().CouponRedeemed(subs)
+	assert.Equal(t, subs.LatestInvoiceID, r.InvoiceID)
+	assert.Equal(t, "large", r.CouponID)
+
+	assert.False(t, c.Accepted().HasOffer())
+}
+
+func TestParseCancellationReportParams(t *testing.T) {
+	p, ve := ParseCancellationReportParams("2026-01-01", "2026-01-31")
+	assert.Nil(t, ve)
+	assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), p.Since)
+	assert.Equal(t, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), p.Until)
+
+	p, ve = ParseCancellationReportParams("", "")
+	assert.Nil(t, ve)
+	assert.Equal(t, defaultReportDays*24*time.Hour, p.Until.Sub(p.Since))
+
+	_, ve = ParseCancellationReportParams("2026-02-01", "2026-01-01")
+	assert.NotNil(t, ve)
+
+	_, ve = ParseCancellationReportParams("01/01/2026", "")
+	assert.NotNil(t, ve)
+}
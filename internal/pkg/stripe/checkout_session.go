@@ -0,0 +1,182 @@
+package stripe
+
+import (
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/lib/validator"
+	"github.com/FTChinese/subscription-api/pkg/reader"
+	"github.com/guregu/null"
+	stripeSdk "github.com/stripe/stripe-go/v72"
+)
+
+// Keys of metadata attached to checkout session and the
+// subscription it creates, so that webhook could restore
+// the request after user paid.
+const (
+	metaFtcID          = "ftcId"
+	metaPriceID        = "priceId"
+	metaIntroductoryID = "introductoryPriceId"
+	metaCouponID       = "coupon"
+	metaPromotionCode  = "promotionCode"
+)
+
+// CheckoutSessionParams is the request body to create a
+// Stripe-hosted checkout page.
+// DefaultPaymentMethod is ignored since user enters payment
+// details on the page.
+type CheckoutSessionParams struct {
+	SubsParams
+	// Stripe replaces {CHECKOUT_SESSION_ID} with the session id.
+	SuccessURL string `json:"successUrl"`
+	CancelURL  string `json:"cancelUrl"`
+}
+
+// Validate checks price and redirect urls.
+// Urls on localhost are only accepted in sandbox mode.
+func (p CheckoutSessionParams) Validate(live bool) *render.ValidationError {
+	if ve := p.SubsParams.Validate(); ve != nil {
+		return ve
+	}
+
+	if ve := validator.EnsureRedirectURL("successUrl", p.SuccessURL, live); ve != nil {
+		return ve
+	}
+
+	return validator.EnsureRedirectURL("cancelUrl", p.CancelURL, live)
+}
+
+// NewSessionParams builds the parameters to create a checkout
+// session in subscription mode.
+// Introductory price is charged as an extra line item together
+// with a trial period, the same as NewSubParams does.
+func (p CheckoutSessionParams) NewSessionParams(ftcID, cusID string, ci reader.CartItemStripe) *stripeSdk.CheckoutSessionParams {
+	meta := p.metadata(ftcID)
+
+	params := &stripeSdk.CheckoutSessionParams{
+		Mode:              stripeSdk.String(string(stripeSdk.CheckoutSessionModeSubscription)),
+		Customer:          stripeSdk.String(cusID),
+		ClientReferenceID: stripeSdk.String(ftcID),
+		LineItems: []*stripeSdk.CheckoutSessionLineItemParams{
+			{
+				Price:    stripeSdk.String(ci.Recurring.ID),
+				Quantity: stripeSdk.Int64(1),
+			},
+		},
+		SubscriptionData: &stripeSdk.CheckoutSessionSubscriptionDataParams{
+			Metadata: meta,
+		},
+		SuccessURL: stripeSdk.String(p.SuccessURL),
+		CancelURL:  stripeSdk.String(p.CancelURL),
+	}
+
+	if !ci.Introductory.IsZero() {
+		params.LineItems = append(params.LineItems, &stripeSdk.CheckoutSessionLineItemParams{
+			Price:    stripeSdk.String(ci.Introductory.ID),
+			Quantity: stripeSdk.Int64(1),
+		})
+
+		params.SubscriptionData.TrialPeriodDays = stripeSdk.Int64(
+			ci.Introductory.PeriodCount.TotalDays())
+	} else if !ci.Coupon.IsZero() {
+		params.Discounts = []*stripeSdk.CheckoutSessionDiscountParams{
+			{
+				Coupon: stripeSdk.String(ci.Coupon.ID),
+			},
+		}
+	} else if !ci.PromoCode.IsZero() {
+		params.Discounts = []*stripeSdk.CheckoutSessionDiscountParams{
+			{
+				PromotionCode: stripeSdk.String(ci.PromoCode.ID),
+			},
+		}
+	}
+
+	// Trial counts from the time user completes the checkout
+	// page rather than when the session is created.
+	if ci.TrialDays > 0 {
+		params.SubscriptionData.TrialPeriodDays = stripeSdk.Int64(ci.TrialDays)
+	}
+
+	for k, v := range meta {
+		params.AddMetadata(k, v)
+	}
+
+	if p.IdempotencyKey != "" {
+		params.SetIdempotencyKey(p.IdempotencyKey)
+	}
+
+	return params
+}
+
+func (p CheckoutSessionParams) metadata(ftcID string) map[string]string {
+	m := map[string]string{
+		metaFtcID:   ftcID,
+		metaPriceID: p.PriceID,
+	}
+
+	if p.IntroductoryPriceID.Valid {
+		m[metaIntroductoryID] = p.IntroductoryPriceID.String
+	}
+
+	if p.CouponID.Valid {
+		m[metaCouponID] = p.CouponID.String
+	}
+
+	if p.PromotionCode.Valid {
+		m[metaPromotionCode] = p.PromotionCode.String
+	}
+
+	return m
+}
+
+// SubsParamsFromMetadata restores the items user selected
+// from the metadata of a checkout session.
+func SubsParamsFromMetadata(m map[string]string) SubsParams {
+	return SubsParams{
+		PriceID:             m[metaPriceID],
+		IntroductoryPriceID: null.NewString(m[metaIntroductoryID], m[metaIntroductoryID] != ""),
+		CouponID:            null.NewString(m[metaCouponID], m[metaCouponID] != ""),
+		PromotionCode:       null.NewString(m[metaPromotionCode], m[metaPromotionCode] != ""),
+	}
+}
+
+// CheckoutSession is returned to client to redirect user
+// to the Stripe-hosted page.
+type CheckoutSession struct {
+	ID        string `json:"id"`
+	URL       string `json:"url"`
+	ExpiresAt int64  `json:"expiresAt"`
+	LiveMode  bool   `json:"liveMode"`
+}
+
+func NewCheckoutSession(s *stripeSdk.CheckoutSession) CheckoutSession {
+	return CheckoutSession{
+		ID:        s.ID,
+		URL:       s.URL,
+		ExpiresAt: s.ExpiresAt,
+		LiveMode:  s.Livemode,
+	}
+}
+
+// PortalSessionParams is the request body to open the
+// Stripe billing portal.
+type PortalSessionParams struct {
+	ReturnURL string `json:"returnUrl"`
+}
+
+func (p PortalSessionParams) Validate(live bool) *render.ValidationError {
+	return validator.EnsureRedirectURL("returnUrl", p.ReturnURL, live)
+}
+
+type PortalSession struct {
+	ID       string `json:"id"`
+	URL      string `json:"url"`
+	LiveMode bool   `json:"liveMode"`
+}
+
+func NewPortalSession(s *stripeSdk.BillingPortalSession) PortalSession {
+	return PortalSession{
+		ID:       s.ID,
+		URL:      s.URL,
+		LiveMode: s.Livemode,
+	}
+}
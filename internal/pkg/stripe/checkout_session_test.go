@@ -0,0 +1,123 @@
+package stripe
+
+import (
+	"testing"
+
+	"github.com/FTChinese/subscription-api/pkg/price"
+	"github.com/FTChinese/subscription-api/pkg/reader"
+	"github.com/guregu/null"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckoutSessionParams_NewSessionParams(t *testing.T) {
+	recurring := price.MockRandomStripePrice()
+	intro := price.MockRandomStripePrice()
+	intro.Kind = price.KindOneTime
+	coupon := recurring.MockRandomCoupon()
+
+	t.Run("introductory", func(t *testing.T) {
+		p := CheckoutSessionParams{
+			SubsParams: SubsParams{
+				PriceID:             recurring.ID,
+				IntroductoryPriceID: null.StringFrom(intro.ID),
+			},
+			SuccessURL: "https://next.ftacademy.cn/checkout/success",
+			CancelURL:  "https://next.ftacademy.cn/checkout/cancel",
+		}
+
+		got := p.NewSessionParams("ftc-id", "cus_test", reader.CartItemStripe{
+			Recurring:    recurring,
+			Introductory: intro,
+		})
+
+		assert.Equal(t, "ftc-id", *got.ClientReferenceID)
+		assert.Len(t, got.LineItems, 2)
+		assert.Equal(t, intro.PeriodCount.TotalDays(), *got.SubscriptionData.TrialPeriodDays)
+		assert.Empty(t, got.Discounts)
+		assert.Equal(t, p.SubsParams, SubsParamsFromMetadata(got.Metadata))
+		assert.Equal(t, got.Metadata, got.SubscriptionData.Metadata)
+	})
+
+	t.Run("coupon", func(t *testing.T) {
+		p := CheckoutSessionParams{
+			SubsParams: SubsParams{
+				PriceID:  recurring.ID,
+				CouponID: null.StringFrom(coupon.ID),
+			},
+		}
+
+		got := p.NewSessionParams("ftc-id", "cus_test", reader.CartItemStripe{
+			Recurring: recurring,
+			Coupon:    coupon,
+		})
+
+		assert.Len(t, got.LineItems, 1)
+		assert.Nil(t, got.SubscriptionData.TrialPeriodDays)
+		assert.Equal(t, coupon.ID, *got.Discounts[0].Coupon)
+		assert.Equal(t, p.SubsParams, SubsParamsFromMetadata(got.Metadata))
+	})
+
+	t.Run("trial", func(t *testing.T) {
+		trialPrice := recurring
+		trialPrice.TrialDays = 7
+		p := CheckoutSessionParams{
+			SubsParams: SubsParams{
+				PriceID: recurring.ID,
+			},
+		}
+
+		got := p.NewSessionParams("ftc-id", "cus_test", reader.CartItemStripe{
+			Recurring: trialPrice,
+		}.WithTrial(true))
+
+		assert.Equal(t, int64(7), *got.SubscriptionData.TrialPeriodDays)
+		assert.Nil(t, got.SubscriptionData.TrialEnd)
+	})
+}
+
+func TestCheckoutSessionParams_Validate(t *testing.T) {
+	recurring := price.MockRandomStripePrice()
+
+	tests := []struct {
+		name       string
+		successURL string
+		cancelURL  string
+		live       bool
+		field      string // Empty if valid.
+	}{
+		{"allowed hosts", "https://next.ftacademy.cn/checkout", "https://www.ftchinese.com/", true, ""},
+		{"plain http", "http://www.ftchinese.com/", "https://www.ftchinese.com/", true, "successUrl"},
+		{"suffix of host", "https://next.ftacademy.cn/checkout", "https://ftacademy.cn.example.com/", true, "cancelUrl"},
+		{"similar host", "https://evilftacademy.cn/", "https://www.ftchinese.com/", true, "successUrl"},
+		{"localhost in sandbox", "http://localhost:3000/checkout", "http://localhost:3000/cancel", false, ""},
+		{"localhost in live", "http://localhost:3000/checkout", "https://www.ftchinese.com/", true, "successUrl"},
+		{"missing", "https://next.ftacademy.cn/checkout", "", false, "cancelUrl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := CheckoutSessionParams{
+				SubsParams: SubsParams{
+					PriceID: recurring.ID,
+				},
+				SuccessURL: tt.successURL,
+				CancelURL:  tt.cancelURL,
+			}
+
+			ve := p.Validate(tt.live)
+			if tt.field == "" {
+				assert.Nil(t, ve)
+				return
+			}
+
+			if assert.NotNil(t, ve) {
+				assert.Equal(t, tt.field, ve.Field)
+			}
+		})
+	}
+}
+
+func TestPortalSessionParams_Validate(t *testing.T) {
+	assert.Nil(t, PortalSessionParams{ReturnURL: "https://next.ftacademy.cn/account"}.Validate(true))
+	assert.NotNil(t, PortalSessionParams{ReturnURL: "https://example.com/"}.Validate(true))
+}
@@ -2,6 +2,7 @@ package stripe
 
 import (
 	"github.com/FTChinese/go-rest/chrono"
+	"github.com/guregu/null"
 )
 
 // CouponRedeemed records which invoice has redeemed
@@ -14,6 +15,7 @@ type CouponRedeemed struct {
 	LiveMode    bool        `json:"liveMode" db:"live_mode"`
 	SubsID      string      `json:"subsId" db:"subs_id"`
 	CouponID    string      `json:"couponId" db:"coupon_id"`
+	PromoCodeID null.String `json:"promotionCodeId" db:"promotion_code_id"` // If redeemed via a promotion code.
 	CreatedUTC  chrono.Time `json:"createdUtc" db:"created_utc"`
 	RedeemedUTC chrono.Time `json:"redeemedUtc" db:"redeemed_utc"`
 }
@@ -29,6 +31,7 @@ SET ftc_user_id = :ftc_user_id,
 	live_mode = :live_mode,
 	subs_id = :subs_id,
 	coupon_id = :coupon_id,
+	promotion_code_id = :promotion_code_id,
 	created_utc = :created_utc,
 	redeemed_utc = :redeemed_utc
 `
@@ -39,6 +42,7 @@ SELECT ftc_user_id,
 	live_mode,
 	subs_id,
 	coupon_id,
+	promotion_code_id,
 	created_utc,
 	redeemed_utc
 FROM premium.stripe_coupon_redeemed
@@ -0,0 +1,128 @@
+package stripe
+
+import (
+	"github.com/FTChinese/go-rest/chrono"
+	"github.com/FTChinese/subscription-api/lib/dt"
+	"github.com/FTChinese/subscription-api/pkg/reader"
+	"github.com/guregu/null"
+	"github.com/stripe/stripe-go/v72"
+)
+
+// Dispute is a chargeback filed by a card holder against a
+// charge. Save in premium.stripe_dispute.
+type Dispute struct {
+	ID                 string      `json:"id" db:"id"`
+	Amount             int64       `json:"amount" db:"amount"`
+	ChargeID           string      `json:"chargeId" db:"charge_id"`
+	Currency           string      `json:"currency" db:"currency"`
+	CustomerID         null.String `json:"customerId" db:"customer_id"`
+	EvidenceDueBy      chrono.Time `json:"evidenceDueBy" db:"evidence_due_by"` // Zero if response is not allowed.
+	HasEvidence        bool        `json:"hasEvidence" db:"has_evidence"`
+	FtcUserID          null.String `json:"ftcUserId" db:"ftc_user_id"`
+	InvoiceID          null.String `json:"invoiceId" db:"invoice_id"`
+	IsChargeRefundable bool        `json:"isChargeRefundable" db:"is_charge_refundable"`
+	LiveMode           bool        `json:"liveMode" db:"live_mode"`
+	PaymentIntentID    null.String `json:"paymentIntentId" db:"payment_intent_id"`
+	Reason             string      `json:"reason" db:"dispute_reason"`
+	Status             string      `json:"status" db:"dispute_status"`
+	SubsID             null.String `json:"subsId" db:"subs_id"`
+	Created            int64       `json:"created" db:"created"`
+}
+
+// NewDispute converts a dispute received from webhook.
+// The charge is retrieved separately with its invoice expanded
+// to find the subscription disputed. It might be nil.
+func NewDispute(d *stripe.Dispute, ch *stripe.Charge) Dispute {
+	var dueBy chrono.Time
+	var hasEvidence bool
+	if d.EvidenceDetails != nil {
+		if d.EvidenceDetails.DueBy > 0 {
+			dueBy = chrono.TimeFrom(dt.FromUnix(d.EvidenceDetails.DueBy))
+		}
+		hasEvidence = d.EvidenceDetails.HasEvidence
+	}
+
+	var chargeID string
+	if d.Charge != nil {
+		chargeID = d.Charge.ID
+	}
+
+	var piID string
+	if d.PaymentIntent != nil {
+		piID = d.PaymentIntent.ID
+	}
+
+	var cusID, invID, subsID string
+	if ch != nil {
+		if ch.Customer != nil {
+			cusID = ch.Customer.ID
+		}
+		if ch.Invoice != nil {
+			invID = ch.Invoice.ID
+			if ch.Invoice.Subscription != nil {
+				subsID = ch.Invoice.Subscription.ID
+			}
+		}
+	}
+
+	return Dispute{
+		ID:                 d.ID,
+		Amount:             d.Amount,
+		ChargeID:           chargeID,
+		Currency:           string(d.Currency),
+		CustomerID:         null.NewString(cusID, cusID != ""),
+		EvidenceDueBy:      dueBy,
+		HasEvidence:        hasEvidence,
+		IsChargeRefundable: d.IsChargeRefundable,
+		LiveMode:           d.Livemode,
+		PaymentIntentID:    null.NewString(piID, piID != ""),
+		InvoiceID:          null.NewString(invID, invID != ""),
+		Reason:             string(d.Reason),
+		Status:             string(d.Status),
+		SubsID:             null.NewString(subsID, subsID != ""),
+		Created:            d.Created,
+	}
+}
+
+func (d Dispute) WithFtcID(id string) Dispute {
+	d.FtcUserID = null.NewString(id, id != "")
+
+	return d
+}
+
+// ReadableAmount formats disputed amount in major unit.
+func (d Dispute) ReadableAmount() string {
+	return readableAmount(d.Currency, d.Amount)
+}
+
+// IsWon tells whether the dispute closed in our favor,
+// including an inquiry closed without escalating.
+func (d Dispute) IsWon() bool {
+	return d.Status == string(stripe.DisputeStatusWon) ||
+		d.Status == string(stripe.DisputeStatusWarningClosed)
+}
+
+func (d Dispute) IsClosed() bool {
+	return d.IsWon() ||
+		d.Status == string(stripe.DisputeStatusLost) ||
+		d.Status == string(stripe.DisputeStatusChargeRefunded)
+}
+
+// Flag marks the subscription and membership as disputed.
+func (d Dispute) Flag(suspend bool) reader.DisputeFlag {
+	return reader.DisputeFlag{
+		DisputeID:  d.ID,
+		Suspended:  suspend,
+		CreatedUTC: chrono.TimeFrom(dt.FromUnix(d.Created)),
+	}
+}
+
+// DashboardURL links to the dispute in Stripe dashboard
+// where evidence is submitted.
+func (d Dispute) DashboardURL() string {
+	if d.LiveMode {
+		return "https://dashboard.stripe.com/disputes/" + d.ID
+	}
+
+	return "https://dashboard.stripe.com/test/disputes/" + d.ID
+}
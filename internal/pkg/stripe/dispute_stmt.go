@@ -0,0 +1,71 @@
+package stripe
+
+const colUpsertDispute = `
+amount = :amount,
+charge_id = :charge_id,
+currency = :currency,
+customer_id = :customer_id,
+evidence_due_by = :evidence_due_by,
+has_evidence = :has_evidence,
+ftc_user_id = :ftc_user_id,
+invoice_id = :invoice_id,
+is_charge_refundable = :is_charge_refundable,
+live_mode = :live_mode,
+payment_intent_id = :payment_intent_id,
+dispute_reason = :dispute_reason,
+dispute_status = :dispute_status,
+subs_id = :subs_id,
+created = :created,
+updated_utc = UTC_TIMESTAMP()
+`
+
+const StmtUpsertDispute = `
+INSERT INTO premium.stripe_dispute
+SET id = :id,
+` + colUpsertDispute + `
+ON DUPLICATE KEY UPDATE
+` + colUpsertDispute
+
+const colSelectDispute = `
+SELECT id,
+	amount,
+	charge_id,
+	currency,
+	customer_id,
+	evidence_due_by,
+	has_evidence,
+	ftc_user_id,
+	invoice_id,
+	is_charge_refundable,
+	live_mode,
+	payment_intent_id,
+	dispute_reason,
+	dispute_status,
+	subs_id,
+	created
+FROM premium.stripe_dispute
+`
+
+// Disputes that are not closed yet.
+const whereOpenDispute = `
+WHERE live_mode = ?
+	AND dispute_status IN (
+		'warning_needs_response',
+		'warning_under_review',
+		'needs_response',
+		'under_review'
+	)
+`
+
+const StmtCountOpenDisputes = `
+SELECT COUNT(*)
+FROM premium.stripe_dispute
+` + whereOpenDispute
+
+// StmtListOpenDisputes lists disputes whose evidence is due
+// soonest first.
+const StmtListOpenDisputes = colSelectDispute +
+	whereOpenDispute + `
+ORDER BY evidence_due_by IS NULL, evidence_due_by ASC
+LIMIT ? OFFSET ?
+`
@@ -0,0 +1,80 @@
+package stripe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	stripeSdk "github.com/stripe/stripe-go/v72"
+)
+
+func mockRawDispute(status stripeSdk.DisputeStatus) *stripeSdk.Dispute {
+	return &stripeSdk.Dispute{
+		ID:       "dp_test",
+		Amount:   3900,
+		Charge:   &stripeSdk.Charge{ID: "ch_test"},
+		Currency: "gbp",
+		EvidenceDetails: &stripeSdk.EvidenceDetails{
+			DueBy: time.Now().AddDate(0, 0, 7).Unix(),
+		},
+		Reason:  stripeSdk.DisputeReasonFraudulent,
+		Status:  status,
+		Created: time.Now().Unix(),
+	}
+}
+
+func TestNewDispute(t *testing.T) {
+	ch := &stripeSdk.Charge{
+		ID:       "ch_test",
+		Customer: &stripeSdk.Customer{ID: "cus_test"},
+		Invoice: &stripeSdk.Invoice{
+			ID:           "in_test",
+			Subscription: &stripeSdk.Subscription{ID: "sub_test"},
+		},
+	}
+
+	d := NewDispute(mockRawDispute(stripeSdk.DisputeStatusNeedsResponse), ch)
+
+	assert.Equal(t, "ch_test", d.ChargeID)
+	assert.Equal(t, "cus_test", d.CustomerID.String)
+	assert.Equal(t, "in_test", d.InvoiceID.String)
+	assert.Equal(t, "sub_test", d.SubsID.String)
+	assert.False(t, d.EvidenceDueBy.IsZero())
+	assert.Equal(t, "£39.00", d.ReadableAmount())
+
+	d = NewDispute(mockRawDispute(stripeSdk.DisputeStatusNeedsResponse), nil)
+	assert.False(t, d.SubsID.Valid)
+}
+
+func TestDispute_IsWon(t *testing.T) {
+	tests := []struct {
+		status stripeSdk.DisputeStatus
+		won    bool
+		closed bool
+	}{
+		{stripeSdk.DisputeStatusNeedsResponse, false, false},
+		{stripeSdk.DisputeStatusUnderReview, false, false},
+		{stripeSdk.DisputeStatusWon, true, true},
+		{stripeSdk.DisputeStatusWarningClosed, true, true},
+		{stripeSdk.DisputeStatusLost, false, true},
+		{stripeSdk.DisputeStatusChargeRefunded, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			d := NewDispute(mockRawDispute(tt.status), nil)
+			assert.Equal(t, tt.won, d.IsWon())
+			assert.Equal(t, tt.closed, d.IsClosed())
+		})
+	}
+}
+
+func TestDispute_Flag(t *testing.T) {
+	d := NewDispute(mockRawDispute(stripeSdk.DisputeStatusNeedsResponse), nil)
+
+	f := d.Flag(true)
+	assert.Equal(t, "dp_test", f.DisputeID)
+	assert.True(t, f.Suspended)
+	assert.Equal(t, d.Created, f.CreatedUTC.Unix())
+
+	assert.Contains(t, d.DashboardURL(), "/test/disputes/dp_test")
+}
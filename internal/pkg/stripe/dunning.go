@@ -0,0 +1,186 @@
+package stripe
+
+import (
+	"strconv"
+
+	"github.com/FTChinese/go-rest/chrono"
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/lib/dt"
+	"github.com/FTChinese/subscription-api/lib/validator"
+	"github.com/FTChinese/subscription-api/pkg/conv"
+	"github.com/FTChinese/subscription-api/pkg/price"
+	"github.com/stripe/stripe-go/v72"
+)
+
+type DunningStatus string
+
+const (
+	DunningStatusOpen      DunningStatus = "open"      // Stripe is still retrying.
+	DunningStatusRecovered DunningStatus = "recovered" // Invoice paid eventually.
+	DunningStatusExhausted DunningStatus = "exhausted" // Stripe gave up and canceled or marked the subscription unpaid.
+)
+
+// Dunning tracks the recovery of a subscription invoice
+// Stripe failed to charge, from the first failed attempt until
+// it is paid or Stripe stops retrying.
+// Saved in premium.stripe_dunning, one row per invoice.
+type Dunning struct {
+	InvoiceID        string        `json:"invoiceId" db:"invoice_id"`
+	SubsID           string        `json:"subsId" db:"subs_id"`
+	CustomerID       string        `json:"customerId" db:"customer_id"`
+	Number           string        `json:"number" db:"identity_number"`
+	AmountDue        int64         `json:"amountDue" db:"amount_due"`
+	Currency         string        `json:"currency" db:"currency"`
+	AttemptCount     int64         `json:"attemptCount" db:"attempt_count"`
+	NextAttemptUTC   chrono.Time   `json:"nextAttemptUtc" db:"next_attempt_utc"`
+	HostedInvoiceURL string        `json:"hostedInvoiceUrl" db:"hosted_invoice_url"`
+	ActionRequired   bool          `json:"actionRequired" db:"action_required"` // Card issuer requires 3DS authentication.
+	Status           DunningStatus `json:"status" db:"dunning_status"`
+	LiveMode         bool          `json:"liveMode" db:"live_mode"`
+	CreatedUTC       chrono.Time   `json:"createdUtc" db:"created_utc"`
+	UpdatedUTC       chrono.Time   `json:"updatedUtc" db:"updated_utc"`
+	ResolvedUTC      chrono.Time   `json:"resolvedUtc" db:"resolved_utc"`
+}
+
+// NewDunning records a failed attempt to pay an invoice
+// received from invoice.payment_failed or
+// invoice.payment_action_required.
+func NewDunning(inv Invoice, actionRequired bool) Dunning {
+	var next chrono.Time
+	if inv.NextPaymentAttempt > 0 {
+		next = chrono.TimeFrom(dt.FromUnix(inv.NextPaymentAttempt))
+	}
+
+	now := chrono.TimeNow()
+
+	return Dunning{
+		InvoiceID:        inv.ID,
+		SubsID:           inv.SubscriptionID.String,
+		CustomerID:       inv.CustomerID,
+		Number:           inv.Number,
+		AmountDue:        inv.AmountDue,
+		Currency:         inv.Currency,
+		AttemptCount:     inv.AttemptCount,
+		NextAttemptUTC:   next,
+		HostedInvoiceURL: inv.HostedInvoiceURL,
+		ActionRequired:   actionRequired,
+		Status:           DunningStatusOpen,
+		LiveMode:         inv.LiveMode,
+		CreatedUTC:       now,
+		UpdatedUTC:       now,
+	}
+}
+
+// ReadableAmount formats amount due in major unit, e.g. £39.00.
+func (d Dunning) ReadableAmount() string {
+	return readableAmount(d.Currency, d.AmountDue)
+}
+
+// readableAmount converts an amount in minor unit of
+// currency, as Stripe uses, to a human-readable string.
+func readableAmount(currency string, amount int64) string {
+	c := price.Currency(currency)
+	if c == price.CurrencyJPY {
+		return c.Symbol() + strconv.FormatInt(amount, 10)
+	}
+
+	return c.Symbol() + conv.FormatMoney(float64(amount)/100)
+}
+
+func (d Dunning) IsZero() bool {
+	return d.InvoiceID == ""
+}
+
+// IsNewAttempt tells whether user should be notified of
+// this failure compared to the one previously recorded.
+// Stripe might deliver the same event more than once, and
+// both events of an attempt requiring authentication.
+func (d Dunning) IsNewAttempt(prev Dunning) bool {
+	if prev.IsZero() {
+		return true
+	}
+
+	if d.AttemptCount > prev.AttemptCount {
+		return true
+	}
+
+	return d.ActionRequired && !prev.ActionRequired
+}
+
+// WithPrior keeps the fields of the previously recorded
+// attempt that a later event should not override.
+func (d Dunning) WithPrior(prev Dunning) Dunning {
+	if prev.IsZero() {
+		return d
+	}
+
+	d.CreatedUTC = prev.CreatedUTC
+	// Both events of the same attempt might arrive in any order.
+	if d.AttemptCount == prev.AttemptCount {
+		d.ActionRequired = d.ActionRequired || prev.ActionRequired
+	}
+
+	return d
+}
+
+// Resolved closes dunning of an invoice.
+func (d Dunning) Resolved(s DunningStatus) Dunning {
+	now := chrono.TimeNow()
+	d.Status = s
+	d.UpdatedUTC = now
+	d.ResolvedUTC = now
+
+	return d
+}
+
+// RequiresDunning checks whether a failed invoice should
+// enter dunning. Only invoices of an existing subscription
+// are retried by Stripe off-session. Failure of the first
+// invoice is handled by client while user is present.
+func (i Invoice) RequiresDunning() bool {
+	return i.SubscriptionID.Valid &&
+		i.BillingReason.String != string(stripe.InvoiceBillingReasonSubscriptionCreate)
+}
+
+// IsUnpaid checks whether an invoice is left unpaid after
+// Stripe stopped collecting it.
+func (i Invoice) IsUnpaid() bool {
+	return i.Status.InvoiceStatus == stripe.InvoiceStatusOpen ||
+		i.Status.InvoiceStatus == stripe.InvoiceStatusUncollectible
+}
+
+// RetryPaymentParams is the request body to pay the open
+// latest invoice of a subscription with another payment method.
+type RetryPaymentParams struct {
+	PaymentMethod  string `json:"paymentMethod"`
+	IdempotencyKey string `json:"idempotency"`
+}
+
+func (p RetryPaymentParams) Validate() *render.ValidationError {
+	return validator.New("paymentMethod").Required().Validate(p.PaymentMethod)
+}
+
+// InvoicePayParams pays an invoice with the payment method
+// immediately instead of waiting for Stripe's next retry.
+func (p RetryPaymentParams) InvoicePayParams() *stripe.InvoicePayParams {
+	params := &stripe.InvoicePayParams{
+		PaymentMethod: stripe.String(p.PaymentMethod),
+	}
+
+	if p.IdempotencyKey != "" {
+		params.SetIdempotencyKey(p.IdempotencyKey)
+	}
+
+	params.AddExpand(KeyPaymentIntent)
+
+	return params
+}
+
+// RetryPaymentResult is returned after paying an open invoice.
+// Client should handle the payment intent's next action if
+// authentication is required.
+type RetryPaymentResult struct {
+	Invoice       Invoice       `json:"invoice"`
+	PaymentIntent PaymentIntent `json:"paymentIntent"`
+	Subs          Subs          `json:"subs"`
+}
@@ -0,0 +1,66 @@
+package stripe
+
+const colUpsertDunning = `
+subs_id = :subs_id,
+customer_id = :customer_id,
+identity_number = :identity_number,
+amount_due = :amount_due,
+currency = :currency,
+attempt_count = :attempt_count,
+next_attempt_utc = :next_attempt_utc,
+hosted_invoice_url = :hosted_invoice_url,
+action_required = :action_required,
+dunning_status = :dunning_status,
+live_mode = :live_mode,
+updated_utc = :updated_utc
+`
+
+const StmtUpsertDunning = `
+INSERT INTO premium.stripe_dunning
+SET invoice_id = :invoice_id,
+	created_utc = :created_utc,
+` + colUpsertDunning + `
+ON DUPLICATE KEY UPDATE
+` + colUpsertDunning
+
+const colSelectDunning = `
+SELECT invoice_id,
+	subs_id,
+	customer_id,
+	identity_number,
+	amount_due,
+	currency,
+	attempt_count,
+	next_attempt_utc,
+	hosted_invoice_url,
+	action_required,
+	dunning_status,
+	live_mode,
+	created_utc,
+	updated_utc,
+	resolved_utc
+FROM premium.stripe_dunning
+`
+
+const StmtRetrieveDunning = colSelectDunning + `
+WHERE invoice_id = ?
+LIMIT 1
+`
+
+// StmtOpenDunning retrieves the invoice of a subscription
+// Stripe is still trying to collect.
+const StmtOpenDunning = colSelectDunning + `
+WHERE subs_id = ?
+	AND dunning_status = 'open'
+ORDER BY created_utc DESC
+LIMIT 1
+`
+
+const StmtResolveDunning = `
+UPDATE premium.stripe_dunning
+SET dunning_status = :dunning_status,
+	updated_utc = :updated_utc,
+	resolved_utc = :resolved_utc
+WHERE invoice_id = :invoice_id
+LIMIT 1
+`
@@ -0,0 +1,95 @@
+package stripe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FTChinese/go-rest/chrono"
+	"github.com/FTChinese/go-rest/enum"
+	"github.com/guregu/null"
+	"github.com/stretchr/testify/assert"
+	stripeSdk "github.com/stripe/stripe-go/v72"
+)
+
+func mockFailedInvoice(attempt int64) Invoice {
+	return Invoice{
+		ID:                 "in_test",
+		AmountDue:          3900,
+		AttemptCount:       attempt,
+		BillingReason:      null.StringFrom(string(stripeSdk.InvoiceBillingReasonSubscriptionCycle)),
+		Currency:           "gbp",
+		CustomerID:         "cus_test",
+		HostedInvoiceURL:   "https://invoice.stripe.com/i/test",
+		NextPaymentAttempt: time.Now().AddDate(0, 0, 3).Unix(),
+		Status:             InvoiceStatus{stripeSdk.InvoiceStatusOpen},
+		SubscriptionID:     null.StringFrom("sub_test"),
+	}
+}
+
+func TestDunning_IsNewAttempt(t *testing.T) {
+	first := NewDunning(mockFailedInvoice(1), false)
+
+	assert.True(t, first.IsNewAttempt(Dunning{}))
+	assert.False(t, first.IsNewAttempt(first), "duplicate event")
+	assert.True(t, NewDunning(mockFailedInvoice(2), false).IsNewAttempt(first))
+	assert.True(t, NewDunning(mockFailedInvoice(1), true).IsNewAttempt(first), "authentication required")
+	assert.False(t, first.IsNewAttempt(NewDunning(mockFailedInvoice(1), true)))
+}
+
+func TestDunning_WithPrior(t *testing.T) {
+	prev := NewDunning(mockFailedInvoice(1), true)
+	prev.CreatedUTC = chrono.TimeFrom(time.Now().AddDate(0, 0, -1))
+
+	d := NewDunning(mockFailedInvoice(1), false).WithPrior(prev)
+	assert.True(t, d.ActionRequired)
+	assert.Equal(t, prev.CreatedUTC, d.CreatedUTC)
+
+	d = NewDunning(mockFailedInvoice(2), false).WithPrior(prev)
+	assert.False(t, d.ActionRequired)
+}
+
+func TestDunning_ReadableAmount(t *testing.T) {
+	d := NewDunning(mockFailedInvoice(1), false)
+	assert.Equal(t, "£39.00", d.ReadableAmount())
+
+	d.Currency = "jpy"
+	d.AmountDue = 3900
+	assert.Equal(t, "¥3900", d.ReadableAmount())
+}
+
+func TestInvoice_RequiresDunning(t *testing.T) {
+	inv := mockFailedInvoice(1)
+	assert.True(t, inv.RequiresDunning())
+
+	inv.BillingReason = null.StringFrom(string(stripeSdk.InvoiceBillingReasonSubscriptionCreate))
+	assert.False(t, inv.RequiresDunning())
+
+	inv = mockFailedInvoice(1)
+	inv.SubscriptionID = null.String{}
+	assert.False(t, inv.RequiresDunning())
+}
+
+func TestSubs_ExpiresAt_dunning(t *testing.T) {
+	periodStart := time.Now().AddDate(0, 0, -20).Truncate(time.Second)
+	periodEnd := periodStart.AddDate(1, 0, 0)
+	canceled := time.Now().Truncate(time.Second)
+
+	subs := Subs{
+		CurrentPeriodStart: chrono.TimeFrom(periodStart),
+		CurrentPeriodEnd:   chrono.TimeFrom(periodEnd),
+		LatestInvoice:      mockFailedInvoice(4),
+	}
+
+	subs.Status = enum.SubsStatusPastDue
+	assert.Equal(t, periodEnd, subs.ExpiresAt(), "grace period while retrying")
+
+	subs.Status = enum.SubsStatusUnpaid
+	assert.Equal(t, periodStart, subs.ExpiresAt())
+
+	subs.Status = enum.SubsStatusCanceled
+	subs.CanceledUTC = chrono.TimeFrom(canceled)
+	assert.Equal(t, periodStart, subs.ExpiresAt())
+
+	subs.LatestInvoice.Status = InvoiceStatus{stripeSdk.InvoiceStatusPaid}
+	assert.Equal(t, canceled, subs.ExpiresAt(), "canceled manually")
+}
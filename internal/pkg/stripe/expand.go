@@ -2,4 +2,7 @@ package stripe
 
 const (
 	KeyLatestInvoicePaymentIntent = "latest_invoice.payment_intent"
+	KeySchedulePhasePrice         = "phases.items.price"
+	KeyPaymentIntent              = "payment_intent"
+	KeyInvoice                    = "invoice"
 )
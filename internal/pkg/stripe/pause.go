@@ -0,0 +1,127 @@
+package stripe
+
+import (
+	"time"
+
+	"github.com/FTChinese/go-rest/chrono"
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/pkg/reader"
+	"github.com/stripe/stripe-go/v72"
+)
+
+// ErrSubsPaused forbids canceling or reactivating a paused
+// subscription. User should resume it first.
+var ErrSubsPaused = &render.ValidationError{
+	Message: "Subscription is paused. Resume it first",
+	Field:   "pause",
+	Code:    render.CodeInvalid,
+}
+
+// MaxPauseDays limits how long a subscription could be paused
+// with a resume date.
+const MaxPauseDays = 90
+
+// PauseParams is the request body to pause payment collection
+// of a subscription.
+// Behavior defaults to void so that no invoice is left for
+// user to pay after resuming.
+// If ResumesAt is zero, user should resume it manually.
+type PauseParams struct {
+	Behavior  stripe.SubscriptionPauseCollectionBehavior `json:"behavior"`
+	ResumesAt chrono.Time                                `json:"resumesAt"`
+}
+
+func (p *PauseParams) Validate() *render.ValidationError {
+	switch p.Behavior {
+	case "":
+		p.Behavior = stripe.SubscriptionPauseCollectionBehaviorVoid
+
+	case stripe.SubscriptionPauseCollectionBehaviorVoid,
+		stripe.SubscriptionPauseCollectionBehaviorKeepAsDraft:
+
+	default:
+		return &render.ValidationError{
+			Message: "Behavior must be one of void or keep_as_draft",
+			Field:   "behavior",
+			Code:    render.CodeInvalid,
+		}
+	}
+
+	if p.ResumesAt.IsZero() {
+		return nil
+	}
+
+	now := time.Now()
+	if !p.ResumesAt.After(now) {
+		return &render.ValidationError{
+			Message: "Resume date must be in the future",
+			Field:   "resumesAt",
+			Code:    render.CodeInvalid,
+		}
+	}
+
+	if p.ResumesAt.After(now.AddDate(0, 0, MaxPauseDays)) {
+		return &render.ValidationError{
+			Message: "Subscription could be paused for at most 90 days",
+			Field:   "resumesAt",
+			Code:    render.CodeInvalid,
+		}
+	}
+
+	return nil
+}
+
+// SubsParams builds the parameters to pause collection.
+func (p PauseParams) SubsParams() *stripe.SubscriptionParams {
+	pc := &stripe.SubscriptionPauseCollectionParams{
+		Behavior: stripe.String(string(p.Behavior)),
+	}
+	if !p.ResumesAt.IsZero() {
+		pc.ResumesAt = stripe.Int64(p.ResumesAt.Unix())
+	}
+
+	params := &stripe.SubscriptionParams{
+		PauseCollection: pc,
+	}
+	params.AddExpand(KeyLatestInvoicePaymentIntent)
+
+	return params
+}
+
+// ResumeParams builds the parameters to resume collection of a
+// paused subscription.
+// If the paid period is already over, billing cycle restarts
+// now so that user pays before getting access again, rather
+// than enjoying the rest of a period not charged.
+func ResumeParams(w reader.PauseWindow) *stripe.SubscriptionParams {
+	params := &stripe.SubscriptionParams{}
+	if !w.PaidThroughUTC.After(time.Now()) {
+		params = RestartCycleParams()
+	} else {
+		params.AddExpand(KeyLatestInvoicePaymentIntent)
+	}
+	// Empty value unsets pause_collection.
+	params.AddExtra("pause_collection", "")
+
+	return params
+}
+
+// RestartCycleParams starts a new billing period now and
+// charges it in full, without crediting the period not paid.
+func RestartCycleParams() *stripe.SubscriptionParams {
+	params := &stripe.SubscriptionParams{
+		BillingCycleAnchorNow: stripe.Bool(true),
+		ProrationBehavior:     stripe.String(string(stripe.SubscriptionProrationBehaviorNone)),
+	}
+	params.AddExpand(KeyLatestInvoicePaymentIntent)
+
+	return params
+}
+
+// IsSkippedByPause tells whether the invoice might be created
+// while collection was paused and thus never charged.
+func (i Invoice) IsSkippedByPause() bool {
+	return i.Status.InvoiceStatus == stripe.InvoiceStatusVoid ||
+		i.Status.InvoiceStatus == stripe.InvoiceStatusDraft ||
+		i.Status.InvoiceStatus == stripe.InvoiceStatusUncollectible
+}
@@ -0,0 +1,85 @@
+package stripe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FTChinese/go-rest/chrono"
+	"github.com/FTChinese/go-rest/enum"
+	"github.com/FTChinese/subscription-api/pkg/addon"
+	"github.com/FTChinese/subscription-api/pkg/ids"
+	"github.com/FTChinese/subscription-api/pkg/reader"
+	"github.com/stretchr/testify/assert"
+	stripeSdk "github.com/stripe/stripe-go/v72"
+)
+
+func TestPauseParams_Validate(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		params  PauseParams
+		wantErr bool
+	}{
+		{"Defaults", PauseParams{}, false},
+		{"Keep as draft", PauseParams{Behavior: stripeSdk.SubscriptionPauseCollectionBehaviorKeepAsDraft}, false},
+		{"Resume date", PauseParams{ResumesAt: chrono.TimeFrom(now.AddDate(0, 0, 30))}, false},
+		{"Mark uncollectible", PauseParams{Behavior: stripeSdk.SubscriptionPauseCollectionBehaviorMarkUncollectible}, true},
+		{"Resume date in the past", PauseParams{ResumesAt: chrono.TimeFrom(now.AddDate(0, 0, -1))}, true},
+		{"Resume date too far", PauseParams{ResumesAt: chrono.TimeFrom(now.AddDate(0, 0, MaxPauseDays+1))}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ve := tt.params.Validate()
+			assert.Equal(t, tt.wantErr, ve != nil)
+		})
+	}
+
+	p := PauseParams{}
+	_ = p.Validate()
+	assert.Equal(t, stripeSdk.SubscriptionPauseCollectionBehaviorVoid, p.Behavior)
+}
+
+func TestSubs_pauseWindow(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	s := Subs{
+		CurrentPeriodStart: chrono.TimeFrom(now.AddDate(0, 0, -10)),
+		CurrentPeriodEnd:   chrono.TimeFrom(now.AddDate(0, 0, 20)),
+		Status:             enum.SubsStatusActive,
+		LatestInvoice:      Invoice{Paid: true},
+	}
+	pc := stripeSdk.SubscriptionPauseCollection{
+		Behavior: stripeSdk.SubscriptionPauseCollectionBehaviorVoid,
+	}
+
+	assert.True(t, s.pauseWindow(stripeSdk.SubscriptionPauseCollection{}).IsZero())
+
+	// Paused within a paid period.
+	s.Pause = s.pauseWindow(pc)
+	assert.True(t, s.CurrentPeriodEnd.Equal(s.ExpiresAt()))
+	assert.False(t, s.IsAutoRenewal())
+
+	// Renewal invoice voided during pause.
+	s.LatestInvoice = Invoice{}
+	s.Pause = s.pauseWindow(pc)
+	assert.True(t, s.CurrentPeriodStart.Equal(s.ExpiresAt()))
+
+	m := s.BuildMembership(ids.UserIDs{}, addon.AddOn{})
+	assert.Equal(t, s.Pause, m.Pause)
+	assert.False(t, m.AutoRenewal)
+}
+
+func TestResumeParams(t *testing.T) {
+	within := ResumeParams(reader.PauseWindow{
+		Behavior:       "void",
+		PaidThroughUTC: chrono.TimeFrom(time.Now().AddDate(0, 0, 5)),
+	})
+	assert.Nil(t, within.BillingCycleAnchorNow)
+	assert.Equal(t, "", within.Extra.Get("pause_collection"))
+
+	over := ResumeParams(reader.PauseWindow{
+		Behavior:       "void",
+		PaidThroughUTC: chrono.TimeFrom(time.Now().AddDate(0, 0, -5)),
+	})
+	assert.True(t, *over.BillingCycleAnchorNow)
+	assert.Contains(t, over.Extra.Values, "pause_collection")
+}
@@ -0,0 +1,256 @@
+package stripe
+
+import (
+	"time"
+
+	"github.com/FTChinese/go-rest/chrono"
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/lib/dt"
+	"github.com/FTChinese/subscription-api/pkg/reader"
+	"github.com/guregu/null"
+	"github.com/stripe/stripe-go/v72"
+)
+
+// RefundExpiry determines how membership is adjusted after
+// an invoice is refunded by staff.
+type RefundExpiry string
+
+const (
+	RefundExpiryKeep     RefundExpiry = ""         // Membership not touched.
+	RefundExpiryNow      RefundExpiry = "now"      // Expires immediately. Requires canceling subscription.
+	RefundExpiryProrated RefundExpiry = "prorated" // Current period shortened by the share refunded.
+)
+
+func (e RefundExpiry) IsValid() bool {
+	return e == RefundExpiryKeep ||
+		e == RefundExpiryNow ||
+		e == RefundExpiryProrated
+}
+
+// RefundParams is the request body for staff to refund an
+// invoice fully or partially.
+// Membership is derived from subscription, so it can only
+// expire together with the subscription:
+// - CancelSubs cancels subscription immediately, and
+// membership expires now;
+// - Expire prorated sets subscription to cancel at a date
+// computed from the share refunded, and membership expires then.
+type RefundParams struct {
+	Amount         int64        `json:"amount"` // In minor unit. 0 refunds all remaining.
+	Reason         string       `json:"reason"`
+	CancelSubs     bool         `json:"cancelSubs"`
+	Expire         RefundExpiry `json:"expire"`
+	IdempotencyKey string       `json:"idempotency"`
+}
+
+func (p RefundParams) Validate() *render.ValidationError {
+	if p.Amount < 0 {
+		return &render.ValidationError{
+			Message: "Refund amount must not be negative",
+			Field:   "amount",
+			Code:    render.CodeInvalid,
+		}
+	}
+
+	switch stripe.RefundReason(p.Reason) {
+	case "",
+		stripe.RefundReasonDuplicate,
+		stripe.RefundReasonFraudulent,
+		stripe.RefundReasonRequestedByCustomer:
+	default:
+		return &render.ValidationError{
+			Message: "Reason must be one of duplicate, fraudulent or requested_by_customer",
+			Field:   "reason",
+			Code:    render.CodeInvalid,
+		}
+	}
+
+	if !p.Expire.IsValid() {
+		return &render.ValidationError{
+			Message: "Expire must be one of now or prorated",
+			Field:   "expire",
+			Code:    render.CodeInvalid,
+		}
+	}
+
+	if p.CancelSubs && p.Expire == RefundExpiryProrated {
+		return &render.ValidationError{
+			Message: "Subscription canceled immediately cannot expire at a prorated date",
+			Field:   "expire",
+			Code:    render.CodeInvalid,
+		}
+	}
+
+	if !p.CancelSubs && p.Expire == RefundExpiryNow {
+		return &render.ValidationError{
+			Message: "Membership expiring now requires canceling subscription",
+			Field:   "cancelSubs",
+			Code:    render.CodeMissingField,
+		}
+	}
+
+	return nil
+}
+
+// AdjustsSubs tells whether subscription and membership
+// are modified after refund.
+func (p RefundParams) AdjustsSubs() bool {
+	return p.CancelSubs || p.Expire != RefundExpiryKeep
+}
+
+// RefundAmount determines the amount to refund from what
+// remains unrefunded of a charge.
+func (p RefundParams) RefundAmount(ch *stripe.Charge) (int64, *render.ValidationError) {
+	remaining := ch.Amount - ch.AmountRefunded
+	if remaining <= 0 {
+		return 0, &render.ValidationError{
+			Message: "Invoice is already fully refunded",
+			Field:   "invoice",
+			Code:    render.CodeAlreadyExists,
+		}
+	}
+
+	if p.Amount == 0 {
+		return remaining, nil
+	}
+
+	if p.Amount > remaining {
+		return 0, &render.ValidationError{
+			Message: "Refund amount exceeds what remains of the payment",
+			Field:   "amount",
+			Code:    render.CodeInvalid,
+		}
+	}
+
+	return p.Amount, nil
+}
+
+// RefundParams builds parameters to refund the charge.
+// Staff name is kept in metadata for reference in dashboard.
+func (p RefundParams) RefundParams(chargeID string, amount int64, staff string) *stripe.RefundParams {
+	params := &stripe.RefundParams{
+		Amount: stripe.Int64(amount),
+		Charge: stripe.String(chargeID),
+	}
+	if p.Reason != "" {
+		params.Reason = stripe.String(p.Reason)
+	}
+	params.AddMetadata("staff", staff)
+
+	if p.IdempotencyKey != "" {
+		params.SetIdempotencyKey(p.IdempotencyKey)
+	}
+
+	return params
+}
+
+// ValidateSubs checks the subscription an invoice belongs to.
+// Only the period of the latest invoice could be shortened
+// by a prorated refund.
+func (p RefundParams) ValidateSubs(s Subs, inv Invoice) *render.ValidationError {
+	if p.Expire == RefundExpiryProrated && s.LatestInvoiceID != inv.ID {
+		return &render.ValidationError{
+			Message: "Only the latest invoice of a subscription could be refunded with prorated expiry",
+			Field:   "expire",
+			Code:    render.CodeInvalid,
+		}
+	}
+
+	return nil
+}
+
+// ProratedExpiry shortens current period of a subscription
+// by the share of the charge refunded so far.
+func ProratedExpiry(s Subs, refunded int64, paid int64) time.Time {
+	if paid <= 0 || refunded >= paid {
+		return time.Now()
+	}
+
+	start := s.CurrentPeriodStart.Time
+	period := s.CurrentPeriodEnd.Sub(start)
+	kept := time.Duration(float64(period) * float64(paid-refunded) / float64(paid))
+
+	return start.Add(kept)
+}
+
+// CancelAtParams stops a subscription at the specified time
+// without crediting the unused time, which is already refunded.
+func CancelAtParams(t time.Time) *stripe.SubscriptionParams {
+	return &stripe.SubscriptionParams{
+		CancelAt:          stripe.Int64(t.Unix()),
+		ProrationBehavior: stripe.String(string(stripe.SubscriptionProrationBehaviorNone)),
+	}
+}
+
+// Refund is a refund of an invoice made by staff.
+// Saved in premium.stripe_refund.
+type Refund struct {
+	ID         string       `json:"id" db:"id"`
+	Amount     int64        `json:"amount" db:"amount"`
+	ChargeID   string       `json:"chargeId" db:"charge_id"`
+	Currency   string       `json:"currency" db:"currency"`
+	InvoiceID  string       `json:"invoiceId" db:"invoice_id"`
+	SubsID     null.String  `json:"subsId" db:"subs_id"`
+	FtcUserID  null.String  `json:"ftcUserId" db:"ftc_user_id"`
+	Reason     string       `json:"reason" db:"refund_reason"`
+	Status     string       `json:"status" db:"refund_status"`
+	CancelSubs bool         `json:"cancelSubs" db:"cancel_subs"`
+	Expire     RefundExpiry `json:"expire" db:"expire_option"`
+	ExpireDate chrono.Date  `json:"expireDate" db:"expire_date"` // Zero if membership is not touched.
+	// Subscription and membership are yet to be adjusted.
+	// Still true if adjustment failed after money refunded,
+	// in which case staff should stop the subscription in
+	// Stripe dashboard.
+	AdjustPending bool        `json:"adjustPending" db:"adjust_pending"`
+	LiveMode      bool        `json:"liveMode" db:"live_mode"`
+	CreatedBy     string      `json:"createdBy" db:"created_by"`
+	CreatedUTC    chrono.Time `json:"createdUtc" db:"created_utc"`
+}
+
+func NewRefund(r *stripe.Refund, inv Invoice, params RefundParams, staff string) Refund {
+	return Refund{
+		ID:         r.ID,
+		Amount:     r.Amount,
+		ChargeID:   inv.ChargeID,
+		Currency:   string(r.Currency),
+		InvoiceID:  inv.ID,
+		SubsID:     inv.SubscriptionID,
+		Reason:     string(r.Reason),
+		Status:     string(r.Status),
+		CancelSubs: params.CancelSubs,
+		Expire:     params.Expire,
+		LiveMode:   inv.LiveMode,
+		CreatedBy:  staff,
+		CreatedUTC: chrono.TimeFrom(dt.FromUnix(r.Created)),
+		// Cleared after subscription and membership adjusted.
+		AdjustPending: params.AdjustsSubs(),
+	}
+}
+
+// WithMember records the user refunded.
+func (r Refund) WithMember(m reader.Membership) Refund {
+	r.FtcUserID = m.FtcID
+	return r
+}
+
+// Adjusted records when membership expires after subscription
+// is stopped.
+func (r Refund) Adjusted(m reader.Membership) Refund {
+	r.ExpireDate = m.ExpireDate
+	r.AdjustPending = false
+	return r
+}
+
+// ReadableAmount formats refunded amount in major unit.
+func (r Refund) ReadableAmount() string {
+	return readableAmount(r.Currency, r.Amount)
+}
+
+// RefundResult is returned after staff refunded an invoice.
+// Subs and Member are zero if subscription is not touched.
+type RefundResult struct {
+	Refund    Refund                     `json:"refund"`
+	Subs      Subs                       `json:"subs"`
+	Member    reader.Membership          `json:"membership"`
+	Versioned reader.MembershipVersioned `json:"-"`
+}
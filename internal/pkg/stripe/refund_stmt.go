@@ -0,0 +1,27 @@
+package stripe
+
+const StmtInsertRefund = `
+INSERT INTO premium.stripe_refund
+SET id = :id,
+	amount = :amount,
+	charge_id = :charge_id,
+	currency = :currency,
+	invoice_id = :invoice_id,
+	subs_id = :subs_id,
+	ftc_user_id = :ftc_user_id,
+	refund_reason = :refund_reason,
+	refund_status = :refund_status,
+	cancel_subs = :cancel_subs,
+	expire_option = :expire_option,
+	expire_date = :expire_date,
+	adjust_pending = :adjust_pending,
+	live_mode = :live_mode,
+	created_by = :created_by,
+	created_utc = :created_utc`
+
+const StmtRefundAdjusted = `
+UPDATE premium.stripe_refund
+SET expire_date = :expire_date,
+	adjust_pending = :adjust_pending
+WHERE id = :id
+LIMIT 1`
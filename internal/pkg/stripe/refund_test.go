@@ -0,0 +1,112 @@
+package stripe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FTChinese/go-rest/chrono"
+	"github.com/FTChinese/go-rest/enum"
+	"github.com/FTChinese/subscription-api/pkg/reader"
+	"github.com/stretchr/testify/assert"
+	stripeSdk "github.com/stripe/stripe-go/v72"
+)
+
+func TestRefundParams_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  RefundParams
+		wantErr bool
+	}{
+		{"Refund only", RefundParams{}, false},
+		{"Partial", RefundParams{Amount: 1000, Reason: "requested_by_customer"}, false},
+		{"Cancel now", RefundParams{CancelSubs: true, Expire: RefundExpiryNow}, false},
+		{"Cancel defaults to expire now", RefundParams{CancelSubs: true}, false},
+		{"Prorated", RefundParams{Expire: RefundExpiryProrated}, false},
+		{"Negative amount", RefundParams{Amount: -1}, true},
+		{"Unknown reason", RefundParams{Reason: "expired_uncaptured_charge"}, true},
+		{"Unknown expire", RefundParams{Expire: "tomorrow"}, true},
+		{"Cancel with prorated", RefundParams{CancelSubs: true, Expire: RefundExpiryProrated}, true},
+		{"Expire now without cancel", RefundParams{Expire: RefundExpiryNow}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ve := tt.params.Validate()
+			assert.Equal(t, tt.wantErr, ve != nil)
+		})
+	}
+}
+
+func TestRefundParams_RefundAmount(t *testing.T) {
+	ch := &stripeSdk.Charge{
+		Amount:         3900,
+		AmountRefunded: 900,
+	}
+
+	amount, ve := RefundParams{}.RefundAmount(ch)
+	assert.Nil(t, ve)
+	assert.Equal(t, int64(3000), amount)
+
+	amount, ve = RefundParams{Amount: 1000}.RefundAmount(ch)
+	assert.Nil(t, ve)
+	assert.Equal(t, int64(1000), amount)
+
+	_, ve = RefundParams{Amount: 3001}.RefundAmount(ch)
+	assert.NotNil(t, ve)
+
+	ch.AmountRefunded = 3900
+	_, ve = RefundParams{}.RefundAmount(ch)
+	assert.NotNil(t, ve)
+}
+
+func TestProratedExpiry(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := Subs{
+		CurrentPeriodStart: chrono.TimeFrom(start),
+		CurrentPeriodEnd:   chrono.TimeFrom(start.AddDate(0, 0, 100)),
+	}
+
+	assert.True(t, start.AddDate(0, 0, 75).Equal(ProratedExpiry(s, 1000, 4000)))
+	assert.WithinDuration(t, time.Now(), ProratedExpiry(s, 4000, 4000), time.Second)
+}
+
+func TestSubs_ExpiresAt_cancelAt(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	s := Subs{
+		WillCancelAtUtc:    chrono.TimeFrom(now.AddDate(0, 0, 10)),
+		CurrentPeriodStart: chrono.TimeFrom(now.AddDate(0, 0, -10)),
+		CurrentPeriodEnd:   chrono.TimeFrom(now.AddDate(0, 0, 20)),
+		Status:             enum.SubsStatusActive,
+	}
+
+	assert.True(t, s.WillCancelAtUtc.Equal(s.ExpiresAt()))
+	assert.False(t, s.IsAutoRenewal())
+
+	s.WillCancelAtUtc = chrono.Time{}
+	assert.True(t, s.CurrentPeriodEnd.Equal(s.ExpiresAt()))
+	assert.True(t, s.IsAutoRenewal())
+}
+
+func TestRefundParams_ValidateSubs(t *testing.T) {
+	s := Subs{LatestInvoiceID: "in_latest"}
+
+	assert.Nil(t, RefundParams{Expire: RefundExpiryProrated}.ValidateSubs(s, Invoice{ID: "in_latest"}))
+	assert.NotNil(t, RefundParams{Expire: RefundExpiryProrated}.ValidateSubs(s, Invoice{ID: "in_earlier"}))
+	assert.Nil(t, RefundParams{CancelSubs: true}.ValidateSubs(s, Invoice{ID: "in_earlier"}))
+}
+
+func TestRefund_Adjusted(t *testing.T) {
+	r := NewRefund(
+		&stripeSdk.Refund{ID: "re_test", Amount: 3900},
+		Invoice{ID: "in_test"},
+		RefundParams{CancelSubs: true},
+		"staff")
+	assert.True(t, r.AdjustPending)
+
+	m := reader.Membership{ExpireDate: chrono.DateNow()}
+	r = r.Adjusted(m)
+	assert.False(t, r.AdjustPending)
+	assert.Equal(t, m.ExpireDate, r.ExpireDate)
+
+	r = NewRefund(&stripeSdk.Refund{ID: "re_test"}, Invoice{ID: "in_test"}, RefundParams{}, "staff")
+	assert.False(t, r.AdjustPending)
+}
@@ -17,6 +17,7 @@ SET ftc_user_id = :ftc_user_id,
 	introductory_price = :introductory_price,
 	checkout_intent = :checkout_intent,
 	coupon = :coupon,
+	promotion_code_id = :promotion_code_id,
 	membership = :membership,
 	request_parameters = :request_parameters,
 	subs_id = :subs_id,
@@ -30,7 +31,8 @@ type ShoppingSession struct {
 	FtcUserID         string                  `db:"ftc_user_id"`
 	RecurringPrice    PriceColumn             `db:"recurring_price"`
 	IntroductoryPrice PriceColumn             `db:"introductory_price"`
-	Coupon            CouponColumn            `db:"coupon"`
+	Coupon            CouponColumn            `db:"coupon"` // Coupon of the promotion code if it is used.
+	PromoCodeID       null.String             `db:"promotion_code_id"`
 	Intent            reader.CheckoutIntent   `db:"checkout_intent"`
 	Membership        reader.MembershipColumn `db:"membership"`
 	RequestParams     SubsReqParamsColumn     `db:"request_parameters"`
@@ -44,7 +46,8 @@ func NewShoppingSession(cart reader.ShoppingCart, params SubsParams) ShoppingSes
 		FtcUserID:         cart.Account.FtcID,
 		RecurringPrice:    PriceColumn{cart.StripeItem.Recurring},
 		IntroductoryPrice: PriceColumn{cart.StripeItem.Introductory},
-		Coupon:            CouponColumn{cart.StripeItem.Coupon},
+		Coupon:            CouponColumn{cart.StripeItem.AppliedCoupon()},
+		PromoCodeID:       null.NewString(cart.StripeItem.PromoCode.ID, !cart.StripeItem.PromoCode.IsZero()),
 		Membership:        reader.MembershipColumn{Membership: cart.CurrentMember},
 		Intent:            cart.Intent,
 		RequestParams:     SubsReqParamsColumn{params},
@@ -70,6 +73,7 @@ func (s ShoppingSession) CouponRedeemed() CouponRedeemed {
 		LiveMode:    s.Coupon.LiveMode,
 		SubsID:      s.Subs.ID,
 		CouponID:    s.Coupon.ID,
+		PromoCodeID: s.PromoCodeID,
 		CreatedUTC:  chrono.TimeNow(),
 		RedeemedUTC: chrono.TimeNow(),
 	}
@@ -67,6 +67,16 @@ type Subs struct {
 	PaymentIntent PaymentIntent   `json:"paymentIntent"`
 	StartDateUTC  chrono.Time     `json:"startDateUtc" db:"start_date_utc"`
 	Status        enum.SubsStatus `json:"status" db:"sub_status"`
+	// Zero if subscription never had a trial.
+	TrialEndUTC chrono.Time `json:"trialEndUtc" db:"trial_end_utc"`
+	// Downgrade or interval switch taking effect at current period end.
+	// Not included when upserting subscription since Stripe's
+	// subscription object only carries the id of a schedule.
+	PendingChange reader.PendingChange `json:"pendingChange" db:"pending_change"`
+	// Set while payment of this subscription is disputed.
+	Dispute reader.DisputeFlag `json:"dispute" db:"dispute"`
+	// Set while payment collection is paused.
+	Pause reader.PauseWindow `json:"pause" db:"pause"`
 	// Time at which the object was created. Measured in seconds since the Unix epoch.
 	Created int64 `json:"-" db:"created"`
 
@@ -100,7 +110,7 @@ func NewSubs(ftcID string, ss *stripe.Subscription) Subs {
 		pi = NewPaymentIntent(ss.LatestInvoice.PaymentIntent)
 	}
 
-	return Subs{
+	subs := Subs{
 		IsFromStripe:           true,
 		ID:                     ss.ID,
 		Edition:                edition,
@@ -122,8 +132,34 @@ func NewSubs(ftcID string, ss *stripe.Subscription) Subs {
 		PaymentIntent:          pi,
 		StartDateUTC:           chrono.TimeFrom(dt.FromUnix(ss.StartDate)),
 		Status:                 status,
+		TrialEndUTC:            chrono.TimeFrom(dt.FromUnix(ss.TrialEnd)),
 		Created:                ss.Created,
 	}
+
+	subs.Pause = subs.pauseWindow(ss.PauseCollection)
+
+	return subs
+}
+
+// pauseWindow determines until when user has paid if payment
+// collection is paused.
+// Latest invoice must be expanded: if it is not paid, it is
+// the one of current period not collected due to pause.
+func (s Subs) pauseWindow(pc stripe.SubscriptionPauseCollection) reader.PauseWindow {
+	if pc.Behavior == "" {
+		return reader.PauseWindow{}
+	}
+
+	paidThrough := s.CurrentPeriodStart
+	if s.LatestInvoice.Paid {
+		paidThrough = s.CurrentPeriodEnd
+	}
+
+	return reader.PauseWindow{
+		Behavior:       string(pc.Behavior),
+		PaidThroughUTC: paidThrough,
+		ResumesAtUTC:   chrono.TimeFrom(dt.FromUnix(pc.ResumesAt)),
+	}
 }
 
 // ExpiresAt determines the exact expiration time.
@@ -134,9 +170,26 @@ func NewSubs(ftcID string, ss *stripe.Subscription) Subs {
 // For automatic cancel, canceled_at should be regarded as the final expiration time.
 // For manual cancel, the cancel_at_period_end is true. It will
 // expire upon current period end.
+// If Stripe gave up collecting payment of current period, either
+// marking it unpaid or canceling it, user is only entitled to
+// periods already paid, so current_period_start is used.
+// The same applies to a paused subscription.
 func (s Subs) ExpiresAt() time.Time {
+	if s.Status == enum.SubsStatusUnpaid {
+		return s.CurrentPeriodStart.Time
+	}
+
 	// If status is not in canceled state.
 	if s.Status != enum.SubsStatusCanceled {
+		// Periods after pause are not paid.
+		if !s.Pause.IsZero() {
+			return s.Pause.PaidThroughUTC.Time
+		}
+		// Set to cancel within current period after a
+		// prorated refund.
+		if !s.WillCancelAtUtc.IsZero() && s.WillCancelAtUtc.Before(s.CurrentPeriodEnd.Time) {
+			return s.WillCancelAtUtc.Time
+		}
 		return s.CurrentPeriodEnd.Time
 	}
 
@@ -144,6 +197,11 @@ func (s Subs) ExpiresAt() time.Time {
 	// If it is neither scheduled to cancel at period end, nor
 	// in a future time, use the canceled_at field.
 	if !s.CancelAtPeriodEnd && s.WillCancelAtUtc.IsZero() {
+		// Latest invoice must be expanded to tell whether it is
+		// canceled after dunning failed.
+		if s.LatestInvoice.IsUnpaid() && s.CurrentPeriodStart.Before(s.CanceledUTC.Time) {
+			return s.CurrentPeriodStart.Time
+		}
 		return s.CanceledUTC.Time
 	}
 
@@ -153,12 +211,12 @@ func (s Subs) ExpiresAt() time.Time {
 	}
 
 	// cancel_at is set, use it.
-	// This won't happen for our design.
+	// Only set by a prorated refund.
 	return s.WillCancelAtUtc.Time
 }
 
 func (s Subs) IsAutoRenewal() bool {
-	if s.CancelAtPeriodEnd {
+	if s.CancelAtPeriodEnd || !s.WillCancelAtUtc.IsZero() || !s.Pause.IsZero() {
 		return false
 	}
 
@@ -167,6 +225,21 @@ func (s Subs) IsAutoRenewal() bool {
 		s.Status == enum.SubsStatusTrialing
 }
 
+func (s Subs) IsTrialing() bool {
+	return s.Status == enum.SubsStatusTrialing
+}
+
+// ReadableRenewalAmount formats the amount of the price to be
+// charged upon next renewal, e.g., after trial ends.
+func (s Subs) ReadableRenewalAmount() string {
+	if len(s.Items) == 0 {
+		return ""
+	}
+
+	p := s.Items[0].Price
+	return readableAmount(string(p.Currency), p.UnitAmount)
+}
+
 func (s Subs) IsExpired() bool {
 	if s.IsAutoRenewal() {
 		return false
@@ -198,6 +271,12 @@ func (s Subs) ShouldUpsert(m reader.Membership) bool {
 	return false
 }
 
+func (s Subs) WithPendingChange(c reader.PendingChange) Subs {
+	s.PendingChange = c
+
+	return s
+}
+
 func (s Subs) WithFtcID(id string) Subs {
 	s.FtcUserID = null.StringFrom(id)
 
@@ -226,6 +305,8 @@ func (s Subs) BuildMembership(ids ids.UserIDs, addOn addon.AddOn) reader.Members
 		Status:        s.Status,
 		AppleSubsID:   null.String{},
 		B2BLicenceID:  null.String{},
+		PendingChange: s.PendingChange,
+		Pause:         s.Pause,
 		AddOn:         addOn,
 	}
 }
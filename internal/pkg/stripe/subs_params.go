@@ -12,12 +12,15 @@ import (
 
 // SubsParams is the request body to create a new subscription
 // or update an existing one.
-// IntroductoryPriceID and CouponID are mutually exclusive.
+// IntroductoryPriceID, CouponID and PromotionCode are mutually exclusive.
 // When an introductory price exists, a coupon should never be applied.
 type SubsParams struct {
 	PriceID             string      `json:"priceId"`
 	IntroductoryPriceID null.String `json:"introductoryPriceId"`
 	CouponID            null.String `json:"coupon"`
+	// The customer-facing code of a Stripe promotion code,
+	// not its id.
+	PromotionCode null.String `json:"promotionCode"`
 	// https://stripe.com/docs/api/subscriptions/create#create_subscription-default_payment_method
 	DefaultPaymentMethod null.String `json:"defaultPaymentMethod"`
 	// Generated by client. This is optional.
@@ -35,6 +38,14 @@ func (pr SubsParams) Validate() *render.ValidationError {
 		}
 	}
 
+	if pr.PromotionCode.Valid && (pr.IntroductoryPriceID.Valid || pr.CouponID.Valid) {
+		return &render.ValidationError{
+			Message: "promotion code cannot be used together with introductory price or coupon",
+			Field:   "promotionCode",
+			Code:    render.CodeInvalid,
+		}
+	}
+
 	return validator.New("priceId").Required().Validate(pr.PriceID)
 }
 
@@ -106,6 +117,13 @@ func (pr SubsParams) NewSubParams(cusID string, ci reader.CartItemStripe) *strip
 			ci.Introductory.PeriodCount.TotalDays())
 	} else if !ci.Coupon.IsZero() {
 		params.Coupon = stripeSdk.String(ci.Coupon.ID)
+	} else if !ci.PromoCode.IsZero() {
+		params.PromotionCode = stripeSdk.String(ci.PromoCode.ID)
+	}
+
+	// Coupon, if any, applies to invoices after trial.
+	if ci.TrialDays > 0 {
+		params.TrialEnd = stripeSdk.Int64(ci.TrialEnd().Unix())
 	}
 
 	// {
@@ -145,6 +163,8 @@ func (pr SubsParams) UpdateSubParams(itemID string, ci reader.CartItemStripe) *s
 	// a coupon is optional.
 	if !ci.Coupon.IsZero() {
 		params.Coupon = stripeSdk.String(ci.Coupon.ID)
+	} else if !ci.PromoCode.IsZero() {
+		params.PromotionCode = stripeSdk.String(ci.PromoCode.ID)
 	}
 
 	if pr.DefaultPaymentMethod.Valid {
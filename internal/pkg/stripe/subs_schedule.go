@@ -0,0 +1,131 @@
+package stripe
+
+import (
+	"github.com/FTChinese/go-rest/chrono"
+	"github.com/FTChinese/subscription-api/lib/dt"
+	"github.com/FTChinese/subscription-api/pkg/price"
+	"github.com/FTChinese/subscription-api/pkg/reader"
+	stripeSdk "github.com/stripe/stripe-go/v72"
+)
+
+// ScheduleParams builds the phases of a subscription schedule
+// so that a downgrade or interval switch takes effect at
+// current period end instead of immediately:
+// - Phase 1 keeps current price until current period end;
+// - Phase 2 charges the new price for one period, after which
+// the schedule releases the subscription to renew as usual.
+// The schedule must be created from the subscription, which
+// copies current price into its only phase.
+func (pr SubsParams) ScheduleParams(sched *stripeSdk.SubscriptionSchedule, ci reader.CartItemStripe) *stripeSdk.SubscriptionScheduleParams {
+	current := currentPhase(sched)
+
+	var items []*stripeSdk.SubscriptionSchedulePhaseItemParams
+	if current != nil {
+		for _, item := range current.Items {
+			if item.Price == nil {
+				continue
+			}
+			items = append(items, &stripeSdk.SubscriptionSchedulePhaseItemParams{
+				Price:    stripeSdk.String(item.Price.ID),
+				Quantity: stripeSdk.Int64(item.Quantity),
+			})
+		}
+	}
+
+	next := &stripeSdk.SubscriptionSchedulePhaseParams{
+		Items: []*stripeSdk.SubscriptionSchedulePhaseItemParams{
+			{
+				Price:    stripeSdk.String(ci.Recurring.ID),
+				Quantity: stripeSdk.Int64(1),
+			},
+		},
+		Iterations: stripeSdk.Int64(1),
+	}
+	// Phases only accept a coupon, not a promotion code.
+	if coupon := ci.AppliedCoupon(); !coupon.IsZero() {
+		next.Coupon = stripeSdk.String(coupon.ID)
+	}
+
+	params := &stripeSdk.SubscriptionScheduleParams{
+		EndBehavior: stripeSdk.String(string(stripeSdk.SubscriptionScheduleEndBehaviorRelease)),
+		Phases: []*stripeSdk.SubscriptionSchedulePhaseParams{
+			{
+				Items:     items,
+				StartDate: stripeSdk.Int64(sched.CurrentPhase.StartDate),
+				EndDate:   stripeSdk.Int64(sched.CurrentPhase.EndDate),
+			},
+			next,
+		},
+		ProrationBehavior: stripeSdk.String(string(stripeSdk.SubscriptionSchedulePhaseProrationBehaviorNone)),
+	}
+
+	if pr.IdempotencyKey != "" {
+		params.SetIdempotencyKey(pr.IdempotencyKey)
+	}
+
+	params.AddExpand(KeySchedulePhasePrice)
+
+	return params
+}
+
+// currentPhase finds the phase in effect now.
+func currentPhase(sched *stripeSdk.SubscriptionSchedule) *stripeSdk.SubscriptionSchedulePhase {
+	if sched.CurrentPhase == nil {
+		return nil
+	}
+
+	for _, p := range sched.Phases {
+		if p.StartDate == sched.CurrentPhase.StartDate {
+			return p
+		}
+	}
+
+	return nil
+}
+
+// NewPendingChange extracts the first phase after current one.
+// It is zero if the schedule is no longer active, or
+// the last phase is already in effect.
+// Prices of phase items must be expanded.
+func NewPendingChange(sched *stripeSdk.SubscriptionSchedule) reader.PendingChange {
+	if sched == nil ||
+		sched.Status != stripeSdk.SubscriptionScheduleStatusActive ||
+		sched.CurrentPhase == nil {
+		return reader.PendingChange{}
+	}
+
+	for _, p := range sched.Phases {
+		if p.StartDate < sched.CurrentPhase.EndDate {
+			continue
+		}
+
+		if len(p.Items) == 0 || p.Items[0].Price == nil {
+			continue
+		}
+
+		sp := price.NewStripePrice(p.Items[0].Price)
+
+		return reader.PendingChange{
+			ScheduleID:   sched.ID,
+			Edition:      sp.Edition(),
+			PriceID:      sp.ID,
+			EffectiveUTC: chrono.TimeFrom(dt.FromUnix(p.StartDate)),
+		}
+	}
+
+	return reader.PendingChange{}
+}
+
+// ScheduleSubsID gets the id of the subscription a schedule
+// manages, or the one it released.
+func ScheduleSubsID(sched *stripeSdk.SubscriptionSchedule) string {
+	if sched.Subscription != nil {
+		return sched.Subscription.ID
+	}
+
+	if sched.ReleasedSubscription != nil {
+		return sched.ReleasedSubscription.ID
+	}
+
+	return ""
+}
@@ -0,0 +1,99 @@
+package stripe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FTChinese/go-rest/enum"
+	"github.com/FTChinese/subscription-api/pkg/price"
+	"github.com/FTChinese/subscription-api/pkg/reader"
+	"github.com/stretchr/testify/assert"
+	stripeSdk "github.com/stripe/stripe-go/v72"
+)
+
+func mockSchedule() *stripeSdk.SubscriptionSchedule {
+	start := time.Now().AddDate(0, -1, 0).Unix()
+	end := time.Now().AddDate(0, 0, 5).Unix()
+
+	return &stripeSdk.SubscriptionSchedule{
+		ID:     "sub_sched_test",
+		Status: stripeSdk.SubscriptionScheduleStatusActive,
+		CurrentPhase: &stripeSdk.SubscriptionScheduleCurrentPhase{
+			StartDate: start,
+			EndDate:   end,
+		},
+		Phases: []*stripeSdk.SubscriptionSchedulePhase{
+			{
+				Items: []*stripeSdk.SubscriptionSchedulePhaseItem{
+					{
+						Price:    &stripeSdk.Price{ID: "price_premium"},
+						Quantity: 1,
+					},
+				},
+				StartDate: start,
+				EndDate:   end,
+			},
+			{
+				Items: []*stripeSdk.SubscriptionSchedulePhaseItem{
+					{
+						Price: &stripeSdk.Price{
+							ID:      "price_standard",
+							Product: &stripeSdk.Product{ID: "prod_test"},
+							Metadata: map[string]string{
+								"tier":  "standard",
+								"years": "1",
+							},
+							Recurring: &stripeSdk.PriceRecurring{
+								Interval:      stripeSdk.PriceRecurringIntervalYear,
+								IntervalCount: 1,
+							},
+						},
+						Quantity: 1,
+					},
+				},
+				StartDate: end,
+				EndDate:   time.Now().AddDate(1, 0, 5).Unix(),
+			},
+		},
+		Subscription: &stripeSdk.Subscription{ID: "sub_test"},
+	}
+}
+
+func TestNewPendingChange(t *testing.T) {
+	sched := mockSchedule()
+
+	got := NewPendingChange(sched)
+
+	assert.Equal(t, "sub_sched_test", got.ScheduleID)
+	assert.Equal(t, "price_standard", got.PriceID)
+	assert.Equal(t, enum.TierStandard, got.Tier)
+	assert.Equal(t, sched.CurrentPhase.EndDate, got.EffectiveUTC.Unix())
+
+	// Next phase started.
+	sched.CurrentPhase = &stripeSdk.SubscriptionScheduleCurrentPhase{
+		StartDate: sched.Phases[1].StartDate,
+		EndDate:   sched.Phases[1].EndDate,
+	}
+	assert.True(t, NewPendingChange(sched).IsZero())
+
+	sched = mockSchedule()
+	sched.Status = stripeSdk.SubscriptionScheduleStatusReleased
+	assert.True(t, NewPendingChange(sched).IsZero())
+}
+
+func TestSubsParams_ScheduleParams(t *testing.T) {
+	sched := mockSchedule()
+	recurring := price.MockRandomStripePrice()
+
+	got := SubsParams{PriceID: recurring.ID}.ScheduleParams(sched, reader.CartItemStripe{
+		Recurring: recurring,
+	})
+
+	assert.Len(t, got.Phases, 2)
+	assert.Equal(t, "price_premium", *got.Phases[0].Items[0].Price)
+	assert.Equal(t, sched.CurrentPhase.EndDate, *got.Phases[0].EndDate)
+	assert.Equal(t, recurring.ID, *got.Phases[1].Items[0].Price)
+	assert.Equal(t, int64(1), *got.Phases[1].Iterations)
+	assert.Nil(t, got.Phases[1].Coupon)
+	assert.Equal(t, "release", *got.EndBehavior)
+}
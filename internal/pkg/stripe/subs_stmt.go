@@ -20,6 +20,8 @@ latest_invoice_id = :latest_invoice_id,
 live_mode = :live_mode,
 start_date_utc = :start_date_utc,
 sub_status = :sub_status,
+trial_end_utc = :trial_end_utc,
+pause = :pause,
 created = :created
 `
 
@@ -67,7 +69,35 @@ SELECT id,
 	payment_intent_id,
 	start_date_utc,
 	sub_status,
+	trial_end_utc,
+	pending_change,
+	dispute,
+	pause,
 	created
 FROM premium.stripe_subscription
 WHERE id = ?
 LIMIT 1`
+
+const StmtSetSubsPendingChange = `
+UPDATE premium.stripe_subscription
+SET pending_change = ?,
+	updated_utc = UTC_TIMESTAMP()
+WHERE id = ?
+LIMIT 1`
+
+const StmtSetSubsDispute = `
+UPDATE premium.stripe_subscription
+SET dispute = ?,
+	updated_utc = UTC_TIMESTAMP()
+WHERE id = ?
+LIMIT 1`
+
+// StmtHasSubsOfUser checks whether an ftc user ever had a
+// Stripe subscription, regardless of its status.
+const StmtHasSubsOfUser = `
+SELECT EXISTS (
+	SELECT *
+	FROM premium.stripe_subscription
+	WHERE ftc_user_id = ?
+) AS has_subs
+`
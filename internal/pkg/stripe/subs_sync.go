@@ -171,9 +171,11 @@ func (b SubsSyncBuilder) Build() (SubsResult, error) {
 
 	// Current membership must be created from the subs,
 	// simply update it.
+	// Access stays suspended until the dispute is won.
 	newMmb := b.Subs.BuildMembership(
 		b.UserIDs,
-		b.StripeMember.AddOn)
+		b.StripeMember.AddOn).
+		WithDispute(b.StripeMember.Dispute)
 
 	return SubsResult{
 		Modified: newMmb.IsModified(b.StripeMember),
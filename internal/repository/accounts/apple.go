@@ -0,0 +1,84 @@
+package accounts
+
+import (
+	"github.com/FTChinese/subscription-api/pkg/account"
+	"github.com/FTChinese/subscription-api/pkg/applelogin"
+)
+
+// AppleSignUp creates a new account for an Apple user
+// and links them together.
+func (env Env) AppleSignUp(a account.BaseAccount, l applelogin.Link) error {
+	defer env.logger.Sync()
+	sugar := env.logger.Sugar()
+
+	tx, err := env.beginAccountTx()
+	if err != nil {
+		sugar.Error(err)
+		return err
+	}
+
+	if err := tx.CreateAccount(a); err != nil {
+		sugar.Error(err)
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.CreateProfile(a); err != nil {
+		sugar.Error(err)
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.InsertAppleLink(l); err != nil {
+		sugar.Error(err)
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		sugar.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// LinkApple links an Apple user to an existing account.
+func (env Env) LinkApple(l applelogin.Link) error {
+	_, err := env.dbs.Write.NamedExec(applelogin.StmtInsertLink, l)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (env Env) AppleLinkBySub(sub string) (applelogin.Link, error) {
+	var l applelogin.Link
+	err := env.dbs.Read.Get(&l, applelogin.StmtLinkBySub, sub)
+	if err != nil {
+		return applelogin.Link{}, err
+	}
+
+	return l, nil
+}
+
+func (env Env) AppleLinkByFtcID(ftcID string) (applelogin.Link, error) {
+	var l applelogin.Link
+	err := env.dbs.Read.Get(&l, applelogin.StmtLinkByFtcID, ftcID)
+	if err != nil {
+		return applelogin.Link{}, err
+	}
+
+	return l, nil
+}
+
+// UnlinkApple removes the Apple user linked to an account.
+func (env Env) UnlinkApple(ftcID string) error {
+	_, err := env.dbs.Write.Exec(applelogin.StmtDeleteLink, ftcID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package accounts
+
+import (
+	"testing"
+
+	"github.com/FTChinese/subscription-api/pkg/account"
+	"github.com/FTChinese/subscription-api/pkg/applelogin"
+	"github.com/FTChinese/subscription-api/pkg/db"
+	"github.com/brianvoe/gofakeit/v5"
+	"github.com/google/uuid"
+	"github.com/guregu/null"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestEnv_AppleSignUp(t *testing.T) {
+	env := New(db.MockMySQL(), zaptest.NewLogger(t))
+
+	claims := applelogin.IdentityClaims{
+		Subject:        uuid.New().String(),
+		Email:          gofakeit.Email(),
+		IsPrivateEmail: true,
+	}
+
+	a := account.NewAppleBaseAccount(claims.Email, null.String{}, true)
+	l := applelogin.NewLink(a.FtcID, claims)
+
+	if err := env.AppleSignUp(a, l); err != nil {
+		t.Error(err)
+		return
+	}
+
+	got, err := env.AppleLinkBySub(claims.Subject)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if got.FtcID != a.FtcID {
+		t.Errorf("linked to %s, want %s", got.FtcID, a.FtcID)
+	}
+
+	if err := env.UnlinkApple(a.FtcID); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,166 @@
+package accounts
+
+import (
+	"github.com/FTChinese/subscription-api/pkg/account"
+)
+
+// SaveEmailChange cancels previous pending changes and
+// saves the new one.
+func (env Env) SaveEmailChange(c account.EmailChange) error {
+	tx, err := env.dbs.Write.Beginx()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(account.StmtCancelEmailChanges, c.FtcID)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	_, err = tx.NamedExec(account.StmtInsertEmailChange, c)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (env Env) PendingEmailChange(ftcID string) (account.EmailChange, error) {
+	var c account.EmailChange
+	err := env.dbs.Write.Get(&c, account.StmtPendingEmailChange, ftcID)
+	if err != nil {
+		return account.EmailChange{}, err
+	}
+
+	return c, nil
+}
+
+func (env Env) EmailChangeByToken(token string) (account.EmailChange, error) {
+	var c account.EmailChange
+	err := env.dbs.Write.Get(&c, account.StmtEmailChangeByToken, token)
+	if err != nil {
+		return account.EmailChange{}, err
+	}
+
+	return c, nil
+}
+
+func (env Env) EmailChangeByRevertToken(token string) (account.EmailChange, error) {
+	var c account.EmailChange
+	err := env.dbs.Write.Get(&c, account.StmtEmailChangeByRevertToken, token)
+	if err != nil {
+		return account.EmailChange{}, err
+	}
+
+	return c, nil
+}
+
+func (env Env) CancelEmailChanges(ftcID string) error {
+	_, err := env.dbs.Write.Exec(account.StmtCancelEmailChanges, ftcID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (env Env) IncEmailChangeAttempts(id string) error {
+	_, err := env.dbs.Write.Exec(account.StmtIncEmailChangeAttempts, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ConfirmEmailChange switches account to the new email,
+// which is verified by the confirmation itself,
+// and backs up the old one.
+// The passed in change should already be confirmed.
+func (env Env) ConfirmEmailChange(c account.EmailChange, current account.BaseAccount) error {
+	defer env.logger.Sync()
+	sugar := env.logger.Sugar()
+
+	tx, err := env.dbs.Write.Beginx()
+	if err != nil {
+		sugar.Error(err)
+		return err
+	}
+
+	_, err = tx.NamedExec(account.StmtUpdateEmail, current.WithEmail(c.NewEmail))
+	if err != nil {
+		sugar.Error(err)
+		_ = tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec(account.StmtEmailVerified, c.FtcID)
+	if err != nil {
+		sugar.Error(err)
+		_ = tx.Rollback()
+		return err
+	}
+
+	_, err = tx.NamedExec(account.StmtBackUpEmail, current)
+	if err != nil {
+		sugar.Error(err)
+		_ = tx.Rollback()
+		return err
+	}
+
+	_, err = tx.NamedExec(account.StmtUpdateEmailChange, c)
+	if err != nil {
+		sugar.Error(err)
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RevertEmailChange restores the old email.
+// The passed in change should already be reverted.
+func (env Env) RevertEmailChange(c account.EmailChange, current account.BaseAccount) error {
+	defer env.logger.Sync()
+	sugar := env.logger.Sugar()
+
+	tx, err := env.dbs.Write.Beginx()
+	if err != nil {
+		sugar.Error(err)
+		return err
+	}
+
+	_, err = tx.NamedExec(account.StmtUpdateEmail, current.WithEmail(c.OldEmail))
+	if err != nil {
+		sugar.Error(err)
+		_ = tx.Rollback()
+		return err
+	}
+
+	if c.OldVerified {
+		_, err = tx.Exec(account.StmtEmailVerified, c.FtcID)
+		if err != nil {
+			sugar.Error(err)
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	_, err = tx.NamedExec(account.StmtBackUpEmail, current)
+	if err != nil {
+		sugar.Error(err)
+		_ = tx.Rollback()
+		return err
+	}
+
+	_, err = tx.NamedExec(account.StmtUpdateEmailChange, c)
+	if err != nil {
+		sugar.Error(err)
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
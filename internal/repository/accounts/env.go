@@ -19,6 +19,13 @@ func New(myDBs db.ReadWriteMyDBs, logger *zap.Logger) Env {
 	}
 }
 
+// WithLogger returns a copy logging with l, usually the
+// request-scoped logger.
+func (env Env) WithLogger(l *zap.Logger) Env {
+	env.logger = l
+	return env
+}
+
 func (env Env) beginAccountTx() (txrepo.AccountTx, error) {
 	tx, err := env.dbs.Delete.Beginx()
 	if err != nil {
@@ -0,0 +1,74 @@
+package accounts
+
+import (
+	"github.com/FTChinese/subscription-api/pkg/account"
+)
+
+// SaveMagicLink saves a passwordless login session.
+func (env Env) SaveMagicLink(s account.MagicLinkSession) error {
+	_, err := env.dbs.Write.NamedExec(account.StmtInsertMagicLink, s)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// MagicLinkByToken retrieves a session from the link in email.
+func (env Env) MagicLinkByToken(token string) (account.MagicLinkSession, error) {
+	var s account.MagicLinkSession
+	err := env.dbs.Write.Get(&s, account.StmtMagicLinkByToken, token)
+	if err != nil {
+		return account.MagicLinkSession{}, err
+	}
+
+	return s, nil
+}
+
+// MagicLinkByEmail retrieves the latest code-based session for email.
+func (env Env) MagicLinkByEmail(email string) (account.MagicLinkSession, error) {
+	var s account.MagicLinkSession
+	err := env.dbs.Write.Get(&s, account.StmtMagicLinkByEmail, email)
+	if err != nil {
+		return account.MagicLinkSession{}, err
+	}
+
+	return s, nil
+}
+
+func (env Env) IncMagicLinkAttempts(token string) error {
+	_, err := env.dbs.Write.Exec(account.StmtIncMagicLinkAttempts, token)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DisableMagicLink marks the session as used.
+// Returns false if it is already consumed by another request.
+func (env Env) DisableMagicLink(token string) (bool, error) {
+	result, err := env.dbs.Write.Exec(account.StmtDisableMagicLink, token)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+// MagicLinkLimit counts sessions requested in the past hour
+// by the same email and IP.
+func (env Env) MagicLinkLimit(email string, ip string) (account.MagicLinkLimit, error) {
+	var l account.MagicLinkLimit
+	err := env.dbs.Read.Get(&l, account.StmtMagicLinkLimit, email, ip)
+	if err != nil {
+		return account.MagicLinkLimit{}, err
+	}
+
+	return l, nil
+}
@@ -0,0 +1,61 @@
+package accounts
+
+import (
+	"testing"
+
+	"github.com/FTChinese/subscription-api/internal/pkg/input"
+	"github.com/FTChinese/subscription-api/pkg/account"
+	"github.com/FTChinese/subscription-api/pkg/db"
+	"github.com/brianvoe/gofakeit/v5"
+	"github.com/google/uuid"
+	"github.com/guregu/null"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestEnv_DisableMagicLink(t *testing.T) {
+	env := New(db.MockMySQL(), zaptest.NewLogger(t))
+
+	a := account.BaseAccount{
+		FtcID: uuid.New().String(),
+		Email: gofakeit.Email(),
+	}
+
+	s, err := account.NewMagicLinkSession(
+		input.MagicLinkParams{Email: a.Email, UseCode: true},
+		a,
+		null.StringFrom(gofakeit.IPv4Address()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := env.SaveMagicLink(s); err != nil {
+		t.Error(err)
+		return
+	}
+
+	got, err := env.MagicLinkByEmail(a.Email)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !got.MatchCode(s.AppCode.String) {
+		t.Errorf("code mismatched")
+	}
+
+	ok, _ := env.DisableMagicLink(s.Token)
+	if !ok {
+		t.Error("session should be disabled")
+	}
+
+	ok, _ = env.DisableMagicLink(s.Token)
+	if ok {
+		t.Error("session should be used only once")
+	}
+
+	l, err := env.MagicLinkLimit(a.Email, s.UserIP.String)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	t.Logf("%+v", l)
+}
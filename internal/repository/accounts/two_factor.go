@@ -0,0 +1,197 @@
+package accounts
+
+import (
+	"github.com/FTChinese/subscription-api/pkg/account"
+)
+
+// SaveTwoFactor starts, or restarts, 2FA enrollment.
+func (env Env) SaveTwoFactor(f account.TwoFactor) error {
+	_, err := env.dbs.Write.NamedExec(account.StmtUpsertTwoFactor, f)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RetrieveTwoFactor loads 2FA setting of a user.
+// sql.ErrNoRows indicates the user never enrolled.
+func (env Env) RetrieveTwoFactor(ftcID string) (account.TwoFactor, error) {
+	var f account.TwoFactor
+	err := env.dbs.Read.Get(&f, account.StmtRetrieveTwoFactor, ftcID)
+	if err != nil {
+		return account.TwoFactor{}, err
+	}
+
+	return f, nil
+}
+
+// UseTOTPStep saves the time step of a code accepted.
+// Returns false if the step is already used.
+func (env Env) UseTOTPStep(f account.TwoFactor) (bool, error) {
+	result, err := env.dbs.Write.NamedExec(account.StmtUseTOTPStep, f)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+// EnableTwoFactor turns on 2FA and replaces recovery codes
+// in a single transaction.
+func (env Env) EnableTwoFactor(f account.TwoFactor, codes []account.RecoveryCode) error {
+	tx, err := env.dbs.Write.Beginx()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.NamedExec(account.StmtEnableTwoFactor, f)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec(account.StmtDeleteRecoveryCodes, f.FtcID)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	for _, c := range codes {
+		_, err = tx.NamedExec(account.StmtInsertRecoveryCode, c)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ReplaceRecoveryCodes invalidates existing recovery codes
+// and saves new ones.
+func (env Env) ReplaceRecoveryCodes(ftcID string, codes []account.RecoveryCode) error {
+	tx, err := env.dbs.Write.Beginx()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(account.StmtDeleteRecoveryCodes, ftcID)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	for _, c := range codes {
+		_, err = tx.NamedExec(account.StmtInsertRecoveryCode, c)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DisableTwoFactor removes 2FA setting together with recovery codes.
+func (env Env) DisableTwoFactor(ftcID string) error {
+	tx, err := env.dbs.Delete.Beginx()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(account.StmtDeleteTwoFactor, ftcID)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec(account.StmtDeleteRecoveryCodes, ftcID)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UseRecoveryCode consumes a recovery code.
+// Returns false if the code does not exist or is already used.
+func (env Env) UseRecoveryCode(ftcID string, code string) (bool, error) {
+	result, err := env.dbs.Write.Exec(
+		account.StmtUseRecoveryCode,
+		ftcID,
+		account.HashRecoveryCode(code))
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n == 1, nil
+}
+
+func (env Env) CountRecoveryCodes(ftcID string) (int64, error) {
+	var n int64
+	err := env.dbs.Read.Get(&n, account.StmtCountRecoveryCodes, ftcID)
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+func (env Env) SaveTwoFactorChallenge(c account.TwoFactorChallenge) error {
+	_, err := env.dbs.Write.NamedExec(account.StmtInsertTwoFactorChallenge, c)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RetrieveTwoFactorChallenge loads a challenge from the
+// write db since it is usually created just before.
+func (env Env) RetrieveTwoFactorChallenge(token string) (account.TwoFactorChallenge, error) {
+	var c account.TwoFactorChallenge
+	err := env.dbs.Write.Get(&c, account.StmtRetrieveTwoFactorChallenge, token)
+	if err != nil {
+		return account.TwoFactorChallenge{}, err
+	}
+
+	return c, nil
+}
+
+// IncChallengeAttempts counts a failed answer to a challenge.
+func (env Env) IncChallengeAttempts(token string) error {
+	_, err := env.dbs.Write.Exec(account.StmtIncChallengeAttempts, token)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DisableTwoFactorChallenge flags a challenge as used.
+// Returns false if it is already used by another request.
+func (env Env) DisableTwoFactorChallenge(token string) (bool, error) {
+	result, err := env.dbs.Write.Exec(account.StmtDisableTwoFactorChallenge, token)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
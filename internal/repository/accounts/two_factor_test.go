@@ -0,0 +1,47 @@
+package accounts
+
+import (
+	"testing"
+
+	"github.com/FTChinese/subscription-api/pkg/account"
+	"github.com/FTChinese/subscription-api/pkg/db"
+	"github.com/google/uuid"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestEnv_EnableTwoFactor(t *testing.T) {
+	env := New(db.MockMySQL(), zaptest.NewLogger(t))
+
+	ftcID := uuid.New().String()
+
+	tf, err := account.NewTwoFactor(ftcID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := env.SaveTwoFactor(tf); err != nil {
+		t.Error(err)
+		return
+	}
+
+	codes, hashed := account.NewRecoveryCodes(ftcID)
+
+	if err := env.EnableTwoFactor(tf.Confirmed(), hashed); err != nil {
+		t.Error(err)
+		return
+	}
+
+	ok, err := env.UseRecoveryCode(ftcID, codes[0])
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !ok {
+		t.Error("recovery code should be accepted")
+	}
+
+	ok, _ = env.UseRecoveryCode(ftcID, codes[0])
+	if ok {
+		t.Error("recovery code should be used only once")
+	}
+}
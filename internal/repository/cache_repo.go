@@ -1,67 +1,86 @@
 package repository
 
 import (
-	"errors"
+	"context"
+
 	"github.com/FTChinese/subscription-api/internal/pkg/android"
+	"github.com/FTChinese/subscription-api/pkg/cachestore"
 	"github.com/FTChinese/subscription-api/pkg/ids"
 	"github.com/FTChinese/subscription-api/pkg/reader"
-	"github.com/patrickmn/go-cache"
 )
 
 func paywallCacheKey(live bool) string {
-	return "paywall_" + ids.GetBoolKey(live)
+	return "paywall:" + ids.GetBoolKey(live)
 }
 
-const cacheKeyAndroidLatest = "android_latest_release"
+const cacheKeyAndroidLatest = "android:latest_release"
 
 type CacheRepo struct {
-	cache *cache.Cache
+	cache cachestore.Store
 }
 
-func NewCacheRepo(c *cache.Cache) CacheRepo {
+func NewCacheRepo(c cachestore.Store) CacheRepo {
 	return CacheRepo{
 		cache: c,
 	}
 }
 
 // CachePaywall saves paywall data in cache.
-// It never expires.
-func (repo CacheRepo) CachePaywall(p reader.Paywall, live bool) {
-	repo.cache.Set(
+// It lives until any part of paywall is modified.
+func (repo CacheRepo) CachePaywall(p reader.Paywall, live bool) error {
+	return repo.cache.Set(
+		context.Background(),
 		paywallCacheKey(live),
-		p,
-		cache.NoExpiration)
+		p)
 }
 
 func (repo CacheRepo) LoadPaywall(live bool) (reader.Paywall, error) {
-	x, found := repo.cache.Get(paywallCacheKey(live))
-	if found {
-		if paywall, ok := x.(reader.Paywall); ok {
-			return paywall, nil
-		}
+	var p reader.Paywall
+	err := repo.cache.Get(
+		context.Background(),
+		paywallCacheKey(live),
+		&p)
+	if err != nil {
+		return reader.Paywall{}, err
 	}
 
-	return reader.Paywall{}, errors.New("not found")
+	return p, nil
 }
 
-func (repo CacheRepo) AndroidLatest(release android.Release) {
-	repo.cache.Set(
+// InvalidatePaywall drops paywall of both live and sandbox
+// mode on all instances since a product, price, discount
+// or banner might be shared by both.
+func (repo CacheRepo) InvalidatePaywall() error {
+	return repo.cache.Delete(
+		context.Background(),
+		paywallCacheKey(true),
+		paywallCacheKey(false))
+}
+
+func (repo CacheRepo) AndroidLatest(release android.Release) error {
+	return repo.cache.Set(
+		context.Background(),
 		cacheKeyAndroidLatest,
-		release,
-		cache.DefaultExpiration)
+		release)
 }
 
 func (repo CacheRepo) LoadAndroidLatest() (android.Release, error) {
-	x, found := repo.cache.Get(cacheKeyAndroidLatest)
-	if found {
-		if release, ok := x.(android.Release); ok {
-			return release, nil
-		}
+	var r android.Release
+	err := repo.cache.Get(
+		context.Background(),
+		cacheKeyAndroidLatest,
+		&r)
+	if err != nil {
+		return android.Release{}, err
 	}
 
-	return android.Release{}, errors.New("not found")
+	return r, nil
 }
 
-func (repo CacheRepo) Clear() {
-	repo.cache.Flush()
+func (repo CacheRepo) Clear() error {
+	return repo.cache.Delete(
+		context.Background(),
+		paywallCacheKey(true),
+		paywallCacheKey(false),
+		cacheKeyAndroidLatest)
 }
@@ -0,0 +1,127 @@
+package cmsrepo
+
+import (
+	gorest "github.com/FTChinese/go-rest"
+	"github.com/FTChinese/subscription-api/pkg"
+	"github.com/FTChinese/subscription-api/pkg/cms"
+)
+
+func (env Env) RetrieveStaffRole(name string) (cms.StaffRole, error) {
+	var s cms.StaffRole
+	err := env.dbs.Read.Get(&s, cms.StmtRetrieveStaffRole, name)
+	if err != nil {
+		return cms.StaffRole{}, err
+	}
+
+	return s, nil
+}
+
+func (env Env) ListStaffRoles() ([]cms.StaffRole, error) {
+	var list = make([]cms.StaffRole, 0)
+	err := env.dbs.Read.Select(&list, cms.StmtListStaffRoles)
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (env Env) UpsertStaffRole(s cms.StaffRole) error {
+	_, err := env.dbs.Write.NamedExec(cms.StmtUpsertStaffRole, s)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (env Env) DeleteStaffRole(name string) error {
+	_, err := env.dbs.Delete.Exec(cms.StmtDeleteStaffRole, name)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (env Env) SaveAuditEntry(e cms.AuditEntry) error {
+	_, err := env.dbs.Write.NamedExec(cms.StmtInsertAuditEntry, e)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (env Env) countAuditEntries(f cms.AuditFilter) (int64, error) {
+	where, args := f.Where()
+
+	var count int64
+	err := env.dbs.Read.Get(
+		&count,
+		cms.StmtCountAuditEntries(where),
+		args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (env Env) listAuditEntries(f cms.AuditFilter, p gorest.Pagination) ([]cms.AuditEntry, error) {
+	where, args := f.Where()
+
+	var list = make([]cms.AuditEntry, 0)
+	err := env.dbs.Read.Select(
+		&list,
+		cms.StmtListAuditEntries(where),
+		append(args, p.Limit, p.Offset())...)
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// ListAuditEntries queries audit log, newest first.
+func (env Env) ListAuditEntries(f cms.AuditFilter, p gorest.Pagination) (pkg.PagedData[cms.AuditEntry], error) {
+	defer env.logger.Sync()
+	sugar := env.logger.Sugar()
+
+	countCh := make(chan int64)
+	listCh := make(chan pkg.AsyncResult[[]cms.AuditEntry])
+
+	go func() {
+		defer close(countCh)
+		n, err := env.countAuditEntries(f)
+		if err != nil {
+			sugar.Error(err)
+		}
+
+		countCh <- n
+	}()
+
+	go func() {
+		defer close(listCh)
+		l, err := env.listAuditEntries(f, p)
+		if err != nil {
+			sugar.Error(err)
+		}
+		listCh <- pkg.AsyncResult[[]cms.AuditEntry]{
+			Value: l,
+			Err:   err,
+		}
+	}()
+
+	count, listResult := <-countCh, <-listCh
+
+	if listResult.Err != nil {
+		return pkg.PagedData[cms.AuditEntry]{}, listResult.Err
+	}
+
+	return pkg.PagedData[cms.AuditEntry]{
+		Total:      count,
+		Pagination: p,
+		Data:       listResult.Value,
+	}, nil
+}
@@ -0,0 +1,19 @@
+package mailrepo
+
+import (
+	"github.com/FTChinese/subscription-api/pkg/db"
+	"go.uber.org/zap"
+)
+
+// Env persists outgoing emails in the outbox.
+type Env struct {
+	dbs    db.ReadWriteMyDBs
+	logger *zap.Logger
+}
+
+func New(dbs db.ReadWriteMyDBs, logger *zap.Logger) Env {
+	return Env{
+		dbs:    dbs,
+		logger: logger,
+	}
+}
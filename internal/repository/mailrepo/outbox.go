@@ -0,0 +1,156 @@
+package mailrepo
+
+import (
+	gorest "github.com/FTChinese/go-rest"
+	"github.com/FTChinese/subscription-api/pkg"
+	"github.com/FTChinese/subscription-api/pkg/postman"
+)
+
+// Enqueue saves a parcel to be sent later.
+func (env Env) Enqueue(e postman.Envelope) error {
+	_, err := env.dbs.Write.NamedExec(postman.StmtEnqueue, e)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (env Env) RetrieveEnvelope(id string) (postman.Envelope, error) {
+	var e postman.Envelope
+	err := env.dbs.Read.Get(&e, postman.StmtRetrieveEnvelope, id)
+	if err != nil {
+		return postman.Envelope{}, err
+	}
+
+	return e, nil
+}
+
+// ListDue loads envelopes whose next attempt is due.
+// Use the write db so that envelopes just updated by the
+// sender are not read from a lagging replica.
+func (env Env) ListDue(limit int64) ([]postman.Envelope, error) {
+	var list = make([]postman.Envelope, 0)
+	err := env.dbs.Write.Select(&list, postman.StmtListDueEnvelopes, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// Claim marks an envelope as being sent and returns it with
+// the new claim token. It returns false if the envelope is
+// already claimed by another sender or changed since listed.
+func (env Env) Claim(e postman.Envelope) (postman.Envelope, bool, error) {
+	claimed := e.Claimed()
+
+	result, err := env.dbs.Write.Exec(
+		postman.StmtClaimEnvelope,
+		claimed.ClaimToken,
+		e.ID,
+		e.Status,
+		e.ClaimToken)
+	if err != nil {
+		return e, false, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return e, false, err
+	}
+
+	return claimed, n == 1, nil
+}
+
+// SaveDelivery records the result of an attempt. It returns
+// false if a failure is not saved since the claim was taken
+// over by another sender.
+func (env Env) SaveDelivery(e postman.Envelope) (bool, error) {
+	result, err := env.dbs.Write.NamedExec(postman.StmtUpdateDelivery, e)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n == 1, nil
+}
+
+func (env Env) countEnvelopes(ftcID, email string) (int64, error) {
+	var count int64
+	err := env.dbs.Read.Get(
+		&count,
+		postman.StmtCountEnvelopes,
+		ftcID,
+		email)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (env Env) listEnvelopes(ftcID, email string, p gorest.Pagination) ([]postman.Envelope, error) {
+	var list = make([]postman.Envelope, 0)
+	err := env.dbs.Read.Select(
+		&list,
+		postman.StmtListEnvelopes,
+		ftcID,
+		email,
+		p.Limit,
+		p.Offset())
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// ListEnvelopes lists all messages sent to a user, either
+// by the user's id or email address since some letters,
+// like email verification, are sent before we know the id.
+func (env Env) ListEnvelopes(ftcID, email string, p gorest.Pagination) (pkg.PagedData[postman.Envelope], error) {
+	defer env.logger.Sync()
+	sugar := env.logger.Sugar()
+
+	countCh := make(chan int64)
+	listCh := make(chan pkg.AsyncResult[[]postman.Envelope])
+
+	go func() {
+		defer close(countCh)
+		n, err := env.countEnvelopes(ftcID, email)
+		if err != nil {
+			sugar.Error(err)
+		}
+
+		countCh <- n
+	}()
+
+	go func() {
+		defer close(listCh)
+		l, err := env.listEnvelopes(ftcID, email, p)
+		if err != nil {
+			sugar.Error(err)
+		}
+		listCh <- pkg.AsyncResult[[]postman.Envelope]{
+			Value: l,
+			Err:   err,
+		}
+	}()
+
+	count, listResult := <-countCh, <-listCh
+
+	if listResult.Err != nil {
+		return pkg.PagedData[postman.Envelope]{}, listResult.Err
+	}
+
+	return pkg.PagedData[postman.Envelope]{
+		Total:      count,
+		Pagination: p,
+		Data:       listResult.Value,
+	}, nil
+}
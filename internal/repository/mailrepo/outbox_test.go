@@ -0,0 +1,39 @@
+package mailrepo
+
+import (
+	"testing"
+
+	"github.com/FTChinese/subscription-api/faker"
+	"github.com/FTChinese/subscription-api/pkg/db"
+	"github.com/FTChinese/subscription-api/pkg/postman"
+	"github.com/brianvoe/gofakeit/v5"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestEnv_Enqueue(t *testing.T) {
+	faker.SeedGoFake()
+
+	env := New(db.MockMySQL(), zaptest.NewLogger(t))
+
+	e := postman.NewEnvelope(postman.Parcel{
+		FromAddress: "no-reply@ftchinese.com",
+		FromName:    "FT中文网",
+		ToAddress:   gofakeit.Email(),
+		ToName:      gofakeit.Username(),
+		Subject:     "Test",
+		Body:        gofakeit.Sentence(10),
+	})
+
+	if err := env.Enqueue(e); err != nil {
+		t.Error(err)
+		return
+	}
+
+	list, err := env.ListDue(10)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	t.Logf("%s", faker.MustMarshalIndent(list))
+}
@@ -0,0 +1,156 @@
+package sessionrepo
+
+import (
+	"context"
+
+	"github.com/FTChinese/subscription-api/pkg/cachestore"
+	"github.com/FTChinese/subscription-api/pkg/db"
+	"github.com/FTChinese/subscription-api/pkg/ids"
+	"github.com/FTChinese/subscription-api/pkg/session"
+	"go.uber.org/zap"
+)
+
+// Env persists login sessions.
+// Sessions are cached briefly in the shared cache so that
+// verifying an access token does not hit db on every request.
+// Cached copies are deleted upon refresh and revocation, which
+// is published to all instances.
+type Env struct {
+	dbs    db.ReadWriteMyDBs
+	cache  cachestore.Store
+	logger *zap.Logger
+}
+
+func New(dbs db.ReadWriteMyDBs, c cachestore.Store, logger *zap.Logger) Env {
+	return Env{
+		dbs:    dbs,
+		cache:  c,
+		logger: logger,
+	}
+}
+
+func sessionCacheKey(id string) string {
+	return "session:" + id
+}
+
+// uncache removes cached sessions. Failure is only logged
+// since the cached copy expires shortly anyway.
+func (env Env) uncache(sessionIDs ...string) {
+	if len(sessionIDs) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(sessionIDs))
+	for _, id := range sessionIDs {
+		keys = append(keys, sessionCacheKey(id))
+	}
+
+	err := env.cache.Delete(context.Background(), keys...)
+	if err != nil {
+		env.logger.Error("Failed to invalidate session", zap.Error(err))
+	}
+}
+
+func (env Env) SaveSession(s session.Session) error {
+	_, err := env.dbs.Write.NamedExec(session.StmtInsertSession, s)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// LoadSession retrieves a session from cache or db.
+// Refresh token hashes are not kept in cache, so a session
+// loaded here is only used to check whether it is active.
+func (env Env) LoadSession(id string) (session.Session, error) {
+	var s session.Session
+	err := env.cache.Get(context.Background(), sessionCacheKey(id), &s)
+	if err == nil {
+		return s, nil
+	}
+
+	err = env.dbs.Read.Get(&s, session.StmtRetrieveSession, id)
+	if err != nil {
+		return session.Session{}, err
+	}
+
+	err = env.cache.Set(context.Background(), sessionCacheKey(id), s)
+	if err != nil {
+		env.logger.Error("Failed to cache session", zap.Error(err))
+	}
+
+	return s, nil
+}
+
+// SessionByRefresh finds a session by a refresh token,
+// which could be either current or previous one.
+func (env Env) SessionByRefresh(token string) (session.Session, error) {
+	h := session.HashRefreshToken(token)
+
+	var s session.Session
+	err := env.dbs.Write.Get(&s, session.StmtSessionByRefresh, h, h)
+	if err != nil {
+		return session.Session{}, err
+	}
+
+	return s, nil
+}
+
+// RefreshSession saves the rotated refresh token.
+// Returns false if the previous token is already rotated by
+// a concurrent request.
+func (env Env) RefreshSession(s session.Session) (bool, error) {
+	result, err := env.dbs.Write.NamedExec(session.StmtRefreshSession, s)
+	if err != nil {
+		return false, err
+	}
+
+	env.uncache(s.ID)
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+func (env Env) RevokeSession(id string) error {
+	_, err := env.dbs.Write.Exec(session.StmtRevokeSession, id)
+	if err != nil {
+		return err
+	}
+
+	env.uncache(id)
+
+	return nil
+}
+
+// RevokeUserSessions logs out a user from all devices.
+// Sessions carrying either of the ids are revoked.
+func (env Env) RevokeUserSessions(userIDs ids.UserIDs) error {
+	_, err := env.dbs.Write.Exec(
+		session.StmtRevokeUserSessions,
+		userIDs.FtcID,
+		userIDs.UnionID)
+	if err != nil {
+		return err
+	}
+
+	// Cache is keyed by session id. Look them up after
+	// revocation so that none created meanwhile is missed.
+	var sessionIDs []string
+	err = env.dbs.Write.Select(
+		&sessionIDs,
+		session.StmtRevokedUserSessionIDs,
+		userIDs.FtcID,
+		userIDs.UnionID)
+	if err != nil {
+		return err
+	}
+
+	env.uncache(sessionIDs...)
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package sessionrepo
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FTChinese/subscription-api/pkg/cachestore"
+	"github.com/FTChinese/subscription-api/pkg/config"
+	"github.com/FTChinese/subscription-api/pkg/db"
+	"github.com/FTChinese/subscription-api/pkg/footprint"
+	"github.com/FTChinese/subscription-api/pkg/ids"
+	"github.com/FTChinese/subscription-api/pkg/session"
+	"github.com/google/uuid"
+	"github.com/guregu/null"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestEnv_RevokeSession(t *testing.T) {
+	env := New(
+		db.MockMySQL(),
+		cachestore.NewMemory(cachestore.TTLs{Default: time.Minute}, nil),
+		zaptest.NewLogger(t))
+
+	issuer := session.NewIssuer(
+		session.NewSigner(config.SessionConfig{
+			ActiveKey: "test",
+			Keys:      []config.SigningKey{{ID: "test", Secret: strings.Repeat("x", 32)}},
+		}),
+		time.Minute,
+		time.Hour)
+
+	s, cred, err := issuer.Start(
+		ids.UserIDs{FtcID: null.StringFrom(uuid.New().String())}.MustNormalize(),
+		footprint.AuthMethodEmail,
+		footprint.MockClient(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := env.SaveSession(s); err != nil {
+		t.Error(err)
+		return
+	}
+
+	got, err := env.SessionByRefresh(cred.RefreshToken)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if got.ID != s.ID {
+		t.Errorf("got session %s, want %s", got.ID, s.ID)
+	}
+
+	if err := env.RevokeSession(s.ID); err != nil {
+		t.Error(err)
+		return
+	}
+
+	got, err = env.LoadSession(s.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if got.IsActive() {
+		t.Error("session should be revoked")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/FTChinese/subscription-api/pkg/account"
 	"github.com/FTChinese/subscription-api/pkg/db"
 	"github.com/FTChinese/subscription-api/pkg/ids"
+	"github.com/FTChinese/subscription-api/pkg/metrics"
 	"github.com/FTChinese/subscription-api/pkg/reader"
 )
 
@@ -125,5 +126,7 @@ func (env ReaderCommon) VersionMembership(v reader.MembershipVersioned) error {
 		return err
 	}
 
+	metrics.MembershipChanged(v.CreatedBy.String)
+
 	return nil
 }
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"github.com/FTChinese/subscription-api/internal/pkg/stripe"
+)
+
+// UpsertCancellation saves the reason why user canceled a
+// subscription and the retention offer presented.
+func (repo StripeRepo) UpsertCancellation(c stripe.Cancellation) error {
+	_, err := repo.dbs.Write.NamedExecContext(repo.ctx,
+		stripe.StmtUpsertCancellation,
+		c)
+
+	return err
+}
+
+func (repo StripeRepo) RetrieveCancellation(subsID string) (stripe.Cancellation, error) {
+	var c stripe.Cancellation
+	err := repo.dbs.Read.GetContext(repo.ctx,
+		&c,
+		stripe.StmtRetrieveCancellation,
+		subsID)
+
+	if err != nil {
+		return stripe.Cancellation{}, err
+	}
+
+	return c, nil
+}
+
+func (repo StripeRepo) SaveOfferAccepted(c stripe.Cancellation) error {
+	_, err := repo.dbs.Write.NamedExecContext(repo.ctx,
+		stripe.StmtAcceptRetentionOffer,
+		c)
+
+	return err
+}
+
+// CancellationReport counts cancellations in a period
+// by tier, tenure and reason.
+func (repo StripeRepo) CancellationReport(params stripe.CancellationReportParams, live bool) (stripe.CancellationReport, error) {
+	stats := make([]stripe.CancellationStats, 0)
+	err := repo.dbs.Read.SelectContext(repo.ctx,
+		&stats,
+		stripe.StmtCancellationStats,
+		live,
+		params.Since,
+		params.Until)
+
+	if err != nil {
+		return stripe.CancellationReport{}, err
+	}
+
+	return stripe.CancellationReport{
+		CancellationReportParams: params,
+		Stats:                    stats,
+	}, nil
+}
@@ -6,7 +6,7 @@ import (
 )
 
 func (repo StripeRepo) UpsertCoupon(c price.StripeCoupon) error {
-	_, err := repo.dbs.Write.NamedExec(
+	_, err := repo.dbs.Write.NamedExecContext(repo.ctx,
 		price.StmtUpsertCoupon,
 		c)
 
@@ -15,7 +15,7 @@ func (repo StripeRepo) UpsertCoupon(c price.StripeCoupon) error {
 
 func (repo StripeRepo) RetrieveCoupon(id string, live bool) (price.StripeCoupon, error) {
 	var c price.StripeCoupon
-	err := repo.dbs.Read.Get(
+	err := repo.dbs.Read.GetContext(repo.ctx,
 		&c,
 		price.StmtRetrieveCoupon,
 		id,
@@ -36,7 +36,7 @@ func (repo StripeRepo) ListPriceCoupons(priceID string, activeOnly bool, live bo
 	}
 
 	var list = make([]price.StripeCoupon, 0)
-	err := repo.dbs.Read.Select(
+	err := repo.dbs.Read.SelectContext(repo.ctx,
 		&list,
 		stmt,
 		priceID,
@@ -49,8 +49,25 @@ func (repo StripeRepo) ListPriceCoupons(priceID string, activeOnly bool, live bo
 	return list, nil
 }
 
+// ListRetentionCoupons retrieves active coupons of a price
+// that could be offered to users canceling a subscription.
+func (repo StripeRepo) ListRetentionCoupons(priceID string, live bool) ([]price.StripeCoupon, error) {
+	var list = make([]price.StripeCoupon, 0)
+	err := repo.dbs.Read.SelectContext(repo.ctx,
+		&list,
+		price.StmtPriceRetentionCoupons,
+		priceID,
+		live)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
 func (repo StripeRepo) UpdateCouponStatus(c price.StripeCoupon) error {
-	_, err := repo.dbs.Write.NamedExec(
+	_, err := repo.dbs.Write.NamedExecContext(repo.ctx,
 		price.StmtChangeCouponStatus,
 		c)
 
@@ -58,7 +75,7 @@ func (repo StripeRepo) UpdateCouponStatus(c price.StripeCoupon) error {
 }
 
 func (repo StripeRepo) InsertCouponRedeemed(r stripe.CouponRedeemed) error {
-	_, err := repo.dbs.Write.NamedExec(
+	_, err := repo.dbs.Write.NamedExecContext(repo.ctx,
 		stripe.StmtInsertCouponRedeemed,
 		r)
 
@@ -67,7 +84,7 @@ func (repo StripeRepo) InsertCouponRedeemed(r stripe.CouponRedeemed) error {
 
 func (repo StripeRepo) LatestCouponApplied(invoiceID string) (stripe.CouponRedeemed, error) {
 	var r stripe.CouponRedeemed
-	err := repo.dbs.Read.Get(
+	err := repo.dbs.Read.GetContext(repo.ctx,
 		&r,
 		stripe.StmtLatestCouponRedeemed,
 		invoiceID)
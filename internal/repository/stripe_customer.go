@@ -7,7 +7,7 @@ import (
 )
 
 func (repo StripeRepo) UpsertCustomer(c stripe.Customer) error {
-	_, err := repo.dbs.Write.NamedExec(
+	_, err := repo.dbs.Write.NamedExecContext(repo.ctx,
 		stripe.StmtUpsertCustomer,
 		c)
 
@@ -21,7 +21,7 @@ func (repo StripeRepo) UpsertCustomer(c stripe.Customer) error {
 func (repo StripeRepo) RetrieveCustomer(id string) (stripe.Customer, error) {
 	var c stripe.Customer
 
-	err := repo.dbs.Read.Get(&c, stripe.StmtRetrieveCustomer, id)
+	err := repo.dbs.Read.GetContext(repo.ctx, &c, stripe.StmtRetrieveCustomer, id)
 
 	if err != nil {
 		return stripe.Customer{}, err
@@ -33,7 +33,7 @@ func (repo StripeRepo) RetrieveCustomer(id string) (stripe.Customer, error) {
 func (repo StripeRepo) listCusPaymentMethods(cusID string, page gorest.Pagination) ([]stripe.PaymentMethod, error) {
 	var paymentMethods = make([]stripe.PaymentMethod, 0)
 
-	err := repo.dbs.Read.Select(
+	err := repo.dbs.Read.SelectContext(repo.ctx,
 		&paymentMethods,
 		stripe.StmtListPaymentMethods,
 		cusID,
@@ -49,7 +49,7 @@ func (repo StripeRepo) listCusPaymentMethods(cusID string, page gorest.Paginatio
 
 func (repo StripeRepo) countCusPaymentMethods(cusID string) (int64, error) {
 	var total int64
-	err := repo.dbs.Read.Get(
+	err := repo.dbs.Read.GetContext(repo.ctx,
 		&total,
 		stripe.StmtCountPaymentMethods,
 		cusID)
@@ -3,14 +3,14 @@ package repository
 import "github.com/FTChinese/subscription-api/internal/pkg/stripe"
 
 func (repo StripeRepo) UpsertDiscount(d stripe.Discount) error {
-	_, err := repo.dbs.Write.NamedExec(stripe.StmtUpsertDiscount, d)
+	_, err := repo.dbs.Write.NamedExecContext(repo.ctx, stripe.StmtUpsertDiscount, d)
 
 	return err
 }
 
 func (repo StripeRepo) RetrieveDiscount(id string) (stripe.Discount, error) {
 	var d stripe.Discount
-	err := repo.dbs.Read.Get(&d, stripe.StmtRetrieveDiscount, id)
+	err := repo.dbs.Read.GetContext(repo.ctx, &d, stripe.StmtRetrieveDiscount, id)
 
 	if err != nil {
 		return stripe.Discount{}, err
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"log"
+
+	gorest "github.com/FTChinese/go-rest"
+	"github.com/FTChinese/subscription-api/internal/pkg/stripe"
+	"github.com/FTChinese/subscription-api/pkg"
+)
+
+// UpsertDispute saves a dispute received from webhook.
+func (repo StripeRepo) UpsertDispute(d stripe.Dispute) error {
+	_, err := repo.dbs.Write.NamedExecContext(repo.ctx,
+		stripe.StmtUpsertDispute,
+		d)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repo StripeRepo) countOpenDisputes(live bool) (int64, error) {
+	var count int64
+	err := repo.dbs.Read.GetContext(repo.ctx,
+		&count,
+		stripe.StmtCountOpenDisputes,
+		live)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (repo StripeRepo) listOpenDisputes(live bool, p gorest.Pagination) ([]stripe.Dispute, error) {
+	list := make([]stripe.Dispute, 0)
+
+	err := repo.dbs.Read.SelectContext(repo.ctx,
+		&list,
+		stripe.StmtListOpenDisputes,
+		live,
+		p.Limit,
+		p.Offset())
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// ListOpenDisputes retrieves disputes not closed yet,
+// with evidence due soonest first.
+func (repo StripeRepo) ListOpenDisputes(live bool, p gorest.Pagination) (pkg.PagedData[stripe.Dispute], error) {
+	countCh := make(chan int64)
+	listCh := make(chan pkg.AsyncResult[[]stripe.Dispute])
+
+	go func() {
+		defer close(countCh)
+		n, err := repo.countOpenDisputes(live)
+		if err != nil {
+			log.Print(err)
+		}
+
+		countCh <- n
+	}()
+
+	go func() {
+		defer close(listCh)
+		list, err := repo.listOpenDisputes(live, p)
+		listCh <- pkg.AsyncResult[[]stripe.Dispute]{
+			Err:   err,
+			Value: list,
+		}
+	}()
+
+	count, listResult := <-countCh, <-listCh
+
+	if listResult.Err != nil {
+		return pkg.PagedData[stripe.Dispute]{}, listResult.Err
+	}
+
+	return pkg.PagedData[stripe.Dispute]{
+		Total:      count,
+		Pagination: p,
+		Data:       listResult.Value,
+	}, nil
+}
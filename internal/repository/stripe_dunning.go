@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/FTChinese/subscription-api/internal/pkg/stripe"
+)
+
+// UpsertDunning saves a failed attempt to pay an invoice.
+func (repo StripeRepo) UpsertDunning(d stripe.Dunning) error {
+	_, err := repo.dbs.Write.NamedExecContext(repo.ctx,
+		stripe.StmtUpsertDunning,
+		d)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RetrieveDunning finds dunning of an invoice.
+// Zero value is returned if it never failed.
+func (repo StripeRepo) RetrieveDunning(invoiceID string) (stripe.Dunning, error) {
+	var d stripe.Dunning
+	err := repo.dbs.Read.GetContext(repo.ctx, &d, stripe.StmtRetrieveDunning, invoiceID)
+	if err != nil && err != sql.ErrNoRows {
+		return stripe.Dunning{}, err
+	}
+
+	return d, nil
+}
+
+// OpenDunning finds the invoice of a subscription still being
+// collected. Zero value is returned if there is none.
+func (repo StripeRepo) OpenDunning(subsID string) (stripe.Dunning, error) {
+	var d stripe.Dunning
+	err := repo.dbs.Read.GetContext(repo.ctx, &d, stripe.StmtOpenDunning, subsID)
+	if err != nil && err != sql.ErrNoRows {
+		return stripe.Dunning{}, err
+	}
+
+	return d, nil
+}
+
+func (repo StripeRepo) ResolveDunning(d stripe.Dunning) error {
+	_, err := repo.dbs.Write.NamedExecContext(repo.ctx,
+		stripe.StmtResolveDunning,
+		d)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
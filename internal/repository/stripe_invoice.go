@@ -4,7 +4,7 @@ import "github.com/FTChinese/subscription-api/internal/pkg/stripe"
 
 // UpsertInvoice inserts or updates an invoice.
 func (repo StripeRepo) UpsertInvoice(i stripe.Invoice) error {
-	_, err := repo.dbs.Write.NamedExec(
+	_, err := repo.dbs.Write.NamedExecContext(repo.ctx,
 		stripe.StmtUpsertInvoice,
 		i)
 	if err != nil {
@@ -16,7 +16,7 @@ func (repo StripeRepo) UpsertInvoice(i stripe.Invoice) error {
 
 func (repo StripeRepo) RetrieveInvoice(id string) (stripe.Invoice, error) {
 	var inv stripe.Invoice
-	err := repo.dbs.Read.Get(&inv, stripe.StmtRetrieveInvoice, id)
+	err := repo.dbs.Read.GetContext(repo.ctx, &inv, stripe.StmtRetrieveInvoice, id)
 	if err != nil {
 		return stripe.Invoice{}, err
 	}
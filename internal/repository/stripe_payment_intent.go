@@ -3,7 +3,7 @@ package repository
 import "github.com/FTChinese/subscription-api/internal/pkg/stripe"
 
 func (repo StripeRepo) UpsertPaymentIntent(pi stripe.PaymentIntent) error {
-	_, err := repo.dbs.Write.NamedExec(
+	_, err := repo.dbs.Write.NamedExecContext(repo.ctx,
 		stripe.StmtUpsertPaymentIntent,
 		pi)
 
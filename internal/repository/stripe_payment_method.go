@@ -8,7 +8,7 @@ import (
 // This usually happens when a default payment method is set
 // on a customer or on a subscription.
 func (repo StripeRepo) UpsertPaymentMethod(pm stripe.PaymentMethod) error {
-	_, err := repo.dbs.Write.NamedExec(
+	_, err := repo.dbs.Write.NamedExecContext(repo.ctx,
 		stripe.StmtInsertPaymentMethod,
 		pm)
 
@@ -21,7 +21,7 @@ func (repo StripeRepo) UpsertPaymentMethod(pm stripe.PaymentMethod) error {
 
 func (repo StripeRepo) RetrievePaymentMethod(id string) (stripe.PaymentMethod, error) {
 	var pm stripe.PaymentMethod
-	err := repo.dbs.Read.Get(
+	err := repo.dbs.Read.GetContext(repo.ctx,
 		&pm,
 		stripe.StmtRetrievePaymentMethod,
 		id)
@@ -8,7 +8,7 @@ import (
 func (repo StripeRepo) ListPaywallPrices(live bool) ([]price.StripePrice, error) {
 	var prices = make([]price.StripePrice, 0)
 
-	err := repo.dbs.Read.Select(
+	err := repo.dbs.Read.SelectContext(repo.ctx,
 		&prices,
 		price.StmtStripeActivePrices,
 		live,
@@ -24,7 +24,7 @@ func (repo StripeRepo) ListPaywallPrices(live bool) ([]price.StripePrice, error)
 // ListPaywallCoupons retrieves active coupons of the all stripe prices present on paywall.
 func (repo StripeRepo) ListPaywallCoupons(live bool) ([]price.StripeCoupon, error) {
 	var list = make([]price.StripeCoupon, 0)
-	err := repo.dbs.Read.Select(
+	err := repo.dbs.Read.SelectContext(repo.ctx,
 		&list,
 		price.StmtPaywallStripeCoupons,
 		live)
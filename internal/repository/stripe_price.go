@@ -13,7 +13,7 @@ import (
 // in db.
 func (repo StripeRepo) countPrices(live bool) (int64, error) {
 	var count int64
-	err := repo.dbs.Read.Get(
+	err := repo.dbs.Read.GetContext(repo.ctx,
 		&count,
 		price.StmtCountStripePrice,
 		live,
@@ -28,7 +28,7 @@ func (repo StripeRepo) countPrices(live bool) (int64, error) {
 func (repo StripeRepo) listPrices(live bool, p gorest.Pagination) ([]price.StripePrice, error) {
 	list := make([]price.StripePrice, 0)
 
-	err := repo.dbs.Read.Select(
+	err := repo.dbs.Read.SelectContext(repo.ctx,
 		&list,
 		price.StmtStripePagedPrices,
 		live,
@@ -89,7 +89,7 @@ func (repo StripeRepo) ListPricesPaged(
 func (repo StripeRepo) RetrievePrice(id string, live bool) (price.StripePrice, error) {
 	var p price.StripePrice
 
-	err := repo.dbs.Read.Get(
+	err := repo.dbs.Read.GetContext(repo.ctx,
 		&p,
 		price.StmtStripePrice,
 		id,
@@ -105,7 +105,7 @@ func (repo StripeRepo) RetrievePrice(id string, live bool) (price.StripePrice, e
 func (repo StripeRepo) IsPriceOnPaywall(id string) (bool, error) {
 	var ok bool
 
-	err := repo.dbs.Read.Get(
+	err := repo.dbs.Read.GetContext(repo.ctx,
 		&ok,
 		price.StmtIsActivePrice,
 		id,
@@ -121,7 +121,7 @@ func (repo StripeRepo) IsPriceOnPaywall(id string) (bool, error) {
 
 func (repo StripeRepo) UpsertPrice(p price.StripePrice) error {
 
-	_, err := repo.dbs.Write.NamedExec(
+	_, err := repo.dbs.Write.NamedExecContext(repo.ctx,
 		price.StmtUpsertStripePrice,
 		p)
 
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"github.com/FTChinese/subscription-api/internal/pkg/stripe"
+)
+
+// SaveRefund records a refund made by staff as soon as Stripe
+// returns it, outside of any transaction so that it is kept
+// even if adjusting membership fails afterwards.
+func (repo StripeRepo) SaveRefund(r stripe.Refund) error {
+	_, err := repo.dbs.Write.NamedExecContext(repo.ctx, stripe.StmtInsertRefund, r)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
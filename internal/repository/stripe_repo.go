@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"context"
+
 	"github.com/FTChinese/subscription-api/pkg/db"
 	"go.uber.org/zap"
 )
@@ -8,21 +10,38 @@ import (
 type StripeRepo struct {
 	dbs    db.ReadWriteMyDBs
 	Logger *zap.Logger
+	ctx    context.Context // Statements run within, for tracing.
 }
 
 func NewStripeRepo(dbs db.ReadWriteMyDBs, logger *zap.Logger) StripeRepo {
 	return StripeRepo{
 		dbs:    dbs,
 		Logger: logger,
+		ctx:    context.Background(),
 	}
 }
 
+// WithLogger returns a copy logging with l.
+func (repo StripeRepo) WithLogger(l *zap.Logger) StripeRepo {
+	repo.Logger = l
+	return repo
+}
+
+// WithContext returns a copy running statements within ctx.
+func (repo StripeRepo) WithContext(ctx context.Context) StripeRepo {
+	repo.ctx = ctx
+	return repo
+}
+
 func (repo StripeRepo) BeginStripeTx() (StripeTx, error) {
-	tx, err := repo.dbs.Write.Beginx()
+	tx, err := repo.dbs.Write.BeginTxx(repo.ctx, nil)
 
 	if err != nil {
 		return StripeTx{}, err
 	}
 
-	return NewStripeTx(tx), nil
+	stx := NewStripeTx(tx)
+	stx.SharedTx = stx.SharedTx.WithContext(repo.ctx)
+
+	return stx, nil
 }
@@ -3,7 +3,7 @@ package repository
 import "github.com/FTChinese/subscription-api/internal/pkg/stripe"
 
 func (repo StripeRepo) UpsertSetupIntent(si stripe.SetupIntent) error {
-	_, err := repo.dbs.Write.NamedExec(
+	_, err := repo.dbs.Write.NamedExecContext(repo.ctx,
 		stripe.StmtUpsertSetupIntent,
 		si)
 
@@ -16,7 +16,7 @@ func (repo StripeRepo) UpsertSetupIntent(si stripe.SetupIntent) error {
 
 func (repo StripeRepo) RetrieveSetupIntent(id string) (stripe.SetupIntent, error) {
 	var si stripe.SetupIntent
-	err := repo.dbs.Read.Get(
+	err := repo.dbs.Read.GetContext(repo.ctx,
 		&si,
 		stripe.RetrieveSetupIntent,
 		id)
@@ -3,7 +3,7 @@ package repository
 import "github.com/FTChinese/subscription-api/internal/pkg/stripe"
 
 func (repo StripeRepo) SaveShoppingSession(s stripe.ShoppingSession) error {
-	_, err := repo.dbs.Write.NamedExec(stripe.StmtShoppingSession, s)
+	_, err := repo.dbs.Write.NamedExecContext(repo.ctx, stripe.StmtShoppingSession, s)
 
 	if err != nil {
 		return err
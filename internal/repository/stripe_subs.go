@@ -1,6 +1,9 @@
 package repository
 
-import "github.com/FTChinese/subscription-api/internal/pkg/stripe"
+import (
+	"github.com/FTChinese/subscription-api/internal/pkg/stripe"
+	"github.com/FTChinese/subscription-api/pkg/reader"
+)
 
 // UpsertSubs inserts or updates an existing subscription.
 // Then payment_intent_id field only exists when expanded is true.
@@ -14,7 +17,7 @@ func (repo StripeRepo) UpsertSubs(s stripe.Subs, expanded bool) error {
 		stmt = stripe.StmtUpsertSubsNotExpanded
 	}
 
-	_, err := repo.dbs.Write.NamedExec(
+	_, err := repo.dbs.Write.NamedExecContext(repo.ctx,
 		stmt,
 		s,
 	)
@@ -25,13 +28,41 @@ func (repo StripeRepo) UpsertSubs(s stripe.Subs, expanded bool) error {
 	return nil
 }
 
+// SavePendingChange saves or clears the change scheduled for
+// a subscription, upon receiving schedule webhook.
+func (repo StripeRepo) SavePendingChange(subsID string, c reader.PendingChange) error {
+	_, err := repo.dbs.Write.ExecContext(repo.ctx, stripe.StmtSetSubsPendingChange, c, subsID)
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.dbs.Write.ExecContext(repo.ctx, reader.StmtSetPendingChange, c, subsID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // RetrieveSubs retrieves the stripe subscription stored in our db.
 func (repo StripeRepo) RetrieveSubs(id string) (stripe.Subs, error) {
 	var s stripe.Subs
-	err := repo.dbs.Read.Get(&s, stripe.StmtRetrieveSubs, id)
+	err := repo.dbs.Read.GetContext(repo.ctx, &s, stripe.StmtRetrieveSubs, id)
 	if err != nil {
 		return s, err
 	}
 
 	return s, nil
 }
+
+// HasSubsOfUser checks whether an ftc user ever subscribed
+// via Stripe.
+func (repo StripeRepo) HasSubsOfUser(ftcID string) (bool, error) {
+	var ok bool
+	err := repo.dbs.Read.GetContext(repo.ctx, &ok, stripe.StmtHasSubsOfUser, ftcID)
+	if err != nil {
+		return false, err
+	}
+
+	return ok, nil
+}
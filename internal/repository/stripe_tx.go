@@ -63,3 +63,46 @@ func (tx StripeTx) RetrieveStripeMember(subID string) (reader.Membership, error)
 
 	return m.Sync(), nil
 }
+
+// SavePendingChange saves or clears the change scheduled
+// for a subscription while its membership is locked.
+func (tx StripeTx) SavePendingChange(subsID string, c reader.PendingChange) error {
+	_, err := tx.Exec(stripe.StmtSetSubsPendingChange, c, subsID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(reader.StmtSetPendingChange, c, subsID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SaveDisputeFlag flags or clears the dispute of a subscription
+// while its membership is locked.
+func (tx StripeTx) SaveDisputeFlag(subsID string, f reader.DisputeFlag) error {
+	_, err := tx.Exec(stripe.StmtSetSubsDispute, f, subsID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(reader.StmtSetDispute, f, subsID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SaveRefundAdjusted records when membership expires after
+// subscription is stopped upon refund.
+func (tx StripeTx) SaveRefundAdjusted(r stripe.Refund) error {
+	_, err := tx.NamedExec(stripe.StmtRefundAdjusted, r)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -3,7 +3,7 @@ package repository
 import "github.com/FTChinese/subscription-api/internal/pkg/stripe"
 
 func (repo StripeRepo) SaveWebhookError(whe stripe.WebhookError) error {
-	_, err := repo.dbs.Write.NamedExec(stripe.StmtInsertWebhookError, whe)
+	_, err := repo.dbs.Write.NamedExecContext(repo.ctx, stripe.StmtInsertWebhookError, whe)
 
 	if err != nil {
 		return err
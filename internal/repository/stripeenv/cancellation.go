@@ -0,0 +1,211 @@
+package stripeenv
+
+import (
+	"database/sql"
+
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/internal/pkg/stripe"
+	"github.com/FTChinese/subscription-api/pkg/price"
+	"github.com/FTChinese/subscription-api/pkg/reader"
+)
+
+// CancelWithReason cancels a subscription at period end,
+// records why, and finds a retention offer the user is
+// eligible for.
+// Subscription is already canceled when recording fails, so
+// the error is only logged and no offer is presented.
+func (env Env) CancelWithReason(params stripe.CancelParams, input stripe.CancelInput) (stripe.CancelResult, error) {
+	defer env.Logger.Sync()
+	sugar := env.Logger.Sugar()
+
+	result, err := env.CancelSubscription(params)
+	if err != nil {
+		return stripe.CancelResult{}, err
+	}
+
+	if !result.Modified {
+		return stripe.CancelResult{
+			SubsResult: result,
+		}, nil
+	}
+
+	c := stripe.NewCancellation(result.Subs, input)
+
+	offer, err := env.findRetentionOffer(result.Subs)
+	if err != nil {
+		sugar.Error(err)
+	}
+	c = c.WithOffer(offer)
+
+	err = env.UpsertCancellation(c)
+	if err != nil {
+		sugar.Error(err)
+		return stripe.CancelResult{
+			SubsResult: result,
+		}, nil
+	}
+
+	if offer.IsZero() {
+		return stripe.CancelResult{
+			SubsResult: result,
+		}, nil
+	}
+
+	return stripe.CancelResult{
+		SubsResult:     result,
+		RetentionOffer: &offer,
+	}, nil
+}
+
+func (env Env) findRetentionOffer(subs stripe.Subs) (price.StripeCoupon, error) {
+	if len(subs.Items) == 0 {
+		return price.StripeCoupon{}, nil
+	}
+
+	prior, err := env.RetrieveCancellation(subs.ID)
+	if err != nil && err != sql.ErrNoRows {
+		return price.StripeCoupon{}, err
+	}
+
+	redeemed, err := env.LatestCouponApplied(subs.LatestInvoiceID)
+	if err != nil && err != sql.ErrNoRows {
+		return price.StripeCoupon{}, err
+	}
+
+	coupons, err := env.ListRetentionCoupons(subs.Items[0].Price.ID, subs.LiveMode)
+	if err != nil {
+		return price.StripeCoupon{}, err
+	}
+
+	return stripe.RetentionOffer(subs, coupons, redeemed, prior), nil
+}
+
+// AcceptRetentionOffer reactivates a subscription canceled at
+// period end with the coupon offered upon cancellation.
+func (env Env) AcceptRetentionOffer(ftcID string, subsID string) (stripe.SubsResult, error) {
+	defer env.Logger.Sync()
+	sugar := env.Logger.Sugar()
+
+	c, err := env.RetrieveCancellation(subsID)
+	if err != nil {
+		return stripe.SubsResult{}, err
+	}
+
+	if c.FtcUserID != ftcID {
+		return stripe.SubsResult{}, sql.ErrNoRows
+	}
+
+	if !c.HasOffer() {
+		return stripe.SubsResult{}, &render.ValidationError{
+			Message: "No retention offer to accept",
+			Field:   "retentionOffer",
+			Code:    render.CodeMissing,
+		}
+	}
+
+	coupon, err := env.RetrieveCoupon(c.OfferCouponID.String, c.LiveMode)
+	if err != nil && err != sql.ErrNoRows {
+		return stripe.SubsResult{}, err
+	}
+
+	if !coupon.IsValid() {
+		return stripe.SubsResult{}, &render.ValidationError{
+			Message: "Retention offer is no longer available",
+			Field:   "retentionOffer",
+			Code:    render.CodeInvalid,
+		}
+	}
+
+	result, err := env.reactivateWithOffer(ftcID, subsID, c)
+	if err != nil {
+		return stripe.SubsResult{}, err
+	}
+
+	// Coupon is applied by now; failing to record it only
+	// allows user another offer later.
+	err = env.SaveOfferAccepted(c.Accepted())
+	if err != nil {
+		sugar.Error(err)
+	}
+
+	redeemed := c.CouponRedeemed(result.Subs)
+	if !redeemed.IsZero() {
+		err = env.InsertCouponRedeemed(redeemed)
+		if err != nil {
+			sugar.Error(err)
+		}
+	}
+
+	return result, nil
+}
+
+// reactivateWithOffer reactivates a subscription canceled at
+// period end with the coupon offered, and syncs membership in
+// the same way as CancelSubscription.
+func (env Env) reactivateWithOffer(ftcID string, subsID string, c stripe.Cancellation) (stripe.SubsResult, error) {
+	defer env.Logger.Sync()
+	sugar := env.Logger.Sugar()
+
+	tx, err := env.BeginStripeTx()
+	if err != nil {
+		sugar.Error(err)
+		return stripe.SubsResult{}, err
+	}
+
+	mmb, err := tx.RetrieveMember(ftcID)
+	if err != nil {
+		sugar.Error(err)
+		_ = tx.Rollback()
+		return stripe.SubsResult{}, err
+	}
+
+	if !mmb.IsStripeSubsMatch(subsID) {
+		_ = tx.Rollback()
+		return stripe.SubsResult{}, sql.ErrNoRows
+	}
+
+	if mmb.IsPaused() {
+		_ = tx.Rollback()
+		return stripe.SubsResult{}, stripe.ErrSubsPaused
+	}
+
+	if mmb.AutoRenewal {
+		_ = tx.Rollback()
+		return stripe.SubsResult{}, &render.ValidationError{
+			Message: "Subscription is not canceled",
+			Field:   "autoRenew",
+			Code:    render.CodeInvalid,
+		}
+	}
+
+	ss, err := env.Client.UpdateSubs(subsID, c.RetentionParams())
+	if err != nil {
+		sugar.Error(err)
+		_ = tx.Rollback()
+		return stripe.SubsResult{}, err
+	}
+
+	sugar.Infof("Reactivated subscription %s with coupon %s", ss.ID, c.OfferCouponID.String)
+
+	subs := stripe.NewSubs(mmb.FtcID.String, ss)
+	result := stripe.SubsSuccessBuilder{
+		UserIDs:       mmb.UserIDs,
+		Kind:          reader.IntentNull,
+		CurrentMember: mmb,
+		Subs:          subs,
+		Archiver:      reader.NewArchiver().ByStripe().ActionReactivate(),
+	}.Build()
+
+	if err := tx.UpdateMember(result.Member); err != nil {
+		sugar.Error(err)
+		_ = tx.Rollback()
+		return stripe.SubsResult{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		sugar.Error(err)
+		return stripe.SubsResult{}, err
+	}
+
+	return result, nil
+}
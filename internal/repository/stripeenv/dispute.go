@@ -0,0 +1,106 @@
+package stripeenv
+
+import (
+	"github.com/FTChinese/subscription-api/internal/pkg/stripe"
+	"github.com/FTChinese/subscription-api/pkg/reader"
+	sdk "github.com/stripe/stripe-go/v72"
+)
+
+// FetchDispute converts a dispute received from webhook,
+// finding the subscription it belongs to from the charge.
+func (env Env) FetchDispute(raw *sdk.Dispute) (stripe.Dispute, error) {
+	if raw.Charge == nil {
+		return stripe.NewDispute(raw, nil), nil
+	}
+
+	ch, err := env.Client.FetchCharge(raw.Charge.ID)
+	if err != nil {
+		return stripe.Dispute{}, err
+	}
+
+	return stripe.NewDispute(raw, ch), nil
+}
+
+// FlagDispute marks the subscription and its membership as
+// disputed. If suspend is true, membership expires immediately
+// and the versioned membership is returned for archiving.
+func (env Env) FlagDispute(d stripe.Dispute, suspend bool) (reader.MembershipVersioned, error) {
+	defer env.Logger.Sync()
+	sugar := env.Logger.Sugar()
+
+	if !d.SubsID.Valid {
+		return reader.MembershipVersioned{}, nil
+	}
+
+	tx, err := env.BeginStripeTx()
+	if err != nil {
+		sugar.Error(err)
+		return reader.MembershipVersioned{}, err
+	}
+
+	mmb, err := tx.RetrieveStripeMember(d.SubsID.String)
+	if err != nil {
+		sugar.Error(err)
+		_ = tx.Rollback()
+		return reader.MembershipVersioned{}, err
+	}
+
+	flag := d.Flag(suspend && !mmb.IsZero())
+	err = tx.SaveDisputeFlag(d.SubsID.String, flag)
+	if err != nil {
+		sugar.Error(err)
+		_ = tx.Rollback()
+		return reader.MembershipVersioned{}, err
+	}
+
+	if !flag.Suspended {
+		if err := tx.Commit(); err != nil {
+			sugar.Error(err)
+			return reader.MembershipVersioned{}, err
+		}
+		return reader.MembershipVersioned{}, nil
+	}
+
+	suspended := mmb.WithDispute(flag)
+	err = tx.UpdateMember(suspended)
+	if err != nil {
+		sugar.Error(err)
+		_ = tx.Rollback()
+		return reader.MembershipVersioned{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		sugar.Error(err)
+		return reader.MembershipVersioned{}, err
+	}
+
+	sugar.Infof("Membership of subscription %s suspended by dispute %s", d.SubsID.String, d.ID)
+
+	return reader.NewMembershipVersioned(suspended).
+		WithPriorVersion(mmb).
+		ArchivedBy(reader.NewArchiver().ByStripe().ActionDispute()), nil
+}
+
+// ClearDispute removes the dispute flag after it is won.
+// Membership should be synced from the subscription
+// afterwards to restore access.
+func (env Env) ClearDispute(subsID string) error {
+	tx, err := env.BeginStripeTx()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.RetrieveStripeMember(subsID)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	err = tx.SaveDisputeFlag(subsID, reader.DisputeFlag{})
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
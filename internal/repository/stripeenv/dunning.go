@@ -0,0 +1,129 @@
+package stripeenv
+
+import (
+	"errors"
+
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/internal/pkg/stripe"
+	sdk "github.com/stripe/stripe-go/v72"
+)
+
+// RecordDunning saves a failed attempt to pay an invoice.
+// The returned bool tells whether this attempt is not seen
+// before so that user should be notified.
+func (env Env) RecordDunning(inv stripe.Invoice, actionRequired bool) (stripe.Dunning, bool, error) {
+	defer env.Logger.Sync()
+	sugar := env.Logger.Sugar()
+
+	prev, err := env.RetrieveDunning(inv.ID)
+	if err != nil {
+		sugar.Error(err)
+		return stripe.Dunning{}, false, err
+	}
+
+	d := stripe.NewDunning(inv, actionRequired)
+	isNew := d.IsNewAttempt(prev)
+	d = d.WithPrior(prev)
+
+	err = env.UpsertDunning(d)
+	if err != nil {
+		sugar.Error(err)
+		return stripe.Dunning{}, false, err
+	}
+
+	return d, isNew, nil
+}
+
+// RecoverDunning closes dunning after an invoice is paid.
+func (env Env) RecoverDunning(invoiceID string) error {
+	d, err := env.RetrieveDunning(invoiceID)
+	if err != nil {
+		return err
+	}
+
+	if d.IsZero() || d.Status != stripe.DunningStatusOpen {
+		return nil
+	}
+
+	return env.ResolveDunning(d.Resolved(stripe.DunningStatusRecovered))
+}
+
+// ExhaustDunning closes dunning of a subscription after
+// Stripe canceled it or marked it unpaid.
+func (env Env) ExhaustDunning(subsID string) error {
+	d, err := env.OpenDunning(subsID)
+	if err != nil {
+		return err
+	}
+
+	if d.IsZero() {
+		return nil
+	}
+
+	return env.ResolveDunning(d.Resolved(stripe.DunningStatusExhausted))
+}
+
+// RetryPayment pays the open latest invoice of a subscription
+// with the payment method user just provided.
+// If the card requires authentication, the invoice and its
+// payment intent is returned without error so that client
+// could confirm it.
+func (env Env) RetryPayment(cusID string, subsID string, params stripe.RetryPaymentParams) (stripe.RetryPaymentResult, error) {
+	defer env.Logger.Sync()
+	sugar := env.Logger.Sugar()
+
+	ss, err := env.Client.FetchSubs(subsID, true)
+	if err != nil {
+		sugar.Error(err)
+		return stripe.RetryPaymentResult{}, err
+	}
+
+	if ss.Customer == nil || ss.Customer.ID != cusID {
+		return stripe.RetryPaymentResult{}, render.NewNotFound("Subscription not found")
+	}
+
+	if ss.LatestInvoice == nil || ss.LatestInvoice.Status != sdk.InvoiceStatusOpen {
+		return stripe.RetryPaymentResult{}, &render.ValidationError{
+			Message: "Subscription has no open invoice to pay",
+			Field:   "invoice",
+			Code:    render.CodeInvalid,
+		}
+	}
+
+	rawInv, err := env.Client.PayInvoice(ss.LatestInvoice.ID, params.InvoicePayParams())
+	if err != nil {
+		var se *sdk.Error
+		if !errors.As(err, &se) || se.Code != sdk.ErrorCodeInvoicePamentIntentRequiresAction {
+			return stripe.RetryPaymentResult{}, err
+		}
+
+		sugar.Infof("Invoice %s requires authentication", ss.LatestInvoice.ID)
+		rawInv, err = env.Client.FetchInvoice(ss.LatestInvoice.ID)
+		if err != nil {
+			return stripe.RetryPaymentResult{}, err
+		}
+	}
+
+	// Payment intent is only expanded when paying succeeded.
+	rawPI := rawInv.PaymentIntent
+	if rawPI != nil && rawPI.Status == "" {
+		rawPI, err = env.Client.FetchPaymentIntent(rawPI.ID)
+		if err != nil {
+			sugar.Error(err)
+			return stripe.RetryPaymentResult{}, err
+		}
+	}
+
+	// Subscription status changes after invoice paid.
+	ss, err = env.Client.FetchSubs(subsID, true)
+	if err != nil {
+		sugar.Error(err)
+		return stripe.RetryPaymentResult{}, err
+	}
+
+	return stripe.RetryPaymentResult{
+		Invoice:       stripe.NewInvoice(rawInv),
+		PaymentIntent: stripe.NewPaymentIntent(rawPI),
+		Subs:          stripe.NewSubs("", ss),
+	}, nil
+}
@@ -1,8 +1,11 @@
 package stripeenv
 
 import (
+	"context"
+
 	"github.com/FTChinese/subscription-api/internal/repository"
 	"github.com/FTChinese/subscription-api/internal/stripeclient"
+	"go.uber.org/zap"
 )
 
 type Env struct {
@@ -16,3 +19,17 @@ func New(client stripeclient.Client, repo repository.StripeRepo) Env {
 		StripeRepo: repo,
 	}
 }
+
+// WithLogger returns a copy whose client and repo log with l.
+func (env Env) WithLogger(l *zap.Logger) Env {
+	return Env{
+		Client:     env.Client.WithLogger(l),
+		StripeRepo: env.StripeRepo.WithLogger(l),
+	}
+}
+
+// WithContext returns a copy whose repo runs statements within ctx.
+func (env Env) WithContext(ctx context.Context) Env {
+	env.StripeRepo = env.StripeRepo.WithContext(ctx)
+	return env
+}
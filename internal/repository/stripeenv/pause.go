@@ -0,0 +1,164 @@
+package stripeenv
+
+import (
+	"database/sql"
+
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/internal/pkg/stripe"
+	"github.com/FTChinese/subscription-api/pkg/reader"
+	sdk "github.com/stripe/stripe-go/v72"
+)
+
+// PauseSubscription pauses payment collection of a subscription.
+// Membership expires at the end of the period already paid
+// and stops auto renewal until resumed.
+func (env Env) PauseSubscription(ftcID string, subsID string, params stripe.PauseParams) (stripe.SubsResult, error) {
+	return env.togglePause(ftcID, subsID, func(mmb reader.Membership) (*sdk.SubscriptionParams, error) {
+		if mmb.IsPaused() {
+			return nil, &render.ValidationError{
+				Message: "Subscription is already paused",
+				Field:   "pause",
+				Code:    render.CodeAlreadyExists,
+			}
+		}
+
+		// Canceled at period end, or in dunning.
+		if !mmb.AutoRenewal {
+			return nil, &render.ValidationError{
+				Message: "Only a subscription renewing automatically could be paused",
+				Field:   "autoRenew",
+				Code:    render.CodeInvalid,
+			}
+		}
+
+		return params.SubsParams(), nil
+	}, reader.NewArchiver().ByStripe().ActionPause())
+}
+
+// ResumeSubscription resumes payment collection of a paused
+// subscription.
+func (env Env) ResumeSubscription(ftcID string, subsID string) (stripe.SubsResult, error) {
+	return env.togglePause(ftcID, subsID, func(mmb reader.Membership) (*sdk.SubscriptionParams, error) {
+		if !mmb.IsPaused() {
+			return nil, &render.ValidationError{
+				Message: "Subscription is not paused",
+				Field:   "pause",
+				Code:    render.CodeInvalid,
+			}
+		}
+
+		return stripe.ResumeParams(mmb.Pause), nil
+	}, reader.NewArchiver().ByStripe().ActionResume())
+}
+
+// togglePause updates the subscription with the params built
+// from current membership, and syncs membership in the same
+// way as CancelSubscription.
+func (env Env) togglePause(
+	ftcID string,
+	subsID string,
+	buildParams func(mmb reader.Membership) (*sdk.SubscriptionParams, error),
+	archiver reader.Archiver,
+) (stripe.SubsResult, error) {
+	defer env.Logger.Sync()
+	sugar := env.Logger.Sugar()
+
+	tx, err := env.BeginStripeTx()
+	if err != nil {
+		sugar.Error(err)
+		return stripe.SubsResult{}, err
+	}
+
+	mmb, err := tx.RetrieveMember(ftcID)
+	if err != nil {
+		sugar.Error(err)
+		_ = tx.Rollback()
+		return stripe.SubsResult{}, err
+	}
+
+	if !mmb.IsStripeSubsMatch(subsID) {
+		_ = tx.Rollback()
+		return stripe.SubsResult{}, sql.ErrNoRows
+	}
+
+	params, err := buildParams(mmb)
+	if err != nil {
+		_ = tx.Rollback()
+		return stripe.SubsResult{}, err
+	}
+
+	ss, err := env.Client.UpdateSubs(subsID, params)
+	if err != nil {
+		sugar.Error(err)
+		_ = tx.Rollback()
+		return stripe.SubsResult{}, err
+	}
+
+	sugar.Infof("Subscription %s pause collection %v", ss.ID, ss.PauseCollection)
+
+	subs := stripe.NewSubs(mmb.FtcID.String, ss)
+	result := stripe.SubsSuccessBuilder{
+		UserIDs:       mmb.UserIDs,
+		Kind:          reader.IntentNull,
+		CurrentMember: mmb,
+		Subs:          subs,
+		Archiver:      archiver,
+	}.Build()
+
+	if err := tx.UpdateMember(result.Member); err != nil {
+		sugar.Error(err)
+		_ = tx.Rollback()
+		return stripe.SubsResult{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		sugar.Error(err)
+		return stripe.SubsResult{}, err
+	}
+
+	return result, nil
+}
+
+// RestartResumedCycle handles a subscription resumed by Stripe
+// upon the resume date. Invoice of current period was voided,
+// or left as draft or uncollectible during pause; so billing
+// cycle restarts now to charge before granting access again.
+// The subscription is returned as is if it was not paused, or
+// is already charged, e.g., resumed by user via API.
+func (env Env) RestartResumedCycle(ss *sdk.Subscription) (*sdk.Subscription, error) {
+	defer env.Logger.Sync()
+	sugar := env.Logger.Sugar()
+
+	if ss.PauseCollection.Behavior != "" {
+		return ss, nil
+	}
+
+	stored, err := env.RetrieveSubs(ss.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ss, nil
+		}
+		return nil, err
+	}
+
+	if stored.Pause.IsZero() {
+		return ss, nil
+	}
+
+	expanded, err := env.Client.FetchSubs(ss.ID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if expanded.Status != sdk.SubscriptionStatusActive || expanded.LatestInvoice == nil {
+		return expanded, nil
+	}
+
+	if !stripe.NewInvoice(expanded.LatestInvoice).IsSkippedByPause() {
+		return expanded, nil
+	}
+
+	sugar.Infof("Restarting billing cycle of resumed subscription %s", ss.ID)
+
+	return env.Client.UpdateSubs(ss.ID, stripe.RestartCycleParams())
+}
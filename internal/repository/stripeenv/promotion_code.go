@@ -0,0 +1,29 @@
+package stripeenv
+
+import (
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/pkg/price"
+)
+
+// FindPromoCode resolves the code user entered into a
+// promotion code with its coupon.
+// An active one takes precedence if a code is reused after
+// its predecessor is deactivated.
+func (env Env) FindPromoCode(code string) (price.StripePromoCode, error) {
+	list, err := env.Client.ListPromoCodes(code)
+	if err != nil {
+		return price.StripePromoCode{}, err
+	}
+
+	if len(list) == 0 {
+		return price.StripePromoCode{}, render.NewNotFound("Promotion code not found")
+	}
+
+	for _, p := range list {
+		if p.Active {
+			return price.NewStripePromoCode(p), nil
+		}
+	}
+
+	return price.NewStripePromoCode(list[0]), nil
+}
@@ -0,0 +1,199 @@
+package stripeenv
+
+import (
+	"time"
+
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/internal/pkg/stripe"
+	"github.com/FTChinese/subscription-api/internal/repository"
+	"github.com/FTChinese/subscription-api/pkg/reader"
+	sdk "github.com/stripe/stripe-go/v72"
+)
+
+// RefundInvoice refunds the charge of an invoice on behalf of
+// staff, optionally stopping the subscription and adjusting
+// membership accordingly.
+// Membership is locked until the refund is recorded so that
+// webhook arriving in the meantime waits for it.
+// The refund is saved as soon as Stripe returns it. If
+// adjustment fails afterwards, no error is returned since
+// money is already refunded; the refund is returned with
+// AdjustPending instead.
+func (env Env) RefundInvoice(invoiceID string, params stripe.RefundParams, staff string) (stripe.RefundResult, error) {
+	defer env.Logger.Sync()
+	sugar := env.Logger.Sugar()
+
+	rawInv, err := env.Client.FetchInvoice(invoiceID)
+	if err != nil {
+		return stripe.RefundResult{}, err
+	}
+	inv := stripe.NewInvoice(rawInv)
+
+	if !inv.Paid || inv.ChargeID == "" {
+		return stripe.RefundResult{}, &render.ValidationError{
+			Message: "Only a paid invoice could be refunded",
+			Field:   "invoice",
+			Code:    render.CodeInvalid,
+		}
+	}
+
+	if params.AdjustsSubs() && !inv.SubscriptionID.Valid {
+		return stripe.RefundResult{}, &render.ValidationError{
+			Message: "Invoice does not belong to a subscription",
+			Field:   "invoice",
+			Code:    render.CodeInvalid,
+		}
+	}
+
+	var ss *sdk.Subscription
+	if params.Expire == stripe.RefundExpiryProrated {
+		ss, err = env.Client.FetchSubs(inv.SubscriptionID.String, false)
+		if err != nil {
+			sugar.Error(err)
+			return stripe.RefundResult{}, err
+		}
+
+		if ve := params.ValidateSubs(stripe.NewSubs("", ss), inv); ve != nil {
+			return stripe.RefundResult{}, ve
+		}
+	}
+
+	ch, err := env.Client.FetchCharge(inv.ChargeID)
+	if err != nil {
+		sugar.Error(err)
+		return stripe.RefundResult{}, err
+	}
+
+	amount, ve := params.RefundAmount(ch)
+	if ve != nil {
+		return stripe.RefundResult{}, ve
+	}
+
+	tx, err := env.BeginStripeTx()
+	if err != nil {
+		sugar.Error(err)
+		return stripe.RefundResult{}, err
+	}
+
+	var mmb reader.Membership
+	if inv.SubscriptionID.Valid {
+		mmb, err = tx.RetrieveStripeMember(inv.SubscriptionID.String)
+		if err != nil {
+			sugar.Error(err)
+			_ = tx.Rollback()
+			return stripe.RefundResult{}, err
+		}
+	}
+
+	if params.AdjustsSubs() && mmb.IsZero() {
+		_ = tx.Rollback()
+		return stripe.RefundResult{}, render.NewNotFound("Membership of the subscription not found")
+	}
+
+	rawRefund, err := env.Client.NewRefund(params.RefundParams(inv.ChargeID, amount, staff))
+	if err != nil {
+		sugar.Error(err)
+		_ = tx.Rollback()
+		return stripe.RefundResult{}, err
+	}
+
+	sugar.Infof("Invoice %s refunded %d by %s", inv.ID, rawRefund.Amount, staff)
+
+	result := stripe.RefundResult{
+		Refund: stripe.NewRefund(rawRefund, inv, params, staff).
+			WithMember(mmb),
+		Member: mmb,
+	}
+
+	if err := env.SaveRefund(result.Refund); err != nil {
+		sugar.Errorf("Refund %s of invoice %s not saved: %s", rawRefund.ID, inv.ID, err)
+		_ = tx.Rollback()
+		return stripe.RefundResult{}, err
+	}
+
+	if !params.AdjustsSubs() {
+		if err := tx.Commit(); err != nil {
+			sugar.Error(err)
+		}
+		return result, nil
+	}
+
+	adjusted, err := env.adjustAfterRefund(tx, result.Refund, mmb, params, ss, ch.AmountRefunded+amount, ch.Amount)
+	if err != nil {
+		sugar.Errorf("Refund %s saved but subscription %s not adjusted: %s", rawRefund.ID, inv.SubscriptionID.String, err)
+		_ = tx.Rollback()
+		return result, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		sugar.Errorf("Refund %s saved but subscription %s not adjusted: %s", rawRefund.ID, inv.SubscriptionID.String, err)
+		return result, nil
+	}
+
+	return adjusted, nil
+}
+
+// adjustAfterRefund stops subscription and updates membership
+// and the refund saved in tx.
+func (env Env) adjustAfterRefund(
+	tx repository.StripeTx,
+	refund stripe.Refund,
+	mmb reader.Membership,
+	params stripe.RefundParams,
+	ss *sdk.Subscription,
+	refunded int64,
+	paid int64,
+) (stripe.RefundResult, error) {
+	if err := env.releasePendingChange(tx, mmb); err != nil {
+		return stripe.RefundResult{}, err
+	}
+
+	ss, err := env.stopSubs(refund.SubsID.String, params, ss, refunded, paid)
+	if err != nil {
+		return stripe.RefundResult{}, err
+	}
+
+	subsResult := stripe.SubsSuccessBuilder{
+		UserIDs:       mmb.UserIDs,
+		Kind:          reader.IntentNull,
+		CurrentMember: mmb,
+		Subs:          stripe.NewSubs(mmb.FtcID.String, ss),
+		Archiver:      reader.NewArchiver().By(refund.CreatedBy).ActionRefund(),
+	}.Build()
+
+	if err := tx.UpdateMember(subsResult.Member); err != nil {
+		return stripe.RefundResult{}, err
+	}
+
+	refund = refund.Adjusted(subsResult.Member)
+	if err := tx.SaveRefundAdjusted(refund); err != nil {
+		return stripe.RefundResult{}, err
+	}
+
+	return stripe.RefundResult{
+		Refund:    refund,
+		Subs:      subsResult.Subs,
+		Member:    subsResult.Member,
+		Versioned: subsResult.Versioned,
+	}, nil
+}
+
+// stopSubs cancels a subscription immediately, or at the
+// date current period is shortened to after a prorated refund.
+// ss is the subscription fetched before refunding to check
+// the invoice is the latest, required for prorated refund.
+func (env Env) stopSubs(subsID string, params stripe.RefundParams, ss *sdk.Subscription, refunded int64, paid int64) (*sdk.Subscription, error) {
+	if params.CancelSubs {
+		return env.Client.CancelSubsNow(subsID)
+	}
+
+	at := stripe.ProratedExpiry(stripe.NewSubs("", ss), refunded, paid)
+	if !at.After(time.Now()) {
+		return env.Client.CancelSubsNow(subsID)
+	}
+
+	cancelAt := stripe.CancelAtParams(at)
+	cancelAt.AddExpand(stripe.KeyLatestInvoicePaymentIntent)
+
+	return env.Client.UpdateSubs(subsID, cancelAt)
+}
@@ -0,0 +1,176 @@
+package stripeenv
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/internal/pkg/stripe"
+	"github.com/FTChinese/subscription-api/internal/repository"
+	"github.com/FTChinese/subscription-api/pkg/reader"
+	sdk "github.com/stripe/stripe-go/v72"
+)
+
+// scheduleChange defers a downgrade or interval switch to
+// current period end by attaching a subscription schedule.
+// An existing schedule is updated so that only the latest
+// choice takes effect.
+// Neither the subscription nor membership changes until
+// Stripe starts the next phase, so only the pending change
+// is saved.
+func (env Env) scheduleChange(
+	tx repository.StripeTx,
+	cart reader.ShoppingCart,
+	subs stripe.Subs,
+	params stripe.SubsParams,
+) (stripe.SubsResult, error) {
+	defer env.Logger.Sync()
+	sugar := env.Logger.Sugar()
+
+	mmb := cart.CurrentMember
+
+	var sched *sdk.SubscriptionSchedule
+	var err error
+	if mmb.PendingChange.IsZero() {
+		sched, err = env.Client.NewScheduleFromSubs(subs.ID)
+	} else {
+		sched, err = env.Client.FetchSchedule(mmb.PendingChange.ScheduleID)
+	}
+	if err != nil {
+		sugar.Error(err)
+		return stripe.SubsResult{}, err
+	}
+
+	if sched.CurrentPhase == nil {
+		return stripe.SubsResult{}, errors.New("subscription schedule has no phase in effect")
+	}
+
+	sched, err = env.Client.UpdateSchedule(
+		sched.ID,
+		params.ScheduleParams(sched, cart.StripeItem))
+	if err != nil {
+		sugar.Error(err)
+		return stripe.SubsResult{}, err
+	}
+
+	change := stripe.NewPendingChange(sched)
+	sugar.Infof("Subscription %s scheduled to change to %s at %s", subs.ID, change.PriceID, change.EffectiveUTC)
+
+	err = tx.SavePendingChange(subs.ID, change)
+	if err != nil {
+		sugar.Error(err)
+		return stripe.SubsResult{}, err
+	}
+
+	mmb.PendingChange = change
+
+	return stripe.SubsResult{
+		Modified: false,
+		Subs:     subs.WithPendingChange(change),
+		Member:   mmb,
+	}, nil
+}
+
+// releasePendingChange drops the schedule attached to a
+// subscription before it is modified immediately; otherwise
+// the schedule would override the modification at period end.
+func (env Env) releasePendingChange(tx repository.StripeTx, m reader.Membership) error {
+	if m.PendingChange.IsZero() {
+		return nil
+	}
+
+	_, err := env.Client.ReleaseSchedule(m.PendingChange.ScheduleID)
+	if err != nil {
+		return err
+	}
+
+	return tx.SavePendingChange(m.StripeSubsID.String, reader.PendingChange{})
+}
+
+// CancelPendingChange releases the schedule so that the
+// subscription renews with current price.
+func (env Env) CancelPendingChange(ftcID string, subsID string) (stripe.SubsResult, error) {
+	defer env.Logger.Sync()
+	sugar := env.Logger.Sugar()
+
+	tx, err := env.BeginStripeTx()
+	if err != nil {
+		sugar.Error(err)
+		return stripe.SubsResult{}, err
+	}
+
+	mmb, err := tx.RetrieveMember(ftcID)
+	if err != nil {
+		sugar.Error(err)
+		_ = tx.Rollback()
+		return stripe.SubsResult{}, err
+	}
+
+	if !mmb.IsStripeSubsMatch(subsID) {
+		_ = tx.Rollback()
+		return stripe.SubsResult{}, sql.ErrNoRows
+	}
+
+	if mmb.PendingChange.IsZero() {
+		_ = tx.Rollback()
+		return stripe.SubsResult{}, render.NewNotFound("No pending change for this subscription")
+	}
+
+	err = env.releasePendingChange(tx, mmb)
+	if err != nil {
+		sugar.Error(err)
+		_ = tx.Rollback()
+		return stripe.SubsResult{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		sugar.Error(err)
+		return stripe.SubsResult{}, err
+	}
+
+	subs, err := env.LoadOrFetchSubs(subsID, false)
+	if err != nil {
+		sugar.Error(err)
+	}
+
+	mmb.PendingChange = reader.PendingChange{}
+
+	return stripe.SubsResult{
+		Modified: false,
+		Subs:     subs.WithPendingChange(reader.PendingChange{}),
+		Member:   mmb,
+	}, nil
+}
+
+// SyncSchedule saves the pending change of a schedule
+// received from webhook. Prices are not expanded in webhook
+// payload, so the schedule is fetched again unless it is
+// no longer active.
+func (env Env) SyncSchedule(sched *sdk.SubscriptionSchedule) (reader.PendingChange, error) {
+	defer env.Logger.Sync()
+	sugar := env.Logger.Sugar()
+
+	subsID := stripe.ScheduleSubsID(sched)
+	if subsID == "" {
+		return reader.PendingChange{}, nil
+	}
+
+	if sched.Status == sdk.SubscriptionScheduleStatusActive {
+		s, err := env.Client.FetchSchedule(sched.ID)
+		if err != nil {
+			sugar.Error(err)
+			return reader.PendingChange{}, err
+		}
+		sched = s
+	}
+
+	change := stripe.NewPendingChange(sched)
+
+	err := env.SavePendingChange(subsID, change)
+	if err != nil {
+		sugar.Error(err)
+		return reader.PendingChange{}, err
+	}
+
+	return change, nil
+}
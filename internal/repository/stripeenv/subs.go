@@ -72,6 +72,13 @@ func (env Env) CreateSubscription(cart reader.ShoppingCart, params stripe.SubsPa
 		return cart, stripe.SubsResult{}, errors.New("this endpoint only permit creating a new stripe subscription")
 	}
 
+	cart, err = env.WithTrial(cart)
+	if err != nil {
+		sugar.Error(err)
+		_ = tx.Rollback()
+		return cart, stripe.SubsResult{}, err
+	}
+
 	sugar.Info("Creating stripe subscription")
 	// Contact Stripe API.
 	ss, err := env.Client.NewSubs(params.NewSubParams(cart.Account.StripeID.String, cart.StripeItem))
@@ -189,6 +196,30 @@ func (env Env) UpdateSubscription(
 		return cart, stripe.SubsResult{}, err
 	}
 
+	// Downgrade and interval switch take effect at period end
+	// so that user keeps what is already paid for.
+	if cart.Intent.Kind.IsScheduled() {
+		result, err := env.scheduleChange(tx, cart, currentSubs, params)
+		if err != nil {
+			_ = tx.Rollback()
+			return cart, stripe.SubsResult{}, err
+		}
+
+		if err := tx.Commit(); err != nil {
+			sugar.Error(err)
+			return cart, stripe.SubsResult{}, err
+		}
+
+		return cart, result, nil
+	}
+
+	// Upgrading immediately supersedes any scheduled change.
+	if err := env.releasePendingChange(tx, mmb); err != nil {
+		sugar.Error(err)
+		_ = tx.Rollback()
+		return cart, stripe.SubsResult{}, err
+	}
+
 	ss, err := env.Client.UpdateSubs(
 		currentSubs.ID,
 		params.UpdateSubParams(currentSubs.Items[0].ID, cart.StripeItem),
@@ -257,6 +288,11 @@ func (env Env) CancelSubscription(params stripe.CancelParams) (stripe.SubsResult
 		return stripe.SubsResult{}, sql.ErrNoRows
 	}
 
+	if mmb.IsPaused() {
+		_ = tx.Rollback()
+		return stripe.SubsResult{}, stripe.ErrSubsPaused
+	}
+
 	// If you want to cancel it, and membership is not auto-renewal,
 	// it means it is already canceled.
 	// If cancel is false, you are reactivating a canceled subscription.
@@ -272,6 +308,16 @@ func (env Env) CancelSubscription(params stripe.CancelParams) (stripe.SubsResult
 		}, nil
 	}
 
+	// A subscription managed by schedule cannot be canceled
+	// at period end, and there is nothing to change to anyway.
+	if params.Cancel {
+		if err := env.releasePendingChange(tx, mmb); err != nil {
+			sugar.Error(err)
+			_ = tx.Rollback()
+			return stripe.SubsResult{}, err
+		}
+	}
+
 	ss, err := env.Client.CancelSubs(params.SubID, params.Cancel)
 	if err != nil {
 		sugar.Error(err)
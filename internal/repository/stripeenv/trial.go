@@ -0,0 +1,27 @@
+package stripeenv
+
+import (
+	"github.com/FTChinese/subscription-api/pkg/reader"
+)
+
+// WithTrial grants the trial of the price in cart if user
+// never had any membership, nor any Stripe subscription which
+// might be detached from membership later.
+func (env Env) WithTrial(cart reader.ShoppingCart) (reader.ShoppingCart, error) {
+	if !cart.StripeItem.IsTrialOffered() {
+		return cart, nil
+	}
+
+	if !cart.CurrentMember.IsZero() {
+		cart.StripeItem = cart.StripeItem.WithTrial(false)
+		return cart, nil
+	}
+
+	subscribed, err := env.HasSubsOfUser(cart.Account.FtcID)
+	if err != nil {
+		return cart, err
+	}
+
+	cart.StripeItem = cart.StripeItem.WithTrial(!subscribed)
+	return cart, nil
+}
@@ -3,6 +3,7 @@ package subrepo
 import (
 	"database/sql"
 	"github.com/FTChinese/subscription-api/internal/pkg/ftcpay"
+	"github.com/FTChinese/subscription-api/pkg/metrics"
 )
 
 // ConfirmOrder updates the order received from webhook,
@@ -149,12 +150,17 @@ func (env Env) ConfirmOrder(pr ftcpay.PaymentResult, order ftcpay.Order) (ftcpay
 	}
 
 	sugar.Info("Order confirmation finished")
+	if !order.IsConfirmed() {
+		metrics.OrdersConfirmed.
+			WithLabelValues(order.PaymentMethod.String()).
+			Inc()
+	}
 
 	return confirmed, nil
 }
 
 func (env Env) SaveConfirmErr(e *ftcpay.ConfirmError) error {
-	_, err := env.dbs.Write.NamedExec(
+	_, err := env.dbs.Write.NamedExecContext(env.ctx,
 		ftcpay.StmtSaveConfirmResult,
 		e)
 
@@ -170,7 +176,7 @@ func (env Env) SavePayResult(result ftcpay.PaymentResult) error {
 		return nil
 	}
 
-	_, err := env.dbs.Write.NamedExec(ftcpay.StmtSavePayResult, result)
+	_, err := env.dbs.Write.NamedExecContext(env.ctx, ftcpay.StmtSavePayResult, result)
 	if err != nil {
 		return err
 	}
@@ -6,7 +6,7 @@ import (
 )
 
 func (env Env) InsertDiscountRedeemed(r ftcpay.DiscountRedeemed) error {
-	_, err := env.dbs.Write.NamedExec(
+	_, err := env.dbs.Write.NamedExecContext(env.ctx,
 		ftcpay.StmtInsertDiscountRedeemed,
 		r)
 
@@ -15,7 +15,7 @@ func (env Env) InsertDiscountRedeemed(r ftcpay.DiscountRedeemed) error {
 
 func (env Env) RetrieveDiscountRedeemed(userIDs ids.UserIDs, discountID string) (ftcpay.DiscountRedeemed, error) {
 	var redeemed ftcpay.DiscountRedeemed
-	err := env.dbs.Read.Get(
+	err := env.dbs.Read.GetContext(env.ctx,
 		&redeemed,
 		ftcpay.StmtRetrieveDiscountRedeemed,
 		userIDs.BuildFindInSet(),
@@ -1,6 +1,8 @@
 package subrepo
 
 import (
+	"context"
+
 	"github.com/FTChinese/subscription-api/internal/repository/txrepo"
 	"github.com/FTChinese/subscription-api/pkg/db"
 	"go.uber.org/zap"
@@ -11,6 +13,7 @@ import (
 type Env struct {
 	dbs    db.ReadWriteMyDBs
 	logger *zap.Logger
+	ctx    context.Context // Statements run within, for tracing.
 }
 
 // New creates a new instance of Env.
@@ -18,15 +21,34 @@ func New(dbs db.ReadWriteMyDBs, logger *zap.Logger) Env {
 	return Env{
 		dbs:    dbs,
 		logger: logger,
+		ctx:    context.Background(),
 	}
 }
 
+// WithLogger returns a copy logging with l, usually the
+// request-scoped logger.
+func (env Env) WithLogger(l *zap.Logger) Env {
+	env.logger = l
+	return env
+}
+
+// WithContext returns a copy running statements within ctx,
+// usually the request context, so that SQL spans belong to
+// the trace of the request.
+func (env Env) WithContext(ctx context.Context) Env {
+	env.ctx = ctx
+	return env
+}
+
 func (env Env) BeginOrderTx() (txrepo.OrderTx, error) {
-	tx, err := env.dbs.Delete.Beginx()
+	tx, err := env.dbs.Delete.BeginTxx(env.ctx, nil)
 
 	if err != nil {
 		return txrepo.OrderTx{}, err
 	}
 
-	return txrepo.NewOrderTx(tx), nil
+	otx := txrepo.NewOrderTx(tx)
+	otx.SharedTx = otx.SharedTx.WithContext(env.ctx)
+
+	return otx, nil
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/FTChinese/subscription-api/pkg"
 	"github.com/FTChinese/subscription-api/pkg/footprint"
 	"github.com/FTChinese/subscription-api/pkg/ids"
+	"github.com/FTChinese/subscription-api/pkg/metrics"
 	"github.com/FTChinese/subscription-api/pkg/reader"
 )
 
@@ -70,12 +71,16 @@ func (env Env) CreateOrder(cart reader.ShoppingCart) (ftcpay.PaymentIntent, erro
 		return ftcpay.PaymentIntent{}, err
 	}
 
+	metrics.OrdersCreated.
+		WithLabelValues(pi.Order.PaymentMethod.String()).
+		Inc()
+
 	return pi, nil
 }
 
 func (env Env) SaveOrderMeta(c footprint.OrderClient) error {
 
-	_, err := env.dbs.Write.NamedExec(
+	_, err := env.dbs.Write.NamedExecContext(env.ctx,
 		footprint.StmtInsertOrderClient,
 		c)
 
@@ -90,7 +95,7 @@ func (env Env) SaveOrderMeta(c footprint.OrderClient) error {
 func (env Env) RetrieveOrder(orderID string) (ftcpay.Order, error) {
 	var order ftcpay.Order
 
-	err := env.dbs.Read.Get(
+	err := env.dbs.Read.GetContext(env.ctx,
 		&order,
 		ftcpay.StmtSelectOrder,
 		orderID)
@@ -104,7 +109,7 @@ func (env Env) RetrieveOrder(orderID string) (ftcpay.Order, error) {
 
 func (env Env) countOrders(uids ids.UserIDs) (int64, error) {
 	var count int64
-	err := env.dbs.Read.Get(
+	err := env.dbs.Read.GetContext(env.ctx,
 		&count,
 		ftcpay.StmtCountOrders,
 		uids.BuildFindInSet(),
@@ -119,7 +124,7 @@ func (env Env) countOrders(uids ids.UserIDs) (int64, error) {
 
 func (env Env) listOrders(ids ids.UserIDs, p gorest.Pagination) ([]ftcpay.Order, error) {
 	var orders = make([]ftcpay.Order, 0)
-	err := env.dbs.Read.Select(
+	err := env.dbs.Read.SelectContext(env.ctx,
 		&orders,
 		ftcpay.StmtListOrders,
 		ids.BuildFindInSet(),
@@ -178,7 +183,7 @@ func (env Env) ListOrders(ids ids.UserIDs, p gorest.Pagination) (pkg.PagedData[f
 func (env Env) orderHeader(orderID string) (ftcpay.Order, error) {
 	var order ftcpay.Order
 
-	err := env.dbs.Read.Get(
+	err := env.dbs.Read.GetContext(env.ctx,
 		&order,
 		ftcpay.StmtOrderHeader,
 		orderID)
@@ -193,7 +198,7 @@ func (env Env) orderHeader(orderID string) (ftcpay.Order, error) {
 func (env Env) orderTail(orderID string) (ftcpay.Order, error) {
 	var order ftcpay.Order
 
-	err := env.dbs.Read.Get(
+	err := env.dbs.Read.GetContext(env.ctx,
 		&order,
 		ftcpay.StmtOrderTail,
 		orderID)
@@ -6,7 +6,7 @@ import (
 )
 
 func (env Env) SaveAliWebhookPayload(p ali.WebhookPayload) error {
-	_, err := env.dbs.Write.NamedExec(
+	_, err := env.dbs.Write.NamedExecContext(env.ctx,
 		ali.StmtSavePayload,
 		p)
 
@@ -18,7 +18,7 @@ func (env Env) SaveAliWebhookPayload(p ali.WebhookPayload) error {
 }
 
 func (env Env) SaveAliOrderQueryPayload(p ali.OrderQueryPayload) error {
-	_, err := env.dbs.Write.NamedExec(
+	_, err := env.dbs.Write.NamedExecContext(env.ctx,
 		ali.StmtSavePayload,
 		p)
 
@@ -30,7 +30,7 @@ func (env Env) SaveAliOrderQueryPayload(p ali.OrderQueryPayload) error {
 }
 
 func (env Env) SaveWxPayload(schema wechat.PayloadSchema) error {
-	_, err := env.dbs.Write.NamedExec(
+	_, err := env.dbs.Write.NamedExecContext(env.ctx,
 		wechat.StmtSavePayload,
 		schema)
 
@@ -5,7 +5,7 @@ import (
 )
 
 func (env Env) SavePaymentIntent(pi ftcpay.PaymentIntentSchema) error {
-	_, err := env.dbs.Write.NamedExec(
+	_, err := env.dbs.Write.NamedExecContext(env.ctx,
 		ftcpay.StmtSavePaymentIntent,
 		pi)
 
@@ -19,7 +19,7 @@ func (env Env) SavePaymentIntent(pi ftcpay.PaymentIntentSchema) error {
 func (env Env) RetrievePaymentIntent(orderID string) (ftcpay.PaymentIntent, error) {
 	var intent ftcpay.PaymentIntentSchema
 
-	err := env.dbs.Read.Get(
+	err := env.dbs.Read.GetContext(env.ctx,
 		&intent,
 		ftcpay.StmtRetrievePaymentIntent,
 		orderID)
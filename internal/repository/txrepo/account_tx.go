@@ -2,6 +2,7 @@ package txrepo
 
 import (
 	"github.com/FTChinese/subscription-api/pkg/account"
+	"github.com/FTChinese/subscription-api/pkg/applelogin"
 	"github.com/jmoiron/sqlx"
 )
 
@@ -56,6 +57,16 @@ func (tx AccountTx) AddUnionIDToFtc(a account.BaseAccount) error {
 	return nil
 }
 
+// InsertAppleLink maps an Apple user to ftc account.
+func (tx AccountTx) InsertAppleLink(l applelogin.Link) error {
+	_, err := tx.NamedExec(applelogin.StmtInsertLink, l)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // RetrieveMobiles retrieves all rows matching a ftc id
 // or a mobile number.
 // Returns two row at maximum.
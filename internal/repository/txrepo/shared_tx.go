@@ -1,6 +1,7 @@
 package txrepo
 
 import (
+	"context"
 	"database/sql"
 	"github.com/FTChinese/subscription-api/pkg/ids"
 	"github.com/FTChinese/subscription-api/pkg/invoice"
@@ -10,14 +11,49 @@ import (
 
 type SharedTx struct {
 	*sqlx.Tx
+	ctx context.Context // Statements run within, for tracing.
 }
 
 func NewSharedTx(tx *sqlx.Tx) SharedTx {
 	return SharedTx{
-		Tx: tx,
+		Tx:  tx,
+		ctx: context.Background(),
 	}
 }
 
+// WithContext returns a copy running statements within ctx.
+// It should be the context the transaction began with.
+func (tx SharedTx) WithContext(ctx context.Context) SharedTx {
+	tx.ctx = ctx
+	return tx
+}
+
+func (tx SharedTx) context() context.Context {
+	if tx.ctx == nil {
+		return context.Background()
+	}
+	return tx.ctx
+}
+
+// The following shadow methods of sqlx.Tx so that statements
+// of all transactions pass the context.
+
+func (tx SharedTx) Get(dest interface{}, query string, args ...interface{}) error {
+	return tx.Tx.GetContext(tx.context(), dest, query, args...)
+}
+
+func (tx SharedTx) Select(dest interface{}, query string, args ...interface{}) error {
+	return tx.Tx.SelectContext(tx.context(), dest, query, args...)
+}
+
+func (tx SharedTx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return tx.Tx.ExecContext(tx.context(), query, args...)
+}
+
+func (tx SharedTx) NamedExec(query string, arg interface{}) (sql.Result, error) {
+	return tx.Tx.NamedExecContext(tx.context(), query, arg)
+}
+
 // CreateMember creates a new membership.
 func (tx SharedTx) CreateMember(m reader.Membership) error {
 
@@ -1,27 +1,46 @@
 package internal
 
 import (
+	"context"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/FTChinese/go-rest/render"
 	"github.com/FTChinese/subscription-api/internal/access"
 	"github.com/FTChinese/subscription-api/internal/app/api"
+	"github.com/FTChinese/subscription-api/internal/app/mailer"
 	"github.com/FTChinese/subscription-api/internal/pkg/letter"
 	"github.com/FTChinese/subscription-api/internal/repository/accounts"
+	"github.com/FTChinese/subscription-api/internal/repository/cmsrepo"
 	"github.com/FTChinese/subscription-api/internal/repository/iaprepo"
+	"github.com/FTChinese/subscription-api/internal/repository/mailrepo"
+	"github.com/FTChinese/subscription-api/internal/repository/sessionrepo"
 	"github.com/FTChinese/subscription-api/internal/repository/shared"
+	"github.com/FTChinese/subscription-api/internal/stripeclient"
 	"github.com/FTChinese/subscription-api/pkg/ali"
+	"github.com/FTChinese/subscription-api/pkg/applelogin"
+	"github.com/FTChinese/subscription-api/pkg/background"
+	"github.com/FTChinese/subscription-api/pkg/cachestore"
+	"github.com/FTChinese/subscription-api/pkg/cms"
 	"github.com/FTChinese/subscription-api/pkg/config"
 	"github.com/FTChinese/subscription-api/pkg/db"
+	"github.com/FTChinese/subscription-api/pkg/health"
+	"github.com/FTChinese/subscription-api/pkg/idempotency"
+	"github.com/FTChinese/subscription-api/pkg/metrics"
+	"github.com/FTChinese/subscription-api/pkg/postman"
+	"github.com/FTChinese/subscription-api/pkg/ratelimit"
+	"github.com/FTChinese/subscription-api/pkg/session"
+	"github.com/FTChinese/subscription-api/pkg/tracing"
 	"github.com/FTChinese/subscription-api/pkg/wechat"
 	"github.com/FTChinese/subscription-api/pkg/wxlogin"
 	"github.com/FTChinese/subscription-api/pkg/xhttp"
 	"github.com/FTChinese/subscription-api/pkg/ztsms"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
-	"github.com/patrickmn/go-cache"
 )
 
 type ServerStatus struct {
@@ -29,19 +48,69 @@ type ServerStatus struct {
 	Build      string `json:"build"`
 	Commit     string `json:"commit"`
 	Port       string `json:"-"`
+	AdminPort  string `json:"-"`
 	Production bool   `json:"production"` // Determine which db to use.
 	LiveMode   bool   `json:"liveMode"`
 }
 
+// StartServer blocks until SIGINT or SIGTERM is received,
+// then stops accepting connections and waits for in-flight
+// requests and background tasks before returning.
 func StartServer(s ServerStatus) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	logger := config.MustGetLogger(s.Production)
+	shutdownTracing, err := tracing.Setup(config.MustTracingConfig(), "subscription-api", s.Version)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Work started by handlers that should not be lost on
+	// deploy, e.g., saving membership history and emails.
+	tasks := background.NewRunner(logger)
+
 	myDBs := db.MustNewMyDBs()
 	gormDBs := db.MustNewMultiGormDBs(s.Production)
 	rdb := db.NewRedis(config.MustRedisAddress().Pick(s.Production))
 
-	// Set the cache default expiration and cleanup interval both to 2 hours.
-	cacheStore := cache.New(2*time.Hour, 2*time.Hour)
-	emailService := letter.NewService(logger)
+	// Cache shared by all instances. Local copies are dropped
+	// when another instance publishes invalidation.
+	cacheCfg := config.MustCacheConfig()
+	cacheTTLs := cachestore.TTLs{
+		Default:     cacheCfg.DefaultDuration(),
+		ByNamespace: cacheCfg.Durations(),
+	}
+	cacheMetrics := cachestore.NewMetrics()
+	metrics.RegisterCache(cacheMetrics)
+	var cacheStore cachestore.Store
+	if cacheCfg.Backend == config.CacheBackendMemory {
+		cacheStore = cachestore.NewMemory(cacheTTLs, cacheMetrics)
+	} else {
+		tiered := cachestore.NewTiered(rdb, cacheTTLs, cacheMetrics, logger)
+		go tiered.Listen(ctx)
+		cacheStore = tiered
+	}
+
+	emailService := letter.NewService(mailrepo.New(myDBs, logger), logger)
+
+	// Deliver letters queued in the outbox.
+	// Letters not sent before shutdown stay in the outbox.
+	sender := mailer.NewSender(
+		myDBs,
+		postman.MustNewTransport(config.MustMailTransport()),
+		logger)
+	tasks.Go(func() {
+		sender.Run(ctx, 30*time.Second)
+	})
+
+	sessionCfg := config.MustSessionConfig()
+	sessions := api.SessionStarter{
+		Issuer: session.NewIssuer(
+			session.NewSigner(sessionCfg),
+			sessionCfg.AccessDuration(),
+			sessionCfg.RefreshDuration()),
+		Repo: sessionrepo.New(myDBs, cacheStore, logger),
+	}
 
 	readerBaseRepo := shared.NewReaderCommon(myDBs)
 	userShared := api.UserShared{
@@ -50,13 +119,20 @@ func StartServer(s ServerStatus) {
 		SMSClient:    ztsms.NewClient(logger),
 		Logger:       logger,
 		EmailService: emailService,
+		AppleSignIn:  applelogin.NewVerifier(config.MustAppleSignIn()),
+		Sessions:     sessions,
+		Tasks:        tasks,
+		Live:         s.LiveMode,
 	}
 
 	authRouter := api.NewAuthRouter(userShared)
-	accountRouter := api.NewAccountRouter(userShared)
+	accountRouter := api.NewAccountRouter(
+		userShared,
+		stripeclient.New(s.LiveMode, logger))
 	ftcPayRoutes := api.NewFtcPayRoutes(
 		myDBs,
 		cacheStore,
+		tasks,
 		logger,
 		s.LiveMode)
 
@@ -65,6 +141,7 @@ func StartServer(s ServerStatus) {
 		Client:       iaprepo.NewClient(logger),
 		ReaderRepo:   readerBaseRepo,
 		EmailService: emailService,
+		Tasks:        tasks,
 		Logger:       logger,
 		Live:         s.LiveMode,
 	}
@@ -72,6 +149,7 @@ func StartServer(s ServerStatus) {
 	stripeRoutes := api.NewStripeRoutes(
 		myDBs,
 		cacheStore,
+		tasks,
 		logger,
 		s.LiveMode)
 
@@ -79,6 +157,7 @@ func StartServer(s ServerStatus) {
 	paywallRouter := api.NewPaywallRouter(
 		myDBs,
 		cacheStore,
+		tasks,
 		logger,
 		s.LiveMode)
 
@@ -86,18 +165,33 @@ func StartServer(s ServerStatus) {
 		myDBs,
 		logger)
 
-	cmsRouter := api.NewCMSRouter(myDBs, s.LiveMode, logger)
+	tokenRepo := access.NewEnv(gormDBs, cacheStore, config.MustLegacyTokens(), config.MustTokenRotationConfig(), logger)
+	cmsRouter := api.NewCMSRouter(myDBs, tokenRepo, tasks, s.LiveMode, logger)
 
 	appRouter := api.NewAndroidRouter(
 		myDBs,
 		cacheStore,
 		logger)
 
-	wxAuth := api.NewWxAuth(myDBs, logger)
+	wxAuth := api.NewWxAuth(myDBs, sessions, tasks, logger)
 
-	guard := access.NewGuard(gormDBs)
+	guard := access.NewGuard(tokenRepo)
+	staffGuard := access.NewStaffGuard(
+		cmsrepo.New(myDBs, logger),
+		tasks,
+		config.CMSBootstrapAdmins())
+	rateLimit := ratelimit.NewMiddleware(rdb, config.MustRateLimits(), config.MustTrustedProxies())
+	idempotent := idempotency.NewMiddleware(rdb, config.MustIdempotencyConfig(), logger)
+	// Derive user ids from session token. Mounted after
+	// CheckToken so that requests without a valid access token
+	// never reach session lookup.
+	sessionGuard := access.NewSessionGuard(sessionCfg, sessions.Issuer, sessions.Repo)
 
 	r := chi.NewRouter()
+	r.Use(metrics.HTTP)
+	r.Use(xhttp.RequestID)
+	r.Use(tracing.Middleware)
+	r.Use(xhttp.RequestLogger(logger))
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 	r.Use(xhttp.DumpRequest)
@@ -105,14 +199,27 @@ func StartServer(s ServerStatus) {
 
 	r.Route("/auth", func(r chi.Router) {
 		r.Use(guard.CheckToken)
-		r.Route("/email", func(r chi.Router) {
+		r.Use(rateLimit.Limit(config.RateLimitDefault))
+		r.Use(rateLimit.Limit(config.RateLimitAuth))
+		r.Use(guard.RequireScope(access.ScopeReader))
+		// Counts attempts on the same email or mobile
+		// regardless of which ip they come from.
+		perCredential := rateLimit.Limit(config.RateLimitCredential)
+		r.With(sessionGuard.Authenticate).Route("/email", func(r chi.Router) {
 			// Checks if an email exists.
 			// The email parameter should be sent as a query parameter `?v=<email>`
 			// Returns HTTP status code 204 if the email exists,
 			// or 404 if not found.
 			r.Get("/exists", authRouter.EmailExists)
 			// Authenticate user's email + password combination.
-			r.Post("/login", authRouter.EmailLogin)
+			r.With(perCredential).Post("/login", authRouter.EmailLogin)
+			// Answer the challenge returned by /login if
+			// user enabled two-factor authentication.
+			r.With(perCredential).Post("/login/2fa", authRouter.EmailLoginTwoFactor)
+			// Passwordless login. Send a link or code to email.
+			r.With(perCredential).Post("/magic-link", authRouter.RequestMagicLink)
+			// Exchange the token or email + code for account.
+			r.With(perCredential).Post("/magic-link/verify", authRouter.VerifyMagicLink)
 			// Create a new account using the provided email + password
 			// When user login with mobile for the 1st time,
 			// choose to sign up with a new email, it is
@@ -122,9 +229,13 @@ func StartServer(s ServerStatus) {
 			// a token send to user's email.
 			// This is used only in desktop browsers.
 			r.Post("/verification/{token}", authRouter.VerifyEmail)
+			// Confirm email change by the link sent to new address.
+			r.Post("/change/confirm", accountRouter.ConfirmEmailChange)
+			// Undo email change by the link sent to old address.
+			r.Post("/change/revert", accountRouter.RevertEmailChange)
 		})
 
-		r.Route("/mobile", func(r chi.Router) {
+		r.With(sessionGuard.Authenticate).Route("/mobile", func(r chi.Router) {
 			// Create a SMS code and send it to user for login.
 			// This differ from /account/mobile/verification in that
 			// there is not user id set in header; therefore the record
@@ -135,7 +246,7 @@ func StartServer(s ServerStatus) {
 			// code and user id together; otherwise we user id field
 			// won't exist along with the code, which indicates the
 			// user is logging in using mobile for the first  time.
-			r.Put("/verification", authRouter.RequestSMSVerification)
+			r.With(perCredential).Put("/verification", authRouter.RequestSMSVerification)
 			// Verifies an SMS code. If the code is found, a nullable
 			// user id associated with the code is returned.
 			// There are 3 choices to follow depending on the
@@ -146,14 +257,14 @@ func StartServer(s ServerStatus) {
 			//   - Let user link to an existing email account;
 			//   - Let user sign up with a new email+password just as the email signup workflow,
 			//     and this new email account will have mobile attached.
-			r.Post("/verification", authRouter.VerifySMSCode)
+			r.With(perCredential).Post("/verification", authRouter.VerifySMSCode)
 			// Verifies an existing email account credentials.
 			// If passed, retrieve user's full account and check if
 			// the mobile is set to another one. If it is taken
 			// by another one, returns 422; otherwise update
 			// the account's mobile field and returns it.
 			// In background thread we persist phone number to db.
-			r.Post("/link", authRouter.MobileLinkExistingEmail)
+			r.With(perCredential).Post("/link", authRouter.MobileLinkExistingEmail)
 			// When user login with mobile for the first time,
 			// and has no account previously created, create a
 			// new email account with mobile number set to the
@@ -165,7 +276,7 @@ func StartServer(s ServerStatus) {
 			r.Post("/signup", authRouter.MobileSignUp)
 		})
 
-		r.Route("/password-reset", func(r chi.Router) {
+		r.With(sessionGuard.Authenticate).Route("/password-reset", func(r chi.Router) {
 			// Reset password after user's identifiy is verified.
 			r.Post("/", authRouter.ResetPassword)
 			// Request password reset letter.
@@ -178,7 +289,23 @@ func StartServer(s ServerStatus) {
 			r.Get("/codes", authRouter.VerifyResetCode)
 		})
 
-		r.Route("/wx", func(r chi.Router) {
+		r.With(sessionGuard.Authenticate).Route("/apple", func(r chi.Router) {
+			// Log in with an Apple identity token, creating
+			// a new account for first time user.
+			r.Post("/login", authRouter.AppleLogin)
+		})
+
+		// Not behind session guard: the handlers verify session
+		// token themselves so that an expired one could still
+		// be refreshed, or logged out by refresh token.
+		r.Route("/session", func(r chi.Router) {
+			// Exchange refresh token for a new session token.
+			r.Post("/refresh", authRouter.RefreshSession)
+			// Revoke current session.
+			r.Post("/logout", authRouter.Logout)
+		})
+
+		r.With(sessionGuard.Authenticate).Route("/wx", func(r chi.Router) {
 			r.Use(xhttp.RequireAppID)
 			r.Post("/login", wxAuth.Login)
 			r.Post("/refresh", wxAuth.Refresh)
@@ -205,6 +332,9 @@ func StartServer(s ServerStatus) {
 
 	r.Route("/account", func(r chi.Router) {
 		r.Use(guard.CheckToken)
+		r.Use(sessionGuard.Authenticate)
+		r.Use(rateLimit.Limit(config.RateLimitDefault))
+		r.Use(guard.RequireScope(access.ScopeReader))
 
 		// Get account by uuid.
 		r.With(xhttp.RequireFtcID).
@@ -216,12 +346,14 @@ func StartServer(s ServerStatus) {
 		r.Route("/email", func(r chi.Router) {
 			r.Use(xhttp.RequireFtcID)
 
-			// Update email.
-			// Possible issues when user is also a Stripe customer:
-			// the email we have at hand will be inconsistent with Stripe customer's email.
-			// However this is a not a big problem since customer id is not affected.
-			// We can still find this customer.
+			// Request to change email. It stays pending until
+			// the new address is confirmed, after which Stripe
+			// customer's email is synced.
 			r.Patch("/", accountRouter.UpdateEmail)
+			r.Get("/pending", accountRouter.LoadEmailChange)
+			r.Delete("/pending", accountRouter.CancelEmailChange)
+			// Confirm by the code sent to new address.
+			r.Post("/confirm", accountRouter.ConfirmEmailChange)
 			r.Post("/request-verification", accountRouter.RequestVerification)
 		})
 
@@ -236,6 +368,18 @@ func StartServer(s ServerStatus) {
 			//r.Post("/verification", accountRouter.VerifyPassword)
 		})
 
+		r.Route("/2fa", func(r chi.Router) {
+			r.Use(xhttp.RequireFtcID)
+			r.Get("/", accountRouter.LoadTwoFactor)
+			// Start enrollment. Returns a TOTP secret.
+			r.Post("/", accountRouter.EnrollTwoFactor)
+			// Verify a code to turn on 2FA. Returns recovery codes.
+			r.Post("/confirm", accountRouter.ConfirmTwoFactor)
+			r.Post("/recovery-codes", accountRouter.RegenerateRecoveryCodes)
+			// Requires password and a code or recovery code.
+			r.Delete("/", accountRouter.DisableTwoFactor)
+		})
+
 		r.Route("/mobile", func(r chi.Router) {
 			r.Use(xhttp.RequireFtcID)
 			r.Post("/", accountRouter.DeleteMobile)
@@ -262,6 +406,15 @@ func StartServer(s ServerStatus) {
 			r.Patch("/", accountRouter.UpdateProfile)
 		})
 
+		r.Route("/apple", func(r chi.Router) {
+			r.Use(xhttp.RequireFtcID)
+			r.Get("/", accountRouter.LoadAppleLink)
+			// Email logged-in user links to Apple after
+			// authorized on client side.
+			r.Post("/link", accountRouter.AppleLinkEmail)
+			r.Post("/unlink", accountRouter.AppleUnlinkEmail)
+		})
+
 		r.Route("/wx", func(r chi.Router) {
 			r.Use(xhttp.RequireUnionID)
 			r.Get("/", accountRouter.LoadAccountByWx)
@@ -279,7 +432,12 @@ func StartServer(s ServerStatus) {
 	// Requires user id.
 	r.Route("/wxpay", func(r chi.Router) {
 		r.Use(guard.CheckToken)
+		r.Use(sessionGuard.Authenticate)
+		r.Use(rateLimit.Limit(config.RateLimitDefault))
+		r.Use(rateLimit.Limit(config.RateLimitPayment))
+		r.Use(guard.RequireScope(access.ScopeReader))
 		r.Use(xhttp.RequireFtcOrUnionID)
+		r.Use(idempotent.Handle)
 
 		// Create a new subscription for desktop browser
 		r.Post("/desktop", ftcPayRoutes.WxPay(wechat.TradeTypeDesktop))
@@ -297,7 +455,12 @@ func StartServer(s ServerStatus) {
 	// Require user id.
 	r.Route("/alipay", func(r chi.Router) {
 		r.Use(guard.CheckToken)
+		r.Use(sessionGuard.Authenticate)
+		r.Use(rateLimit.Limit(config.RateLimitDefault))
+		r.Use(rateLimit.Limit(config.RateLimitPayment))
+		r.Use(guard.RequireScope(access.ScopeReader))
 		r.Use(xhttp.RequireFtcOrUnionID)
+		r.Use(idempotent.Handle)
 		r.Use(xhttp.FormParsed)
 
 		// Create an order for desktop browser
@@ -312,14 +475,21 @@ func StartServer(s ServerStatus) {
 
 	r.Route("/membership", func(r chi.Router) {
 		r.Use(guard.CheckToken)
+		r.Use(sessionGuard.Authenticate)
+		r.Use(rateLimit.Limit(config.RateLimitDefault))
+		r.Use(guard.RequireScope(access.ScopeReader))
 		r.Use(xhttp.RequireFtcOrUnionID)
 		// Get the membership of a user
 		r.Get("/", accountRouter.LoadMembership)
-		r.Post("/addons", ftcPayRoutes.ClaimAddOn)
+		r.With(idempotent.Handle).
+			Post("/addons", ftcPayRoutes.ClaimAddOn)
 	})
 
 	r.Route("/orders", func(r chi.Router) {
 		r.Use(guard.CheckToken)
+		r.Use(sessionGuard.Authenticate)
+		r.Use(rateLimit.Limit(config.RateLimitDefault))
+		r.Use(guard.RequireScope(access.ScopeReader))
 		r.Use(xhttp.RequireFtcOrUnionID)
 
 		// Pagination: page=<int>&per_page=<int>
@@ -335,6 +505,9 @@ func StartServer(s ServerStatus) {
 
 	r.Route("/ftc-pay", func(r chi.Router) {
 		r.Use(guard.CheckToken)
+		r.Use(sessionGuard.Authenticate)
+		r.Use(rateLimit.Limit(config.RateLimitDefault))
+		r.Use(guard.RequireScope(access.ScopeReader))
 		r.Use(xhttp.RequireFtcOrUnionID)
 		r.Route("/invoices", func(r chi.Router) {
 			// List a user's invoices. Use query parameter `kind=create|renew|upgrade|addon` to filter.
@@ -350,6 +523,9 @@ func StartServer(s ServerStatus) {
 	// All the following endpoints require `X-User-Id` header set except publishable-key and prices section.
 	r.Route("/stripe", func(r chi.Router) {
 		r.Use(guard.CheckToken)
+		r.Use(sessionGuard.Authenticate)
+		r.Use(rateLimit.Limit(config.RateLimitDefault))
+		r.Use(guard.RequireScope(access.ScopeReader))
 
 		r.Get("/publishable-key", stripeRoutes.PublishableKey)
 
@@ -375,12 +551,20 @@ func StartServer(s ServerStatus) {
 			r.Get("/{id}", stripeRoutes.LoadStripeCoupon)
 		})
 
+		r.Route("/promotion-codes", func(r chi.Router) {
+			r.Use(xhttp.RequireFtcID)
+			// Check a customer-facing code against a price
+			// and current user before subscribing.
+			r.Post("/validate", stripeRoutes.ValidatePromoCode)
+		})
+
 		r.Route("/customers", func(r chi.Router) {
 
 			r.Use(xhttp.RequireFtcID)
 
 			// Create a stripe customer if not exists yet
-			r.Post("/", stripeRoutes.CreateCustomer)
+			r.With(rateLimit.Limit(config.RateLimitPayment), idempotent.Handle).
+				Post("/", stripeRoutes.CreateCustomer)
 			// Use this to check customer's default source and default payment method.
 			// refresh=true
 			r.Get("/{id}", stripeRoutes.GetCustomer)
@@ -399,7 +583,8 @@ func StartServer(s ServerStatus) {
 		r.Route("/setup-intents", func(r chi.Router) {
 			r.Use(xhttp.RequireFtcID)
 			// Create a payment method
-			r.Post("/", stripeRoutes.CreateSetupIntent)
+			r.With(idempotent.Handle).
+				Post("/", stripeRoutes.CreateSetupIntent)
 			// ?refresh=true
 			r.With(xhttp.FormParsed).
 				Get("/{id}", stripeRoutes.GetSetupIntent)
@@ -421,7 +606,8 @@ func StartServer(s ServerStatus) {
 			r.Use(xhttp.RequireFtcID)
 
 			// Create a subscription
-			r.Post("/", stripeRoutes.CreateSubs)
+			r.With(rateLimit.Limit(config.RateLimitPayment), idempotent.Handle).
+				Post("/", stripeRoutes.CreateSubs)
 			// Get a single subscription
 			r.Get("/{id}", stripeRoutes.LoadSubs)
 			// Update a subscription
@@ -429,6 +615,18 @@ func StartServer(s ServerStatus) {
 			r.Post("/{id}/refresh", stripeRoutes.RefreshSubs)
 			r.Post("/{id}/cancel", stripeRoutes.CancelSubs)
 			r.Post("/{id}/reactivate", stripeRoutes.ReactivateSubscription)
+			// Reactivate with the coupon offered upon cancellation.
+			r.With(rateLimit.Limit(config.RateLimitPayment)).
+				Post("/{id}/retention-offer", stripeRoutes.AcceptRetentionOffer)
+			// Stop charging renewals for a while, and access with it.
+			r.Post("/{id}/pause", stripeRoutes.PauseSubs)
+			r.With(rateLimit.Limit(config.RateLimitPayment)).
+				Post("/{id}/resume", stripeRoutes.ResumeSubs)
+			// Drop a downgrade or cycle switch scheduled at period end.
+			r.Delete("/{id}/pending-change", stripeRoutes.CancelPendingChange)
+			// Pay the open invoice of a subscription in dunning.
+			r.With(rateLimit.Limit(config.RateLimitPayment), idempotent.Handle).
+				Post("/{id}/retry-payment", stripeRoutes.RetryPayment)
 			r.Get("/{id}/default-payment-method", stripeRoutes.GetSubsDefaultPaymentMethod)
 			r.Post("/{id}/default-payment-method", stripeRoutes.UpdateSubsDefaultPayMethod)
 			r.Get("/{id}/latest-invoice", stripeRoutes.LoadLatestInvoice)
@@ -439,10 +637,24 @@ func StartServer(s ServerStatus) {
 			// ?refresh=true
 			r.Get("/{id}", stripeRoutes.LoadInvoice)
 		})
+
+		// Stripe-hosted checkout page for a new subscription.
+		r.With(xhttp.RequireFtcID, rateLimit.Limit(config.RateLimitPayment), idempotent.Handle).
+			Post("/checkout-sessions", stripeRoutes.CreateCheckoutSession)
+		// Stripe billing portal for an existing customer.
+		r.With(xhttp.RequireFtcID).
+			Post("/portal-sessions", stripeRoutes.CreatePortalSession)
 	})
 
 	r.Route("/paywall", func(r chi.Router) {
 		r.Use(guard.CheckToken)
+		r.Use(rateLimit.Limit(config.RateLimitDefault))
+		r.Use(guard.RequireScope(access.ScopeReader))
+		// Only CMS could modify paywall.
+		r.Use(guard.RequireWriteScope(access.ScopePaywallWrite))
+		r.Use(staffGuard.Audit)
+		r.Use(staffGuard.RequireWrite(cms.PermPrices))
+		r.Use(paywallRouter.InvalidateCache)
 
 		// Data used to build a paywall.
 		// Live server only outputs live data while sandbox for sandbox data only.
@@ -505,8 +717,10 @@ func StartServer(s ServerStatus) {
 	})
 
 	r.Route("/webhook", func(r chi.Router) {
-		r.Post("/wxpay", ftcPayRoutes.WxWebHook)
-		r.Post("/alipay", ftcPayRoutes.AliWebHook)
+		r.With(metrics.Webhook("wechat")).
+			Post("/wxpay", ftcPayRoutes.WxWebHook)
+		r.With(metrics.Webhook("alipay")).
+			Post("/alipay", ftcPayRoutes.AliWebHook)
 		// Events
 		//invoice.finalized
 		//invoice.payment_succeeded
@@ -517,12 +731,17 @@ func StartServer(s ServerStatus) {
 		//customer.subscription.created
 		// http://www.ftacademy.cn/api/v1/webhook/stripe For version 1
 		// http://www.ftacademy.cn/api/v2/webhook/stripe For version 2
-		r.Post("/stripe", stripeRoutes.WebHook)
-		r.Post("/apple", iapRouter.WebHook)
+		r.With(metrics.Webhook("stripe")).
+			Post("/stripe", stripeRoutes.WebHook)
+		r.With(metrics.Webhook("apple")).
+			Post("/apple", iapRouter.WebHook)
 	})
 
 	r.Route("/apple", func(r chi.Router) {
 		r.Use(guard.CheckToken)
+		r.Use(sessionGuard.Authenticate)
+		r.Use(rateLimit.Limit(config.RateLimitDefault))
+		r.Use(guard.RequireScope(access.ScopeReader))
 
 		// Verify an encoded receipt and returns the decoded data.
 		r.Post("/verify-receipt", iapRouter.VerifyReceipt)
@@ -550,6 +769,8 @@ func StartServer(s ServerStatus) {
 
 	r.Route("/apps", func(r chi.Router) {
 		r.Use(guard.CheckToken)
+		r.Use(rateLimit.Limit(config.RateLimitDefault))
+		r.Use(guard.RequireScope(access.ScopeReader))
 
 		r.Route("/android", func(r chi.Router) {
 			// Use ?refresh=true to bust cache.
@@ -561,6 +782,8 @@ func StartServer(s ServerStatus) {
 
 	r.Route("/legal", func(r chi.Router) {
 		r.Use(guard.CheckToken)
+		r.Use(rateLimit.Limit(config.RateLimitDefault))
+		r.Use(guard.RequireScope(access.ScopeReader))
 
 		r.Get("/", legalRoutes.ListActive)
 		r.Get("/{id}", legalRoutes.Load)
@@ -569,15 +792,33 @@ func StartServer(s ServerStatus) {
 	// Isolate dangerous operations from user-facing features.
 	r.Route("/cms", func(r chi.Router) {
 		r.Use(guard.CheckToken)
+		r.Use(rateLimit.Limit(config.RateLimitDefault))
 		r.Use(xhttp.RequireStaffName)
+		// Record all modifications by staff.
+		r.Use(staffGuard.Audit)
+
+		r.Route("/access-tokens", func(r chi.Router) {
+			r.Use(guard.RequireScope(access.ScopeTokenAdmin))
+			r.Use(staffGuard.Require(cms.PermAccessTokens))
+			r.Get("/", cmsRouter.ListAccessTokens)
+			// Returns the token value only once.
+			r.Post("/", cmsRouter.IssueAccessToken)
+			// Replace with a new token of the same client and scopes.
+			r.Post("/{id}/rotate", cmsRouter.RotateAccessToken)
+			r.Delete("/{id}", cmsRouter.RevokeAccessToken)
+		})
 
 		r.Route("/orders", func(r chi.Router) {
+			r.Use(guard.RequireScope(access.ScopeCMSMembership))
+			r.Use(staffGuard.Require(cms.PermOrders))
 			r.With(xhttp.FormParsed).
 				Get("/", ftcPayRoutes.CMSListOrders)
 			r.Get("/{id}", ftcPayRoutes.CMSListOrders)
 		})
 
 		r.Route("/memberships", func(r chi.Router) {
+			r.Use(guard.RequireScope(access.ScopeCMSMembership))
+			r.Use(staffGuard.Require(cms.PermMemberships))
 			// Create or update a membership for a user
 			r.Post("/", cmsRouter.UpsertMembership)
 			r.Delete("/{id}", cmsRouter.DeleteMembership)
@@ -589,15 +830,45 @@ func StartServer(s ServerStatus) {
 		//	Get("/snapshots", cmsRouter.ListMemberSnapshots)
 
 		r.Route("/addons", func(r chi.Router) {
+			r.Use(guard.RequireScope(access.ScopeCMSMembership))
+			r.Use(staffGuard.Require(cms.PermAddOns))
 			// Add an invoice to a user.
 			// If the invoice is targeting addon, then
 			// membership should be updated accordingly.
 			r.Post("/", cmsRouter.CreateAddOn)
 		})
 
+		r.Route("/refunds", func(r chi.Router) {
+			r.Use(guard.RequireScope(access.ScopeCMSMembership))
+			r.Use(staffGuard.Require(cms.PermRefunds))
+			// Refund a Stripe invoice, optionally stopping the
+			// subscription and adjusting membership.
+			r.With(idempotent.Handle).Post("/stripe/{id}", stripeRoutes.RefundInvoice)
+		})
+
+		r.Route("/disputes", func(r chi.Router) {
+			r.Use(guard.RequireScope(access.ScopeCMSMembership))
+			r.Use(staffGuard.Require(cms.PermDisputes))
+			// Open Stripe disputes, earliest evidence due first.
+			// ?page=<int>&per_page=<int>
+			r.With(xhttp.FormParsed).Get("/", stripeRoutes.ListOpenDisputes)
+		})
+
+		r.Route("/cancellations", func(r chi.Router) {
+			r.Use(guard.RequireScope(access.ScopeCMSMembership))
+			r.Use(staffGuard.Require(cms.PermReports))
+			// Why users cancel Stripe subscriptions, by tier and tenure.
+			// ?since=<YYYY-MM-DD>&until=<YYYY-MM-DD>
+			r.With(xhttp.FormParsed).Get("/report", stripeRoutes.CancellationReport)
+		})
+
 		r.Route("/stripe", func(r chi.Router) {
+			r.Use(guard.RequireScope(access.ScopePaywallWrite))
 
 			r.Route("/prices", func(r chi.Router) {
+				r.Use(staffGuard.Require(cms.PermPrices))
+				// Stripe prices and coupons are part of paywall.
+				r.Use(paywallRouter.InvalidateCache)
 				// ?page=<int>&per_page=<int>
 				r.With(xhttp.FormParsed).Get("/", stripeRoutes.ListPricesPaged)
 				// Add some essential metadata to a stripe price.
@@ -607,6 +878,8 @@ func StartServer(s ServerStatus) {
 			})
 
 			r.Route("/coupons", func(r chi.Router) {
+				r.Use(staffGuard.Require(cms.PermCoupons))
+				r.Use(paywallRouter.InvalidateCache)
 				// Link a coupon to a price, or modify its metadata
 				r.Post("/{id}", stripeRoutes.UpdateStripeCoupon)
 				r.Patch("/{id}/activate", stripeRoutes.ActivateCoupon)
@@ -618,22 +891,130 @@ func StartServer(s ServerStatus) {
 		})
 
 		r.Route("/legal", func(r chi.Router) {
+			r.Use(guard.RequireScope(access.ScopeCMSContent))
+			r.Use(staffGuard.Require(cms.PermLegal))
 			r.Get("/", legalRoutes.ListAll)
 			r.Post("/", legalRoutes.Create)
 			r.Patch("/{id}", legalRoutes.Update)
 			r.Post("/{id}/publish", legalRoutes.Publish)
 		})
 
+		r.Route("/accounts", func(r chi.Router) {
+			r.Use(guard.RequireScope(access.ScopeCMSMembership))
+			r.Use(staffGuard.Require(cms.PermAccounts))
+			// Turn off 2FA for a user who lost both
+			// authenticator and recovery codes.
+			r.Delete("/{id}/2fa", cmsRouter.ResetTwoFactor)
+		})
+
+		r.Route("/emails", func(r chi.Router) {
+			r.Use(guard.RequireScope(access.ScopeCMSMembership))
+			r.Use(staffGuard.Require(cms.PermEmails))
+			// List emails sent to a user.
+			// ?ftc_id=<uuid>&page=<int>&per_page=<int>
+			r.With(xhttp.FormParsed).Get("/", cmsRouter.ListEmails)
+			r.Get("/{id}", cmsRouter.LoadEmail)
+			// Put a copy of the email into the outbox.
+			r.Post("/{id}/resend", cmsRouter.ResendEmail)
+		})
+
 		r.Route("/android", func(r chi.Router) {
+			r.Use(guard.RequireScope(access.ScopeCMSContent))
+			r.Use(staffGuard.Require(cms.PermAndroid))
 			r.Post("/", appRouter.CreateRelease)
 			r.Patch("/{versionName}", appRouter.UpdateRelease)
 			r.Delete("/{versionName}", appRouter.DeleteRelease)
 		})
+
+		r.Route("/staff", func(r chi.Router) {
+			r.Use(guard.RequireScope(access.ScopeTokenAdmin))
+			r.Use(staffGuard.Require(cms.PermStaff))
+			r.Get("/", cmsRouter.ListStaffRoles)
+			// Grant or change role.
+			r.Put("/{name}", cmsRouter.AssignStaffRole)
+			r.Delete("/{name}", cmsRouter.RemoveStaffRole)
+		})
+
+		// ?staff=<name>&action=<prefix>&target_id=<id>&since=<YYYY-MM-DD>&until=<YYYY-MM-DD>&page=<int>&per_page=<int>
+		r.With(
+			guard.RequireScope(access.ScopeTokenAdmin),
+			staffGuard.Require(cms.PermAuditLog),
+			xhttp.FormParsed).
+			Get("/audit-logs", cmsRouter.ListAuditLog)
 	})
 
 	r.Get("/__version", func(w http.ResponseWriter, req *http.Request) {
 		_ = render.New(w).OK(s)
 	})
 
-	log.Fatal(http.ListenAndServe(":"+s.Port, r))
+	healthCfg := config.MustHealthConfig()
+	checks := health.New(healthCfg.Timeout(), health.Redis(rdb)).
+		With(health.MySQL(myDBs)...).
+		With(health.GormDBs(gormDBs)...).
+		With(health.Optional(healthCfg)...).
+		WithLogger(logger)
+	// Liveness. Always 200 while the process is running.
+	r.Get("/__health", health.Live)
+
+	// Readiness reveals which dependency is down, and metrics
+	// reveal traffic and payment volume, so they are only
+	// served on the admin port, which is reachable by load
+	// balancer and Prometheus but not exposed to public.
+	// Readiness is 503 if MySQL or Redis is unreachable.
+	adminMux := http.NewServeMux()
+	adminMux.Handle("/", checks.Handler())
+	adminMux.Handle("/metrics", metrics.Handler())
+
+	adminSrv := &http.Server{
+		Addr:    ":" + s.AdminPort,
+		Handler: adminMux,
+	}
+
+	srv := &http.Server{
+		Addr:    ":" + s.Port,
+		Handler: r,
+	}
+
+	go func() {
+		err := srv.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	go func() {
+		log.Printf("Health checks and metrics on port %s", s.AdminPort)
+		err := adminSrv.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	// A second signal kills the process immediately.
+	stop()
+
+	timeout := config.MustShutdownConfig().Timeout()
+	log.Printf("Shutting down. Waiting up to %s for requests and background tasks", timeout)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Requests not finished: %s", err)
+	}
+	_ = adminSrv.Shutdown(shutdownCtx)
+	if err := tasks.Wait(shutdownCtx); err != nil {
+		log.Printf("Background tasks not finished: %s", err)
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Printf("Failed to flush traces: %s", err)
+	}
+
+	_ = rdb.Close()
+	_ = gormDBs.Close()
+	_ = myDBs.Close()
+	_ = logger.Sync()
+
+	log.Print("Server stopped")
 }
@@ -0,0 +1,15 @@
+package stripeclient
+
+import (
+	ftcStripe "github.com/FTChinese/subscription-api/internal/pkg/stripe"
+	"github.com/stripe/stripe-go/v72"
+)
+
+// FetchCharge retrieves a charge with its invoice expanded
+// so that the subscription it pays for is known.
+func (c Client) FetchCharge(id string) (*stripe.Charge, error) {
+	params := &stripe.ChargeParams{}
+	params.AddExpand(ftcStripe.KeyInvoice)
+
+	return c.sc.Charges.Get(id, params)
+}
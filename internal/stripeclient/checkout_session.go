@@ -0,0 +1,22 @@
+package stripeclient
+
+import (
+	"github.com/stripe/stripe-go/v72"
+)
+
+// NewCheckoutSession creates a Stripe-hosted payment page.
+func (c Client) NewCheckoutSession(params *stripe.CheckoutSessionParams) (*stripe.CheckoutSession, error) {
+	return c.sc.CheckoutSessions.New(params)
+}
+
+// NewPortalSession creates a billing portal session for
+// customer to manage subscription and payment methods.
+// The portal's features are configured in Stripe dashboard.
+func (c Client) NewPortalSession(cusID string, returnURL string) (*stripe.BillingPortalSession, error) {
+	params := &stripe.BillingPortalSessionParams{
+		Customer:  stripe.String(cusID),
+		ReturnURL: stripe.String(returnURL),
+	}
+
+	return c.sc.BillingPortalSessions.New(params)
+}
@@ -1,7 +1,12 @@
 package stripeclient
 
 import (
+	"net/http"
+
 	"github.com/FTChinese/subscription-api/pkg/config"
+	"github.com/FTChinese/subscription-api/pkg/metrics"
+	"github.com/FTChinese/subscription-api/pkg/tracing"
+	"github.com/stripe/stripe-go/v72"
 	"github.com/stripe/stripe-go/v72/client"
 	"go.uber.org/zap"
 )
@@ -11,16 +16,58 @@ type Client struct {
 	logger *zap.Logger
 }
 
+// New creates a client with the secret key of live or test
+// mode. Requests are sent to the backend configured in the
+// `stripe_backend` section, or Stripe API if not configured.
 func New(live bool, logger *zap.Logger) Client {
 
 	key := config.MustStripeAPIKey().Pick(live)
 
+	return NewWithBackend(key, config.StripeBackend().URL, logger)
+}
+
+// NewWithBackend creates a client sending requests to baseURL,
+// e.g., stripe-mock or a stripetest.Server.
+// An empty baseURL uses Stripe API.
+func NewWithBackend(key string, baseURL string, logger *zap.Logger) Client {
+	httpClient := &http.Client{
+		// Same as stripe-go default.
+		Timeout:   config.OutboundTimeout,
+		Transport: tracing.Transport(metrics.Transport("stripe", nil)),
+	}
+
+	if baseURL == "" {
+		return Client{
+			sc:     client.New(key, stripe.NewBackends(httpClient)),
+			logger: logger,
+		}
+	}
+
+	newConfig := func() *stripe.BackendConfig {
+		return &stripe.BackendConfig{
+			HTTPClient: httpClient,
+			URL:        stripe.String(baseURL),
+			// A local backend either answers or not at all.
+			MaxNetworkRetries: stripe.Int64(0),
+		}
+	}
+
 	return Client{
-		sc:     client.New(key, nil),
+		sc: client.New(key, &stripe.Backends{
+			API:     stripe.GetBackendWithConfig(stripe.APIBackend, newConfig()),
+			Connect: stripe.GetBackendWithConfig(stripe.ConnectBackend, newConfig()),
+			Uploads: stripe.GetBackendWithConfig(stripe.UploadsBackend, newConfig()),
+		}),
 		logger: logger,
 	}
 }
 
+// WithLogger returns a copy logging with l.
+func (c Client) WithLogger(l *zap.Logger) Client {
+	c.logger = l
+	return c
+}
+
 func (c Client) Get() *client.API {
 	return c.sc
 }
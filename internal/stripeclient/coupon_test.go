@@ -0,0 +1,37 @@
+package stripeclient
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_FetchCoupon(t *testing.T) {
+	b := newTestBackend(t)
+	id := b.addCoupon(500)
+
+	c, err := b.client.FetchCoupon(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, id, c.ID)
+	if b.isStateful() {
+		assert.Equal(t, int64(500), c.AmountOff)
+	}
+}
+
+func TestClient_UpdateCoupon(t *testing.T) {
+	b := newTestBackend(t)
+	b.requireStateful(t)
+	id := b.addCoupon(500)
+
+	c, err := b.client.UpdateCoupon(id, map[string]string{
+		"price_id": "price_test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "price_test", c.Metadata["price_id"])
+}
@@ -51,3 +51,13 @@ func (c Client) SetCusDefaultPaymentMethod(cusID string, pmID string) (*sdk.Cust
 
 	return c.sc.Customers.Update(cusID, params)
 }
+
+// UpdateCustomerEmail keeps customer's email in sync with
+// FTC account after it is changed.
+func (c Client) UpdateCustomerEmail(cusID string, email string) (*sdk.Customer, error) {
+	params := &sdk.CustomerParams{
+		Email: sdk.String(email),
+	}
+
+	return c.sc.Customers.Update(cusID, params)
+}
@@ -1,32 +1,74 @@
 package stripeclient
 
 import (
-	"github.com/brianvoe/gofakeit/v5"
-	"go.uber.org/zap/zaptest"
 	"testing"
+
+	"github.com/brianvoe/gofakeit/v5"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestClient_CreateCustomer(t *testing.T) {
-	client := New(false, zaptest.NewLogger(t))
+	b := newTestBackend(t)
 
-	cus, err := client.CreateCustomer(gofakeit.Email())
+	email := gofakeit.Email()
+	cus, err := b.client.CreateCustomer(email)
+	if err != nil {
+		t.Fatal(err)
+	}
 
+	assert.NotEmpty(t, cus.ID)
+	if b.isStateful() {
+		assert.Equal(t, email, cus.Email)
+	}
+}
+
+func TestClient_FetchCustomer(t *testing.T) {
+	b := newTestBackend(t)
+	created := b.newCustomer(t, false)
+
+	cus, err := b.client.FetchCustomer(created.ID)
 	if err != nil {
-		t.Error(err)
-		return
+		t.Fatal(err)
 	}
 
-	t.Logf("Customer %v", cus)
+	assert.Equal(t, created.ID, cus.ID)
 }
 
-func TestClient_RetrieveCustomer(t *testing.T) {
-	client := New(false, zaptest.NewLogger(t))
+func TestClient_FetchCustomer_missing(t *testing.T) {
+	b := newTestBackend(t)
+	b.requireStateful(t)
+
+	_, err := b.client.FetchCustomer("cus_missing")
+
+	assert.Error(t, err)
+}
+
+func TestClient_SetCusDefaultPaymentMethod(t *testing.T) {
+	b := newTestBackend(t)
+	b.requireStateful(t)
+
+	cus := b.newCustomer(t, false)
+	pmID := b.fake.AddPaymentMethod(cus.ID)
+
+	cus, err := b.client.SetCusDefaultPaymentMethod(cus.ID, pmID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, pmID, cus.InvoiceSettings.DefaultPaymentMethod.ID)
+}
+
+func TestClient_UpdateCustomerEmail(t *testing.T) {
+	b := newTestBackend(t)
+	b.requireStateful(t)
+
+	cus := b.newCustomer(t, false)
+	email := gofakeit.Email()
 
-	cus, err := client.FetchCustomer("cus_Ht6nKQQUq4ag2I")
+	cus, err := b.client.UpdateCustomerEmail(cus.ID, email)
 	if err != nil {
-		t.Error(err)
-		return
+		t.Fatal(err)
 	}
 
-	t.Logf("Customer %v", cus)
+	assert.Equal(t, email, cus.Email)
 }
@@ -14,3 +14,17 @@ func (c Client) FetchInvoice(id string) (*sdk.Invoice, error) {
 
 	return inv, nil
 }
+
+// PayInvoice attempts to collect an open invoice immediately.
+func (c Client) PayInvoice(id string, params *sdk.InvoicePayParams) (*sdk.Invoice, error) {
+	defer c.logger.Sync()
+	sugar := c.logger.Sugar()
+
+	inv, err := c.sc.Invoices.Pay(id, params)
+	if err != nil {
+		sugar.Error(err)
+		return nil, err
+	}
+
+	return inv, nil
+}
@@ -0,0 +1,46 @@
+package stripeclient
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stripe/stripe-go/v72"
+)
+
+func TestClient_PayInvoice(t *testing.T) {
+	b := newTestBackend(t)
+	b.requireStateful(t)
+
+	cus := b.newCustomer(t, false)
+	ss, err := b.client.NewSubs(&stripe.SubscriptionParams{
+		Customer: stripe.String(cus.ID),
+		Items: []*stripe.SubscriptionItemsParams{
+			{Price: stripe.String(b.addPrice(stripe.PriceRecurringIntervalYear))},
+		},
+		PaymentBehavior: stripe.String("default_incomplete"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inv, err := b.client.FetchInvoice(ss.LatestInvoice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, stripe.InvoiceStatusOpen, inv.Status)
+
+	inv, err = b.client.PayInvoice(inv.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, stripe.InvoiceStatusPaid, inv.Status)
+
+	ss, err = b.client.FetchSubs(ss.ID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, stripe.SubscriptionStatusActive, ss.Status)
+
+	_, err = b.client.PayInvoice(inv.ID, nil)
+	assert.Error(t, err)
+}
@@ -1 +1,23 @@
 package stripeclient
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_FetchPaymentMethod(t *testing.T) {
+	b := newTestBackend(t)
+	b.requireStateful(t)
+
+	cus := b.newCustomer(t, false)
+	pmID := b.fake.AddPaymentMethod(cus.ID)
+
+	pm, err := b.client.FetchPaymentMethod(pmID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, cus.ID, pm.Customer.ID)
+	assert.Equal(t, "4242", pm.Card.Last4)
+}
@@ -3,19 +3,52 @@ package stripeclient
 import (
 	"testing"
 
-	"github.com/FTChinese/subscription-api/faker"
-	"go.uber.org/zap/zaptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stripe/stripe-go/v72"
 )
 
-func TestClient_RetrievePrice(t *testing.T) {
-	faker.MustSetupViper()
+func TestClient_FetchPrice(t *testing.T) {
+	b := newTestBackend(t)
+	id := b.addPrice(stripe.PriceRecurringIntervalYear)
 
-	client := New(false, zaptest.NewLogger(t))
-	p, err := client.FetchPrice("price_1Juuu2BzTK0hABgJTXiK4NTt")
+	p, err := b.client.FetchPrice(id)
 	if err != nil {
-		t.Error(err)
-		return
+		t.Fatal(err)
 	}
 
-	t.Logf("%s", faker.MustMarshalIndent(p))
+	assert.Equal(t, id, p.ID)
+	if b.isStateful() {
+		assert.Equal(t, int64(3900), p.UnitAmount)
+		assert.Equal(t, stripe.PriceRecurringIntervalYear, p.Recurring.Interval)
+	}
+}
+
+func TestClient_ListPrices(t *testing.T) {
+	b := newTestBackend(t)
+	b.addPrice(stripe.PriceRecurringIntervalYear)
+	b.addPrice(stripe.PriceRecurringIntervalMonth)
+
+	list, err := b.client.ListPrices()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b.isStateful() {
+		assert.Len(t, list, 2)
+	}
+}
+
+func TestClient_SetPriceMeta(t *testing.T) {
+	b := newTestBackend(t)
+	b.requireStateful(t)
+	id := b.addPrice(stripe.PriceRecurringIntervalYear)
+
+	p, err := b.client.SetPriceMeta(id, map[string]string{
+		"trial_days": "7",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "7", p.Metadata["trial_days"])
 }
@@ -0,0 +1,22 @@
+package stripeclient
+
+import "github.com/stripe/stripe-go/v72"
+
+// ListPromoCodes finds promotion codes by the customer-facing
+// code, case-insensitive. Inactive ones are included so that
+// caller could tell why a code cannot be used.
+func (c Client) ListPromoCodes(code string) ([]*stripe.PromotionCode, error) {
+	iter := c.sc.PromotionCodes.List(&stripe.PromotionCodeListParams{
+		Code: stripe.String(code),
+		ListParams: stripe.ListParams{
+			Limit: stripe.Int64(10),
+		},
+	})
+
+	list := iter.PromotionCodeList()
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	return list.Data, nil
+}
@@ -0,0 +1,8 @@
+package stripeclient
+
+import "github.com/stripe/stripe-go/v72"
+
+// NewRefund refunds a charge fully or partially.
+func (c Client) NewRefund(params *stripe.RefundParams) (*stripe.Refund, error) {
+	return c.sc.Refunds.New(params)
+}
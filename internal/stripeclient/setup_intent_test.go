@@ -1,49 +1,46 @@
 package stripeclient
 
 import (
-	"github.com/FTChinese/subscription-api/faker"
-	sdk "github.com/stripe/stripe-go/v72"
-	"go.uber.org/zap/zaptest"
 	"testing"
+
+	"github.com/FTChinese/subscription-api/internal/pkg/stripe"
+	"github.com/stretchr/testify/assert"
+	sdk "github.com/stripe/stripe-go/v72"
 )
 
-func TestClient_FetchSetupIntent(t *testing.T) {
-	faker.MustSetupViper()
+func TestClient_CreateSetupIntent(t *testing.T) {
+	b := newTestBackend(t)
+	cus := b.newCustomer(t, false)
 
-	c := New(false, zaptest.NewLogger(t))
+	si, err := b.client.CreateSetupIntent(stripe.CustomerParams{
+		Customer: cus.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	type args struct {
-		id                  string
-		expandPaymentMethod bool
+	assert.NotEmpty(t, si.ClientSecret)
+	if b.isStateful() {
+		assert.Equal(t, cus.ID, si.Customer.ID)
+		assert.Equal(t, sdk.SetupIntentStatusRequiresPaymentMethod, si.Status)
 	}
-	tests := []struct {
-		name    string
-		args    args
-		want    *sdk.SetupIntent
-		wantErr bool
-	}{
-		{
-			name: "",
-			args: args{
-				id:                  "seti_1KUQKcBzTK0hABgJssUtMfl8",
-				expandPaymentMethod: true,
-			},
-			want:    nil,
-			wantErr: false,
-		},
+}
+
+func TestClient_FetchSetupIntent(t *testing.T) {
+	b := newTestBackend(t)
+	cus := b.newCustomer(t, false)
+
+	created, err := b.client.CreateSetupIntent(stripe.CustomerParams{
+		Customer: cus.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
 	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got, err := c.FetchSetupIntent(tt.args.id, tt.args.expandPaymentMethod)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("FetchSetupIntent() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			//if !reflect.DeepEqual(got, tt.want) {
-			//	t.Errorf("FetchSetupIntent() got = %v, want %v", got, tt.want)
-			//}
-
-			t.Logf("%+v", got)
-		})
+
+	si, err := b.client.FetchSetupIntent(created.ID, true)
+	if err != nil {
+		t.Fatal(err)
 	}
+
+	assert.Equal(t, created.ID, si.ID)
 }
@@ -0,0 +1,135 @@
+//go:build !production
+// +build !production
+
+package stripetest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (s *Server) createCustomer(w http.ResponseWriter, req *http.Request) {
+	form := parseForm(req)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cus := object{
+		"id":       s.newID("cus"),
+		"object":   "customer",
+		"email":    form.Get("email"),
+		"created":  time.Now().Unix(),
+		"livemode": false,
+		"invoice_settings": object{
+			"default_payment_method": nil,
+		},
+		"metadata": object{},
+	}
+	mergeMetadata(cus, form)
+	s.objects[cus["id"].(string)] = cus
+
+	s.render(w, cus, expands(form))
+}
+
+func (s *Server) updateCustomer(w http.ResponseWriter, req *http.Request) {
+	form := parseForm(req)
+	id := chi.URLParam(req, "id")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cus, ok := s.lookup(id, "customer")
+	if !ok {
+		writeMissing(w, "customer", id)
+		return
+	}
+
+	if v := form.Get("email"); v != "" {
+		cus["email"] = v
+	}
+
+	if pmID := form.Get("invoice_settings[default_payment_method]"); pmID != "" {
+		if _, ok := s.lookup(pmID, "payment_method"); !ok {
+			writeMissing(w, "payment_method", pmID)
+			return
+		}
+		cus["invoice_settings"] = object{
+			"default_payment_method": pmID,
+		}
+	}
+	mergeMetadata(cus, form)
+
+	s.render(w, cus, expands(form))
+}
+
+func (s *Server) createSetupIntent(w http.ResponseWriter, req *http.Request) {
+	form := parseForm(req)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cusID := form.Get("customer")
+	if _, ok := s.lookup(cusID, "customer"); !ok {
+		writeMissing(w, "customer", cusID)
+		return
+	}
+
+	id := s.newID("seti")
+	si := object{
+		"id":                   id,
+		"object":               "setup_intent",
+		"client_secret":        id + "_secret_test",
+		"customer":             cusID,
+		"payment_method":       nil,
+		"payment_method_types": []string{"card"},
+		"status":               "requires_payment_method",
+		"usage":                "off_session",
+		"created":              time.Now().Unix(),
+		"livemode":             false,
+		"metadata":             object{},
+	}
+	s.objects[id] = si
+
+	s.render(w, si, expands(form))
+}
+
+func (s *Server) listPaymentMethods(w http.ResponseWriter, req *http.Request) {
+	form := parseForm(req)
+	cusID := form.Get("customer")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.list(w, "/v1/payment_methods", func(o object) bool {
+		return o["object"] == "payment_method" && o["customer"] == cusID
+	})
+}
+
+func (s *Server) createEphemeralKey(w http.ResponseWriter, req *http.Request) {
+	form := parseForm(req)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cusID := form.Get("customer")
+	if _, ok := s.lookup(cusID, "customer"); !ok {
+		writeMissing(w, "customer", cusID)
+		return
+	}
+
+	now := time.Now()
+	id := s.newID("ephkey")
+	writeJSON(w, http.StatusOK, object{
+		"id":     id,
+		"object": "ephemeral_key",
+		"associated_objects": []object{
+			{"id": cusID, "type": "customer"},
+		},
+		"created":  now.Unix(),
+		"expires":  now.Add(time.Hour).Unix(),
+		"livemode": false,
+		"secret":   "ek_test_" + id,
+	})
+}
@@ -0,0 +1,343 @@
+//go:build !production
+// +build !production
+
+// Package stripetest provides an in-memory stand-in for the
+// part of Stripe API used by this app, together with helpers
+// to sign webhook events, so that Stripe integration could be
+// tested offline.
+//
+// Objects are kept as decoded JSON. Only the parameters sent
+// by stripeclient are understood; others are ignored.
+package stripetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stripe/stripe-go/v72"
+)
+
+type object = map[string]interface{}
+
+// Server handles Stripe API requests sent to its URL.
+type Server struct {
+	*httptest.Server
+	mu      sync.Mutex
+	seq     int
+	objects map[string]object // Keyed by id.
+}
+
+// NewServer starts a server. Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		objects: map[string]object{},
+	}
+
+	r := chi.NewRouter()
+	r.Route("/v1", func(r chi.Router) {
+		r.Post("/customers", s.createCustomer)
+		r.Get("/customers/{id}", s.retrieve("customer"))
+		r.Post("/customers/{id}", s.updateCustomer)
+
+		r.Post("/setup_intents", s.createSetupIntent)
+		r.Get("/setup_intents/{id}", s.retrieve("setup_intent"))
+
+		r.Get("/payment_methods", s.listPaymentMethods)
+		r.Get("/payment_methods/{id}", s.retrieve("payment_method"))
+
+		r.Post("/ephemeral_keys", s.createEphemeralKey)
+
+		r.Post("/subscriptions", s.createSubs)
+		r.Get("/subscriptions/{id}", s.retrieve("subscription"))
+		r.Post("/subscriptions/{id}", s.updateSubs)
+		r.Delete("/subscriptions/{id}", s.cancelSubs)
+
+		r.Get("/invoices/{id}", s.retrieve("invoice"))
+		r.Post("/invoices/{id}/pay", s.payInvoice)
+
+		r.Get("/payment_intents/{id}", s.retrieve("payment_intent"))
+
+		r.Get("/prices", s.listPrices)
+		r.Get("/prices/{id}", s.retrieve("price"))
+		r.Post("/prices/{id}", s.updateMetadata("price"))
+
+		r.Get("/coupons/{id}", s.retrieve("coupon"))
+		r.Post("/coupons/{id}", s.updateMetadata("coupon"))
+	})
+	r.NotFound(func(w http.ResponseWriter, req *http.Request) {
+		writeError(w, http.StatusNotFound, "", fmt.Sprintf("Unrecognized request URL (%s: %s)", req.Method, req.URL.Path), "")
+	})
+
+	s.Server = httptest.NewServer(r)
+
+	return s
+}
+
+// AddPrice saves a price so that it could be retrieved and
+// subscribed to. An id is generated if missing.
+func (s *Server) AddPrice(p *stripe.Price) string {
+	return s.add("price", "price", p)
+}
+
+// AddCoupon saves a coupon so that it could be retrieved and
+// applied to subscriptions. An id is generated if missing.
+func (s *Server) AddCoupon(c *stripe.Coupon) string {
+	return s.add("coupon", "coupon", c)
+}
+
+// AddPaymentMethod attaches a test card to a customer, as if
+// the customer completed a setup intent.
+func (s *Server) AddPaymentMethod(cusID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pm := object{
+		"id":              s.newID("pm"),
+		"object":          "payment_method",
+		"type":            "card",
+		"customer":        cusID,
+		"billing_details": object{},
+		"card": object{
+			"brand":     "visa",
+			"country":   "US",
+			"exp_month": 12,
+			"exp_year":  time.Now().Year() + 3,
+			"funding":   "credit",
+			"last4":     "4242",
+		},
+		"created":  time.Now().Unix(),
+		"livemode": false,
+		"metadata": object{},
+	}
+	s.objects[pm["id"].(string)] = pm
+
+	return pm["id"].(string)
+}
+
+// Object returns a copy of the object saved under id, or nil.
+func (s *Server) Object(id string) map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.objects[id]
+	if !ok {
+		return nil
+	}
+
+	return clone(o)
+}
+
+func (s *Server) add(prefix string, kind string, v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+
+	var o object
+	if err := json.Unmarshal(b, &o); err != nil {
+		panic(err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id, _ := o["id"].(string); id == "" {
+		o["id"] = s.newID(prefix)
+	}
+	o["object"] = kind
+	s.objects[o["id"].(string)] = o
+
+	return o["id"].(string)
+}
+
+// newID must be called with lock held.
+func (s *Server) newID(prefix string) string {
+	s.seq++
+	return fmt.Sprintf("%s_test%08d", prefix, s.seq)
+}
+
+// lookup must be called with lock held.
+func (s *Server) lookup(id string, kind string) (object, bool) {
+	o, ok := s.objects[id]
+	if !ok || o["object"] != kind {
+		return nil, false
+	}
+
+	return o, true
+}
+
+// render writes a copy of o with fields in expand replaced by
+// the objects they refer to. Must be called with lock held.
+func (s *Server) render(w http.ResponseWriter, o object, expand []string) {
+	c := clone(o)
+	for _, path := range expand {
+		s.expand(c, strings.Split(path, "."))
+	}
+
+	writeJSON(w, http.StatusOK, c)
+}
+
+func (s *Server) expand(o object, path []string) {
+	v, ok := o[path[0]]
+	if !ok {
+		return
+	}
+
+	if id, isID := v.(string); isID {
+		target, found := s.objects[id]
+		if !found {
+			return
+		}
+		v = clone(target)
+		o[path[0]] = v
+	}
+
+	if len(path) > 1 {
+		if child, ok := v.(object); ok {
+			s.expand(child, path[1:])
+		}
+	}
+}
+
+func (s *Server) retrieve(kind string) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		form := parseForm(req)
+		id := chi.URLParam(req, "id")
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		o, ok := s.lookup(id, kind)
+		if !ok {
+			writeMissing(w, kind, id)
+			return
+		}
+
+		s.render(w, o, expands(form))
+	}
+}
+
+func (s *Server) updateMetadata(kind string) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		form := parseForm(req)
+		id := chi.URLParam(req, "id")
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		o, ok := s.lookup(id, kind)
+		if !ok {
+			writeMissing(w, kind, id)
+			return
+		}
+
+		mergeMetadata(o, form)
+
+		s.render(w, o, expands(form))
+	}
+}
+
+func (s *Server) list(w http.ResponseWriter, path string, match func(o object) bool) {
+	data := make([]interface{}, 0)
+	for _, o := range s.objects {
+		if match(o) {
+			data = append(data, clone(o))
+		}
+	}
+
+	writeJSON(w, http.StatusOK, object{
+		"object":   "list",
+		"data":     data,
+		"has_more": false,
+		"url":      path,
+	})
+}
+
+func parseForm(req *http.Request) url.Values {
+	_ = req.ParseForm()
+	return req.Form
+}
+
+// expands collects expand[] or expand[0] parameters.
+func expands(form url.Values) []string {
+	var paths []string
+	for k, v := range form {
+		if strings.HasPrefix(k, "expand[") {
+			paths = append(paths, v...)
+		}
+	}
+
+	return paths
+}
+
+func mergeMetadata(o object, form url.Values) {
+	m, ok := o["metadata"].(object)
+	if !ok {
+		m = object{}
+	}
+
+	for k := range form {
+		if strings.HasPrefix(k, "metadata[") && strings.HasSuffix(k, "]") {
+			key := strings.TrimSuffix(strings.TrimPrefix(k, "metadata["), "]")
+			if v := form.Get(k); v == "" {
+				delete(m, key)
+			} else {
+				m[key] = v
+			}
+		}
+	}
+
+	o["metadata"] = m
+}
+
+func formInt(form url.Values, key string) int64 {
+	n, _ := strconv.ParseInt(form.Get(key), 10, 64)
+	return n
+}
+
+func clone(o object) object {
+	b, err := json.Marshal(o)
+	if err != nil {
+		panic(err)
+	}
+
+	var c object
+	if err := json.Unmarshal(b, &c); err != nil {
+		panic(err)
+	}
+
+	return c
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code string, msg string, param string) {
+	writeJSON(w, status, object{
+		"error": object{
+			"type":    "invalid_request_error",
+			"code":    code,
+			"message": msg,
+			"param":   param,
+		},
+	})
+}
+
+func writeMissing(w http.ResponseWriter, kind string, id string) {
+	writeError(w, http.StatusNotFound, "resource_missing", fmt.Sprintf("No such %s: '%s'", kind, id), "id")
+}
+
+func writeParamMissing(w http.ResponseWriter, param string) {
+	writeError(w, http.StatusBadRequest, "parameter_missing", "Missing required param: "+param+".", param)
+}
@@ -0,0 +1,422 @@
+//go:build !production
+// +build !production
+
+package stripetest
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// periodEnd adds a billing interval of price p to start.
+func periodEnd(p object, start time.Time) time.Time {
+	recurring, _ := p["recurring"].(object)
+	interval, _ := recurring["interval"].(string)
+	count, _ := recurring["interval_count"].(float64)
+	n := int(count)
+	if n == 0 {
+		n = 1
+	}
+
+	switch interval {
+	case "day":
+		return start.AddDate(0, 0, n)
+	case "week":
+		return start.AddDate(0, 0, 7*n)
+	case "year":
+		return start.AddDate(n, 0, 0)
+	default:
+		return start.AddDate(0, n, 0)
+	}
+}
+
+func amountOf(p object, discount interface{}) int64 {
+	amount, _ := p["unit_amount"].(float64)
+	if d, ok := discount.(object); ok {
+		if c, ok := d["coupon"].(object); ok {
+			off, _ := c["amount_off"].(float64)
+			amount -= off
+		}
+	}
+
+	if amount < 0 {
+		return 0
+	}
+
+	return int64(amount)
+}
+
+// newInvoice creates an invoice of subscription, paid if a
+// payment method exists, together with its payment intent.
+// Must be called with lock held.
+func (s *Server) newInvoice(subs object, p object, reason string, paid bool) object {
+	now := time.Now().Unix()
+	amount := amountOf(p, subs["discount"])
+	if subs["status"] == "trialing" {
+		amount = 0
+	}
+
+	invID := s.newID("in")
+
+	piID := s.newID("pi")
+	piStatus := "requires_payment_method"
+	if paid {
+		piStatus = "succeeded"
+	}
+	s.objects[piID] = object{
+		"id":            piID,
+		"object":        "payment_intent",
+		"amount":        amount,
+		"client_secret": piID + "_secret_test",
+		"currency":      p["currency"],
+		"customer":      subs["customer"],
+		"invoice":       invID,
+		"status":        piStatus,
+		"created":       now,
+		"livemode":      false,
+		"metadata":      object{},
+	}
+
+	status := "open"
+	var amountPaid int64
+	if paid {
+		status = "paid"
+		amountPaid = amount
+	}
+	inv := object{
+		"id":               invID,
+		"object":           "invoice",
+		"amount_due":       amount,
+		"amount_paid":      amountPaid,
+		"amount_remaining": amount - amountPaid,
+		"billing_reason":   reason,
+		"currency":         p["currency"],
+		"customer":         subs["customer"],
+		"discount":         subs["discount"],
+		"paid":             paid,
+		"payment_intent":   piID,
+		"period_start":     subs["current_period_start"],
+		"period_end":       subs["current_period_end"],
+		"status":           status,
+		"subscription":     subs["id"],
+		"subtotal":         amount,
+		"total":            amount,
+		"created":          now,
+		"livemode":         false,
+		"lines": object{
+			"object":   "list",
+			"data":     []object{},
+			"has_more": false,
+			"url":      "/v1/invoices/" + invID + "/lines",
+		},
+		"metadata": object{},
+	}
+	s.objects[invID] = inv
+	subs["latest_invoice"] = invID
+
+	return inv
+}
+
+// discountOf builds a discount from the coupon parameter.
+// Must be called with lock held.
+func (s *Server) discountOf(w http.ResponseWriter, subs object, couponID string) (object, bool) {
+	coupon, ok := s.lookup(couponID, "coupon")
+	if !ok {
+		writeMissing(w, "coupon", couponID)
+		return nil, false
+	}
+
+	return object{
+		"id":           s.newID("di"),
+		"object":       "discount",
+		"coupon":       clone(coupon),
+		"customer":     subs["customer"],
+		"subscription": subs["id"],
+		"start":        time.Now().Unix(),
+	}, true
+}
+
+// defaultPaymentMethod picks the one set on subscription,
+// then the one of customer.
+func (s *Server) defaultPaymentMethod(form url.Values, cus object) interface{} {
+	if pm := form.Get("default_payment_method"); pm != "" {
+		return pm
+	}
+
+	settings, _ := cus["invoice_settings"].(object)
+	return settings["default_payment_method"]
+}
+
+func (s *Server) createSubs(w http.ResponseWriter, req *http.Request) {
+	form := parseForm(req)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cusID := form.Get("customer")
+	if cusID == "" {
+		writeParamMissing(w, "customer")
+		return
+	}
+	cus, ok := s.lookup(cusID, "customer")
+	if !ok {
+		writeMissing(w, "customer", cusID)
+		return
+	}
+
+	priceID := form.Get("items[0][price]")
+	if priceID == "" {
+		writeParamMissing(w, "items")
+		return
+	}
+	p, ok := s.lookup(priceID, "price")
+	if !ok {
+		writeMissing(w, "price", priceID)
+		return
+	}
+
+	now := time.Now()
+	id := s.newID("sub")
+	pm := s.defaultPaymentMethod(form, cus)
+
+	subs := object{
+		"id":                     id,
+		"object":                 "subscription",
+		"cancel_at":              0,
+		"cancel_at_period_end":   false,
+		"canceled_at":            0,
+		"current_period_start":   now.Unix(),
+		"current_period_end":     periodEnd(p, now).Unix(),
+		"customer":               cusID,
+		"default_payment_method": pm,
+		"discount":               nil,
+		"ended_at":               0,
+		"items": object{
+			"object": "list",
+			"data": []object{
+				{
+					"id":           s.newID("si"),
+					"object":       "subscription_item",
+					"price":        clone(p),
+					"quantity":     1,
+					"subscription": id,
+					"created":      now.Unix(),
+				},
+			},
+			"has_more": false,
+			"url":      "/v1/subscription_items?subscription=" + id,
+		},
+		"pause_collection": nil,
+		"start_date":       now.Unix(),
+		"status":           "active",
+		"created":          now.Unix(),
+		"livemode":         false,
+		"metadata":         object{},
+	}
+	mergeMetadata(subs, form)
+
+	trialEnd := formInt(form, "trial_end")
+	if days := formInt(form, "trial_period_days"); days > 0 {
+		trialEnd = now.AddDate(0, 0, int(days)).Unix()
+	}
+	if trialEnd > 0 {
+		subs["status"] = "trialing"
+		subs["trial_start"] = now.Unix()
+		subs["trial_end"] = trialEnd
+		subs["current_period_end"] = trialEnd
+	}
+
+	if couponID := form.Get("coupon"); couponID != "" {
+		d, ok := s.discountOf(w, subs, couponID)
+		if !ok {
+			return
+		}
+		subs["discount"] = d
+	}
+
+	paid := pm != nil || subs["status"] == "trialing"
+	if !paid {
+		subs["status"] = "incomplete"
+	}
+	s.newInvoice(subs, p, "subscription_create", paid)
+	// Stored as decoded JSON like any other object.
+	subs = clone(subs)
+	s.objects[id] = subs
+
+	s.render(w, subs, expands(form))
+}
+
+func (s *Server) updateSubs(w http.ResponseWriter, req *http.Request) {
+	form := parseForm(req)
+	id := chi.URLParam(req, "id")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subs, ok := s.lookup(id, "subscription")
+	if !ok {
+		writeMissing(w, "subscription", id)
+		return
+	}
+
+	now := time.Now()
+
+	if v := form.Get("cancel_at_period_end"); v != "" {
+		cancel, _ := strconv.ParseBool(v)
+		subs["cancel_at_period_end"] = cancel
+		if cancel {
+			subs["canceled_at"] = now.Unix()
+		} else {
+			subs["canceled_at"] = 0
+		}
+	}
+
+	if _, ok := form["cancel_at"]; ok {
+		subs["cancel_at"] = formInt(form, "cancel_at")
+	}
+
+	if pm := form.Get("default_payment_method"); pm != "" {
+		if _, ok := s.lookup(pm, "payment_method"); !ok {
+			writeMissing(w, "payment_method", pm)
+			return
+		}
+		subs["default_payment_method"] = pm
+	}
+
+	if _, ok := form["coupon"]; ok {
+		if couponID := form.Get("coupon"); couponID == "" {
+			subs["discount"] = nil
+		} else {
+			d, ok := s.discountOf(w, subs, couponID)
+			if !ok {
+				return
+			}
+			subs["discount"] = d
+		}
+	}
+
+	// Unset with an empty string, or set behavior and resumes_at.
+	if _, ok := form["pause_collection"]; ok {
+		subs["pause_collection"] = nil
+	} else if b := form.Get("pause_collection[behavior]"); b != "" {
+		subs["pause_collection"] = object{
+			"behavior":   b,
+			"resumes_at": formInt(form, "pause_collection[resumes_at]"),
+		}
+	}
+
+	if form.Get("trial_end") == "now" && subs["status"] == "trialing" {
+		subs["status"] = "active"
+		subs["trial_end"] = now.Unix()
+	}
+
+	mergeMetadata(subs, form)
+
+	item := subs["items"].(object)["data"].([]interface{})[0].(object)
+	p := item["price"].(object)
+
+	var newCycle bool
+	if priceID := form.Get("items[0][price]"); priceID != "" && priceID != p["id"] {
+		newPrice, ok := s.lookup(priceID, "price")
+		if !ok {
+			writeMissing(w, "price", priceID)
+			return
+		}
+		p = clone(newPrice)
+		item["price"] = p
+		newCycle = true
+	}
+
+	if form.Get("billing_cycle_anchor") == "now" {
+		newCycle = true
+	}
+
+	if newCycle {
+		subs["current_period_start"] = now.Unix()
+		subs["current_period_end"] = periodEnd(p, now).Unix()
+		s.newInvoice(subs, p, "subscription_update", subs["default_payment_method"] != nil)
+	}
+
+	s.render(w, subs, expands(form))
+}
+
+func (s *Server) cancelSubs(w http.ResponseWriter, req *http.Request) {
+	form := parseForm(req)
+	id := chi.URLParam(req, "id")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subs, ok := s.lookup(id, "subscription")
+	if !ok {
+		writeMissing(w, "subscription", id)
+		return
+	}
+
+	now := time.Now().Unix()
+	subs["status"] = "canceled"
+	subs["canceled_at"] = now
+	subs["ended_at"] = now
+
+	s.render(w, subs, expands(form))
+}
+
+func (s *Server) payInvoice(w http.ResponseWriter, req *http.Request) {
+	form := parseForm(req)
+	id := chi.URLParam(req, "id")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inv, ok := s.lookup(id, "invoice")
+	if !ok {
+		writeMissing(w, "invoice", id)
+		return
+	}
+
+	if inv["status"] != "open" {
+		writeError(w, http.StatusBadRequest, "invoice_not_open", "Invoice is already "+inv["status"].(string)+".", "")
+		return
+	}
+
+	inv["status"] = "paid"
+	inv["paid"] = true
+	inv["amount_paid"] = inv["amount_due"]
+	inv["amount_remaining"] = 0
+
+	if piID, ok := inv["payment_intent"].(string); ok {
+		if pi, ok := s.lookup(piID, "payment_intent"); ok {
+			pi["status"] = "succeeded"
+		}
+	}
+
+	if subsID, ok := inv["subscription"].(string); ok {
+		if subs, ok := s.lookup(subsID, "subscription"); ok {
+			if st := subs["status"]; st == "incomplete" || st == "past_due" || st == "unpaid" {
+				subs["status"] = "active"
+			}
+		}
+	}
+
+	s.render(w, inv, expands(form))
+}
+
+func (s *Server) listPrices(w http.ResponseWriter, req *http.Request) {
+	form := parseForm(req)
+	activeOnly := form.Get("active") == "true"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.list(w, "/v1/prices", func(o object) bool {
+		if o["object"] != "price" {
+			return false
+		}
+
+		return !activeOnly || o["active"] == true
+	})
+}
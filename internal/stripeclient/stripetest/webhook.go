@@ -0,0 +1,78 @@
+//go:build !production
+// +build !production
+
+package stripetest
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/stripe/stripe-go/v72"
+	"github.com/stripe/stripe-go/v72/webhook"
+)
+
+// SignPayload computes the Stripe-Signature header of a
+// webhook payload signed at t in the same way as Stripe.
+func SignPayload(payload []byte, secret string, t time.Time) string {
+	sig := webhook.ComputeSignature(t, payload, secret)
+
+	return fmt.Sprintf("t=%d,v1=%s", t.Unix(), hex.EncodeToString(sig))
+}
+
+// NewEvent wraps obj in an event of eventType.
+// obj could be an SDK struct or an object returned by
+// Server.Object.
+func NewEvent(eventType string, obj interface{}) ([]byte, error) {
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	return json.Marshal(map[string]interface{}{
+		"id":          fmt.Sprintf("evt_test%d", now.UnixNano()),
+		"object":      "event",
+		"api_version": stripe.APIVersion,
+		"created":     now.Unix(),
+		"livemode":    false,
+		"type":        eventType,
+		"data": map[string]json.RawMessage{
+			"object": raw,
+		},
+		"pending_webhooks": 1,
+	})
+}
+
+// NewWebhookRequest builds a request delivering an event of
+// eventType wrapping obj to target, signed with secret as
+// Stripe does, so that it passes webhook.ConstructEvent.
+func NewWebhookRequest(target string, secret string, eventType string, obj interface{}) (*http.Request, error) {
+	payload, err := NewEvent(eventType, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	req := httptest.NewRequest(http.MethodPost, target, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Stripe-Signature", SignPayload(payload, secret, time.Now()))
+
+	return req, nil
+}
+
+// NewWebhookRequest builds a signed request delivering the
+// current state of the object saved under id, e.g., after a
+// subscription is updated via API.
+func (s *Server) NewWebhookRequest(target string, secret string, eventType string, id string) (*http.Request, error) {
+	obj := s.Object(id)
+	if obj == nil {
+		return nil, fmt.Errorf("stripetest: no object %s", id)
+	}
+
+	return NewWebhookRequest(target, secret, eventType, obj)
+}
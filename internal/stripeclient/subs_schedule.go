@@ -0,0 +1,37 @@
+package stripeclient
+
+import (
+	ftcStripe "github.com/FTChinese/subscription-api/internal/pkg/stripe"
+	"github.com/stripe/stripe-go/v72"
+)
+
+// NewScheduleFromSubs creates a schedule managing an existing
+// subscription. The schedule has only one phase copied
+// from the subscription.
+func (c Client) NewScheduleFromSubs(subsID string) (*stripe.SubscriptionSchedule, error) {
+	params := &stripe.SubscriptionScheduleParams{
+		FromSubscription: stripe.String(subsID),
+	}
+	params.AddExpand(ftcStripe.KeySchedulePhasePrice)
+
+	return c.sc.SubscriptionSchedules.New(params)
+}
+
+// FetchSchedule retrieves a schedule with prices of each phase expanded.
+func (c Client) FetchSchedule(id string) (*stripe.SubscriptionSchedule, error) {
+	params := &stripe.SubscriptionScheduleParams{}
+	params.AddExpand(ftcStripe.KeySchedulePhasePrice)
+
+	return c.sc.SubscriptionSchedules.Get(id, params)
+}
+
+func (c Client) UpdateSchedule(id string, params *stripe.SubscriptionScheduleParams) (*stripe.SubscriptionSchedule, error) {
+	return c.sc.SubscriptionSchedules.Update(id, params)
+}
+
+// ReleaseSchedule stops a schedule from managing the
+// subscription, dropping any phase not started yet.
+// The subscription itself is not changed.
+func (c Client) ReleaseSchedule(id string) (*stripe.SubscriptionSchedule, error) {
+	return c.sc.SubscriptionSchedules.Release(id, nil)
+}
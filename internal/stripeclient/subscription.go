@@ -40,13 +40,25 @@ func (c Client) SetSubsDefaultPaymentMethod(subsID string, pmID string) (*stripe
 func (c Client) FetchSubs(subID string, expand bool) (*stripe.Subscription, error) {
 	var params *stripe.SubscriptionParams
 	if expand {
-		p := stripe.Params{}
-		p.AddExpand(ftcStripe.KeyLatestInvoicePaymentIntent)
+		params = &stripe.SubscriptionParams{}
+		params.AddExpand(ftcStripe.KeyLatestInvoicePaymentIntent)
 	}
 
 	return c.sc.Subscriptions.Get(subID, params)
 }
 
+// CancelSubsNow cancels a subscription immediately without
+// crediting unused time.
+func (c Client) CancelSubsNow(subID string) (*stripe.Subscription, error) {
+	params := &stripe.SubscriptionCancelParams{
+		InvoiceNow: stripe.Bool(false),
+		Prorate:    stripe.Bool(false),
+	}
+	params.AddExpand(ftcStripe.KeyLatestInvoicePaymentIntent)
+
+	return c.sc.Subscriptions.Cancel(subID, params)
+}
+
 // CancelSubs cancels a subscription at current period end if the passed in parameter `cancel` is true, or reactivate it if false.
 func (c Client) CancelSubs(subID string, cancel bool) (*stripe.Subscription, error) {
 	params := &stripe.SubscriptionParams{
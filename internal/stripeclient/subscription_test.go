@@ -1,51 +1,189 @@
 package stripeclient
 
 import (
-	"github.com/FTChinese/subscription-api/faker"
-	"github.com/stripe/stripe-go/v72"
-	"go.uber.org/zap/zaptest"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stripe/stripe-go/v72"
 )
 
 func TestClient_NewSubs(t *testing.T) {
-	faker.MustSetupViper()
+	b := newTestBackend(t)
+	priceID := b.addPrice(stripe.PriceRecurringIntervalYear)
 
-	client := New(false, zaptest.NewLogger(t))
-
-	type args struct {
-		params *stripe.SubscriptionParams
-	}
 	tests := []struct {
-		name    string
-		fields  Client
-		args    args
-		want    *stripe.Subscription
-		wantErr bool
+		name       string
+		withCard   bool
+		params     func(cusID string) *stripe.SubscriptionParams
+		wantStatus stripe.SubscriptionStatus
 	}{
 		{
-			name:    "Introductory offer",
-			fields:  client,
-			args:    args{},
-			want:    nil,
-			wantErr: false,
+			name:     "Default payment method",
+			withCard: true,
+			params: func(cusID string) *stripe.SubscriptionParams {
+				return &stripe.SubscriptionParams{
+					Customer: stripe.String(cusID),
+					Items: []*stripe.SubscriptionItemsParams{
+						{Price: stripe.String(priceID)},
+					},
+				}
+			},
+			wantStatus: stripe.SubscriptionStatusActive,
+		},
+		{
+			name:     "Payment incomplete",
+			withCard: false,
+			params: func(cusID string) *stripe.SubscriptionParams {
+				return &stripe.SubscriptionParams{
+					Customer: stripe.String(cusID),
+					Items: []*stripe.SubscriptionItemsParams{
+						{Price: stripe.String(priceID)},
+					},
+					PaymentBehavior: stripe.String("default_incomplete"),
+				}
+			},
+			wantStatus: stripe.SubscriptionStatusIncomplete,
+		},
+		{
+			name:     "Free trial",
+			withCard: false,
+			params: func(cusID string) *stripe.SubscriptionParams {
+				return &stripe.SubscriptionParams{
+					Customer: stripe.String(cusID),
+					Items: []*stripe.SubscriptionItemsParams{
+						{Price: stripe.String(priceID)},
+					},
+					TrialEnd: stripe.Int64(time.Now().AddDate(0, 0, 7).Unix()),
+				}
+			},
+			wantStatus: stripe.SubscriptionStatusTrialing,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			c := Client{
-				sc:     tt.fields.sc,
-				logger: tt.fields.logger,
+			cus := b.newCustomer(t, tt.withCard)
+
+			params := tt.params(cus.ID)
+			params.AddExpand("latest_invoice.payment_intent")
+
+			got, err := b.client.NewSubs(params)
+			if err != nil {
+				t.Fatal(err)
 			}
-			got, err := c.NewSubs(tt.args.params)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("NewSubs() error = %v, wantErr %v", err, tt.wantErr)
+
+			assert.NotEmpty(t, got.ID)
+			if !b.isStateful() {
 				return
 			}
-			//if !reflect.DeepEqual(got, tt.want) {
-			//	t.Errorf("NewSubs() got = %v, want %v", got, tt.want)
-			//}
 
-			t.Logf("%s", faker.MustMarshalIndent(got))
+			assert.Equal(t, tt.wantStatus, got.Status)
+			assert.Equal(t, priceID, got.Items.Data[0].Price.ID)
+			assert.NotNil(t, got.LatestInvoice.PaymentIntent)
 		})
 	}
 }
+
+func TestClient_NewSubs_missingCustomer(t *testing.T) {
+	b := newTestBackend(t)
+
+	_, err := b.client.NewSubs(&stripe.SubscriptionParams{
+		Items: []*stripe.SubscriptionItemsParams{
+			{Price: stripe.String(b.addPrice(stripe.PriceRecurringIntervalYear))},
+		},
+	})
+
+	if assert.Error(t, err) {
+		assert.Equal(t, stripe.ErrorCodeParameterMissing, err.(*stripe.Error).Code)
+	}
+}
+
+func newTestSubs(t *testing.T, b testBackend) *stripe.Subscription {
+	cus := b.newCustomer(t, true)
+
+	ss, err := b.client.NewSubs(&stripe.SubscriptionParams{
+		Customer: stripe.String(cus.ID),
+		Items: []*stripe.SubscriptionItemsParams{
+			{Price: stripe.String(b.addPrice(stripe.PriceRecurringIntervalYear))},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ss
+}
+
+func TestClient_FetchSubs(t *testing.T) {
+	b := newTestBackend(t)
+	created := newTestSubs(t, b)
+
+	ss, err := b.client.FetchSubs(created.ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, created.ID, ss.ID)
+	if b.isStateful() {
+		assert.Equal(t, stripe.InvoiceStatusPaid, ss.LatestInvoice.Status)
+		assert.Equal(t, stripe.PaymentIntentStatusSucceeded, ss.LatestInvoice.PaymentIntent.Status)
+	}
+}
+
+func TestClient_CancelSubs(t *testing.T) {
+	b := newTestBackend(t)
+	b.requireStateful(t)
+	created := newTestSubs(t, b)
+
+	ss, err := b.client.CancelSubs(created.ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, ss.CancelAtPeriodEnd)
+	assert.Equal(t, stripe.SubscriptionStatusActive, ss.Status)
+
+	ss, err = b.client.CancelSubs(created.ID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, ss.CancelAtPeriodEnd)
+}
+
+func TestClient_CancelSubsNow(t *testing.T) {
+	b := newTestBackend(t)
+	b.requireStateful(t)
+	created := newTestSubs(t, b)
+
+	ss, err := b.client.CancelSubsNow(created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, stripe.SubscriptionStatusCanceled, ss.Status)
+	assert.NotZero(t, ss.EndedAt)
+}
+
+func TestClient_UpdateSubs(t *testing.T) {
+	b := newTestBackend(t)
+	b.requireStateful(t)
+	created := newTestSubs(t, b)
+	monthly := b.addPrice(stripe.PriceRecurringIntervalMonth)
+	couponID := b.addCoupon(500)
+
+	ss, err := b.client.UpdateSubs(created.ID, &stripe.SubscriptionParams{
+		Items: []*stripe.SubscriptionItemsParams{
+			{
+				ID:    stripe.String(created.Items.Data[0].ID),
+				Price: stripe.String(monthly),
+			},
+		},
+		Coupon: stripe.String(couponID),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, monthly, ss.Items.Data[0].Price.ID)
+	assert.Equal(t, couponID, ss.Discount.Coupon.ID)
+	assert.NotEqual(t, created.LatestInvoice.ID, ss.LatestInvoice.ID)
+}
@@ -9,9 +9,14 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+
+	"github.com/FTChinese/subscription-api/pkg/metrics"
+	"github.com/FTChinese/subscription-api/pkg/tracing"
 )
 
-var httpClient = &http.Client{}
+var httpClient = &http.Client{
+	Transport: tracing.Transport(metrics.Transport("", nil)),
+}
 
 type Fetch struct {
 	method    string
@@ -0,0 +1,93 @@
+package totp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// Test vectors from RFC 6238 Appendix B, truncated to 6 digits.
+func TestCode(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want string
+	}{
+		{
+			name: "59",
+			t:    time.Unix(59, 0),
+			want: "287082",
+		},
+		{
+			name: "1111111109",
+			t:    time.Unix(1111111109, 0),
+			want: "081804",
+		},
+		{
+			name: "1234567890",
+			t:    time.Unix(1234567890, 0),
+			want: "005924",
+		},
+		{
+			name: "20000000000",
+			t:    time.Unix(20000000000, 0),
+			want: "353130",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Code(secret, tt.t)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Code() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	code, _ := Code(secret, now)
+
+	if !Validate(secret, code, now) {
+		t.Error("current code should be valid")
+	}
+
+	if !Validate(secret, code, now.Add(Period*time.Second)) {
+		t.Error("code of previous period should be accepted")
+	}
+
+	if Validate(secret, code, now.Add(3*Period*time.Second)) {
+		t.Error("stale code should be rejected")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	secret, err := NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	code, _ := Code(secret, now)
+
+	step, ok := Match(secret, code, now, 0)
+	if !ok || step != now.Unix()/Period {
+		t.Errorf("Match() = %d, %v", step, ok)
+	}
+
+	// Replayed in the next period.
+	if _, ok := Match(secret, code, now.Add(Period*time.Second), step); ok {
+		t.Error("code of an accepted step should be rejected")
+	}
+}
@@ -0,0 +1,53 @@
+package validator
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/FTChinese/go-rest/render"
+)
+
+// Hosts users could be redirected to, or sent a link of.
+// Subdomains are also accepted.
+var redirectHosts = []string{
+	"ftacademy.cn",
+	"ftchinese.com",
+	"chineseft.com",
+}
+
+// EnsureRedirectURL prevents our pages and emails from being
+// used to send users to arbitrary sites.
+// Urls on localhost are only accepted in sandbox mode.
+func EnsureRedirectURL(field string, rawURL string, live bool) *render.ValidationError {
+	if ve := New(field).Required().Validate(rawURL); ve != nil {
+		return ve
+	}
+
+	invalid := &render.ValidationError{
+		Message: field + " is not an allowed redirect url",
+		Field:   field,
+		Code:    render.CodeInvalid,
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return invalid
+	}
+
+	host := u.Hostname()
+	if !live && (host == "localhost" || host == "127.0.0.1") {
+		return nil
+	}
+
+	if u.Scheme != "https" {
+		return invalid
+	}
+
+	for _, h := range redirectHosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return nil
+		}
+	}
+
+	return invalid
+}
@@ -0,0 +1,31 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnsureRedirectURL(t *testing.T) {
+	tests := []struct {
+		url   string
+		live  bool
+		valid bool
+	}{
+		{"https://next.ftacademy.cn/checkout", true, true},
+		{"https://www.ftchinese.com/", true, true},
+		{"http://www.ftchinese.com/", true, false},
+		{"https://ftacademy.cn.example.com/", true, false},
+		{"https://evilftacademy.cn/", true, false},
+		{"http://localhost:3000/checkout", false, true},
+		{"http://localhost:3000/checkout", true, false},
+		{"", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			ve := EnsureRedirectURL("successUrl", tt.url, tt.live)
+			assert.Equal(t, tt.valid, ve == nil)
+		})
+	}
+}
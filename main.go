@@ -26,11 +26,13 @@ var commit string
 var (
 	production bool // Determine which db to use
 	liveMode   bool // Determine pricing mode.
+	adminPort  string
 )
 
 func init() {
 	flag.BoolVar(&production, "production", true, "Connect to production MySQL database by default, or localhost if false")
 	flag.BoolVar(&liveMode, "livemode", true, "Determine live/sandbox mode for webhook, and which of Stripe or Apple service to use")
+	flag.StringVar(&adminPort, "admin-port", config.AdminPort, "Serve /__ready and /metrics on this port, which should not be exposed to public")
 	var v = flag.Bool("v", false, "print current version")
 
 	flag.Parse()
@@ -50,6 +52,7 @@ func main() {
 		Build:      build,
 		Commit:     commit,
 		Port:       config.Port,
+		AdminPort:  adminPort,
 		Production: production,
 		LiveMode:   liveMode,
 	}
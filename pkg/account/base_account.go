@@ -70,6 +70,30 @@ func NewMobileBaseAccount(params input.MobileSignUpParams) BaseAccount {
 	}
 }
 
+// NewAppleBaseAccount creates an account for a user signed
+// in with Apple for the first time. The email might be a
+// private relay address. It is verified by Apple.
+// A random password is generated so that user could
+// only log in with Apple or passwordless email link until
+// a password is set.
+func NewAppleBaseAccount(email string, name null.String, verified bool) BaseAccount {
+	if !name.Valid {
+		name = null.StringFrom(email)
+	}
+
+	return BaseAccount{
+		FtcID:      uuid.New().String(),
+		UnionID:    null.String{},
+		StripeID:   null.String{},
+		Email:      email,
+		Password:   rand.String(16),
+		Mobile:     null.String{},
+		UserName:   name,
+		AvatarURL:  null.String{},
+		IsVerified: verified,
+	}
+}
+
 func (a BaseAccount) IsFtc() bool {
 	return a.FtcID != ""
 }
@@ -0,0 +1,154 @@
+package account
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"time"
+
+	gorest "github.com/FTChinese/go-rest"
+	"github.com/FTChinese/go-rest/chrono"
+	"github.com/FTChinese/subscription-api/internal/pkg/input"
+	"github.com/FTChinese/subscription-api/pkg/config"
+	"github.com/FTChinese/subscription-api/pkg/ids"
+	"github.com/guregu/null"
+)
+
+type EmailChangeStatus string
+
+const (
+	EmailChangePending   EmailChangeStatus = "pending"
+	EmailChangeConfirmed EmailChangeStatus = "confirmed"
+	EmailChangeReverted  EmailChangeStatus = "reverted"
+	EmailChangeCancelled EmailChangeStatus = "cancelled"
+)
+
+const (
+	// How long the new address could be confirmed.
+	emailChangeExpiresIn = 24 * 60 * 60
+	// How long the old address could undo a confirmed change.
+	emailRevertExpiresIn = 7 * 24 * 60 * 60
+	// maxEmailChangeAttempts limits guessing of the 6-digit code.
+	maxEmailChangeAttempts = 5
+)
+
+// EmailChange is a request to replace account's email.
+// The account email is not touched until the new address
+// is confirmed by the link or code sent to it.
+// After confirmed, the old address receives a letter with
+// a revert link in case the change is not made by owner.
+type EmailChange struct {
+	ID          string            `json:"id" db:"change_id"`
+	FtcID       string            `json:"-" db:"ftc_id"`
+	OldEmail    string            `json:"oldEmail" db:"old_email"`
+	OldVerified bool              `json:"-" db:"old_verified"`
+	NewEmail    string            `json:"newEmail" db:"new_email"`
+	Status      EmailChangeStatus `json:"status" db:"status"`
+	// Token is used to build the link sent to new address.
+	Token     string      `json:"-" db:"token"`
+	AppCode   null.String `json:"-" db:"app_code"`
+	SourceURL null.String `json:"-" db:"source_url"`
+	Attempts  int64       `json:"-" db:"attempts"`
+	// RevertToken is generated after confirmation and sent to old address.
+	RevertToken  null.String `json:"-" db:"revert_token"`
+	CreatedUTC   chrono.Time `json:"createdUtc" db:"created_utc"`
+	ConfirmedUTC chrono.Time `json:"confirmedUtc" db:"confirmed_utc"`
+	RevertedUTC  chrono.Time `json:"revertedUtc" db:"reverted_utc"`
+}
+
+// NewEmailChange creates a pending change for current account.
+// Mobile apps should use a code instead of link.
+func NewEmailChange(a BaseAccount, params input.EmailUpdateParams, useCode bool) (EmailChange, error) {
+	token, err := gorest.RandomHex(32)
+	if err != nil {
+		return EmailChange{}, err
+	}
+
+	c := EmailChange{
+		ID:          ids.EmailChangeID(),
+		FtcID:       a.FtcID,
+		OldEmail:    a.Email,
+		OldVerified: a.IsVerified,
+		NewEmail:    params.Email,
+		Status:      EmailChangePending,
+		Token:       token,
+		SourceURL:   params.SourceURL,
+		CreatedUTC:  chrono.TimeNow(),
+	}
+
+	if useCode {
+		c.SourceURL = null.String{}
+		c.AppCode = null.StringFrom(ids.PwResetCode())
+	} else if c.SourceURL.IsZero() {
+		c.SourceURL = null.StringFrom(config.EmailChangeURL)
+	}
+
+	return c, nil
+}
+
+// ConfirmURL is sent to the new address.
+// Empty if code is used.
+func (c EmailChange) ConfirmURL() string {
+	if c.AppCode.Valid {
+		return ""
+	}
+
+	return fmt.Sprintf("%s/%s", c.SourceURL.String, c.Token)
+}
+
+// RevertURL is sent to the old address after confirmation.
+func (c EmailChange) RevertURL() string {
+	return fmt.Sprintf("%s/%s", config.EmailRevertURL, c.RevertToken.String)
+}
+
+// CanConfirm checks whether the change is still pending and not expired.
+func (c EmailChange) CanConfirm() bool {
+	if c.Status != EmailChangePending || c.Attempts >= maxEmailChangeAttempts {
+		return false
+	}
+
+	return c.CreatedUTC.Add(emailChangeExpiresIn * time.Second).After(time.Now())
+}
+
+// MatchCode compares code in constant time.
+func (c EmailChange) MatchCode(code string) bool {
+	if !c.AppCode.Valid {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(c.AppCode.String), []byte(code)) == 1
+}
+
+// CanRevert checks whether the old address could still undo
+// the change.
+func (c EmailChange) CanRevert() bool {
+	if c.Status != EmailChangeConfirmed {
+		return false
+	}
+
+	return c.ConfirmedUTC.Add(emailRevertExpiresIn * time.Second).After(time.Now())
+}
+
+// RevertDays is used in the letter.
+func (c EmailChange) RevertDays() int64 {
+	return emailRevertExpiresIn / (24 * 60 * 60)
+}
+
+func (c EmailChange) Confirmed() (EmailChange, error) {
+	token, err := gorest.RandomHex(32)
+	if err != nil {
+		return EmailChange{}, err
+	}
+
+	c.Status = EmailChangeConfirmed
+	c.RevertToken = null.StringFrom(token)
+	c.ConfirmedUTC = chrono.TimeNow()
+
+	return c, nil
+}
+
+func (c EmailChange) Reverted() EmailChange {
+	c.Status = EmailChangeReverted
+	c.RevertedUTC = chrono.TimeNow()
+
+	return c
+}
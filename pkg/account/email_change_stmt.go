@@ -0,0 +1,71 @@
+package account
+
+const StmtInsertEmailChange = `
+INSERT INTO user_db.email_change
+SET change_id = :change_id,
+	ftc_id = :ftc_id,
+	old_email = :old_email,
+	old_verified = :old_verified,
+	new_email = :new_email,
+	status = :status,
+	token = UNHEX(:token),
+	app_code = :app_code,
+	source_url = :source_url,
+	attempts = :attempts,
+	created_utc = :created_utc`
+
+const colsEmailChange = `
+SELECT change_id,
+	ftc_id,
+	old_email,
+	old_verified,
+	new_email,
+	status,
+	LOWER(HEX(token)) AS token,
+	app_code,
+	source_url,
+	attempts,
+	LOWER(HEX(revert_token)) AS revert_token,
+	created_utc,
+	confirmed_utc,
+	reverted_utc
+FROM user_db.email_change
+`
+
+// StmtPendingEmailChange retrieves the latest pending change of a user.
+const StmtPendingEmailChange = colsEmailChange + `
+WHERE ftc_id = ?
+	AND status = 'pending'
+ORDER BY created_utc DESC
+LIMIT 1`
+
+const StmtEmailChangeByToken = colsEmailChange + `
+WHERE token = UNHEX(?)
+LIMIT 1`
+
+const StmtEmailChangeByRevertToken = colsEmailChange + `
+WHERE revert_token = UNHEX(?)
+LIMIT 1`
+
+// StmtCancelEmailChanges cancels all pending changes of a user
+// so that only the latest request is valid.
+const StmtCancelEmailChanges = `
+UPDATE user_db.email_change
+SET status = 'cancelled'
+WHERE ftc_id = ?
+	AND status = 'pending'`
+
+const StmtIncEmailChangeAttempts = `
+UPDATE user_db.email_change
+SET attempts = attempts + 1
+WHERE change_id = ?
+LIMIT 1`
+
+const StmtUpdateEmailChange = `
+UPDATE user_db.email_change
+SET status = :status,
+	revert_token = UNHEX(:revert_token),
+	confirmed_utc = :confirmed_utc,
+	reverted_utc = :reverted_utc
+WHERE change_id = :change_id
+LIMIT 1`
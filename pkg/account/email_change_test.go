@@ -0,0 +1,47 @@
+package account
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FTChinese/go-rest/chrono"
+	"github.com/FTChinese/subscription-api/internal/pkg/input"
+	"github.com/brianvoe/gofakeit/v5"
+	"github.com/google/uuid"
+)
+
+func TestEmailChange_Lifecycle(t *testing.T) {
+	a := BaseAccount{
+		FtcID:      uuid.New().String(),
+		Email:      gofakeit.Email(),
+		IsVerified: true,
+	}
+
+	c, err := NewEmailChange(a, input.EmailUpdateParams{Email: gofakeit.Email()}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !c.CanConfirm() {
+		t.Error("new change should be confirmable")
+	}
+	if c.ConfirmURL() != "" || !c.MatchCode(c.AppCode.String) {
+		t.Errorf("code-based change expected, got %+v", c)
+	}
+	if c.CanRevert() {
+		t.Error("pending change should not be reverted")
+	}
+
+	c, err = c.Confirmed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.CanConfirm() || !c.CanRevert() || !c.RevertToken.Valid {
+		t.Errorf("confirmed change expected, got %+v", c)
+	}
+
+	c.ConfirmedUTC = chrono.TimeFrom(time.Now().AddDate(0, 0, -8))
+	if c.CanRevert() {
+		t.Error("revert should expire")
+	}
+}
@@ -0,0 +1,118 @@
+package account
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"time"
+
+	gorest "github.com/FTChinese/go-rest"
+	"github.com/FTChinese/go-rest/chrono"
+	"github.com/FTChinese/subscription-api/internal/pkg/input"
+	"github.com/FTChinese/subscription-api/pkg/config"
+	"github.com/FTChinese/subscription-api/pkg/ids"
+	"github.com/guregu/null"
+)
+
+// Limits of magic link requests within an hour.
+const (
+	magicLinkPerEmail = 5
+	magicLinkPerIP    = 30
+	// maxMagicLinkAttempts limits guessing of the 6-digit code.
+	maxMagicLinkAttempts = 5
+)
+
+// MagicLinkSession holds the token or code sent to user's
+// email to log in without password.
+// Like PwResetSession, web apps get a clickable link built
+// from SourceURL and Token, while mobile apps get a short
+// AppCode which must be used together with email.
+// A session could only be used once.
+type MagicLinkSession struct {
+	Email      string      `json:"email" db:"email"`
+	FtcID      string      `json:"-" db:"ftc_id"`
+	SourceURL  null.String `json:"-" db:"source_url"`
+	Token      string      `json:"-" db:"token"`
+	AppCode    null.String `json:"-" db:"app_code"`
+	Attempts   int64       `json:"-" db:"attempts"`
+	IsUsed     bool        `json:"-" db:"is_used"`
+	ExpiresIn  int64       `json:"expiresIn" db:"expires_in"`
+	UserIP     null.String `json:"-" db:"user_ip"`
+	CreatedUTC chrono.Time `json:"createdUtc" db:"created_utc"`
+}
+
+// NewMagicLinkSession creates a session for the account owning the email.
+func NewMagicLinkSession(params input.MagicLinkParams, a BaseAccount, ip null.String) (MagicLinkSession, error) {
+	token, err := gorest.RandomHex(32)
+	if err != nil {
+		return MagicLinkSession{}, err
+	}
+
+	if params.SourceURL.IsZero() {
+		params.SourceURL = null.StringFrom(config.MagicLinkURL)
+	}
+
+	sess := MagicLinkSession{
+		Email:      a.Email,
+		FtcID:      a.FtcID,
+		SourceURL:  params.SourceURL,
+		Token:      token,
+		AppCode:    null.String{},
+		Attempts:   0,
+		IsUsed:     false,
+		ExpiresIn:  15 * 60,
+		UserIP:     ip,
+		CreatedUTC: chrono.TimeNow(),
+	}
+
+	if params.UseCode {
+		sess.SourceURL = null.String{}
+		sess.AppCode = null.StringFrom(ids.PwResetCode())
+		sess.ExpiresIn = 10 * 60
+	}
+
+	return sess, nil
+}
+
+// BuildURL creates the login link.
+// Returns empty string for code-based session.
+func (s MagicLinkSession) BuildURL() string {
+	if s.AppCode.Valid {
+		return ""
+	}
+
+	return fmt.Sprintf("%s/%s", s.SourceURL.String, s.Token)
+}
+
+// IsValid checks whether the session could still be used to log in.
+func (s MagicLinkSession) IsValid() bool {
+	if s.IsUsed || s.Attempts >= maxMagicLinkAttempts {
+		return false
+	}
+
+	return s.CreatedUTC.Add(time.Duration(s.ExpiresIn) * time.Second).After(time.Now())
+}
+
+// MatchCode compares code in constant time.
+func (s MagicLinkSession) MatchCode(code string) bool {
+	if !s.AppCode.Valid {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(s.AppCode.String), []byte(code)) == 1
+}
+
+// FormatDuration is used in the letter.
+func (s MagicLinkSession) FormatDuration() string {
+	return fmt.Sprintf("%d分钟", s.ExpiresIn/60)
+}
+
+// MagicLinkLimit counts how many sessions are requested
+// in the past hour by the same email and the same IP.
+type MagicLinkLimit struct {
+	ByEmail int64 `db:"by_email"`
+	ByIP    int64 `db:"by_ip"`
+}
+
+func (l MagicLinkLimit) Exceeds() bool {
+	return l.ByEmail >= magicLinkPerEmail || l.ByIP >= magicLinkPerIP
+}
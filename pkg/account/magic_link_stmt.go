@@ -0,0 +1,65 @@
+package account
+
+const StmtInsertMagicLink = `
+INSERT INTO user_db.magic_link
+SET email = :email,
+	ftc_id = :ftc_id,
+	source_url = :source_url,
+	token = UNHEX(:token),
+	app_code = :app_code,
+	attempts = :attempts,
+	is_used = :is_used,
+	expires_in = :expires_in,
+	user_ip = INET6_ATON(:user_ip),
+	created_utc = :created_utc`
+
+const selectMagicLink = `
+SELECT email,
+	ftc_id,
+	source_url,
+	LOWER(HEX(token)) AS token,
+	app_code,
+	attempts,
+	is_used,
+	expires_in,
+	INET6_NTOA(user_ip) AS user_ip,
+	created_utc
+FROM user_db.magic_link
+`
+
+// StmtMagicLinkByToken retrieves a session from a link clicked on web.
+const StmtMagicLinkByToken = selectMagicLink + `
+WHERE token = UNHEX(?)
+LIMIT 1`
+
+// StmtMagicLinkByEmail retrieves the latest code-based
+// session of an email. Only the latest one is used so that
+// attempts of guessing are counted against a single row.
+const StmtMagicLinkByEmail = selectMagicLink + `
+WHERE email = ?
+	AND app_code IS NOT NULL
+ORDER BY created_utc DESC
+LIMIT 1`
+
+const StmtIncMagicLinkAttempts = `
+UPDATE user_db.magic_link
+SET attempts = attempts + 1
+WHERE token = UNHEX(?)
+LIMIT 1`
+
+// StmtDisableMagicLink marks a session as used.
+// The is_used condition ensures only one request could
+// consume it when used concurrently.
+const StmtDisableMagicLink = `
+UPDATE user_db.magic_link
+SET is_used = 1
+WHERE token = UNHEX(?)
+	AND is_used = 0
+LIMIT 1`
+
+const StmtMagicLinkLimit = `
+SELECT
+	COALESCE(SUM(email = ?), 0) AS by_email,
+	COALESCE(SUM(user_ip = INET6_ATON(?)), 0) AS by_ip
+FROM user_db.magic_link
+WHERE created_utc > DATE_SUB(UTC_TIMESTAMP(), INTERVAL 1 HOUR)`
@@ -0,0 +1,74 @@
+package account
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FTChinese/go-rest/chrono"
+	"github.com/FTChinese/subscription-api/internal/pkg/input"
+	"github.com/brianvoe/gofakeit/v5"
+	"github.com/google/uuid"
+	"github.com/guregu/null"
+)
+
+func TestNewMagicLinkSession(t *testing.T) {
+	a := BaseAccount{
+		FtcID: uuid.New().String(),
+		Email: gofakeit.Email(),
+	}
+
+	web, err := NewMagicLinkSession(input.MagicLinkParams{Email: a.Email}, a, null.String{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if web.BuildURL() == "" || web.AppCode.Valid {
+		t.Errorf("web session should have a link only, got %+v", web)
+	}
+
+	app, err := NewMagicLinkSession(input.MagicLinkParams{Email: a.Email, UseCode: true}, a, null.String{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if app.BuildURL() != "" || !app.MatchCode(app.AppCode.String) {
+		t.Errorf("app session should have a code only, got %+v", app)
+	}
+	if app.MatchCode("000000x") {
+		t.Error("wrong code should not match")
+	}
+}
+
+func TestMagicLinkSession_IsValid(t *testing.T) {
+	tests := []struct {
+		name string
+		s    MagicLinkSession
+		want bool
+	}{
+		{
+			name: "Fresh",
+			s:    MagicLinkSession{ExpiresIn: 600, CreatedUTC: chrono.TimeNow()},
+			want: true,
+		},
+		{
+			name: "Expired",
+			s:    MagicLinkSession{ExpiresIn: 600, CreatedUTC: chrono.TimeFrom(time.Now().Add(-11 * time.Minute))},
+			want: false,
+		},
+		{
+			name: "Used",
+			s:    MagicLinkSession{ExpiresIn: 600, IsUsed: true, CreatedUTC: chrono.TimeNow()},
+			want: false,
+		},
+		{
+			name: "Too many attempts",
+			s:    MagicLinkSession{ExpiresIn: 600, Attempts: maxMagicLinkAttempts, CreatedUTC: chrono.TimeNow()},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.s.IsValid(); got != tt.want {
+				t.Errorf("IsValid() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
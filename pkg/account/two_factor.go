@@ -0,0 +1,229 @@
+package account
+
+import (
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	gorest "github.com/FTChinese/go-rest"
+	"github.com/FTChinese/go-rest/chrono"
+	"github.com/FTChinese/go-rest/rand"
+	"github.com/FTChinese/subscription-api/lib/totp"
+	"github.com/guregu/null"
+)
+
+const totpIssuer = "FT中文网"
+
+// TwoFactorMethod is the kind of second factor used to
+// pass a challenge.
+type TwoFactorMethod string
+
+const (
+	TwoFactorNull     TwoFactorMethod = ""
+	TwoFactorTOTP     TwoFactorMethod = "totp"
+	TwoFactorRecovery TwoFactorMethod = "recovery_code"
+)
+
+func (x *TwoFactorMethod) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*x = TwoFactorMethod(s)
+	case string:
+		*x = TwoFactorMethod(s)
+	default:
+		*x = TwoFactorNull
+	}
+
+	return nil
+}
+
+func (x TwoFactorMethod) Value() (driver.Value, error) {
+	if x == TwoFactorNull {
+		return nil, nil
+	}
+
+	return string(x), nil
+}
+
+// TwoFactor is the TOTP setting of an email account.
+// A row is created when user starts enrollment and only
+// takes effect after user confirmed a code generated
+// by the authenticator app.
+type TwoFactor struct {
+	FtcID        string      `json:"-" db:"ftc_id"`
+	Secret       string      `json:"-" db:"secret"`
+	Enabled      bool        `json:"enabled" db:"is_enabled"`
+	ConfirmedUTC chrono.Time `json:"confirmedUtc" db:"confirmed_utc"`
+	// Time step of the last code accepted. Codes at or below
+	// it are rejected so that a code could not be replayed.
+	LastStep   int64       `json:"-" db:"last_step"`
+	CreatedUTC chrono.Time `json:"createdUtc" db:"created_utc"`
+	UpdatedUTC chrono.Time `json:"-" db:"updated_utc"`
+}
+
+// NewTwoFactor starts enrollment with a new secret.
+func NewTwoFactor(ftcID string) (TwoFactor, error) {
+	secret, err := totp.NewSecret()
+	if err != nil {
+		return TwoFactor{}, err
+	}
+
+	return TwoFactor{
+		FtcID:      ftcID,
+		Secret:     secret,
+		Enabled:    false,
+		CreatedUTC: chrono.TimeNow(),
+		UpdatedUTC: chrono.TimeNow(),
+	}, nil
+}
+
+// Enrollment returns what is needed to set up an authenticator app.
+func (f TwoFactor) Enrollment(email string) TwoFactorEnrollment {
+	return TwoFactorEnrollment{
+		Secret: f.Secret,
+		URI:    totp.URI(totpIssuer, email, f.Secret),
+	}
+}
+
+// Verify checks a code generated by authenticator app.
+// The returned copy records the time step of the code, which
+// should be saved to consume it.
+func (f TwoFactor) Verify(code string) (TwoFactor, bool) {
+	step, ok := totp.Match(f.Secret, code, time.Now(), f.LastStep)
+	if !ok {
+		return f, false
+	}
+
+	f.LastStep = step
+	return f, true
+}
+
+// Confirmed turns on 2FA after user proved the secret
+// is saved in authenticator app.
+func (f TwoFactor) Confirmed() TwoFactor {
+	f.Enabled = true
+	f.ConfirmedUTC = chrono.TimeNow()
+	f.UpdatedUTC = chrono.TimeNow()
+
+	return f
+}
+
+// TwoFactorEnrollment is sent to client after enrollment started.
+// Client should render URI as a QR code, and show the secret
+// for manual input.
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// TwoFactorStatus tells client whether 2FA is turned on.
+type TwoFactorStatus struct {
+	Enabled           bool        `json:"enabled"`
+	ConfirmedUTC      chrono.Time `json:"confirmedUtc"`
+	RecoveryCodesLeft int64       `json:"recoveryCodesLeft"`
+}
+
+const recoveryCodeCount = 10
+
+// HashRecoveryCode hashes a recovery code before saving or
+// looking up. Dashes and case are ignored.
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	h := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(h[:])
+}
+
+// RecoveryCode is a one-time code used when user lost
+// access to the authenticator app.
+// Only the hash is saved.
+type RecoveryCode struct {
+	FtcID      string      `db:"ftc_id"`
+	CodeHash   string      `db:"code_hash"`
+	CreatedUTC chrono.Time `db:"created_utc"`
+}
+
+// NewRecoveryCodes generates a set of recovery codes.
+// Returns the plain codes shown to user only once,
+// and their hashed version to save.
+func NewRecoveryCodes(ftcID string) ([]string, []RecoveryCode) {
+	plain := make([]string, 0, recoveryCodeCount)
+	hashed := make([]RecoveryCode, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		c := rand.String(5) + "-" + rand.String(5)
+		plain = append(plain, c)
+		hashed = append(hashed, RecoveryCode{
+			FtcID:      ftcID,
+			CodeHash:   HashRecoveryCode(c),
+			CreatedUTC: chrono.TimeNow(),
+		})
+	}
+
+	return plain, hashed
+}
+
+// TwoFactorChallenge is issued after password is verified
+// for an account with 2FA enabled. Client exchanges the
+// token and a second factor for the account.
+type TwoFactorChallenge struct {
+	Token string `json:"token" db:"token"`
+	FtcID string `json:"-" db:"ftc_id"`
+	// AuthMethod is the first factor passed, e.g., email,
+	// email_link, mobile or apple, which is used to record
+	// the login after challenge answered.
+	AuthMethod  string      `json:"-" db:"auth_method"`
+	DeviceToken null.String `json:"-" db:"device_token"`
+	Attempts    int64       `json:"-" db:"attempts"`
+	IsUsed      bool        `json:"-" db:"is_used"`
+	ExpiresIn   int64       `json:"expiresIn" db:"expires_in"`
+	CreatedUTC  chrono.Time `json:"createdUtc" db:"created_utc"`
+}
+
+// maxChallengeAttempts limits guessing of the 6-digit code.
+const maxChallengeAttempts = 5
+
+func NewTwoFactorChallenge(ftcID string, authMethod string, deviceToken null.String) (TwoFactorChallenge, error) {
+	token, err := gorest.RandomHex(32)
+	if err != nil {
+		return TwoFactorChallenge{}, err
+	}
+
+	return TwoFactorChallenge{
+		Token:       token,
+		FtcID:       ftcID,
+		AuthMethod:  authMethod,
+		DeviceToken: deviceToken,
+		Attempts:    0,
+		IsUsed:      false,
+		ExpiresIn:   5 * 60,
+		CreatedUTC:  chrono.TimeNow(),
+	}, nil
+}
+
+// IsValid checks if the challenge could still be answered.
+func (c TwoFactorChallenge) IsValid() bool {
+	if c.IsUsed || c.Attempts >= maxChallengeAttempts {
+		return false
+	}
+
+	return c.CreatedUTC.Add(time.Duration(c.ExpiresIn) * time.Second).After(time.Now())
+}
+
+// TwoFactorRequired is returned by login endpoints
+// instead of account when 2FA is enabled.
+type TwoFactorRequired struct {
+	Challenge TwoFactorChallenge `json:"challenge"`
+	Methods   []TwoFactorMethod  `json:"methods"`
+}
+
+func NewTwoFactorRequired(c TwoFactorChallenge) TwoFactorRequired {
+	return TwoFactorRequired{
+		Challenge: c,
+		Methods: []TwoFactorMethod{
+			TwoFactorTOTP,
+			TwoFactorRecovery,
+		},
+	}
+}
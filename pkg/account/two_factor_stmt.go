@@ -0,0 +1,117 @@
+package account
+
+// StmtUpsertTwoFactor starts or restarts enrollment.
+// An enabled setting should never be overridden by this.
+const StmtUpsertTwoFactor = `
+INSERT INTO user_db.two_factor
+SET ftc_id = :ftc_id,
+	secret = :secret,
+	is_enabled = :is_enabled,
+	created_utc = :created_utc,
+	updated_utc = :updated_utc
+ON DUPLICATE KEY UPDATE
+	secret = :secret,
+	is_enabled = :is_enabled,
+	confirmed_utc = NULL,
+	updated_utc = :updated_utc`
+
+const StmtRetrieveTwoFactor = `
+SELECT ftc_id,
+	secret,
+	is_enabled,
+	confirmed_utc,
+	last_step,
+	created_utc,
+	updated_utc
+FROM user_db.two_factor
+WHERE ftc_id = ?
+LIMIT 1`
+
+const StmtEnableTwoFactor = `
+UPDATE user_db.two_factor
+SET is_enabled = :is_enabled,
+	confirmed_utc = :confirmed_utc,
+	updated_utc = :updated_utc
+WHERE ftc_id = :ftc_id
+LIMIT 1`
+
+// StmtUseTOTPStep consumes the time step of a code.
+// Check affected rows since a concurrent request might have
+// used the same or a later step.
+const StmtUseTOTPStep = `
+UPDATE user_db.two_factor
+SET last_step = :last_step
+WHERE ftc_id = :ftc_id
+	AND last_step < :last_step
+LIMIT 1`
+
+const StmtDeleteTwoFactor = `
+DELETE FROM user_db.two_factor
+WHERE ftc_id = ?
+LIMIT 1`
+
+const StmtDeleteRecoveryCodes = `
+DELETE FROM user_db.two_factor_recovery
+WHERE ftc_id = ?`
+
+const StmtInsertRecoveryCode = `
+INSERT INTO user_db.two_factor_recovery
+SET ftc_id = :ftc_id,
+	code_hash = UNHEX(:code_hash),
+	created_utc = :created_utc`
+
+// StmtUseRecoveryCode consumes a recovery code.
+// Check affected rows to know whether the code is valid.
+const StmtUseRecoveryCode = `
+UPDATE user_db.two_factor_recovery
+SET used_utc = UTC_TIMESTAMP()
+WHERE ftc_id = ?
+	AND code_hash = UNHEX(?)
+	AND used_utc IS NULL
+LIMIT 1`
+
+const StmtCountRecoveryCodes = `
+SELECT COUNT(*)
+FROM user_db.two_factor_recovery
+WHERE ftc_id = ?
+	AND used_utc IS NULL`
+
+const StmtInsertTwoFactorChallenge = `
+INSERT INTO user_db.two_factor_challenge
+SET token = UNHEX(:token),
+	ftc_id = :ftc_id,
+	auth_method = :auth_method,
+	device_token = :device_token,
+	attempts = :attempts,
+	is_used = :is_used,
+	expires_in = :expires_in,
+	created_utc = :created_utc`
+
+const StmtRetrieveTwoFactorChallenge = `
+SELECT LOWER(HEX(token)) AS token,
+	ftc_id,
+	IFNULL(auth_method, 'email') AS auth_method,
+	device_token,
+	attempts,
+	is_used,
+	expires_in,
+	created_utc
+FROM user_db.two_factor_challenge
+WHERE token = UNHEX(?)
+LIMIT 1`
+
+const StmtIncChallengeAttempts = `
+UPDATE user_db.two_factor_challenge
+SET attempts = attempts + 1
+WHERE token = UNHEX(?)
+LIMIT 1`
+
+// StmtDisableTwoFactorChallenge consumes a challenge.
+// Check affected rows so that a challenge could only be
+// answered once.
+const StmtDisableTwoFactorChallenge = `
+UPDATE user_db.two_factor_challenge
+SET is_used = 1
+WHERE token = UNHEX(?)
+	AND is_used = 0
+LIMIT 1`
@@ -0,0 +1,67 @@
+package account
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FTChinese/go-rest/chrono"
+	"github.com/FTChinese/subscription-api/lib/totp"
+	"github.com/guregu/null"
+)
+
+func TestTwoFactor_Verify(t *testing.T) {
+	f, err := NewTwoFactor("abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, _ := totp.Code(f.Secret, time.Now())
+
+	used, ok := f.Verify(code)
+	if !ok {
+		t.Error("code should be valid")
+	}
+
+	if _, ok := used.Verify(code); ok {
+		t.Error("code already used should be rejected")
+	}
+
+	if _, ok := f.Verify("000000"); ok && code != "000000" {
+		t.Error("wrong code should be rejected")
+	}
+
+	t.Logf("%s", f.Enrollment("test@example.org").URI)
+}
+
+func TestNewRecoveryCodes(t *testing.T) {
+	plain, hashed := NewRecoveryCodes("abc")
+
+	if len(plain) != recoveryCodeCount {
+		t.Errorf("got %d codes, want %d", len(plain), recoveryCodeCount)
+	}
+
+	for i, c := range plain {
+		if HashRecoveryCode(c) != hashed[i].CodeHash {
+			t.Errorf("hash mismatch for %s", c)
+		}
+	}
+}
+
+func TestTwoFactorChallenge_IsValid(t *testing.T) {
+	c, _ := NewTwoFactorChallenge("abc", "email", null.String{})
+
+	if !c.IsValid() {
+		t.Error("new challenge should be valid")
+	}
+
+	c.Attempts = maxChallengeAttempts
+	if c.IsValid() {
+		t.Error("challenge should be invalid after too many attempts")
+	}
+
+	c.Attempts = 0
+	c.CreatedUTC = chrono.TimeFrom(time.Now().Add(-time.Hour))
+	if c.IsValid() {
+		t.Error("challenge should be expired")
+	}
+}
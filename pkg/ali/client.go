@@ -2,6 +2,8 @@ package ali
 
 import (
 	"fmt"
+	"github.com/FTChinese/subscription-api/pkg/metrics"
+	"github.com/FTChinese/subscription-api/pkg/tracing"
 	"github.com/smartwalle/alipay"
 	"go.uber.org/zap"
 	"net/http"
@@ -14,9 +16,14 @@ type PayClient struct {
 }
 
 func NewPayClient(app App, logger *zap.Logger) PayClient {
+	sdk := alipay.New(app.ID, app.PublicKey, app.PrivateKey, true)
+	sdk.Client = &http.Client{
+		Transport: tracing.Transport(metrics.Transport("alipay", nil)),
+	}
+
 	return PayClient{
 		app:    app,
-		sdk:    alipay.New(app.ID, app.PublicKey, app.PrivateKey, true),
+		sdk:    sdk,
 		logger: logger,
 	}
 }
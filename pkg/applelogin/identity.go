@@ -0,0 +1,125 @@
+package applelogin
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/FTChinese/subscription-api/pkg/config"
+)
+
+var (
+	ErrMalformedToken = errors.New("malformed apple identity token")
+	ErrInvalidToken   = errors.New("apple identity token is invalid or expired")
+)
+
+// flexBool decodes Apple's boolean claims which might be
+// sent either as JSON boolean or string "true"/"false".
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	*b = s == "true"
+	return nil
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// IdentityClaims is the payload of an identity token.
+// Email is present on every login, while user's name
+// is only sent to client on the first authorization.
+type IdentityClaims struct {
+	Issuer         string   `json:"iss"`
+	Subject        string   `json:"sub"`
+	Audience       string   `json:"aud"`
+	IssuedAt       int64    `json:"iat"`
+	ExpiresAt      int64    `json:"exp"`
+	Email          string   `json:"email"`
+	EmailVerified  flexBool `json:"email_verified"`
+	IsPrivateEmail flexBool `json:"is_private_email"`
+}
+
+// Verifier checks identity tokens against Apple's public keys.
+type Verifier struct {
+	keys      *KeyStore
+	clientIDs []string
+}
+
+func NewVerifier(c config.AppleSignIn) Verifier {
+	return Verifier{
+		keys:      NewKeyStore(c.JWKSURL, 24*time.Hour),
+		clientIDs: c.ClientIDs,
+	}
+}
+
+func (v Verifier) acceptAudience(aud string) bool {
+	for _, id := range v.clientIDs {
+		if id == aud {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Verify checks the RS256 signature, issuer, audience and expiration.
+func (v Verifier) Verify(token string) (IdentityClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return IdentityClaims{}, ErrMalformedToken
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return IdentityClaims{}, ErrMalformedToken
+	}
+	if h.Alg != "RS256" {
+		return IdentityClaims{}, ErrInvalidToken
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return IdentityClaims{}, ErrMalformedToken
+	}
+
+	key, err := v.keys.Key(h.Kid)
+	if err != nil {
+		return IdentityClaims{}, err
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+		return IdentityClaims{}, ErrInvalidToken
+	}
+
+	var c IdentityClaims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return IdentityClaims{}, ErrMalformedToken
+	}
+
+	if c.Issuer != config.AppleIssuer ||
+		!v.acceptAudience(c.Audience) ||
+		c.Subject == "" ||
+		time.Unix(c.ExpiresAt, 0).Before(time.Now()) {
+		return IdentityClaims{}, ErrInvalidToken
+	}
+
+	return c, nil
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,148 @@
+package applelogin
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/FTChinese/subscription-api/pkg/config"
+)
+
+func signToken(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	h, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": kid})
+	c, _ := json.Marshal(claims)
+
+	signing := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+	digest := sha256.Sum256([]byte(signing))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signing + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(JWKSet{Keys: []JWK{
+			{
+				Kty: "RSA",
+				Kid: "test-kid",
+				Use: "sig",
+				Alg: "RS256",
+				N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			},
+		}})
+	}))
+	defer srv.Close()
+
+	v := NewVerifier(config.AppleSignIn{
+		JWKSURL:   srv.URL,
+		ClientIDs: []string{"com.ft.ftchinese.mobile"},
+	})
+
+	valid := map[string]interface{}{
+		"iss":              config.AppleIssuer,
+		"aud":              "com.ft.ftchinese.mobile",
+		"sub":              "001234.abcdef.0123",
+		"iat":              time.Now().Unix(),
+		"exp":              time.Now().Add(10 * time.Minute).Unix(),
+		"email":            "abc@privaterelay.appleid.com",
+		"email_verified":   "true",
+		"is_private_email": true,
+	}
+
+	c, err := v.Verify(signToken(t, key, "test-kid", valid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Subject != "001234.abcdef.0123" || !bool(c.IsPrivateEmail) || !bool(c.EmailVerified) {
+		t.Errorf("unexpected claims %+v", c)
+	}
+
+	wrongAud := map[string]interface{}{}
+	for k, val := range valid {
+		wrongAud[k] = val
+	}
+	wrongAud["aud"] = "com.example"
+	if _, err := v.Verify(signToken(t, key, "test-kid", wrongAud)); err != ErrInvalidToken {
+		t.Errorf("expected invalid audience rejected, got %v", err)
+	}
+
+	expired := map[string]interface{}{}
+	for k, val := range valid {
+		expired[k] = val
+	}
+	expired["exp"] = time.Now().Add(-time.Minute).Unix()
+	if _, err := v.Verify(signToken(t, key, "test-kid", expired)); err != ErrInvalidToken {
+		t.Errorf("expected expired token rejected, got %v", err)
+	}
+
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	if _, err := v.Verify(signToken(t, other, "test-kid", valid)); err != ErrInvalidToken {
+		t.Errorf("expected bad signature rejected, got %v", err)
+	}
+}
+
+func TestKeyStore_Key_backoff(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var hits int
+	down := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(JWKSet{Keys: []JWK{
+			{
+				Kty: "RSA",
+				Kid: "test-kid",
+				N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			},
+		}})
+	}))
+	defer srv.Close()
+
+	s := NewKeyStore(srv.URL, time.Hour)
+	if _, err := s.Key("test-kid"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Keys turn stale and Apple goes down.
+	s.fetchedAt = time.Now().Add(-2 * time.Hour)
+	down = true
+
+	for i := 0; i < 3; i++ {
+		if _, err := s.Key("test-kid"); err != nil {
+			t.Errorf("expected stale key served, got %v", err)
+		}
+	}
+
+	if _, err := s.Key("unknown-kid"); err == nil {
+		t.Error("expected unknown kid rejected")
+	}
+
+	if hits != 2 {
+		t.Errorf("expected no fetch within backoff, got %d hits", hits)
+	}
+}
@@ -0,0 +1,142 @@
+package applelogin
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/FTChinese/subscription-api/lib/fetch"
+)
+
+// minRefreshInterval prevents an unknown kid, or Apple
+// being unreachable, from triggering a fetch on every request.
+const minRefreshInterval = time.Minute
+
+// JWK is a single RSA key in Apple's key set.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+func (k JWK) PublicKey() (*rsa.PublicKey, error) {
+	if k.Kty != "RSA" {
+		return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// KeyStore fetches Apple's public keys and caches them for ttl.
+// Keys are fetched again when stale or a kid is not found,
+// since Apple rotates keys without notice.
+// A failed fetch is not retried within minRefreshInterval,
+// while stale keys are still served meanwhile.
+type KeyStore struct {
+	url       string
+	ttl       time.Duration
+	mu        sync.RWMutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+	failedAt  time.Time
+}
+
+func NewKeyStore(url string, ttl time.Duration) *KeyStore {
+	return &KeyStore{
+		url:  url,
+		ttl:  ttl,
+		keys: map[string]*rsa.PublicKey{},
+	}
+}
+
+// Key finds the public key by kid.
+func (s *KeyStore) Key(kid string) (*rsa.PublicKey, error) {
+	s.mu.RLock()
+	k, ok := s.keys[kid]
+	age := time.Since(s.fetchedAt)
+	sinceFailed := time.Since(s.failedAt)
+	s.mu.RUnlock()
+
+	if ok && age < s.ttl {
+		return k, nil
+	}
+
+	if age >= minRefreshInterval && sinceFailed >= minRefreshInterval {
+		if err := s.refresh(); err != nil {
+			s.mu.Lock()
+			s.failedAt = time.Now()
+			s.mu.Unlock()
+
+			// Stale key is still usable if Apple is not reachable.
+			if ok {
+				return k, nil
+			}
+			return nil, err
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	k, ok = s.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("apple public key %s not found", kid)
+	}
+
+	return k, nil
+}
+
+func (s *KeyStore) refresh() error {
+	resp, errs := fetch.New().Get(s.url).EndBlob()
+	if errs != nil {
+		return errs[0]
+	}
+
+	if resp.StatusCode != 200 {
+		return errors.New("fetching apple public keys failed: " + resp.Status)
+	}
+
+	var set JWKSet
+	if err := json.Unmarshal(resp.Body, &set); err != nil {
+		return err
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		k, err := jwk.PublicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = k
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.fetchedAt = time.Now()
+	s.mu.Unlock()
+
+	return nil
+}
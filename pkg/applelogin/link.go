@@ -0,0 +1,37 @@
+package applelogin
+
+import (
+	"github.com/FTChinese/go-rest/chrono"
+)
+
+// Link maps an Apple user to an FTC account.
+// Email is what Apple provided when linked, which might
+// be a private relay address forwarding to user's real inbox.
+type Link struct {
+	Subject        string      `json:"-" db:"apple_sub"`
+	FtcID          string      `json:"ftcId" db:"ftc_id"`
+	Email          string      `json:"email" db:"email"`
+	IsPrivateEmail bool        `json:"isPrivateEmail" db:"is_private_email"`
+	CreatedUTC     chrono.Time `json:"createdUtc" db:"created_utc"`
+	UpdatedUTC     chrono.Time `json:"updatedUtc" db:"updated_utc"`
+}
+
+func NewLink(ftcID string, c IdentityClaims) Link {
+	return Link{
+		Subject:        c.Subject,
+		FtcID:          ftcID,
+		Email:          c.Email,
+		IsPrivateEmail: bool(c.IsPrivateEmail),
+		CreatedUTC:     chrono.TimeNow(),
+		UpdatedUTC:     chrono.TimeNow(),
+	}
+}
+
+// IsRelayLogin tests whether the email is the private relay
+// address which stops forwarding once user revokes the
+// authorization in Apple ID settings.
+// Such account could not be unlinked since user would have
+// no way to log in.
+func (l Link) IsRelayLogin(email string) bool {
+	return l.IsPrivateEmail && l.Email == email
+}
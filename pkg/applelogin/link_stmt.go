@@ -0,0 +1,33 @@
+package applelogin
+
+const StmtInsertLink = `
+INSERT INTO user_db.apple_signin
+SET apple_sub = :apple_sub,
+	ftc_id = :ftc_id,
+	email = :email,
+	is_private_email = :is_private_email,
+	created_utc = :created_utc,
+	updated_utc = :updated_utc`
+
+const colsLink = `
+SELECT apple_sub,
+	ftc_id,
+	email,
+	is_private_email,
+	created_utc,
+	updated_utc
+FROM user_db.apple_signin
+`
+
+const StmtLinkBySub = colsLink + `
+WHERE apple_sub = ?
+LIMIT 1`
+
+const StmtLinkByFtcID = colsLink + `
+WHERE ftc_id = ?
+LIMIT 1`
+
+const StmtDeleteLink = `
+DELETE FROM user_db.apple_signin
+WHERE ftc_id = ?
+LIMIT 1`
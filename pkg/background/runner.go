@@ -0,0 +1,92 @@
+// Package background runs work that should outlive the
+// request which started it, such as sending emails or saving
+// membership history, and lets the process wait for it
+// before exiting.
+package background
+
+import (
+	"context"
+	"runtime/debug"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// Runner tracks goroutines started by Go so that Wait could
+// block until all of them finished.
+// A nil *Runner starts untracked goroutines, which is
+// convenient in tests.
+type Runner struct {
+	logger *zap.Logger
+	mu     sync.Mutex
+	wg     sync.WaitGroup
+	closed bool
+}
+
+func NewRunner(logger *zap.Logger) *Runner {
+	return &Runner{
+		logger: logger,
+	}
+}
+
+// Go runs fn in a new goroutine. A panic in fn is logged
+// rather than crashing the process.
+// After Wait is called, fn is run synchronously in the
+// caller's goroutine so that late work is not lost.
+func (r *Runner) Go(fn func()) {
+	if r == nil {
+		go fn()
+		return
+	}
+
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		r.run(fn)
+		return
+	}
+	r.wg.Add(1)
+	r.mu.Unlock()
+
+	go func() {
+		defer r.wg.Done()
+		r.run(fn)
+	}()
+}
+
+func (r *Runner) run(fn func()) {
+	defer func() {
+		if v := recover(); v != nil {
+			r.logger.Error("Background task panicked",
+				zap.Any("panic", v),
+				zap.ByteString("stack", debug.Stack()))
+		}
+	}()
+
+	fn()
+}
+
+// Wait stops accepting new goroutines and blocks until
+// running ones finished or ctx is done.
+func (r *Runner) Wait(ctx context.Context) error {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	r.closed = true
+	r.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,70 @@
+package background
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestRunner_Wait(t *testing.T) {
+	r := NewRunner(zap.NewNop())
+
+	var n int32
+	for i := 0; i < 10; i++ {
+		r.Go(func() {
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&n, 1)
+		})
+	}
+	r.Go(func() {
+		panic("recovered")
+	})
+
+	if err := r.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := atomic.LoadInt32(&n); got != 10 {
+		t.Errorf("%d tasks finished before Wait returned", got)
+	}
+
+	// Run synchronously after closed.
+	r.Go(func() {
+		atomic.AddInt32(&n, 1)
+	})
+	if got := atomic.LoadInt32(&n); got != 11 {
+		t.Errorf("late task not run")
+	}
+}
+
+func TestRunner_WaitTimeout(t *testing.T) {
+	r := NewRunner(zap.NewNop())
+	release := make(chan struct{})
+	defer close(release)
+
+	r.Go(func() {
+		<-release
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := r.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestRunner_nil(t *testing.T) {
+	var r *Runner
+	done := make(chan struct{})
+	r.Go(func() {
+		close(done)
+	})
+	<-done
+
+	if err := r.Wait(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
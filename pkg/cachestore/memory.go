@@ -0,0 +1,84 @@
+package cachestore
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+)
+
+// Memory caches in current process. Values are kept as
+// JSON so that callers get a copy, same as Redis.
+type Memory struct {
+	cache   *cache.Cache
+	ttls    TTLs
+	metrics *Metrics
+}
+
+func NewMemory(ttls TTLs, m *Metrics) Memory {
+	return Memory{
+		cache:   cache.New(ttls.Default, 10*time.Minute),
+		ttls:    ttls,
+		metrics: m,
+	}
+}
+
+func (s Memory) Get(_ context.Context, key string, v interface{}) error {
+	found, err := s.get(key, v)
+	switch {
+	case err != nil:
+		s.metrics.Inc(key, OutcomeError)
+		return err
+	case !found:
+		s.metrics.Inc(key, OutcomeMiss)
+		return ErrMiss
+	}
+
+	s.metrics.Inc(key, OutcomeHitLocal)
+	return nil
+}
+
+func (s Memory) Set(_ context.Context, key string, v interface{}) error {
+	if err := s.set(key, v, s.ttls.Of(key)); err != nil {
+		return err
+	}
+
+	s.metrics.Inc(key, OutcomeSet)
+	return nil
+}
+
+func (s Memory) Delete(_ context.Context, keys ...string) error {
+	for _, k := range keys {
+		s.cache.Delete(k)
+		s.metrics.Inc(k, OutcomeDelete)
+	}
+
+	return nil
+}
+
+// get and set do not count metrics so that Tiered could
+// count once for both layers.
+func (s Memory) get(key string, v interface{}) (bool, error) {
+	x, ok := s.cache.Get(key)
+	if !ok {
+		return false, nil
+	}
+
+	b, ok := x.([]byte)
+	if !ok {
+		return false, nil
+	}
+
+	return true, json.Unmarshal(b, v)
+}
+
+func (s Memory) set(key string, v interface{}, ttl time.Duration) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	s.cache.Set(key, b, ttl)
+	return nil
+}
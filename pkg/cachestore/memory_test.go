@@ -0,0 +1,65 @@
+package cachestore
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTTLs_Of(t *testing.T) {
+	ttls := TTLs{
+		Default: time.Hour,
+		ByNamespace: map[string]time.Duration{
+			"token": time.Minute,
+		},
+	}
+
+	if d := ttls.Of("token:abc"); d != time.Minute {
+		t.Errorf("got %s", d)
+	}
+
+	if d := ttls.Of("paywall:live"); d != time.Hour {
+		t.Errorf("got %s", d)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	type item struct {
+		Name string `json:"name"`
+	}
+
+	m := NewMetrics()
+	s := NewMemory(TTLs{Default: time.Minute}, m)
+	ctx := context.Background()
+
+	var got item
+	if err := s.Get(ctx, "paywall:live", &got); err != ErrMiss {
+		t.Fatalf("expected miss, got %v", err)
+	}
+
+	_ = s.Set(ctx, "paywall:live", item{Name: "a"})
+
+	if err := s.Get(ctx, "paywall:live", &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "a" {
+		t.Errorf("got %v", got)
+	}
+
+	_ = s.Delete(ctx, "paywall:live")
+	if err := s.Get(ctx, "paywall:live", &got); err != ErrMiss {
+		t.Errorf("expected miss after delete, got %v", err)
+	}
+
+	counts := map[Outcome]int64{}
+	for _, c := range m.Snapshot() {
+		if c.Namespace != "paywall" {
+			t.Errorf("unexpected namespace %s", c.Namespace)
+		}
+		counts[c.Outcome] = c.Value
+	}
+
+	if counts[OutcomeMiss] != 2 || counts[OutcomeHitLocal] != 1 || counts[OutcomeSet] != 1 || counts[OutcomeDelete] != 1 {
+		t.Errorf("got %v", counts)
+	}
+}
@@ -0,0 +1,76 @@
+package cachestore
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+type Outcome string
+
+const (
+	OutcomeHitLocal  Outcome = "hit_local"
+	OutcomeHitRemote Outcome = "hit_remote"
+	OutcomeMiss      Outcome = "miss"
+	OutcomeSet       Outcome = "set"
+	OutcomeDelete    Outcome = "delete"
+	OutcomeError     Outcome = "error"
+)
+
+// Metrics counts cache operations per namespace.
+// A nil *Metrics counts nothing.
+type Metrics struct {
+	counters sync.Map // "namespace outcome" -> *int64
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{}
+}
+
+func (m *Metrics) Inc(key string, o Outcome) {
+	if m == nil {
+		return
+	}
+
+	id := Namespace(key) + " " + string(o)
+	c, _ := m.counters.LoadOrStore(id, new(int64))
+	atomic.AddInt64(c.(*int64), 1)
+}
+
+type Count struct {
+	Namespace string  `json:"namespace"`
+	Outcome   Outcome `json:"outcome"`
+	Value     int64   `json:"value"`
+}
+
+// Snapshot lists current counts sorted by namespace and outcome.
+func (m *Metrics) Snapshot() []Count {
+	if m == nil {
+		return nil
+	}
+
+	var list []Count
+	m.counters.Range(func(k, v interface{}) bool {
+		id := k.(string)
+		for i := range id {
+			if id[i] == ' ' {
+				list = append(list, Count{
+					Namespace: id[:i],
+					Outcome:   Outcome(id[i+1:]),
+					Value:     atomic.LoadInt64(v.(*int64)),
+				})
+				break
+			}
+		}
+		return true
+	})
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Namespace != list[j].Namespace {
+			return list[i].Namespace < list[j].Namespace
+		}
+		return list[i].Outcome < list[j].Outcome
+	})
+
+	return list
+}
@@ -0,0 +1,75 @@
+package cachestore
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const redisPrefix = "cache:"
+
+// Redis caches in Redis and is shared by all instances.
+type Redis struct {
+	rdb     *redis.Client
+	ttls    TTLs
+	metrics *Metrics
+}
+
+func NewRedis(rdb *redis.Client, ttls TTLs, m *Metrics) Redis {
+	return Redis{
+		rdb:     rdb,
+		ttls:    ttls,
+		metrics: m,
+	}
+}
+
+func (s Redis) Get(ctx context.Context, key string, v interface{}) error {
+	b, err := s.rdb.Get(ctx, redisPrefix+key).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			s.metrics.Inc(key, OutcomeMiss)
+			return ErrMiss
+		}
+		s.metrics.Inc(key, OutcomeError)
+		return err
+	}
+
+	if err := json.Unmarshal(b, v); err != nil {
+		s.metrics.Inc(key, OutcomeError)
+		return err
+	}
+
+	s.metrics.Inc(key, OutcomeHitRemote)
+	return nil
+}
+
+func (s Redis) Set(ctx context.Context, key string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	err = s.rdb.Set(ctx, redisPrefix+key, b, s.ttls.Of(key)).Err()
+	if err != nil {
+		s.metrics.Inc(key, OutcomeError)
+		return err
+	}
+
+	s.metrics.Inc(key, OutcomeSet)
+	return nil
+}
+
+func (s Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	prefixed := make([]string, len(keys))
+	for i, k := range keys {
+		prefixed[i] = redisPrefix + k
+		s.metrics.Inc(k, OutcomeDelete)
+	}
+
+	return s.rdb.Del(ctx, prefixed...).Err()
+}
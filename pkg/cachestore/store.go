@@ -0,0 +1,49 @@
+// Package cachestore provides a key-value cache shared by
+// all instances of the API.
+//
+// Keys are namespaced by the part before the first colon,
+// e.g., `paywall:live`. TTL and metrics are kept per namespace.
+package cachestore
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrMiss is returned by Get if key is not found or expired.
+var ErrMiss = errors.New("cache miss")
+
+// Store caches values serializable to JSON.
+type Store interface {
+	// Get decodes the cached value into v, which must be
+	// a pointer.
+	Get(ctx context.Context, key string, v interface{}) error
+	Set(ctx context.Context, key string, v interface{}) error
+	// Delete removes keys from this instance and all others.
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// Namespace of a key.
+func Namespace(key string) string {
+	if i := strings.IndexByte(key, ':'); i > 0 {
+		return key[:i]
+	}
+
+	return key
+}
+
+// TTLs decides how long a key lives by its namespace.
+type TTLs struct {
+	Default     time.Duration
+	ByNamespace map[string]time.Duration
+}
+
+func (t TTLs) Of(key string) time.Duration {
+	if d, ok := t.ByNamespace[Namespace(key)]; ok {
+		return d
+	}
+
+	return t.Default
+}
@@ -0,0 +1,112 @@
+package cachestore
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// Channel on which deleted keys are published.
+const invalidationChannel = "cache:invalidate"
+
+// A lost pub/sub message keeps local copy stale for at most
+// this long.
+const maxLocalTTL = 5 * time.Minute
+
+// Tiered keeps a short-lived local copy in front of Redis.
+// Sets and deletes are published over Redis pub/sub so that every
+// instance drops its local copy.
+type Tiered struct {
+	local  Memory
+	remote Redis
+	rdb    *redis.Client
+	logger *zap.Logger
+}
+
+func NewTiered(rdb *redis.Client, ttls TTLs, m *Metrics, logger *zap.Logger) Tiered {
+	return Tiered{
+		local:  NewMemory(ttls, m),
+		remote: NewRedis(rdb, ttls, m),
+		rdb:    rdb,
+		logger: logger,
+	}
+}
+
+func (s Tiered) localTTL(key string) time.Duration {
+	ttl := s.local.ttls.Of(key)
+	if ttl <= 0 || ttl > maxLocalTTL {
+		return maxLocalTTL
+	}
+	return ttl
+}
+
+// Get looks up local copy first, then Redis.
+// If Redis is down, the error is returned and callers should
+// fall back to the source.
+func (s Tiered) Get(ctx context.Context, key string, v interface{}) error {
+	if found, err := s.local.get(key, v); found && err == nil {
+		s.local.metrics.Inc(key, OutcomeHitLocal)
+		return nil
+	}
+
+	if err := s.remote.Get(ctx, key, v); err != nil {
+		return err
+	}
+
+	return s.local.set(key, v, s.localTTL(key))
+}
+
+func (s Tiered) Set(ctx context.Context, key string, v interface{}) error {
+	if err := s.local.set(key, v, s.localTTL(key)); err != nil {
+		return err
+	}
+
+	if err := s.remote.Set(ctx, key, v); err != nil {
+		return err
+	}
+
+	// Other instances might hold an older copy.
+	return s.publish(ctx, key)
+}
+
+func (s Tiered) Delete(ctx context.Context, keys ...string) error {
+	for _, k := range keys {
+		s.local.cache.Delete(k)
+	}
+
+	if err := s.remote.Delete(ctx, keys...); err != nil {
+		return err
+	}
+
+	return s.publish(ctx, keys...)
+}
+
+func (s Tiered) publish(ctx context.Context, keys ...string) error {
+	return s.rdb.Publish(ctx, invalidationChannel, strings.Join(keys, " ")).Err()
+}
+
+// Listen drops local copies deleted by other instances
+// until ctx is cancelled.
+func (s Tiered) Listen(ctx context.Context) {
+	sub := s.rdb.Subscribe(ctx, invalidationChannel)
+	defer sub.Close()
+
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				s.logger.Warn("Cache invalidation channel closed")
+				return
+			}
+			for _, k := range strings.Fields(msg.Payload) {
+				s.local.cache.Delete(k)
+			}
+		}
+	}
+}
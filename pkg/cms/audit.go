@@ -0,0 +1,245 @@
+package cms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/FTChinese/go-rest/chrono"
+	"github.com/FTChinese/subscription-api/pkg/conv"
+	"github.com/guregu/null"
+)
+
+// AuditEntry records a CMS operation that modified data.
+// Entries are never updated or deleted.
+type AuditEntry struct {
+	ID          int64           `json:"id" db:"id"`
+	StaffName   string          `json:"staffName" db:"staff_name"`
+	Role        Role            `json:"role" db:"staff_role"`
+	Action      string          `json:"action" db:"action"` // Method and route pattern, e.g., POST /cms/memberships
+	TargetIDs   conv.StringList `json:"targetIds" db:"target_ids"`
+	RequestBody conv.RawJSON    `json:"requestBody" db:"request_body"`
+	Before      conv.RawJSON    `json:"before" db:"before_state"`
+	After       conv.RawJSON    `json:"after" db:"after_state"`
+	StatusCode  int             `json:"statusCode" db:"status_code"`
+	UserIP      null.String     `json:"userIp" db:"user_ip"`
+	CreatedUTC  chrono.Time     `json:"createdUtc" db:"created_utc"`
+}
+
+// AuditTrail is filled by handlers with data only they know:
+// the ids of the affected rows and the state before and
+// after the change.
+type AuditTrail struct {
+	Staff     StaffRole // Set by permission check.
+	TargetIDs []string
+	Before    interface{}
+	After     interface{}
+}
+
+func (t *AuditTrail) Target(ids ...string) *AuditTrail {
+	for _, id := range ids {
+		if id != "" {
+			t.TargetIDs = append(t.TargetIDs, id)
+		}
+	}
+	return t
+}
+
+func (t *AuditTrail) Change(before, after interface{}) *AuditTrail {
+	t.Before = before
+	t.After = after
+	return t
+}
+
+// Entry builds the log after a request finished.
+// Request body should be a JSON document; otherwise it is
+// dropped. Secrets are masked in body and states.
+func (t *AuditTrail) Entry(action string, body []byte, status int, ip string) AuditEntry {
+	before, _ := conv.RawJSONFrom(t.Before)
+	after, _ := conv.RawJSONFrom(t.After)
+
+	var reqBody conv.RawJSON
+	if isJSON(body) {
+		reqBody = redact(body)
+	}
+
+	return AuditEntry{
+		StaffName:   t.Staff.StaffName,
+		Role:        t.Staff.Role,
+		Action:      action,
+		TargetIDs:   t.TargetIDs,
+		RequestBody: reqBody,
+		Before:      redact(before),
+		After:       redact(after),
+		StatusCode:  status,
+		UserIP:      null.NewString(ip, ip != ""),
+		CreatedUTC:  chrono.TimeNow(),
+	}
+}
+
+type ctxKey int
+
+const keyAuditTrail ctxKey = iota
+
+func WithAuditTrail(ctx context.Context) (context.Context, *AuditTrail) {
+	t := &AuditTrail{}
+	return context.WithValue(ctx, keyAuditTrail, t), t
+}
+
+// AuditTrailFrom returns the trail attached to a request.
+// It never returns nil so that handlers not audited could call
+// it safely.
+func AuditTrailFrom(ctx context.Context) *AuditTrail {
+	if t, ok := ctx.Value(keyAuditTrail).(*AuditTrail); ok {
+		return t
+	}
+
+	return &AuditTrail{}
+}
+
+// AuditFilter narrows down audit log query.
+type AuditFilter struct {
+	StaffName string
+	Action    string // Prefix match.
+	TargetID  string
+	Since     time.Time
+	Until     time.Time
+}
+
+// NewAuditFilter parses query parameters:
+// ?staff=<name>&action=<prefix>&target_id=<id>&since=<YYYY-MM-DD>&until=<YYYY-MM-DD>
+func NewAuditFilter(q url.Values) AuditFilter {
+	f := AuditFilter{
+		StaffName: strings.TrimSpace(q.Get("staff")),
+		Action:    strings.TrimSpace(q.Get("action")),
+		TargetID:  strings.TrimSpace(q.Get("target_id")),
+	}
+
+	if t, err := time.Parse(chrono.SQLDate, q.Get("since")); err == nil {
+		f.Since = t
+	}
+	// Until is inclusive.
+	if t, err := time.Parse(chrono.SQLDate, q.Get("until")); err == nil {
+		f.Until = t.AddDate(0, 0, 1)
+	}
+
+	return f
+}
+
+// Where builds the WHERE clause and its arguments.
+func (f AuditFilter) Where() (string, []interface{}) {
+	var cond []string
+	var args []interface{}
+
+	if f.StaffName != "" {
+		cond = append(cond, "staff_name = ?")
+		args = append(args, f.StaffName)
+	}
+
+	if f.Action != "" {
+		cond = append(cond, "action LIKE ?")
+		args = append(args, f.Action+"%")
+	}
+
+	if f.TargetID != "" {
+		cond = append(cond, "JSON_CONTAINS(target_ids, JSON_QUOTE(?))")
+		args = append(args, f.TargetID)
+	}
+
+	if !f.Since.IsZero() {
+		cond = append(cond, "created_utc >= ?")
+		args = append(args, f.Since)
+	}
+
+	if !f.Until.IsZero() {
+		cond = append(cond, "created_utc < ?")
+		args = append(args, f.Until)
+	}
+
+	if len(cond) == 0 {
+		return "", nil
+	}
+
+	return "\nWHERE " + strings.Join(cond, "\n\tAND "), args
+}
+
+func isJSON(b []byte) bool {
+	s := strings.TrimSpace(string(b))
+	return strings.HasPrefix(s, "{") || strings.HasPrefix(s, "[")
+}
+
+// Fields whose value is masked in audit log, compared in
+// lower case. Names ending with password, secret or token
+// are masked too.
+var secretFields = map[string]bool{
+	"code":         true,
+	"recoverycode": true,
+	"apikey":       true,
+	"privatekey":   true,
+}
+
+const redacted = "[REDACTED]"
+
+func isSecretField(name string) bool {
+	name = strings.ToLower(name)
+	if secretFields[name] {
+		return true
+	}
+
+	for _, suffix := range []string{"password", "secret", "token"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// redact masks values of secret fields in a JSON document.
+// A document failed to parse is dropped rather than
+// kept as is.
+func redact(b conv.RawJSON) conv.RawJSON {
+	if len(b) == 0 {
+		return nil
+	}
+
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return nil
+	}
+
+	out, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return nil
+	}
+
+	return out
+}
+
+func redactValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		for k, val := range x {
+			if isSecretField(k) {
+				x[k] = redacted
+				continue
+			}
+			x[k] = redactValue(val)
+		}
+		return x
+
+	case []interface{}:
+		for i, val := range x {
+			x[i] = redactValue(val)
+		}
+		return x
+	}
+
+	return v
+}
@@ -0,0 +1,44 @@
+package cms
+
+// StmtInsertAuditEntry is the only statement writing to
+// audit log. There is no update or delete.
+const StmtInsertAuditEntry = `
+INSERT INTO cms.audit_log
+SET staff_name = :staff_name,
+	staff_role = :staff_role,
+	action = :action,
+	target_ids = :target_ids,
+	request_body = :request_body,
+	before_state = :before_state,
+	after_state = :after_state,
+	status_code = :status_code,
+	user_ip = :user_ip,
+	created_utc = :created_utc`
+
+const colsAuditEntry = `
+SELECT id,
+	staff_name,
+	staff_role,
+	action,
+	target_ids,
+	request_body,
+	before_state,
+	after_state,
+	status_code,
+	user_ip,
+	created_utc
+FROM cms.audit_log`
+
+// StmtListAuditEntries builds query with the WHERE clause
+// from AuditFilter.
+func StmtListAuditEntries(where string) string {
+	return colsAuditEntry + where + `
+ORDER BY id DESC
+LIMIT ? OFFSET ?`
+}
+
+func StmtCountAuditEntries(where string) string {
+	return `
+SELECT COUNT(*) AS row_count
+FROM cms.audit_log` + where
+}
@@ -0,0 +1,70 @@
+package cms
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestAuditFilter_Where(t *testing.T) {
+	f := NewAuditFilter(url.Values{
+		"staff":     {"alice"},
+		"target_id": {"ftc-uuid"},
+		"since":     {"2022-01-01"},
+		"until":     {"bad-date"},
+	})
+
+	where, args := f.Where()
+
+	want := `
+WHERE staff_name = ?
+	AND JSON_CONTAINS(target_ids, JSON_QUOTE(?))
+	AND created_utc >= ?`
+	if where != want {
+		t.Errorf("Where() = %s, want %s", where, want)
+	}
+
+	if len(args) != 3 {
+		t.Errorf("Where() args = %v", args)
+	}
+
+	where, args = AuditFilter{}.Where()
+	if where != "" || args != nil {
+		t.Errorf("Empty filter produced %s %v", where, args)
+	}
+}
+
+func TestAuditTrail_Entry(t *testing.T) {
+	trail := &AuditTrail{
+		Staff: StaffRole{StaffName: "alice", Role: RoleSupport},
+	}
+	trail.Target("a", "", "b").Change(nil, map[string]string{"tier": "premium"})
+
+	e := trail.Entry("POST /cms/memberships", []byte("not json"), 200, "")
+
+	if len(e.TargetIDs) != 2 {
+		t.Errorf("TargetIDs = %v", e.TargetIDs)
+	}
+	if e.Before != nil || string(e.After) != `{"tier":"premium"}` {
+		t.Errorf("Before = %s, After = %s", e.Before, e.After)
+	}
+	if e.RequestBody != nil || e.UserIP.Valid {
+		t.Errorf("RequestBody = %s, UserIP = %v", e.RequestBody, e.UserIP)
+	}
+}
+
+func TestAuditTrail_Entry_redact(t *testing.T) {
+	trail := &AuditTrail{}
+	trail.Change(nil, map[string]string{"clientId": "web", "token": "abc"})
+
+	body := []byte(`{"email": "a@b.com", "password": "123456", "nested": [{"RecoveryCode": "x", "amount": 12345678901234567}]}`)
+	e := trail.Entry("POST /cms/staff", body, 200, "")
+
+	want := `{"email":"a@b.com","nested":[{"RecoveryCode":"[REDACTED]","amount":12345678901234567}],"password":"[REDACTED]"}`
+	if string(e.RequestBody) != want {
+		t.Errorf("RequestBody = %s, want %s", e.RequestBody, want)
+	}
+
+	if string(e.After) != `{"clientId":"web","token":"[REDACTED]"}` {
+		t.Errorf("After = %s", e.After)
+	}
+}
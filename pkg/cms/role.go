@@ -0,0 +1,138 @@
+package cms
+
+import (
+	"database/sql/driver"
+	"errors"
+
+	"github.com/FTChinese/go-rest/chrono"
+	"github.com/guregu/null"
+)
+
+// Role of a staff using CMS.
+type Role string
+
+const (
+	RoleNull    Role = ""
+	RoleSupport Role = "support"
+	RoleFinance Role = "finance"
+	RoleProduct Role = "product"
+	RoleAdmin   Role = "admin"
+)
+
+func (r Role) IsValid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// Can tells whether this role is granted a permission.
+// Admin is granted everything.
+func (r Role) Can(p Permission) bool {
+	if r == RoleAdmin {
+		return true
+	}
+
+	for _, v := range rolePermissions[r] {
+		if v == p {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Role) Scan(src interface{}) error {
+	if src == nil {
+		*r = RoleNull
+		return nil
+	}
+
+	switch s := src.(type) {
+	case []byte:
+		*r = Role(s)
+		return nil
+
+	case string:
+		*r = Role(s)
+		return nil
+
+	default:
+		return errors.New("incompatible type to scan to cms.Role")
+	}
+}
+
+func (r Role) Value() (driver.Value, error) {
+	if r == RoleNull {
+		return nil, nil
+	}
+
+	return string(r), nil
+}
+
+// Permission is required by a CMS route group.
+type Permission string
+
+const (
+	PermOrders       Permission = "orders"
+	PermMemberships  Permission = "memberships"
+	PermAddOns       Permission = "addons"
+	PermRefunds      Permission = "refunds"
+	PermDisputes     Permission = "disputes"
+	PermReports      Permission = "reports"
+	PermAccounts     Permission = "accounts"
+	PermEmails       Permission = "emails"
+	PermPrices       Permission = "prices"
+	PermCoupons      Permission = "coupons"
+	PermLegal        Permission = "legal"
+	PermAndroid      Permission = "android"
+	PermAccessTokens Permission = "access_tokens"
+	PermAuditLog     Permission = "audit_log"
+	PermStaff        Permission = "staff"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleSupport: {
+		PermOrders,
+		PermMemberships,
+		PermAddOns,
+		PermAccounts,
+		PermEmails,
+	},
+	RoleFinance: {
+		PermOrders,
+		PermMemberships,
+		PermAddOns,
+		PermRefunds,
+		PermDisputes,
+		PermReports,
+	},
+	RoleProduct: {
+		PermPrices,
+		PermCoupons,
+		PermLegal,
+		PermAndroid,
+		PermReports,
+	},
+	RoleAdmin: nil,
+}
+
+// StaffRole assigns a role to a staff.
+// Staff is identified by the `X-Staff-Name` header.
+type StaffRole struct {
+	StaffName  string      `json:"staffName" db:"staff_name"`
+	Role       Role        `json:"role" db:"staff_role"`
+	AssignedBy null.String `json:"assignedBy" db:"assigned_by"`
+	CreatedUTC chrono.Time `json:"createdUtc" db:"created_utc"`
+	UpdatedUTC chrono.Time `json:"updatedUtc" db:"updated_utc"`
+}
+
+func NewStaffRole(name string, role Role, by string) StaffRole {
+	now := chrono.TimeNow()
+
+	return StaffRole{
+		StaffName:  name,
+		Role:       role,
+		AssignedBy: null.StringFrom(by),
+		CreatedUTC: now,
+		UpdatedUTC: now,
+	}
+}
@@ -0,0 +1,33 @@
+package cms
+
+const colsStaffRole = `
+SELECT staff_name,
+	staff_role,
+	assigned_by,
+	created_utc,
+	updated_utc
+FROM cms.staff_role`
+
+const StmtRetrieveStaffRole = colsStaffRole + `
+WHERE staff_name = ?
+LIMIT 1`
+
+const StmtListStaffRoles = colsStaffRole + `
+ORDER BY staff_name`
+
+const StmtUpsertStaffRole = `
+INSERT INTO cms.staff_role
+SET staff_name = :staff_name,
+	staff_role = :staff_role,
+	assigned_by = :assigned_by,
+	created_utc = :created_utc,
+	updated_utc = :updated_utc
+ON DUPLICATE KEY UPDATE
+	staff_role = :staff_role,
+	assigned_by = :assigned_by,
+	updated_utc = :updated_utc`
+
+const StmtDeleteStaffRole = `
+DELETE FROM cms.staff_role
+WHERE staff_name = ?
+LIMIT 1`
@@ -0,0 +1,31 @@
+package cms
+
+import "testing"
+
+func TestRole_Can(t *testing.T) {
+	tests := []struct {
+		role Role
+		perm Permission
+		want bool
+	}{
+		{RoleSupport, PermMemberships, true},
+		{RoleSupport, PermRefunds, false},
+		{RoleFinance, PermRefunds, true},
+		{RoleFinance, PermPrices, false},
+		{RoleFinance, PermDisputes, true},
+		{RoleSupport, PermDisputes, false},
+		{RoleProduct, PermReports, true},
+		{RoleSupport, PermReports, false},
+		{RoleProduct, PermAndroid, true},
+		{RoleProduct, PermAuditLog, false},
+		{RoleAdmin, PermStaff, true},
+		{RoleNull, PermOrders, false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.role)+" "+string(tt.perm), func(t *testing.T) {
+			if got := tt.role.Can(tt.perm); got != tt.want {
+				t.Errorf("Can() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"errors"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	AppleIssuer      = "https://appleid.apple.com"
+	appleDefaultJWKS = AppleIssuer + "/auth/keys"
+)
+
+// AppleSignIn configures verification of Sign in with Apple
+// identity tokens.
+// ClientIDs are the iOS bundle id and web service id
+// accepted as the token audience.
+type AppleSignIn struct {
+	JWKSURL   string   `mapstructure:"jwks_url"`
+	ClientIDs []string `mapstructure:"client_ids"`
+}
+
+func (a AppleSignIn) Validate() error {
+	if len(a.ClientIDs) == 0 {
+		return errors.New("apple sign in client ids cannot be empty")
+	}
+
+	return nil
+}
+
+// LoadAppleSignIn reads the `apple.signin` section.
+// The JWKS url defaults to Apple's public endpoint.
+func LoadAppleSignIn() (AppleSignIn, error) {
+	var a AppleSignIn
+	err := viper.UnmarshalKey("apple.signin", &a)
+	if err != nil {
+		return AppleSignIn{}, err
+	}
+
+	if a.JWKSURL == "" {
+		a.JWKSURL = appleDefaultJWKS
+	}
+
+	if err := a.Validate(); err != nil {
+		return AppleSignIn{}, err
+	}
+
+	return a, nil
+}
+
+func MustAppleSignIn() AppleSignIn {
+	a, err := LoadAppleSignIn()
+	if err != nil {
+		panic(err)
+	}
+
+	return a
+}
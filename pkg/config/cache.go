@@ -0,0 +1,93 @@
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// CacheBackend decides where cached data lives.
+type CacheBackend string
+
+const (
+	// CacheBackendRedis shares cache among all instances.
+	CacheBackendRedis CacheBackend = "redis"
+	// CacheBackendMemory keeps cache per process. Use it only
+	// if a single instance is running.
+	CacheBackendMemory CacheBackend = "memory"
+)
+
+// CacheConfig is loaded from the `cache` section:
+//
+//	[cache]
+//	backend = "redis"
+//	default_ttl_seconds = 7200
+//	[cache.ttl_seconds]
+//	paywall = 86400
+//	token = 600
+type CacheConfig struct {
+	Backend    CacheBackend     `mapstructure:"backend"`
+	DefaultTTL int64            `mapstructure:"default_ttl_seconds"`
+	TTLs       map[string]int64 `mapstructure:"ttl_seconds"`
+}
+
+// TTLs per key namespace used when not set in config.
+var defaultCacheTTLs = map[string]int64{
+	// Invalidated whenever CMS modifies paywall.
+	"paywall": 24 * 3600,
+	"android": 2 * 3600,
+	// Access tokens.
+	"token": 10 * 60,
+	// Login sessions. Kept short since revocation is only
+	// published on a best-effort basis.
+	"session": 60,
+}
+
+func (c CacheConfig) DefaultDuration() time.Duration {
+	return time.Duration(c.DefaultTTL) * time.Second
+}
+
+// Durations converts TTLs of each namespace.
+func (c CacheConfig) Durations() map[string]time.Duration {
+	m := make(map[string]time.Duration, len(c.TTLs))
+	for k, v := range c.TTLs {
+		m[k] = time.Duration(v) * time.Second
+	}
+
+	return m
+}
+
+func LoadCacheConfig() (CacheConfig, error) {
+	var c CacheConfig
+	err := viper.UnmarshalKey("cache", &c)
+	if err != nil {
+		return CacheConfig{}, err
+	}
+
+	if c.Backend == "" {
+		c.Backend = CacheBackendRedis
+	}
+	if c.DefaultTTL <= 0 {
+		c.DefaultTTL = 2 * 3600
+	}
+
+	ttls := make(map[string]int64, len(defaultCacheTTLs))
+	for k, v := range defaultCacheTTLs {
+		ttls[k] = v
+	}
+	for k, v := range c.TTLs {
+		ttls[k] = v
+	}
+	c.TTLs = ttls
+
+	return c, nil
+}
+
+func MustCacheConfig() CacheConfig {
+	c, err := LoadCacheConfig()
+	if err != nil {
+		panic(err)
+	}
+
+	return c
+}
@@ -0,0 +1,18 @@
+package config
+
+import (
+	"github.com/spf13/viper"
+)
+
+// CMSBootstrapAdmins lists staff who act as admin of CMS
+// without a role assigned, from the optional `cms` section:
+//
+//	[cms]
+//	bootstrap_admins = ["<staff name>"]
+//
+// Roles are assigned by admins only, so at least one is
+// needed upon first deploy. A role assigned in db takes
+// precedence; remove the names once admins are assigned.
+func CMSBootstrapAdmins() []string {
+	return viper.GetStringSlice("cms.bootstrap_admins")
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// HealthConfig is loaded from the `health` section:
+//
+//	[health]
+//	timeout_ms = 2000
+//	smtp = true
+//	payment_hosts = ["api.stripe.com:443", "api.mch.weixin.qq.com:443"]
+//
+// SMTP and payment hosts are optional checks. Their failure
+// does not mark the instance as not ready.
+type HealthConfig struct {
+	TimeoutMs    int64    `mapstructure:"timeout_ms"`
+	SMTP         bool     `mapstructure:"smtp"`
+	PaymentHosts []string `mapstructure:"payment_hosts"`
+}
+
+func (c HealthConfig) Timeout() time.Duration {
+	return time.Duration(c.TimeoutMs) * time.Millisecond
+}
+
+func MustHealthConfig() HealthConfig {
+	var c HealthConfig
+	err := viper.UnmarshalKey("health", &c)
+	if err != nil {
+		panic(err)
+	}
+
+	if c.TimeoutMs <= 0 {
+		c.TimeoutMs = 2000
+	}
+
+	return c
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// OutboundTimeout limits a single request to a payment
+// provider, e.g., Stripe API.
+const OutboundTimeout = 80 * time.Second
+
+// IdempotencyConfig is loaded from the optional `idempotency`
+// section:
+//
+//	[idempotency]
+//	ttl_hours = 24
+//	lock_seconds = 300
+//
+// A key stays locked while its first request is processed so
+// that a retry is rejected instead of charging twice. The lock
+// must outlive the slowest request, which might wait on Stripe
+// for OutboundTimeout per attempt; it is never shorter than
+// OutboundTimeout.
+type IdempotencyConfig struct {
+	TTLHours    int64 `mapstructure:"ttl_hours"`
+	LockSeconds int64 `mapstructure:"lock_seconds"`
+}
+
+// TTL is how long a response is kept for replay.
+func (c IdempotencyConfig) TTL() time.Duration {
+	return time.Duration(c.TTLHours) * time.Hour
+}
+
+// LockTTL is how long a key is locked while being processed.
+func (c IdempotencyConfig) LockTTL() time.Duration {
+	return time.Duration(c.LockSeconds) * time.Second
+}
+
+func MustIdempotencyConfig() IdempotencyConfig {
+	var c IdempotencyConfig
+	err := viper.UnmarshalKey("idempotency", &c)
+	if err != nil {
+		panic(err)
+	}
+
+	if c.TTLHours <= 0 {
+		c.TTLHours = 24
+	}
+
+	// Stripe retries a failed request twice by default.
+	if c.LockSeconds <= 0 {
+		c.LockSeconds = 300
+	}
+
+	if c.LockTTL() < OutboundTimeout {
+		c.LockSeconds = int64(OutboundTimeout / time.Second)
+	}
+
+	return c
+}
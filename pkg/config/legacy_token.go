@@ -0,0 +1,31 @@
+package config
+
+import (
+	"github.com/spf13/viper"
+)
+
+// LegacyToken grants scopes to an access token created before
+// scopes were introduced, e.g., the one used by CMS. Loaded
+// from the optional `legacy_tokens` section:
+//
+//	[[legacy_tokens]]
+//	token = "<access token of CMS>"
+//	scopes = ["reader", "paywall:write", "cms:membership", "cms:content", "cms:token"]
+//
+// It keeps such clients working after deploy until they are
+// given a scoped token from /cms/access-tokens. Tokens saved
+// with scopes ignore it.
+type LegacyToken struct {
+	Token  string   `mapstructure:"token"`
+	Scopes []string `mapstructure:"scopes"`
+}
+
+func MustLegacyTokens() []LegacyToken {
+	var list []LegacyToken
+	err := viper.UnmarshalKey("legacy_tokens", &list)
+	if err != nil {
+		panic(err)
+	}
+
+	return list
+}
@@ -0,0 +1,30 @@
+package config
+
+import "github.com/spf13/viper"
+
+const (
+	MailTransportSMTP = "smtp"
+	MailTransportHTTP = "http"
+	MailTransportFile = "file"
+)
+
+// MailTransport determines how queued emails are delivered.
+// Kind is one of smtp, http or file. URL and Key are used only
+// by the http transport and Dir only by the file sink.
+// SMTP is used if the `email.transport` section is missing.
+type MailTransport struct {
+	Kind string `mapstructure:"kind"`
+	URL  string `mapstructure:"url"`
+	Key  string `mapstructure:"key"`
+	Dir  string `mapstructure:"dir"`
+}
+
+func MustMailTransport() MailTransport {
+	var t MailTransport
+	err := viper.UnmarshalKey("email.transport", &t)
+	if err != nil {
+		panic(err)
+	}
+
+	return t
+}
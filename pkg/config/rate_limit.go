@@ -0,0 +1,141 @@
+package config
+
+import (
+	"net"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// RateLimitKey decides what a rate limit rule counts on.
+type RateLimitKey string
+
+const (
+	RateLimitByToken RateLimitKey = "token" // The access token, i.e., a client app.
+	RateLimitByUser  RateLimitKey = "user"  // Ftc id or union id.
+	RateLimitByIP    RateLimitKey = "ip"
+	// Email, mobile or two-factor challenge in request body.
+	RateLimitByCredential RateLimitKey = "credential"
+)
+
+// RateLimitRule allows at most Limit requests within
+// Window seconds per key.
+type RateLimitRule struct {
+	Key    RateLimitKey `mapstructure:"key"`
+	Limit  int64        `mapstructure:"limit"`
+	Window int64        `mapstructure:"window_seconds"`
+}
+
+func (r RateLimitRule) WindowDuration() time.Duration {
+	return time.Duration(r.Window) * time.Second
+}
+
+// Names of route groups having their own rate limit rules.
+const (
+	RateLimitDefault = "default"
+	RateLimitAuth    = "auth"
+	RateLimitPayment = "payment"
+	// Endpoints accepting a credential to be guessed.
+	RateLimitCredential = "credential"
+)
+
+// RateLimits maps route group name to its rules.
+// A request is rejected if any rule is exceeded.
+type RateLimits map[string][]RateLimitRule
+
+var defaultRateLimits = RateLimits{
+	RateLimitDefault: {
+		{Key: RateLimitByToken, Limit: 6000, Window: 60},
+		{Key: RateLimitByUser, Limit: 300, Window: 60},
+		{Key: RateLimitByIP, Limit: 600, Window: 60},
+	},
+	// Credentials guessing.
+	RateLimitAuth: {
+		{Key: RateLimitByIP, Limit: 20, Window: 60},
+		{Key: RateLimitByIP, Limit: 200, Window: 3600},
+	},
+	// Guessing the password or code of a single account
+	// from many ips.
+	RateLimitCredential: {
+		{Key: RateLimitByCredential, Limit: 10, Window: 600},
+		{Key: RateLimitByCredential, Limit: 30, Window: 86400},
+	},
+	// Creating orders or subscriptions.
+	RateLimitPayment: {
+		{Key: RateLimitByUser, Limit: 10, Window: 60},
+		{Key: RateLimitByIP, Limit: 30, Window: 60},
+	},
+}
+
+// LoadRateLimits reads the `rate_limit` section. A group
+// present in config replaces the default rules of that group:
+//
+//	[[rate_limit.auth]]
+//	key = "ip"
+//	limit = 10
+//	window_seconds = 60
+func LoadRateLimits() (RateLimits, error) {
+	var c RateLimits
+	err := viper.UnmarshalKey("rate_limit", &c)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := make(RateLimits, len(defaultRateLimits))
+	for k, v := range defaultRateLimits {
+		merged[k] = v
+	}
+	for k, v := range c {
+		merged[k] = v
+	}
+
+	return merged, nil
+}
+
+func MustRateLimits() RateLimits {
+	c, err := LoadRateLimits()
+	if err != nil {
+		panic(err)
+	}
+
+	return c
+}
+
+// LoadTrustedProxies reads addresses of reverse proxies in
+// front of this app, either single IPs or CIDRs:
+//
+//	[proxy]
+//	trusted = ["10.0.0.0/8", "127.0.0.1"]
+//
+// Only requests coming from them are allowed to carry
+// the client ip in headers like X-User-Ip, X-Forwarded-For.
+func LoadTrustedProxies() ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, s := range viper.GetStringSlice("proxy.trusted") {
+		if !strings.Contains(s, "/") {
+			if strings.Contains(s, ":") {
+				s += "/128"
+			} else {
+				s += "/32"
+			}
+		}
+
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, n)
+	}
+
+	return nets, nil
+}
+
+func MustTrustedProxies() []*net.IPNet {
+	n, err := LoadTrustedProxies()
+	if err != nil {
+		panic(err)
+	}
+
+	return n
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// SessionCompat decides whether user identity headers
+// X-User-Id and X-Union-Id are still accepted without
+// a session token.
+type SessionCompat string
+
+const (
+	// SessionCompatOff accepts session tokens only.
+	SessionCompatOff SessionCompat = "off"
+	// SessionCompatTrusted accepts identity headers from
+	// trusted server-side clients only.
+	SessionCompatTrusted SessionCompat = "trusted"
+	// SessionCompatAll accepts identity headers from any client.
+	// Used during migration of client apps.
+	SessionCompatAll SessionCompat = "all"
+)
+
+// SigningKey is a secret used to sign session tokens.
+// Multiple keys are kept so that tokens signed by a retired
+// key remain valid until they expire.
+type SigningKey struct {
+	ID     string `mapstructure:"id"`
+	Secret string `mapstructure:"secret"`
+}
+
+// SessionConfig is loaded from the `session` section:
+//
+//	[session]
+//	active_key = "k2"
+//	access_ttl_minutes = 15
+//	refresh_ttl_days = 30
+//	compat = "trusted"
+//	trusted_tokens = ["<access token of server-side client>"]
+//	[[session.keys]]
+//	id = "k1"
+//	secret = "..."
+type SessionConfig struct {
+	ActiveKey     string        `mapstructure:"active_key"`
+	Keys          []SigningKey  `mapstructure:"keys"`
+	AccessTTL     int64         `mapstructure:"access_ttl_minutes"`
+	RefreshTTL    int64         `mapstructure:"refresh_ttl_days"`
+	Compat        SessionCompat `mapstructure:"compat"`
+	TrustedTokens []string      `mapstructure:"trusted_tokens"`
+}
+
+// minSecretLen is the minimum length of a signing key.
+// Every key is checked, not only the active one, since a
+// token signed by any of them passes verification.
+const minSecretLen = 32
+
+func (c SessionConfig) Validate() error {
+	seen := make(map[string]bool, len(c.Keys))
+	for _, k := range c.Keys {
+		if k.ID == "" {
+			return errors.New("session signing key id should not be empty")
+		}
+		if seen[k.ID] {
+			return fmt.Errorf("duplicate session signing key id %s", k.ID)
+		}
+		seen[k.ID] = true
+
+		if len(k.Secret) < minSecretLen {
+			return fmt.Errorf("session signing key %s should be at least %d bytes", k.ID, minSecretLen)
+		}
+	}
+
+	if !seen[c.ActiveKey] {
+		return errors.New("active session signing key not found")
+	}
+
+	return nil
+}
+
+func (c SessionConfig) AccessDuration() time.Duration {
+	return time.Duration(c.AccessTTL) * time.Minute
+}
+
+func (c SessionConfig) RefreshDuration() time.Duration {
+	return time.Duration(c.RefreshTTL) * 24 * time.Hour
+}
+
+func LoadSessionConfig() (SessionConfig, error) {
+	var c SessionConfig
+	err := viper.UnmarshalKey("session", &c)
+	if err != nil {
+		return SessionConfig{}, err
+	}
+
+	if c.AccessTTL <= 0 {
+		c.AccessTTL = 15
+	}
+	if c.RefreshTTL <= 0 {
+		c.RefreshTTL = 30
+	}
+	if c.Compat == "" {
+		c.Compat = SessionCompatTrusted
+	}
+
+	if err := c.Validate(); err != nil {
+		return SessionConfig{}, err
+	}
+
+	return c, nil
+}
+
+func MustSessionConfig() SessionConfig {
+	c, err := LoadSessionConfig()
+	if err != nil {
+		panic(err)
+	}
+
+	return c
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSessionConfig_Validate(t *testing.T) {
+	secret := strings.Repeat("x", minSecretLen)
+
+	tests := []struct {
+		name    string
+		config  SessionConfig
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			config: SessionConfig{
+				ActiveKey: "k2",
+				Keys:      []SigningKey{{ID: "k1", Secret: secret}, {ID: "k2", Secret: secret}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "active key missing",
+			config: SessionConfig{
+				ActiveKey: "k2",
+				Keys:      []SigningKey{{ID: "k1", Secret: secret}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "retired key too short",
+			config: SessionConfig{
+				ActiveKey: "k2",
+				Keys:      []SigningKey{{ID: "k1", Secret: ""}, {ID: "k2", Secret: secret}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicate id",
+			config: SessionConfig{
+				ActiveKey: "k1",
+				Keys:      []SigningKey{{ID: "k1", Secret: secret}, {ID: "k1", Secret: "short"}},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
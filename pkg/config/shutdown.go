@@ -0,0 +1,37 @@
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// ShutdownConfig is loaded from the `shutdown` section:
+//
+//	[shutdown]
+//	timeout_seconds = 30
+//
+// After SIGINT or SIGTERM, in-flight requests and background
+// tasks are given this long to finish before the process
+// exits.
+type ShutdownConfig struct {
+	TimeoutSeconds int64 `mapstructure:"timeout_seconds"`
+}
+
+func (c ShutdownConfig) Timeout() time.Duration {
+	return time.Duration(c.TimeoutSeconds) * time.Second
+}
+
+func MustShutdownConfig() ShutdownConfig {
+	var c ShutdownConfig
+	err := viper.UnmarshalKey("shutdown", &c)
+	if err != nil {
+		panic(err)
+	}
+
+	if c.TimeoutSeconds <= 0 {
+		c.TimeoutSeconds = 30
+	}
+
+	return c
+}
@@ -0,0 +1,23 @@
+package config
+
+import (
+	"github.com/spf13/viper"
+)
+
+// StripeBackendConfig is loaded from the optional
+// `stripe_backend` section:
+//
+//	[stripe_backend]
+//	url = "http://localhost:12111"
+//
+// Requests are sent to Stripe API if URL is empty. Set it to
+// run against stripe-mock or another local stand-in.
+type StripeBackendConfig struct {
+	URL string `mapstructure:"url"`
+}
+
+func StripeBackend() StripeBackendConfig {
+	return StripeBackendConfig{
+		URL: viper.GetString("stripe_backend.url"),
+	}
+}
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"github.com/spf13/viper"
+)
+
+// StripeDisputeConfig is loaded from the `stripe_dispute` section:
+//
+//	[stripe_dispute]
+//	suspend_access = true
+//	notify = ["finance@ftchinese.com"]
+//
+// If SuspendAccess is true, membership of a disputed
+// subscription expires as soon as the dispute is created and
+// is restored if it is won. Notify lists addresses to receive
+// a letter when a dispute is created or closed.
+type StripeDisputeConfig struct {
+	SuspendAccess bool     `mapstructure:"suspend_access"`
+	Notify        []string `mapstructure:"notify"`
+}
+
+func MustStripeDisputeConfig() StripeDisputeConfig {
+	var c StripeDisputeConfig
+	err := viper.UnmarshalKey("stripe_dispute", &c)
+	if err != nil {
+		panic(err)
+	}
+
+	return c
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// TokenRotationConfig is loaded from the optional
+// `token_rotation` section:
+//
+//	[token_rotation]
+//	grace_hours = 24
+//
+// A rotated access token keeps working this long so that its
+// client could switch to the replacement without downtime.
+// A negative value revokes the old token at once.
+type TokenRotationConfig struct {
+	GraceHours int64 `mapstructure:"grace_hours"`
+}
+
+func (c TokenRotationConfig) Grace() time.Duration {
+	if c.GraceHours < 0 {
+		return 0
+	}
+
+	return time.Duration(c.GraceHours) * time.Hour
+}
+
+func MustTokenRotationConfig() TokenRotationConfig {
+	var c TokenRotationConfig
+	err := viper.UnmarshalKey("token_rotation", &c)
+	if err != nil {
+		panic(err)
+	}
+
+	if c.GraceHours == 0 {
+		c.GraceHours = 24
+	}
+
+	return c
+}
@@ -0,0 +1,41 @@
+package config
+
+import "github.com/spf13/viper"
+
+const (
+	TracingExporterNone = "none"
+	TracingExporterOTLP = "otlp"
+)
+
+// TracingConfig is loaded from the `tracing` section:
+//
+//	[tracing]
+//	exporter = "otlp"
+//	endpoint = "localhost:4317"
+//	insecure = true
+//	sample_ratio = 0.1
+//
+// Tracing is disabled if the section is missing.
+type TracingConfig struct {
+	Exporter    string  `mapstructure:"exporter"`
+	Endpoint    string  `mapstructure:"endpoint"`
+	Insecure    bool    `mapstructure:"insecure"`
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+}
+
+func MustTracingConfig() TracingConfig {
+	var c TracingConfig
+	err := viper.UnmarshalKey("tracing", &c)
+	if err != nil {
+		panic(err)
+	}
+
+	if c.Exporter == "" {
+		c.Exporter = TracingExporterNone
+	}
+	if c.SampleRatio <= 0 || c.SampleRatio > 1 {
+		c.SampleRatio = 1
+	}
+
+	return c
+}
@@ -2,5 +2,6 @@ package config
 
 const Version = "v6"
 const Port = "8206"
+const AdminPort = "8207"
 const stripeWebhookKey = "api_keys.stripe_webhook_" + Version
 const StripeVersion = "2020-08-27"
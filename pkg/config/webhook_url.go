@@ -11,6 +11,12 @@ const (
 	// PasswordResetURL is the base url to construct url to reset password.
 	// Previously we used https://users.ftchinese.com/password-reset created by the next-user app.
 	PasswordResetURL = readerAppBase + "/reader/password-reset"
+	// MagicLinkURL is the base url to construct the passwordless login link.
+	MagicLinkURL = readerAppBase + "/reader/magic-link"
+	// EmailChangeURL is the base url to confirm a new email address.
+	EmailChangeURL = readerAppBase + "/reader/email-change"
+	// EmailRevertURL is the base url sent to the old email address to undo a change.
+	EmailRevertURL = readerAppBase + "/reader/email-revert"
 )
 
 // AliWxWebhookURL builds the url for one-time purchase.
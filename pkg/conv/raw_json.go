@@ -0,0 +1,62 @@
+package conv
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// RawJSON keeps a JSON document as is, used for SQL JSON
+// columns whose structure varies row by row.
+type RawJSON []byte
+
+// RawJSONFrom marshals any value. Nil value produces empty RawJSON.
+func RawJSONFrom(v interface{}) (RawJSON, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func (j RawJSON) MarshalJSON() ([]byte, error) {
+	if len(j) == 0 {
+		return []byte("null"), nil
+	}
+
+	return j, nil
+}
+
+func (j *RawJSON) UnmarshalJSON(b []byte) error {
+	*j = append((*j)[0:0], b...)
+	return nil
+}
+
+func (j RawJSON) Value() (driver.Value, error) {
+	if len(j) == 0 {
+		return nil, nil
+	}
+
+	return string(j), nil
+}
+
+func (j *RawJSON) Scan(src interface{}) error {
+	if src == nil {
+		*j = nil
+		return nil
+	}
+
+	switch s := src.(type) {
+	case []byte:
+		*j = append(RawJSON{}, s...)
+		return nil
+
+	default:
+		return errors.New("incompatible type to scan to RawJSON")
+	}
+}
@@ -32,8 +32,15 @@ func newGormConfig(production bool) *gorm.Config {
 }
 
 func NewGormDB(c config.Connect, production bool) (*gorm.DB, error) {
-	dsn := buildDSN(c)
-	return gorm.Open(mysql.Open(dsn), newGormConfig(production))
+	// Share the traced connector with sqlx.
+	sqlDB, err := NewMySQL(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return gorm.Open(mysql.New(mysql.Config{
+		Conn: sqlDB.DB,
+	}), newGormConfig(production))
 }
 
 func MustNewGormDB(c config.Connect, production bool) *gorm.DB {
@@ -79,3 +86,20 @@ func mustGormOpenExistingDB(sqlDB *sql.DB, prod bool) *gorm.DB {
 
 	return db
 }
+
+// Close closes the underlying pools and returns the first
+// error.
+func (x MultiGormDBs) Close() error {
+	var err error
+	for _, g := range []*gorm.DB{x.Read, x.Write, x.Delete} {
+		d, e := g.DB()
+		if e == nil {
+			e = d.Close()
+		}
+		if e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
@@ -1,11 +1,13 @@
 package db
 
 import (
+	"database/sql"
 	"fmt"
 	"log"
 	"time"
 
 	"github.com/FTChinese/subscription-api/pkg/config"
+	"github.com/FTChinese/subscription-api/pkg/tracing"
 	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 )
@@ -34,12 +36,20 @@ func buildDSN(c config.Connect) string {
 
 func NewMySQL(c config.Connect) (*sqlx.DB, error) {
 
-	db, err := sqlx.Open("mysql", buildDSN(c))
+	// Parse the DSN to get the same defaults as sqlx.Open.
+	cfg, err := mysql.ParseDSN(buildDSN(c))
+	if err != nil {
+		return nil, err
+	}
 
+	connector, err := mysql.NewConnector(cfg)
 	if err != nil {
 		return nil, err
 	}
 
+	// Spans are only exported if tracing is configured.
+	db := sqlx.NewDb(sql.OpenDB(tracing.WrapConnector(connector)), "mysql")
+
 	if err := db.Ping(); err != nil {
 		return nil, err
 	}
@@ -75,3 +85,15 @@ type ReadWriteMyDBs struct {
 	Write  *sqlx.DB
 	Delete *sqlx.DB
 }
+
+// Close closes all pools and returns the first error.
+func (x ReadWriteMyDBs) Close() error {
+	var err error
+	for _, d := range []*sqlx.DB{x.Read, x.Write, x.Delete} {
+		if e := d.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,47 @@
+package footprint
+
+import (
+	"database/sql/driver"
+
+	"github.com/FTChinese/go-rest/enum"
+)
+
+// AuthMethod is the way a user logged in.
+// It supersets enum.LoginMethod with login methods
+// not known to the shared enum package.
+type AuthMethod string
+
+const (
+	AuthMethodNull      AuthMethod = ""
+	AuthMethodEmail     AuthMethod = "email"
+	AuthMethodWechat    AuthMethod = "wechat"
+	AuthMethodMobile    AuthMethod = "mobile"
+	AuthMethodEmailLink AuthMethod = "email_link" // Passwordless login via magic link or code.
+	AuthMethodApple     AuthMethod = "apple"      // Sign in with Apple
+)
+
+// AuthMethodFrom converts enum.LoginMethod.
+func AuthMethodFrom(m enum.LoginMethod) AuthMethod {
+	return AuthMethod(m.String())
+}
+
+func (x *AuthMethod) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*x = AuthMethod(s)
+	case string:
+		*x = AuthMethod(s)
+	default:
+		*x = AuthMethodNull
+	}
+
+	return nil
+}
+
+func (x AuthMethod) Value() (driver.Value, error) {
+	if x == AuthMethodNull {
+		return nil, nil
+	}
+
+	return string(x), nil
+}
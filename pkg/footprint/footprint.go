@@ -3,16 +3,19 @@ package footprint
 import (
 	"github.com/FTChinese/go-rest/chrono"
 	"github.com/FTChinese/go-rest/enum"
+	"github.com/FTChinese/subscription-api/pkg/account"
 	"github.com/guregu/null"
 )
 
 type Footprint struct {
 	FtcID string `db:"ftc_id"`
 	Client
-	CreatedUTC  chrono.Time      `db:"created_utc"`
-	Source      Source           `db:"source"`
-	AuthMethod  enum.LoginMethod `db:"auth_method"` // Present wWhen Source is login.
-	DeviceToken null.String      `db:"device_token"`
+	CreatedUTC  chrono.Time `db:"created_utc"`
+	Source      Source      `db:"source"`
+	AuthMethod  AuthMethod  `db:"auth_method"` // Present wWhen Source is login.
+	DeviceToken null.String `db:"device_token"`
+	// The second factor used if 2FA is enabled for login.
+	TwoFactor account.TwoFactorMethod `db:"two_factor_method"`
 }
 
 func New(id string, client Client) Footprint {
@@ -39,11 +42,23 @@ func (c Footprint) FromSignUp() Footprint {
 // WithAuth adds the AuthMethod field and DeviceToken
 // if logged in from mobile apps.
 func (c Footprint) WithAuth(method enum.LoginMethod, deviceToken null.String) Footprint {
+	return c.WithAuthMethod(AuthMethodFrom(method), deviceToken)
+}
+
+// WithAuthMethod is the same as WithAuth but accepts
+// login methods not covered by enum.LoginMethod.
+func (c Footprint) WithAuthMethod(method AuthMethod, deviceToken null.String) Footprint {
 	c.AuthMethod = method
 	c.DeviceToken = deviceToken
 	return c
 }
 
+// WithTwoFactor records the second factor used to log in.
+func (c Footprint) WithTwoFactor(m account.TwoFactorMethod) Footprint {
+	c.TwoFactor = m
+	return c
+}
+
 // FromVerification set the source to verification
 func (c Footprint) FromVerification() Footprint {
 	c.Source = SourceVerification
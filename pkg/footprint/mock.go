@@ -106,7 +106,7 @@ func (b MockFootprintBuilder) Build() Footprint {
 		Client:      b.client,
 		CreatedUTC:  chrono.TimeNow(),
 		Source:      source,
-		AuthMethod:  authMethod,
+		AuthMethod:  AuthMethodFrom(authMethod),
 		DeviceToken: null.NewString(deviceToken, deviceToken != ""),
 	}
 }
@@ -14,7 +14,8 @@ SET ftc_id 			= :ftc_id,
     created_utc 	= UTC_TIMESTAMP(),
     source 			= :source,
 	auth_method 	= :auth_method,
-	device_token 	= :device_token`
+	device_token 	= :device_token,
+	two_factor_method = :two_factor_method`
 
 const StmtInsertOrderClient = `
 INSERT INTO premium.client
@@ -0,0 +1,110 @@
+// Package health checks whether dependencies of a process
+// are reachable.
+package health
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"strconv"
+
+	"github.com/FTChinese/subscription-api/pkg/config"
+	"github.com/FTChinese/subscription-api/pkg/db"
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+)
+
+// Check probes a single dependency. A process is not ready
+// if any Critical check fails; failure of other checks only
+// degrades it.
+type Check struct {
+	Name     string
+	Critical bool
+	Probe    func(ctx context.Context) error
+}
+
+func SQL(name string, d *sql.DB) Check {
+	return Check{
+		Name:     name,
+		Critical: true,
+		Probe:    d.PingContext,
+	}
+}
+
+func Gorm(name string, g *gorm.DB) Check {
+	return Check{
+		Name:     name,
+		Critical: true,
+		Probe: func(ctx context.Context) error {
+			d, err := g.DB()
+			if err != nil {
+				return err
+			}
+			return d.PingContext(ctx)
+		},
+	}
+}
+
+func Redis(rdb *redis.Client) Check {
+	return Check{
+		Name:     "redis",
+		Critical: true,
+		Probe: func(ctx context.Context) error {
+			return rdb.Ping(ctx).Err()
+		},
+	}
+}
+
+// TCP only checks that a host accepts connection, e.g., SMTP
+// server or payment provider's API.
+func TCP(name string, addr string) Check {
+	return Check{
+		Name:     name,
+		Critical: false,
+		Probe: func(ctx context.Context) error {
+			var d net.Dialer
+			conn, err := d.DialContext(ctx, "tcp", addr)
+			if err != nil {
+				return err
+			}
+			return conn.Close()
+		},
+	}
+}
+
+// MySQL checks every pool of the sqlx connections.
+func MySQL(dbs db.ReadWriteMyDBs) []Check {
+	return []Check{
+		SQL("mysql_read", dbs.Read.DB),
+		SQL("mysql_write", dbs.Write.DB),
+		SQL("mysql_delete", dbs.Delete.DB),
+	}
+}
+
+// GormDBs checks every pool of the GORM connections.
+func GormDBs(dbs db.MultiGormDBs) []Check {
+	return []Check{
+		Gorm("gorm_read", dbs.Read),
+		Gorm("gorm_write", dbs.Write),
+		Gorm("gorm_delete", dbs.Delete),
+	}
+}
+
+// Optional builds the checks enabled in config: the SMTP
+// server and outbound payment hosts.
+func Optional(c config.HealthConfig) []Check {
+	var checks []Check
+
+	if c.SMTP {
+		conn := config.MustGetHanqiConn()
+		checks = append(checks, TCP(
+			"smtp",
+			net.JoinHostPort(conn.Host, strconv.Itoa(conn.Port))))
+	}
+
+	for _, host := range c.PaymentHosts {
+		checks = append(checks, TCP(host, host))
+	}
+
+	return checks
+}
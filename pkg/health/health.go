@@ -0,0 +1,149 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/FTChinese/go-rest/render"
+	"go.uber.org/zap"
+)
+
+type Status string
+
+const (
+	StatusOK       Status = "ok"
+	StatusDegraded Status = "degraded" // A non-critical check failed.
+	StatusDown     Status = "down"
+)
+
+// Result of a single check.
+// Error is never serialized since it might expose hosts and
+// credentials of a dependency. It is logged instead.
+type Result struct {
+	Name     string `json:"name"`
+	Status   Status `json:"status"`
+	Critical bool   `json:"critical"`
+	Latency  int64  `json:"latencyMs"`
+	Error    string `json:"-"`
+}
+
+type Report struct {
+	Status Status   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Health runs all checks concurrently, each bounded by timeout.
+type Health struct {
+	checks  []Check
+	timeout time.Duration
+	logger  *zap.Logger
+}
+
+func New(timeout time.Duration, checks ...Check) Health {
+	return Health{
+		checks:  checks,
+		timeout: timeout,
+		logger:  zap.NewNop(),
+	}
+}
+
+// WithLogger sets where errors of failed checks are written.
+func (h Health) WithLogger(l *zap.Logger) Health {
+	h.logger = l
+	return h
+}
+
+func (h Health) With(checks ...Check) Health {
+	h.checks = append(append([]Check{}, h.checks...), checks...)
+	return h
+}
+
+func (h Health) Run(ctx context.Context) Report {
+	results := make([]Result, len(h.checks))
+
+	var wg sync.WaitGroup
+	for i, c := range h.checks {
+		wg.Add(1)
+		go func(i int, c Check) {
+			defer wg.Done()
+			results[i] = h.run(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	status := StatusOK
+	for _, r := range results {
+		if r.Status == StatusOK {
+			continue
+		}
+		h.logger.Error("Health check failed",
+			zap.String("check", r.Name),
+			zap.Bool("critical", r.Critical),
+			zap.String("error", r.Error))
+		if r.Critical {
+			status = StatusDown
+		} else if status != StatusDown {
+			status = StatusDegraded
+		}
+	}
+
+	return Report{
+		Status: status,
+		Checks: results,
+	}
+}
+
+func (h Health) run(ctx context.Context, c Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.Probe(ctx)
+
+	r := Result{
+		Name:     c.Name,
+		Status:   StatusOK,
+		Critical: c.Critical,
+		Latency:  time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		r.Status = StatusDown
+		r.Error = err.Error()
+	}
+
+	return r
+}
+
+// Live reports the process is running. It checks nothing so
+// that a failing dependency does not get the process restarted.
+func Live(w http.ResponseWriter, _ *http.Request) {
+	_ = render.New(w).OK(Report{
+		Status: StatusOK,
+		Checks: []Result{},
+	})
+}
+
+// Ready responds 503 if any critical dependency is down so
+// that load balancer stops routing to this instance.
+func (h Health) Ready(w http.ResponseWriter, req *http.Request) {
+	report := h.Run(req.Context())
+
+	code := http.StatusOK
+	if report.Status == StatusDown {
+		code = http.StatusServiceUnavailable
+	}
+
+	_ = render.New(w).NoCache().JSON(code, report)
+}
+
+// Handler serves the liveness and readiness endpoints, used
+// by processes that do not serve HTTP otherwise.
+func (h Health) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/__health", Live)
+	mux.HandleFunc("/__ready", h.Ready)
+
+	return mux
+}
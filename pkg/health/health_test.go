@@ -0,0 +1,90 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func probe(err error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return err
+	}
+}
+
+func TestHealth_Run(t *testing.T) {
+	ok := Check{Name: "mysql_read", Critical: true, Probe: probe(nil)}
+	optional := Check{Name: "smtp", Probe: probe(errors.New("refused"))}
+	critical := Check{Name: "redis", Critical: true, Probe: probe(errors.New("refused"))}
+
+	tests := []struct {
+		name   string
+		checks []Check
+		want   Status
+	}{
+		{"all ok", []Check{ok}, StatusOK},
+		{"optional failed", []Check{ok, optional}, StatusDegraded},
+		{"critical failed", []Check{ok, optional, critical}, StatusDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New(time.Second, tt.checks...).Run(context.Background())
+			if r.Status != tt.want {
+				t.Errorf("got %s, want %s", r.Status, tt.want)
+			}
+			if len(r.Checks) != len(tt.checks) {
+				t.Errorf("got %d results", len(r.Checks))
+			}
+		})
+	}
+}
+
+func TestHealth_Timeout(t *testing.T) {
+	slow := Check{
+		Name:     "mysql_write",
+		Critical: true,
+		Probe: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+
+	h := New(10*time.Millisecond, slow)
+
+	w := httptest.NewRecorder()
+	h.Ready(w, httptest.NewRequest(http.MethodGet, "/__ready", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("got %d", w.Code)
+	}
+}
+
+func TestHealth_Ready_logsErrors(t *testing.T) {
+	core, logs := observer.New(zap.ErrorLevel)
+	h := New(time.Second,
+		Check{Name: "redis", Critical: true, Probe: probe(errors.New("dial tcp 10.0.0.1:6379: refused"))},
+		Check{Name: "smtp", Probe: probe(errors.New("auth failed for user"))},
+	).WithLogger(zap.New(core))
+
+	w := httptest.NewRecorder()
+	h.Ready(w, httptest.NewRequest(http.MethodGet, "/__ready", nil))
+
+	if strings.Contains(w.Body.String(), "refused") || strings.Contains(w.Body.String(), "auth failed") {
+		t.Errorf("error exposed in response: %s", w.Body.String())
+	}
+
+	if logs.Len() != 2 {
+		t.Fatalf("got %d log entries, want 2", logs.Len())
+	}
+	if logs.FilterField(zap.String("error", "dial tcp 10.0.0.1:6379: refused")).Len() != 1 {
+		t.Errorf("redis error not logged: %v", logs.All())
+	}
+}
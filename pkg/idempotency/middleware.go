@@ -0,0 +1,158 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/pkg/config"
+	"github.com/FTChinese/subscription-api/pkg/ids"
+	"github.com/FTChinese/subscription-api/pkg/xhttp"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// Request bodies of payment creation are small.
+const maxBody = 64 << 10
+
+type Middleware struct {
+	store  Store
+	logger *zap.Logger
+}
+
+func NewMiddleware(rdb *redis.Client, cfg config.IdempotencyConfig, logger *zap.Logger) Middleware {
+	return Middleware{
+		store:  NewStore(rdb, cfg.TTL(), cfg.LockTTL()),
+		logger: logger,
+	}
+}
+
+// Handle replays the response if a request carrying the same
+// Idempotency-Key header is seen within the configured TTL,
+// 24 hours by default.
+// Requests without the header are not affected.
+// A key reused with a different request body is rejected
+// with 422, and a retry arriving while the first one is still
+// in progress gets 409.
+// Server errors are not stored so that client could retry.
+func (m Middleware) Handle(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		key := strings.TrimSpace(req.Header.Get(Header))
+		if key == "" || req.Method != http.MethodPost {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		if len(key) > 255 {
+			_ = render.New(w).BadRequest(Header + " is too long")
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(req.Body, maxBody+1))
+		if err != nil {
+			_ = render.New(w).BadRequest(err.Error())
+			return
+		}
+		req.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), req.Body))
+		// Not a payment request.
+		if len(body) > maxBody {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		storeKey := storeKey(req, key)
+		fingerprint := Fingerprint(req, body)
+
+		ok, existing, err := m.store.Acquire(req.Context(), storeKey, fingerprint)
+		if err != nil {
+			// Proceed without protection rather than blocking payment.
+			xhttp.LoggerFrom(req.Context(), m.logger).
+				Error("Idempotency store unavailable", zap.Error(err))
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		if !ok {
+			switch {
+			case !existing.Matches(fingerprint):
+				_ = render.New(w).Unprocessable(&render.ValidationError{
+					Message: Header + " is already used by a different request",
+					Field:   "idempotencyKey",
+					Code:    render.CodeAlreadyExists,
+				})
+
+			case existing.State == StateProcessing:
+				_ = render.New(w).HandleError(render.NewResponseError(
+					http.StatusConflict,
+					"A request with the same "+Header+" is in progress"))
+
+			default:
+				existing.Replay(w)
+			}
+			return
+		}
+
+		rec := &recorder{
+			ResponseWriter: w,
+			status:         http.StatusOK,
+		}
+		next.ServeHTTP(rec, req)
+
+		// Client disconnect should not prevent saving result.
+		ctx := context.Background()
+		if rec.status >= http.StatusInternalServerError {
+			err = m.store.Release(ctx, storeKey)
+		} else {
+			err = m.store.Save(ctx, storeKey, Record{
+				State:       StateDone,
+				Fingerprint: fingerprint,
+				StatusCode:  rec.status,
+				ContentType: w.Header().Get("Content-Type"),
+				Body:        rec.body.Bytes(),
+			})
+		}
+		if err != nil {
+			xhttp.LoggerFrom(req.Context(), m.logger).
+				Error("Idempotency store error", zap.Error(err))
+		}
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// storeKey scopes the key by user, or by access token for
+// requests without user, so that clients cannot collide.
+func storeKey(req *http.Request, key string) string {
+	owner := ""
+	if u, ok := ids.UserIDsFromContext(req.Context()); ok {
+		owner = u.GetCompoundID()
+	} else if id := xhttp.GetFtcID(req.Header); id != "" {
+		owner = id
+	} else if id := xhttp.GetUnionID(req.Header); id != "" {
+		owner = id
+	} else {
+		owner, _ = xhttp.GetAccessToken(req)
+	}
+
+	return "idempotency:" + Fingerprint(req, []byte(owner))[:16] + ":" + key
+}
+
+// recorder copies response body while writing to client.
+type recorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
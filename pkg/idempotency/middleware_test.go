@@ -0,0 +1,73 @@
+package idempotency
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/wxpay/app", nil)
+
+	a := Fingerprint(req, []byte(`{"priceId":"a"}`))
+	b := Fingerprint(req, []byte(`{"priceId":"b"}`))
+
+	if a == b {
+		t.Error("different body should have different fingerprint")
+	}
+
+	if a != Fingerprint(req, []byte(`{"priceId":"a"}`)) {
+		t.Error("same body should have same fingerprint")
+	}
+
+	live := httptest.NewRequest(http.MethodPost, "/wxpay/app?live=false", nil)
+	if a == Fingerprint(live, []byte(`{"priceId":"a"}`)) {
+		t.Error("different query should have different fingerprint")
+	}
+}
+
+func TestStoreKey(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/wxpay/app", nil)
+	req.Header.Set("X-User-Id", "ftc-a")
+	a := storeKey(req, "k")
+
+	req.Header.Set("X-User-Id", "ftc-b")
+	b := storeKey(req, "k")
+
+	if a == b {
+		t.Error("same key of different users should not collide")
+	}
+
+	if !strings.HasSuffix(a, ":k") {
+		t.Errorf("got %s", a)
+	}
+}
+
+func TestRecord_Replay(t *testing.T) {
+	w := httptest.NewRecorder()
+	rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+	rec.Header().Set("Content-Type", "application/json")
+	rec.WriteHeader(http.StatusCreated)
+	_, _ = rec.Write([]byte(`{"id":"order"}`))
+
+	r := Record{
+		State:       StateDone,
+		StatusCode:  rec.status,
+		ContentType: w.Header().Get("Content-Type"),
+		Body:        rec.body.Bytes(),
+	}
+
+	replayed := httptest.NewRecorder()
+	r.Replay(replayed)
+
+	if replayed.Code != http.StatusCreated {
+		t.Errorf("status = %d", replayed.Code)
+	}
+	if replayed.Body.String() != w.Body.String() {
+		t.Errorf("body = %s", replayed.Body.String())
+	}
+	if replayed.Header().Get(HeaderReplayed) != "true" {
+		t.Error("missing replayed header")
+	}
+}
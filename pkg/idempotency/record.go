@@ -0,0 +1,58 @@
+// Package idempotency makes retries of non-idempotent
+// requests safe by replaying the response of the first one.
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+)
+
+// Header is sent by clients to identify retries of the same
+// request. Use a UUID generated once per user action.
+const Header = "Idempotency-Key"
+
+// HeaderReplayed is set on responses replayed from store.
+const HeaderReplayed = "Idempotent-Replayed"
+
+type State string
+
+const (
+	StateProcessing State = "processing"
+	StateDone       State = "done"
+)
+
+// Record is the outcome of the first request of a key.
+type Record struct {
+	State       State  `json:"state"`
+	Fingerprint string `json:"fingerprint"`
+	StatusCode  int    `json:"statusCode"`
+	ContentType string `json:"contentType"`
+	Body        []byte `json:"body"`
+}
+
+// Fingerprint identifies the content of a request so that a
+// key reused on a different request could be detected.
+func Fingerprint(req *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(req.Method))
+	h.Write([]byte(req.URL.Path))
+	h.Write([]byte(req.URL.RawQuery))
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (r Record) Matches(fingerprint string) bool {
+	return r.Fingerprint == fingerprint
+}
+
+// Replay writes stored response.
+func (r Record) Replay(w http.ResponseWriter) {
+	if r.ContentType != "" {
+		w.Header().Set("Content-Type", r.ContentType)
+	}
+	w.Header().Set(HeaderReplayed, "true")
+	w.WriteHeader(r.StatusCode)
+	_, _ = w.Write(r.Body)
+}
@@ -0,0 +1,78 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type Store struct {
+	rdb *redis.Client
+	// How long a response is kept for replay.
+	ttl time.Duration
+	// How long a key is locked while the first request is
+	// being processed.
+	lockTTL time.Duration
+}
+
+func NewStore(rdb *redis.Client, ttl time.Duration, lockTTL time.Duration) Store {
+	return Store{
+		rdb:     rdb,
+		ttl:     ttl,
+		lockTTL: lockTTL,
+	}
+}
+
+// Acquire claims a key for a new request. If the key already
+// exists, false is returned together with the existing record.
+func (s Store) Acquire(ctx context.Context, key string, fingerprint string) (bool, Record, error) {
+	b, err := json.Marshal(Record{
+		State:       StateProcessing,
+		Fingerprint: fingerprint,
+	})
+	if err != nil {
+		return false, Record{}, err
+	}
+
+	ok, err := s.rdb.SetNX(ctx, key, b, s.lockTTL).Result()
+	if err != nil {
+		return false, Record{}, err
+	}
+	if ok {
+		return true, Record{}, nil
+	}
+
+	v, err := s.rdb.Get(ctx, key).Bytes()
+	if err != nil {
+		// Expired between SETNX and GET.
+		if err == redis.Nil {
+			return s.Acquire(ctx, key, fingerprint)
+		}
+		return false, Record{}, err
+	}
+
+	var r Record
+	if err := json.Unmarshal(v, &r); err != nil {
+		return false, Record{}, err
+	}
+
+	return false, r, nil
+}
+
+// Save keeps the response for replay.
+func (s Store) Save(ctx context.Context, key string, r Record) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	return s.rdb.Set(ctx, key, b, s.ttl).Err()
+}
+
+// Release removes a key so that the request could be retried,
+// e.g., after server error.
+func (s Store) Release(ctx context.Context, key string) error {
+	return s.rdb.Del(ctx, key).Err()
+}
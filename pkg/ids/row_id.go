@@ -76,3 +76,15 @@ func LegalDocID() string {
 
 	return h
 }
+
+func EnvelopeID() string {
+	return "msg_" + rand.String(12)
+}
+
+func EmailChangeID() string {
+	return "ech_" + rand.String(12)
+}
+
+func SessionID() string {
+	return "ses_" + rand.String(20)
+}
@@ -0,0 +1,33 @@
+package ids
+
+import (
+	"context"
+	"net/http"
+)
+
+type ctxKey int
+
+const keyUserIDs ctxKey = iota
+
+// WithUserIDs saves authenticated user ids in context.
+func WithUserIDs(ctx context.Context, u UserIDs) context.Context {
+	return context.WithValue(ctx, keyUserIDs, u)
+}
+
+// UserIDsFromContext retrieves user ids set by the session
+// middleware. The second value is false if the request
+// is not authenticated for a user.
+func UserIDsFromContext(ctx context.Context) (UserIDs, bool) {
+	u, ok := ctx.Value(keyUserIDs).(UserIDs)
+	return u, ok
+}
+
+// UserIDsFromRequest prefers the ids derived from session
+// token, falling back to headers.
+func UserIDsFromRequest(req *http.Request) UserIDs {
+	if u, ok := UserIDsFromContext(req.Context()); ok {
+		return u
+	}
+
+	return UserIDsFromHeader(req.Header)
+}
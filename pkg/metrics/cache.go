@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"github.com/FTChinese/subscription-api/pkg/cachestore"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var cacheOpsDesc = prometheus.NewDesc(
+	namespace+"_cache_operations_total",
+	"Cache operations by key namespace and outcome.",
+	[]string{"namespace", "outcome"},
+	nil,
+)
+
+type cacheCollector struct {
+	m *cachestore.Metrics
+}
+
+func (c cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cacheOpsDesc
+}
+
+func (c cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, v := range c.m.Snapshot() {
+		ch <- prometheus.MustNewConstMetric(
+			cacheOpsDesc,
+			prometheus.CounterValue,
+			float64(v.Value),
+			v.Namespace,
+			string(v.Outcome))
+	}
+}
+
+// RegisterCache exports the counters kept by cachestore.
+func RegisterCache(m *cachestore.Metrics) {
+	prometheus.MustRegister(cacheCollector{m: m})
+}
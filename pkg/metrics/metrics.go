@@ -0,0 +1,110 @@
+// Package metrics exports Prometheus metrics of the API
+// and pollers.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "subs"
+
+var (
+	httpDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of HTTP requests by chi route pattern.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"method", "route", "status"},
+	)
+
+	// OrdersCreated counts ftc orders by enum.PayMethod.
+	OrdersCreated = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "orders_created_total",
+			Help:      "Orders created by payment method.",
+		},
+		[]string{"pay_method"},
+	)
+
+	// OrdersConfirmed counts ftc orders confirmed by webhook,
+	// client verification or poller.
+	OrdersConfirmed = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "orders_confirmed_total",
+			Help:      "Orders confirmed by payment method.",
+		},
+		[]string{"pay_method"},
+	)
+
+	webhookEvents = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "webhook_events_total",
+			Help:      "Webhook events received by provider, event type and outcome.",
+		},
+		[]string{"provider", "type", "outcome"},
+	)
+
+	membershipChanges = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "membership_changes_total",
+			Help:      "Membership versions saved by archiver name and action.",
+		},
+		[]string{"archiver", "action"},
+	)
+
+	outboundDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "outbound",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of requests to third-party APIs.",
+			Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		},
+		[]string{"service", "operation", "outcome"},
+	)
+
+	pollerItems = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "poller",
+			Name:      "items_total",
+			Help:      "Items processed by pollers; outcome is total, succeeded or failed.",
+		},
+		[]string{"app", "outcome"},
+	)
+)
+
+// Handler serves metrics in Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// MembershipChanged counts a membership version. The archiver
+// is formatted as `name.action`, e.g., `stripe.webhook`.
+func MembershipChanged(archiver string) {
+	name, action := archiver, ""
+	for i := 0; i < len(archiver); i++ {
+		if archiver[i] == '.' {
+			name, action = archiver[:i], archiver[i+1:]
+			break
+		}
+	}
+
+	membershipChanges.WithLabelValues(name, action).Inc()
+}
+
+// PollerInc counts an item processed by a poller.
+func PollerInc(app string, outcome string) {
+	pollerItems.WithLabelValues(app, outcome).Inc()
+}
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestOperationOf(t *testing.T) {
+	tests := map[string]string{
+		"/v1/subscriptions/sub_123": "subscriptions",
+		"/pay/unifiedorder":         "pay",
+		"/":                         "/",
+		"":                          "/",
+	}
+
+	for path, want := range tests {
+		if got := operationOf(path); got != want {
+			t.Errorf("operationOf(%q) = %s, want %s", path, got, want)
+		}
+	}
+}
+
+func TestMembershipChanged(t *testing.T) {
+	MembershipChanged("stripe.webhook")
+
+	got := testutil.ToFloat64(membershipChanges.WithLabelValues("stripe", "webhook"))
+	if got != 1 {
+		t.Errorf("got %f", got)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	h := Webhook("alipay")(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		e := WebhookEventFrom(req.Context())
+		e.Type = "TRADE_SUCCESS"
+		e.Outcome = WebhookRetry
+		_, _ = w.Write([]byte("fail"))
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/webhook/alipay", nil))
+
+	got := testutil.ToFloat64(webhookEvents.WithLabelValues("alipay", "TRADE_SUCCESS", WebhookRetry))
+	if got != 1 {
+		t.Errorf("got %f", got)
+	}
+
+	h = Webhook("stripe")(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/webhook/stripe", nil))
+
+	got = testutil.ToFloat64(webhookEvents.WithLabelValues("stripe", "unknown", WebhookRejected))
+	if got != 1 {
+		t.Errorf("got %f", got)
+	}
+}
@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+func outcomeOf(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
+
+// OutboundTimer measures a call to third-party API made by
+// an SDK that does not accept custom http.Client.
+type OutboundTimer struct {
+	service   string
+	operation string
+	start     time.Time
+}
+
+func StartOutbound(service, operation string) OutboundTimer {
+	return OutboundTimer{
+		service:   service,
+		operation: operation,
+		start:     time.Now(),
+	}
+}
+
+func (t OutboundTimer) Done(err error) {
+	outboundDuration.
+		WithLabelValues(t.service, t.operation, outcomeOf(err)).
+		Observe(time.Since(t.start).Seconds())
+}
+
+// transport observes every round trip.
+type transport struct {
+	service string
+	base    http.RoundTripper
+}
+
+// Transport wraps base to measure outbound requests.
+// If service is empty, the host name is used.
+// Operation is the method and the first path segment after
+// any version prefix, e.g., `GET subscriptions` for Stripe's
+// /v1/subscriptions/sub_xxx.
+func Transport(service string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return transport{
+		service: service,
+		base:    base,
+	}
+}
+
+func (t transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	service := t.service
+	if service == "" {
+		service = req.URL.Hostname()
+	}
+
+	timer := StartOutbound(service, req.Method+" "+operationOf(req.URL.Path))
+	resp, err := t.base.RoundTrip(req)
+	if err == nil && resp.StatusCode >= http.StatusInternalServerError {
+		timer.Done(errServerError)
+	} else {
+		timer.Done(err)
+	}
+
+	return resp, err
+}
+
+type outboundError string
+
+func (e outboundError) Error() string { return string(e) }
+
+const errServerError = outboundError("server error")
+
+func operationOf(path string) string {
+	for _, seg := range strings.Split(path, "/") {
+		if seg == "" || isVersion(seg) {
+			continue
+		}
+		return seg
+	}
+
+	return "/"
+}
+
+func isVersion(seg string) bool {
+	return len(seg) > 1 && seg[0] == 'v' && seg[1] >= '0' && seg[1] <= '9'
+}
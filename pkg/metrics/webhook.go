@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Outcomes of a webhook event.
+const (
+	WebhookOK       = "ok"       // Accepted.
+	WebhookRetry    = "retry"    // Asked provider to resend.
+	WebhookRejected = "rejected" // Invalid payload or signature.
+)
+
+// WebhookEvent is filled by webhook handlers since only they
+// know the event type. Providers like Alipay and Wechat
+// always get 200, so the handler should also set Outcome
+// when asking for retry.
+type WebhookEvent struct {
+	Type    string
+	Outcome string
+}
+
+type ctxKey int
+
+const keyWebhookEvent ctxKey = iota
+
+// WebhookEventFrom never returns nil.
+func WebhookEventFrom(ctx context.Context) *WebhookEvent {
+	if e, ok := ctx.Value(keyWebhookEvent).(*WebhookEvent); ok {
+		return e
+	}
+
+	return &WebhookEvent{}
+}
+
+// Webhook counts events received from a provider. If handler
+// did not set the outcome, it is derived from status code.
+func Webhook(provider string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, req *http.Request) {
+			e := &WebhookEvent{}
+			req = req.WithContext(context.WithValue(req.Context(), keyWebhookEvent, e))
+			ww := middleware.NewWrapResponseWriter(w, req.ProtoMajor)
+
+			next.ServeHTTP(ww, req)
+
+			if e.Type == "" {
+				e.Type = "unknown"
+			}
+			if e.Outcome == "" {
+				switch status := ww.Status(); {
+				case status >= http.StatusInternalServerError:
+					e.Outcome = WebhookRetry
+				case status >= http.StatusBadRequest:
+					e.Outcome = WebhookRejected
+				default:
+					e.Outcome = WebhookOK
+				}
+			}
+
+			webhookEvents.WithLabelValues(provider, e.Type, e.Outcome).Inc()
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
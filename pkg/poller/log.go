@@ -2,6 +2,7 @@ package poller
 
 import (
 	"github.com/FTChinese/go-rest/chrono"
+	"github.com/FTChinese/subscription-api/pkg/metrics"
 	"sync"
 )
 
@@ -42,16 +43,19 @@ func (p *Log) IncTotal() {
 	p.mux.Lock()
 	p.Total++
 	p.mux.Unlock()
+	metrics.PollerInc(string(p.AppName), "total")
 }
 
 func (p *Log) IncSuccess() {
 	p.mux.Lock()
 	p.Succeeded++
 	p.mux.Unlock()
+	metrics.PollerInc(string(p.AppName), "succeeded")
 }
 
 func (p *Log) IncFailure() {
 	p.mux.Lock()
 	p.Failed++
 	p.mux.Unlock()
+	metrics.PollerInc(string(p.AppName), "failed")
 }
@@ -0,0 +1,142 @@
+package postman
+
+import (
+	"time"
+
+	"github.com/FTChinese/go-rest/chrono"
+	"github.com/FTChinese/subscription-api/pkg/ids"
+	"github.com/google/uuid"
+	"github.com/guregu/null"
+)
+
+// DeliveryStatus is the state of a parcel in the outbox.
+type DeliveryStatus string
+
+const (
+	DeliveryPending  DeliveryStatus = "pending"  // Waiting for the first attempt.
+	DeliverySending  DeliveryStatus = "sending"  // Claimed by a sender.
+	DeliveryRetrying DeliveryStatus = "retrying" // Last attempt failed; scheduled to retry.
+	DeliverySent     DeliveryStatus = "sent"
+	DeliveryDead     DeliveryStatus = "dead" // Gave up after MaxAttempts.
+)
+
+const (
+	defaultMaxAttempts = 8
+	baseBackoff        = time.Minute
+	maxBackoff         = 6 * time.Hour
+)
+
+// Backoff calculates the delay before the next attempt
+// after n failed attempts: 1m, 2m, 4m... capped at 6 hours.
+func Backoff(n int64) time.Duration {
+	if n < 1 {
+		return 0
+	}
+
+	d := baseBackoff
+	for i := int64(1); i < n; i++ {
+		d *= 2
+		if d >= maxBackoff {
+			return maxBackoff
+		}
+	}
+
+	return d
+}
+
+// Envelope is a row in the outbox. A parcel is enqueued
+// as an envelope and delivered later by a sender so that
+// emails survive transient failures of the transport.
+type Envelope struct {
+	ID    string      `json:"id" db:"id"`
+	FtcID null.String `json:"ftcId" db:"ftc_id"`
+	Parcel
+	Status         DeliveryStatus `json:"status" db:"status"`
+	Attempts       int64          `json:"attempts" db:"attempts"`
+	MaxAttempts    int64          `json:"maxAttempts" db:"max_attempts"`
+	LastError      null.String    `json:"lastError" db:"last_error"`
+	Transport      null.String    `json:"transport" db:"transport"`
+	ResentFrom     null.String    `json:"resentFrom" db:"resent_from"`
+	ClaimToken     null.String    `json:"-" db:"claim_token"` // Identifies the latest claim.
+	NextAttemptUTC chrono.Time    `json:"nextAttemptUtc" db:"next_attempt_utc"`
+	SentUTC        chrono.Time    `json:"sentUtc" db:"sent_utc"`
+	CreatedUTC     chrono.Time    `json:"createdUtc" db:"created_utc"`
+	UpdatedUTC     chrono.Time    `json:"updatedUtc" db:"updated_utc"`
+}
+
+// NewEnvelope puts a parcel into an envelope ready to be sent.
+func NewEnvelope(p Parcel) Envelope {
+	now := chrono.TimeNow()
+
+	return Envelope{
+		ID:             ids.EnvelopeID(),
+		Parcel:         p,
+		Status:         DeliveryPending,
+		Attempts:       0,
+		MaxAttempts:    defaultMaxAttempts,
+		NextAttemptUTC: now,
+		CreatedUTC:     now,
+		UpdatedUTC:     now,
+	}
+}
+
+// WithFtcID links the envelope to a user so that CMS could
+// list all messages sent to the user.
+func (e Envelope) WithFtcID(id string) Envelope {
+	e.FtcID = null.NewString(id, id != "")
+	return e
+}
+
+// Claimed takes an envelope for sending under a new token,
+// which fences off any sender holding an earlier claim.
+func (e Envelope) Claimed() Envelope {
+	e.Status = DeliverySending
+	e.ClaimToken = null.StringFrom(uuid.New().String())
+
+	return e
+}
+
+// Delivered marks an envelope as successfully sent.
+func (e Envelope) Delivered(transport string) Envelope {
+	now := chrono.TimeNow()
+
+	e.Status = DeliverySent
+	e.Attempts++
+	e.LastError = null.String{}
+	e.Transport = null.StringFrom(transport)
+	e.SentUTC = now
+	e.UpdatedUTC = now
+
+	return e
+}
+
+// Failed records a failed attempt and schedules the next one,
+// or moves the envelope to dead letters if attempts are exhausted.
+func (e Envelope) Failed(transport string, err error) Envelope {
+	now := chrono.TimeNow()
+
+	e.Attempts++
+	e.LastError = null.StringFrom(err.Error())
+	e.Transport = null.StringFrom(transport)
+	e.UpdatedUTC = now
+
+	if e.Attempts >= e.MaxAttempts {
+		e.Status = DeliveryDead
+		return e
+	}
+
+	e.Status = DeliveryRetrying
+	e.NextAttemptUTC = chrono.TimeFrom(now.Add(Backoff(e.Attempts)))
+
+	return e
+}
+
+// Resend creates a new envelope carrying the same parcel.
+// The original one is kept intact as history.
+func (e Envelope) Resend() Envelope {
+	n := NewEnvelope(e.Parcel)
+	n.FtcID = e.FtcID
+	n.ResentFrom = null.StringFrom(e.ID)
+
+	return n
+}
@@ -0,0 +1,110 @@
+package postman
+
+const StmtEnqueue = `
+INSERT INTO user_db.mail_outbox
+SET id = :id,
+	ftc_id = :ftc_id,
+	from_address = :from_address,
+	from_name = :from_name,
+	to_address = :to_address,
+	to_name = :to_name,
+	subject = :subject,
+	body = :body,
+	status = :status,
+	attempts = :attempts,
+	max_attempts = :max_attempts,
+	resent_from = :resent_from,
+	next_attempt_utc = :next_attempt_utc,
+	created_utc = :created_utc,
+	updated_utc = :updated_utc`
+
+const colsEnvelope = `
+SELECT id,
+	ftc_id,
+	from_address,
+	from_name,
+	to_address,
+	to_name,
+	subject,
+	body,
+	status,
+	attempts,
+	max_attempts,
+	last_error,
+	transport,
+	resent_from,
+	claim_token,
+	next_attempt_utc,
+	sent_utc,
+	created_utc,
+	updated_utc
+FROM user_db.mail_outbox`
+
+const StmtRetrieveEnvelope = colsEnvelope + `
+WHERE id = ?
+LIMIT 1`
+
+// StmtListDueEnvelopes selects envelopes ready to be sent.
+// Rows stuck in sending state for a long time are assumed
+// to be abandoned by a crashed sender and picked up again.
+const StmtListDueEnvelopes = colsEnvelope + `
+WHERE (
+		status IN ('pending', 'retrying')
+		AND next_attempt_utc <= UTC_TIMESTAMP()
+	) OR (
+		status = 'sending'
+		AND updated_utc < DATE_SUB(UTC_TIMESTAMP(), INTERVAL 10 MINUTE)
+	)
+ORDER BY next_attempt_utc ASC
+LIMIT ?`
+
+// StmtClaimEnvelope flags an envelope as being sent under
+// a new claim token.
+// It only affects a row still in the state and holding the
+// token it was listed with, so checking affected rows avoids
+// sending twice when multiple instances share the same outbox.
+// A row marked sent in the meantime is never claimed again.
+const StmtClaimEnvelope = `
+UPDATE user_db.mail_outbox
+SET status = 'sending',
+	claim_token = ?,
+	updated_utc = UTC_TIMESTAMP()
+WHERE id = ?
+	AND status = ?
+	AND claim_token <=> ?
+LIMIT 1`
+
+// StmtUpdateDelivery saves the result of an attempt.
+// A failure is only saved by the sender holding the latest
+// claim so that a sender presumed crashed cannot reschedule
+// an envelope taken over by another one. A successful
+// delivery is always saved so that the row is not sent
+// again once it is picked up as abandoned.
+const StmtUpdateDelivery = `
+UPDATE user_db.mail_outbox
+SET status = :status,
+	attempts = :attempts,
+	last_error = :last_error,
+	transport = :transport,
+	next_attempt_utc = :next_attempt_utc,
+	sent_utc = :sent_utc,
+	updated_utc = :updated_utc
+WHERE id = :id
+	AND (
+		(status = 'sending' AND claim_token = :claim_token)
+		OR :status = 'sent'
+	)
+LIMIT 1`
+
+const whereRecipient = `
+WHERE ftc_id = ? OR to_address = ?`
+
+const StmtListEnvelopes = colsEnvelope +
+	whereRecipient + `
+ORDER BY created_utc DESC
+LIMIT ? OFFSET ?`
+
+const StmtCountEnvelopes = `
+SELECT COUNT(*) AS row_count
+FROM user_db.mail_outbox` +
+	whereRecipient
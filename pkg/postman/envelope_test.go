@@ -0,0 +1,111 @@
+package postman
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int64
+		want time.Duration
+	}{
+		{
+			name: "No attempt",
+			n:    0,
+			want: 0,
+		},
+		{
+			name: "First failure",
+			n:    1,
+			want: time.Minute,
+		},
+		{
+			name: "Third failure",
+			n:    3,
+			want: 4 * time.Minute,
+		},
+		{
+			name: "Capped",
+			n:    20,
+			want: 6 * time.Hour,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Backoff(tt.n); got != tt.want {
+				t.Errorf("Backoff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvelope_Failed(t *testing.T) {
+	e := NewEnvelope(Parcel{
+		ToAddress: "test@example.org",
+		Subject:   "Test",
+	})
+
+	e = e.Failed("smtp", errors.New("connection refused"))
+
+	if e.Status != DeliveryRetrying {
+		t.Errorf("Status = %s, want %s", e.Status, DeliveryRetrying)
+	}
+
+	if !e.NextAttemptUTC.After(e.CreatedUTC.Time) {
+		t.Errorf("next attempt should be scheduled after creation")
+	}
+
+	for e.Status == DeliveryRetrying {
+		e = e.Failed("smtp", errors.New("connection refused"))
+	}
+
+	if e.Status != DeliveryDead {
+		t.Errorf("Status = %s, want %s", e.Status, DeliveryDead)
+	}
+
+	if e.Attempts != e.MaxAttempts {
+		t.Errorf("Attempts = %d, want %d", e.Attempts, e.MaxAttempts)
+	}
+}
+
+func TestEnvelope_Claimed(t *testing.T) {
+	e := NewEnvelope(Parcel{
+		ToAddress: "test@example.org",
+	})
+
+	a := e.Claimed()
+	if a.Status != DeliverySending {
+		t.Errorf("Status = %s, want %s", a.Status, DeliverySending)
+	}
+
+	if !a.ClaimToken.Valid {
+		t.Error("claimed envelope should have a token")
+	}
+
+	if b := a.Claimed(); b.ClaimToken == a.ClaimToken {
+		t.Error("each claim should have a new token")
+	}
+}
+
+func TestEnvelope_Resend(t *testing.T) {
+	e := NewEnvelope(Parcel{
+		ToAddress: "test@example.org",
+	}).WithFtcID("abc").Delivered("smtp")
+
+	got := e.Resend()
+
+	if got.ID == e.ID {
+		t.Error("resent envelope should have a new id")
+	}
+
+	if got.ResentFrom.String != e.ID {
+		t.Errorf("ResentFrom = %s, want %s", got.ResentFrom.String, e.ID)
+	}
+
+	if got.Status != DeliveryPending || got.Attempts != 0 {
+		t.Errorf("resent envelope should be pending")
+	}
+}
@@ -0,0 +1,71 @@
+package postman
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileSink writes each parcel as a JSON file under a directory
+// instead of sending it. Used in development and tests.
+type FileSink struct {
+	dir string
+}
+
+func NewFileSink(dir string) (FileSink, error) {
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "subs-api-mails")
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return FileSink{}, err
+	}
+
+	return FileSink{dir: dir}, nil
+}
+
+func (s FileSink) Name() string {
+	return "file"
+}
+
+func (s FileSink) Deliver(p Parcel) error {
+	b, err := json.MarshalIndent(p, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d_%s.json", time.Now().UnixNano(), p.ToAddress)
+
+	return os.WriteFile(filepath.Join(s.dir, name), b, 0644)
+}
+
+// Delivered reads back all parcels written to the sink,
+// ordered by delivery time.
+func (s FileSink) Delivered() ([]Parcel, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	parcels := make([]Parcel, 0)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		b, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		var p Parcel
+		if err := json.Unmarshal(b, &p); err != nil {
+			return nil, err
+		}
+		parcels = append(parcels, p)
+	}
+
+	return parcels, nil
+}
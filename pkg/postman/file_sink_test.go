@@ -0,0 +1,34 @@
+package postman
+
+import (
+	"testing"
+)
+
+func TestFileSink_Deliver(t *testing.T) {
+	sink, err := NewFileSink(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := Parcel{
+		FromAddress: "no-reply@ftchinese.com",
+		FromName:    "FT中文网",
+		ToAddress:   "test@example.org",
+		ToName:      "Test",
+		Subject:     "Test",
+		Body:        "Hello",
+	}
+
+	if err := sink.Deliver(p); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := sink.Delivered()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 1 || got[0] != p {
+		t.Errorf("Delivered() = %v, want %v", got, p)
+	}
+}
@@ -0,0 +1,67 @@
+package postman
+
+import (
+	"fmt"
+
+	"github.com/FTChinese/subscription-api/lib/fetch"
+)
+
+type httpAddress struct {
+	Address string `json:"address"`
+	Name    string `json:"name"`
+}
+
+// httpMessage is the request body sent to the mail API.
+type httpMessage struct {
+	From    httpAddress `json:"from"`
+	To      httpAddress `json:"to"`
+	Subject string      `json:"subject"`
+	Text    string      `json:"text"`
+}
+
+// HTTPTransport delivers parcels by posting JSON to a
+// transactional email provider.
+type HTTPTransport struct {
+	url string
+	key string
+}
+
+func NewHTTPTransport(url, key string) HTTPTransport {
+	return HTTPTransport{
+		url: url,
+		key: key,
+	}
+}
+
+func (t HTTPTransport) Name() string {
+	return "http"
+}
+
+func (t HTTPTransport) Deliver(p Parcel) error {
+	resp, errs := fetch.New().
+		Post(t.url).
+		SetBearerAuth(t.key).
+		SendJSON(httpMessage{
+			From: httpAddress{
+				Address: p.FromAddress,
+				Name:    p.FromName,
+			},
+			To: httpAddress{
+				Address: p.ToAddress,
+				Name:    p.ToName,
+			},
+			Subject: p.Subject,
+			Text:    p.Body,
+		}).
+		EndBlob()
+
+	if errs != nil {
+		return errs[0]
+	}
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("mail api responded %s: %s", resp.Status, resp.Body)
+	}
+
+	return nil
+}
@@ -1,10 +1,10 @@
 package postman
 
 type Parcel struct {
-	FromAddress string
-	FromName    string
-	ToAddress   string
-	ToName      string
-	Subject     string
-	Body        string
+	FromAddress string `json:"fromAddress" db:"from_address"`
+	FromName    string `json:"fromName" db:"from_name"`
+	ToAddress   string `json:"toAddress" db:"to_address"`
+	ToName      string `json:"toName" db:"to_name"`
+	Subject     string `json:"subject" db:"subject"`
+	Body        string `json:"body" db:"body"`
 }
@@ -5,7 +5,7 @@ import (
 	"github.com/go-mail/mail"
 )
 
-// Postman wraps mail.Dialer.
+// Postman wraps mail.Dialer to deliver parcels via SMTP.
 type Postman struct {
 	dialer *mail.Dialer
 }
@@ -17,6 +17,10 @@ func New(c config.Connect) Postman {
 	}
 }
 
+func (pm Postman) Name() string {
+	return "smtp"
+}
+
 // Deliver asks the postman to deliver a parcel.
 func (pm Postman) Deliver(p Parcel) error {
 	m := mail.NewMessage()
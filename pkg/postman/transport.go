@@ -0,0 +1,41 @@
+package postman
+
+import (
+	"fmt"
+
+	"github.com/FTChinese/subscription-api/pkg/config"
+)
+
+// Transport delivers a parcel to its recipient.
+// Postman delivers via SMTP, HTTPTransport via a transactional
+// email provider's REST API, and FileSink writes parcels to
+// local disk so that tests never hit a real mail server.
+type Transport interface {
+	Deliver(p Parcel) error
+	Name() string
+}
+
+// NewTransport creates a Transport as specified by configuration.
+func NewTransport(c config.MailTransport) (Transport, error) {
+	switch c.Kind {
+	case config.MailTransportSMTP, "":
+		return New(config.MustGetHanqiConn()), nil
+
+	case config.MailTransportHTTP:
+		return NewHTTPTransport(c.URL, c.Key), nil
+
+	case config.MailTransportFile:
+		return NewFileSink(c.Dir)
+	}
+
+	return nil, fmt.Errorf("unknown mail transport %s", c.Kind)
+}
+
+func MustNewTransport(c config.MailTransport) Transport {
+	t, err := NewTransport(c)
+	if err != nil {
+		panic(err)
+	}
+
+	return t
+}
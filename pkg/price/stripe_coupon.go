@@ -7,16 +7,23 @@ import (
 	"github.com/FTChinese/subscription-api/lib/validator"
 	"github.com/guregu/null"
 	"github.com/stripe/stripe-go/v72"
+	"math"
+	"strings"
 	"time"
 )
 
+// StripeCouponMeta is kept in a coupon's metadata.
+// Kind is set to retention for coupons reserved for users
+// canceling a subscription, which are never shown on paywall.
 type StripeCouponMeta struct {
 	PriceID null.String `json:"priceId" db:"price_id"`
+	Kind    OfferKind   `json:"kind" db:"offer_kind"`
 	dt.TimeSlot
 }
 
 func ParseStripeCouponMeta(m map[string]string) StripeCouponMeta {
 	priceId := m["price_id"]
+	kind := m["kind"]
 	start := m["start_utc"]
 	end := m["end_utc"]
 
@@ -31,6 +38,7 @@ func ParseStripeCouponMeta(m map[string]string) StripeCouponMeta {
 
 	return StripeCouponMeta{
 		PriceID: null.NewString(priceId, priceId != ""),
+		Kind:    OfferKind(kind),
 		TimeSlot: dt.TimeSlot{
 			StartUTC: chrono.TimeFrom(startTime),
 			EndUTC:   chrono.TimeFrom(endTime),
@@ -45,6 +53,7 @@ func (p StripeCouponMeta) Validate() *render.ValidationError {
 func (p StripeCouponMeta) ToMap() map[string]string {
 	return map[string]string{
 		"price_id":  p.PriceID.String,
+		"kind":      string(p.Kind),
 		"start_utc": p.StartUTC.Format(time.RFC3339),
 		"end_utc":   p.EndUTC.Format(time.RFC3339),
 	}
@@ -71,6 +80,7 @@ type StripeCoupon struct {
 	IsFromStripe bool        `json:"-"`
 	ID           string      `json:"id" db:"id"`
 	AmountOff    int64       `json:"amountOff" db:"amount_off"`
+	PercentOff   float64     `json:"percentOff" db:"percent_off"`
 	Created      int64       `json:"created" db:"created"`
 	Currency     string      `json:"currency" db:"currency"`
 	Duration     null.String `json:"duration" db:"duration"`
@@ -96,6 +106,7 @@ func NewStripeCoupon(c *stripe.Coupon) StripeCoupon {
 		IsFromStripe:     true,
 		ID:               c.ID,
 		AmountOff:        c.AmountOff,
+		PercentOff:       c.PercentOff,
 		Created:          c.Created,
 		Currency:         string(c.Currency),
 		Duration:         null.NewString(string(c.Duration), c.Duration != ""),
@@ -121,7 +132,7 @@ func (c StripeCoupon) IsValid() bool {
 		return false
 	}
 
-	if c.AmountOff <= 0 {
+	if c.AmountOff <= 0 && c.PercentOff <= 0 {
 		return false
 	}
 
@@ -132,6 +143,31 @@ func (c StripeCoupon) IsValid() bool {
 	return c.NowIn()
 }
 
+// IsRetention tells whether the coupon is reserved for
+// retention offers.
+func (c StripeCoupon) IsRetention() bool {
+	return c.Kind == OfferKindRetention
+}
+
+// DiscountOf calculates the actual amount deducted from a
+// price. An amount-off coupon in another currency does not
+// apply at all.
+func (c StripeCoupon) DiscountOf(p StripePrice) int64 {
+	if c.AmountOff > 0 {
+		if !strings.EqualFold(c.Currency, string(p.Currency)) {
+			return 0
+		}
+
+		if c.AmountOff > p.UnitAmount {
+			return p.UnitAmount
+		}
+
+		return c.AmountOff
+	}
+
+	return int64(math.Round(float64(p.UnitAmount) * c.PercentOff / 100))
+}
+
 func (c StripeCoupon) Activate() StripeCoupon {
 	c.Status = DiscountStatusActive
 	c.UpdatedUTC = chrono.TimeNow()
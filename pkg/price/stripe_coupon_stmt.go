@@ -2,6 +2,7 @@ package price
 
 const colInsertCoupon = `
 amount_off = :amount_off,
+percent_off = :percent_off,
 created = :created,
 currency = :currency,
 duration = :duration,
@@ -9,6 +10,7 @@ end_utc = :end_utc,
 live_mode = :live_mode,
 display_name = :name,
 price_id = :price_id,
+offer_kind = :offer_kind,
 redeem_by = :redeem_by,
 start_utc = :start_utc,
 current_status = :status,
@@ -25,6 +27,7 @@ ON DUPLICATE KEY UPDATE
 const colSelectCoupon = `
 SELECT c.id,
 	c.amount_off,
+	c.percent_off,
 	c.created,
 	c.currency,
 	c.duration,
@@ -32,6 +35,7 @@ SELECT c.id,
 	c.live_mode,
 	c.display_name AS name,
 	c.price_id,
+	c.offer_kind,
 	c.start_utc,
 	c.current_status AS status,
 	c.updated_utc
@@ -56,14 +60,25 @@ ORDER BY c.updated_utc DESC
 // StmtPriceActiveCoupons retrieve all active coupons
 // of a price, regardless of whether the price is
 // active on paywall or not.
+// Retention coupons are excluded.
 // Used by user-facing apps.
 const StmtPriceActiveCoupons = colSelectCoupon + `
 WHERE c.price_id = ?
 	AND c.live_mode = ?
 	AND c.current_status = 'active'
+	AND (c.offer_kind IS NULL OR c.offer_kind != 'retention')
 ORDER BY c.amount_off DESC
 `
 
+// StmtPriceRetentionCoupons retrieves active coupons of a
+// price reserved for users canceling a subscription.
+const StmtPriceRetentionCoupons = colSelectCoupon + `
+WHERE c.price_id = ?
+	AND c.live_mode = ?
+	AND c.current_status = 'active'
+	AND c.offer_kind = 'retention'
+`
+
 // StmtPaywallStripeCoupons retrieves all coupons
 // of prices that are currently present on paywall.
 // It is achieved by LEFT JOIN product_active_price,
@@ -75,6 +90,7 @@ LEFT JOIN subs_product.product_active_price AS a
 WHERE c.live_mode = ?
 	AND a.source = 'stripe'
 	AND c.current_status = 'active'
+	AND (c.offer_kind IS NULL OR c.offer_kind != 'retention')
 	AND (c.end_utc IS NULL OR c.end_utc >= UTC_TIMESTAMP())
 ORDER BY c.amount_off DESC
 `
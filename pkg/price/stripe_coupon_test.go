@@ -40,3 +40,28 @@ func TestStripeCoupon_IsValid(t *testing.T) {
 		})
 	}
 }
+
+func TestStripeCoupon_DiscountOf(t *testing.T) {
+	p := StripePrice{
+		Currency:   "gbp",
+		UnitAmount: 3900,
+	}
+
+	tests := []struct {
+		name   string
+		coupon StripeCoupon
+		want   int64
+	}{
+		{"amount off", StripeCoupon{AmountOff: 500, Currency: "gbp"}, 500},
+		{"amount off exceeds price", StripeCoupon{AmountOff: 5000, Currency: "gbp"}, 3900},
+		{"other currency", StripeCoupon{AmountOff: 500, Currency: "usd"}, 0},
+		{"percent off", StripeCoupon{PercentOff: 20}, 780},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.coupon.DiscountOf(p); got != tt.want {
+				t.Errorf("DiscountOf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
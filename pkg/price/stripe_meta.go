@@ -11,16 +11,21 @@ import (
 	"github.com/stripe/stripe-go/v72"
 )
 
+// MaxTrialDays is the longest trial Stripe permits.
+const MaxTrialDays = 730
+
 // StripePriceMeta parsed the fields defined in stripe price's medata field.
 // Those are customer-defined key-value pairs.
 // StartUTC and EndUTC only exists when
 // Introductory is true.
+// TrialDays only applies to recurring price.
 type StripePriceMeta struct {
 	Introductory bool            `json:"introductory"` // Is it an introductory price? Co-exist with StartUTC and EndUTC.
 	PeriodCount  dt.YearMonthDay `json:"periodCount"`
-	Tier         enum.Tier       `json:"tier"`     // The tier of this price. Always exists.
-	StartUTC     chrono.Time     `json:"startUtc"` // Start time if Introductory is true; otherwise omit.
-	EndUTC       chrono.Time     `json:"endUtc"`   // End time if Introductory is true; otherwise omit.
+	Tier         enum.Tier       `json:"tier"`      // The tier of this price. Always exists.
+	StartUTC     chrono.Time     `json:"startUtc"`  // Start time if Introductory is true; otherwise omit.
+	EndUTC       chrono.Time     `json:"endUtc"`    // End time if Introductory is true; otherwise omit.
+	TrialDays    int64           `json:"trialDays"` // Free trial offered to new subscribers. 0 for no trial.
 
 	PeriodDays int64 `json:"periodDays"` // Deprecated
 }
@@ -40,9 +45,21 @@ func (m *StripePriceMeta) Validate() *render.ValidationError {
 		m.PeriodCount = dt.YearMonthDay{}
 		m.StartUTC = chrono.TimeZero()
 		m.EndUTC = chrono.TimeZero()
+
+		// Stripe permits trial up to 2 years.
+		if m.TrialDays < 0 || m.TrialDays > MaxTrialDays {
+			return &render.ValidationError{
+				Message: "trial days must be within 0 to 730",
+				Field:   "trialDays",
+				Code:    render.CodeInvalid,
+			}
+		}
 		return nil
 	}
 
+	// Introductory price itself is a kind of trial.
+	m.TrialDays = 0
+
 	// Introductory price requires start and end time.
 	if m.StartUTC.Time.IsZero() {
 		return &render.ValidationError{
@@ -135,6 +152,7 @@ func (m StripePriceMeta) ToParams() map[string]string {
 		"introductory": strconv.FormatBool(m.Introductory),
 		"start_utc":    start,
 		"end_utc":      end,
+		"trial_days":   strconv.FormatInt(m.TrialDays, 10),
 	}
 }
 
@@ -160,6 +178,7 @@ func StripePriceMetaFromFtc(p FtcPrice) StripePriceMeta {
 // - introductory: boolean
 // - start_utc?: string
 // - end_utc?: string
+// - trial_days?: number
 func ParseStripePriceMeta(m map[string]string) StripePriceMeta {
 	tier, _ := enum.ParseTier(m["tier"])
 	pd, _ := strconv.Atoi(m["period_days"])
@@ -167,6 +186,7 @@ func ParseStripePriceMeta(m map[string]string) StripePriceMeta {
 	months, _ := strconv.Atoi(m["months"])
 	days, _ := strconv.Atoi(m["days"])
 	isIntro, _ := strconv.ParseBool(m["introductory"])
+	trialDays, _ := strconv.ParseInt(m["trial_days"], 10, 64)
 	start := m["start_utc"]
 	end := m["end_utc"]
 
@@ -190,6 +210,7 @@ func ParseStripePriceMeta(m map[string]string) StripePriceMeta {
 		Introductory: isIntro,
 		StartUTC:     chrono.TimeFrom(startTime),
 		EndUTC:       chrono.TimeFrom(endTime),
+		TrialDays:    trialDays,
 	}.SyncPeriod()
 }
 
@@ -23,8 +23,9 @@ type StripePrice struct {
 	PeriodCount    ColumnYearMonthDay `json:"periodCount" db:"period_count"`
 	Tier           enum.Tier          `json:"tier" db:"tier"` // The tier of this price.
 	UnitAmount     int64              `json:"unitAmount" db:"unit_amount"`
-	StartUTC       chrono.Time        `json:"startUtc" db:"start_utc"` // Start time if Introductory is true; otherwise omit.
-	EndUTC         chrono.Time        `json:"endUtc" db:"end_utc"`     // End time if Introductory is true; otherwise omit.
+	StartUTC       chrono.Time        `json:"startUtc" db:"start_utc"`   // Start time if Introductory is true; otherwise omit.
+	EndUTC         chrono.Time        `json:"endUtc" db:"end_utc"`       // End time if Introductory is true; otherwise omit.
+	TrialDays      int64              `json:"trialDays" db:"trial_days"` // Free trial for new subscribers of a recurring price.
 	Created        int64              `json:"created" db:"created"`
 
 	Metadata  StripePriceMeta      `json:"metadata"`  // Deprecated
@@ -53,6 +54,7 @@ func NewStripePrice(p *stripe.Price) StripePrice {
 		UnitAmount:     p.UnitAmount,
 		StartUTC:       meta.StartUTC,
 		EndUTC:         meta.EndUTC,
+		TrialDays:      meta.TrialDays,
 		Created:        p.Created,
 
 		Metadata:  meta,
@@ -15,6 +15,7 @@ SELECT p.id AS id,
 	p.unit_amount AS unit_amount,
 	p.start_utc AS start_utc,
 	p.end_utc AS end_utc,
+	p.trial_days AS trial_days,
 	p.created AS created
 FROM subs_product.stripe_price AS p
 `
@@ -75,6 +76,7 @@ tier = :tier,
 unit_amount = :unit_amount,
 start_utc = :start_utc,
 end_utc = :end_utc,
+trial_days = :trial_days,
 created = :created
 `
 
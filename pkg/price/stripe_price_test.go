@@ -41,3 +41,28 @@ func TestStripePrice_uniqueFeatures(t *testing.T) {
 		})
 	}
 }
+
+func TestParseStripePriceMeta_trialDays(t *testing.T) {
+	m := ParseStripePriceMeta(map[string]string{
+		"tier":       "standard",
+		"years":      "1",
+		"trial_days": "7",
+	})
+
+	if m.TrialDays != 7 {
+		t.Errorf("TrialDays = %d, want 7", m.TrialDays)
+	}
+
+	if ve := m.Validate(); ve != nil {
+		t.Error(ve)
+	}
+
+	m.TrialDays = MaxTrialDays + 1
+	if ve := m.Validate(); ve == nil {
+		t.Error("expected trial days out of range")
+	}
+
+	if got := ParseStripePriceMeta(m.ToParams()).TrialDays; got != m.TrialDays {
+		t.Errorf("ToParams() trial_days = %d, want %d", got, m.TrialDays)
+	}
+}
@@ -0,0 +1,141 @@
+package price
+
+import (
+	"strings"
+	"time"
+
+	"github.com/FTChinese/go-rest/render"
+	"github.com/stripe/stripe-go/v72"
+)
+
+// StripePromoCodeRestrictions limits who and what a promotion
+// code could be redeemed on.
+type StripePromoCodeRestrictions struct {
+	FirstTimeTransaction  bool   `json:"firstTimeTransaction"` // Only for customers who never paid.
+	MinimumAmount         int64  `json:"minimumAmount"`
+	MinimumAmountCurrency string `json:"minimumAmountCurrency"`
+}
+
+// StripePromoCode is a customer-facing code created in
+// Stripe dashboard to redeem a coupon.
+// Unlike StripeCoupon, it is not linked to a price by CMS,
+// so it is always retrieved from Stripe API.
+type StripePromoCode struct {
+	ID             string                      `json:"id"`
+	Code           string                      `json:"code"`
+	Active         bool                        `json:"active"`
+	Coupon         StripeCoupon                `json:"coupon"`
+	PercentOff     float64                     `json:"percentOff"` // Coupon's percent off. StripeCoupon only has amount off.
+	Products       []string                    `json:"products"`   // Coupon only applies to these products if not empty.
+	CustomerID     string                      `json:"customerId"` // Only this customer could redeem it if not empty.
+	ExpiresAt      int64                       `json:"expiresAt"`
+	MaxRedemptions int64                       `json:"maxRedemptions"`
+	TimesRedeemed  int64                       `json:"timesRedeemed"`
+	Restrictions   StripePromoCodeRestrictions `json:"restrictions"`
+	LiveMode       bool                        `json:"liveMode"`
+}
+
+func NewStripePromoCode(p *stripe.PromotionCode) StripePromoCode {
+	var coupon StripeCoupon
+	var percentOff float64
+	var products []string
+	if p.Coupon != nil {
+		coupon = NewStripeCoupon(p.Coupon)
+		percentOff = p.Coupon.PercentOff
+		if p.Coupon.AppliesTo != nil {
+			products = p.Coupon.AppliesTo.Products
+		}
+	}
+
+	var cusID string
+	if p.Customer != nil {
+		cusID = p.Customer.ID
+	}
+
+	var r StripePromoCodeRestrictions
+	if p.Restrictions != nil {
+		r = StripePromoCodeRestrictions{
+			FirstTimeTransaction:  p.Restrictions.FirstTimeTransaction,
+			MinimumAmount:         p.Restrictions.MinimumAmount,
+			MinimumAmountCurrency: string(p.Restrictions.MinimumAmountCurrency),
+		}
+	}
+
+	return StripePromoCode{
+		ID:             p.ID,
+		Code:           p.Code,
+		Active:         p.Active,
+		Coupon:         coupon,
+		PercentOff:     percentOff,
+		Products:       products,
+		CustomerID:     cusID,
+		ExpiresAt:      p.ExpiresAt,
+		MaxRedemptions: p.MaxRedemptions,
+		TimesRedeemed:  p.TimesRedeemed,
+		Restrictions:   r,
+		LiveMode:       p.Livemode,
+	}
+}
+
+func (p StripePromoCode) IsZero() bool {
+	return p.ID == ""
+}
+
+// IsFirstTimeOnly tells whether the code is restricted to
+// customers without any successful payment.
+func (p StripePromoCode) IsFirstTimeOnly() bool {
+	return p.Restrictions.FirstTimeTransaction
+}
+
+func (p StripePromoCode) invalid(msg string) *render.ValidationError {
+	return &render.ValidationError{
+		Message: msg,
+		Field:   "promotionCode",
+		Code:    render.CodeInvalid,
+	}
+}
+
+// Validate checks restrictions that do not depend on user's
+// purchase history against the price to subscribe and the
+// customer redeeming it.
+// First-time restriction is checked when deducing checkout intent.
+func (p StripePromoCode) Validate(recurring StripePrice, cusID string) *render.ValidationError {
+	if !p.Active || p.Coupon.Status != DiscountStatusActive {
+		return p.invalid("Promotion code is no longer active")
+	}
+
+	if p.ExpiresAt > 0 && time.Now().Unix() > p.ExpiresAt {
+		return p.invalid("Promotion code is expired")
+	}
+
+	if p.MaxRedemptions > 0 && p.TimesRedeemed >= p.MaxRedemptions {
+		return p.invalid("Promotion code is fully redeemed")
+	}
+
+	if p.CustomerID != "" && p.CustomerID != cusID {
+		return p.invalid("Promotion code is not available to you")
+	}
+
+	if len(p.Products) > 0 && !containsString(p.Products, recurring.ProductID) {
+		return p.invalid("Promotion code does not apply to this price")
+	}
+
+	if p.Restrictions.MinimumAmount > 0 {
+		if !strings.EqualFold(p.Restrictions.MinimumAmountCurrency, string(recurring.Currency)) ||
+			recurring.UnitAmount < p.Restrictions.MinimumAmount {
+			return p.invalid("Price does not reach the minimum amount of promotion code")
+		}
+	}
+
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
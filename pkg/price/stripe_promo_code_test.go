@@ -0,0 +1,121 @@
+package price
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStripePromoCode_Validate(t *testing.T) {
+	recurring := StripePrice{
+		ID:         "price_standard_year",
+		Currency:   "gbp",
+		ProductID:  "prod_standard",
+		UnitAmount: 3999,
+	}
+
+	valid := StripePromoCode{
+		ID:     "promo_1",
+		Code:   "WELCOME",
+		Active: true,
+		Coupon: StripeCoupon{
+			ID:     "coupon_1",
+			Status: DiscountStatusActive,
+		},
+	}
+
+	tests := []struct {
+		name    string
+		code    func(p StripePromoCode) StripePromoCode
+		cusID   string
+		wantErr bool
+	}{
+		{
+			name:    "No restrictions",
+			code:    func(p StripePromoCode) StripePromoCode { return p },
+			wantErr: false,
+		},
+		{
+			name: "Inactive",
+			code: func(p StripePromoCode) StripePromoCode {
+				p.Active = false
+				return p
+			},
+			wantErr: true,
+		},
+		{
+			name: "Expired",
+			code: func(p StripePromoCode) StripePromoCode {
+				p.ExpiresAt = time.Now().Add(-time.Hour).Unix()
+				return p
+			},
+			wantErr: true,
+		},
+		{
+			name: "Fully redeemed",
+			code: func(p StripePromoCode) StripePromoCode {
+				p.MaxRedemptions = 10
+				p.TimesRedeemed = 10
+				return p
+			},
+			wantErr: true,
+		},
+		{
+			name: "Another customer",
+			code: func(p StripePromoCode) StripePromoCode {
+				p.CustomerID = "cus_other"
+				return p
+			},
+			cusID:   "cus_me",
+			wantErr: true,
+		},
+		{
+			name: "Another product",
+			code: func(p StripePromoCode) StripePromoCode {
+				p.Products = []string{"prod_premium"}
+				return p
+			},
+			wantErr: true,
+		},
+		{
+			name: "Below minimum amount",
+			code: func(p StripePromoCode) StripePromoCode {
+				p.Restrictions.MinimumAmount = 5000
+				p.Restrictions.MinimumAmountCurrency = "gbp"
+				return p
+			},
+			wantErr: true,
+		},
+		{
+			name: "Minimum amount in another currency",
+			code: func(p StripePromoCode) StripePromoCode {
+				p.Restrictions.MinimumAmount = 100
+				p.Restrictions.MinimumAmountCurrency = "usd"
+				return p
+			},
+			wantErr: true,
+		},
+		{
+			name: "Meets all restrictions",
+			code: func(p StripePromoCode) StripePromoCode {
+				p.ExpiresAt = time.Now().Add(time.Hour).Unix()
+				p.MaxRedemptions = 10
+				p.TimesRedeemed = 9
+				p.CustomerID = "cus_me"
+				p.Products = []string{"prod_standard"}
+				p.Restrictions.MinimumAmount = 3000
+				p.Restrictions.MinimumAmountCurrency = "GBP"
+				return p
+			},
+			cusID:   "cus_me",
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.code(valid).Validate(recurring, tt.cusID)
+			if (got != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", got, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+// Package ratelimit throttles requests across all instances
+// by counting them in Redis.
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/FTChinese/go-rest/rand"
+	"github.com/go-redis/redis/v8"
+)
+
+// slidingWindow keeps the timestamp of each request within
+// the window in a sorted set.
+// Returns allowed (1 or 0), count in window, and milliseconds
+// until the oldest request leaves the window.
+var slidingWindow = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', key, 0, now - window)
+local count = redis.call('ZCARD', key)
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', key, now, ARGV[4])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', key, window)
+
+local reset = window
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+
+return {allowed, count, reset}
+`)
+
+// Result of counting a request.
+type Result struct {
+	Allowed   bool
+	Limit     int64
+	Remaining int64
+	Reset     time.Duration // Until a slot frees up.
+}
+
+type Limiter struct {
+	rdb *redis.Client
+}
+
+func NewLimiter(rdb *redis.Client) Limiter {
+	return Limiter{
+		rdb: rdb,
+	}
+}
+
+// Allow counts a request under key and tells whether it is
+// within limit.
+func (l Limiter) Allow(ctx context.Context, key string, limit int64, window time.Duration) (Result, error) {
+	now := time.Now().UnixMilli()
+
+	v, err := slidingWindow.Run(
+		ctx,
+		l.rdb,
+		[]string{key},
+		now,
+		window.Milliseconds(),
+		limit,
+		strconv.FormatInt(now, 10)+"-"+rand.String(6),
+	).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+
+	remaining := limit - v[1]
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return Result{
+		Allowed:   v[0] == 1,
+		Limit:     limit,
+		Remaining: remaining,
+		Reset:     time.Duration(v[2]) * time.Millisecond,
+	}, nil
+}
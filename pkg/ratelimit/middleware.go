@@ -0,0 +1,217 @@
+package ratelimit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/FTChinese/go-rest/render"
+	"github.com/FTChinese/subscription-api/pkg/config"
+	"github.com/FTChinese/subscription-api/pkg/footprint"
+	"github.com/FTChinese/subscription-api/pkg/ids"
+	"github.com/FTChinese/subscription-api/pkg/xhttp"
+	"github.com/go-redis/redis/v8"
+)
+
+// Response headers as proposed by IETF draft
+// RateLimit Header Fields for HTTP.
+const (
+	HeaderLimit     = "RateLimit-Limit"
+	HeaderRemaining = "RateLimit-Remaining"
+	HeaderReset     = "RateLimit-Reset"
+)
+
+type Middleware struct {
+	limiter Limiter
+	limits  config.RateLimits
+	proxies []*net.IPNet // Trusted to forward client ip.
+}
+
+func NewMiddleware(rdb *redis.Client, limits config.RateLimits, proxies []*net.IPNet) Middleware {
+	return Middleware{
+		limiter: NewLimiter(rdb),
+		limits:  limits,
+		proxies: proxies,
+	}
+}
+
+// Limit applies the rules of a route group.
+// If Redis is unavailable requests are let through.
+func (m Middleware) Limit(group string) func(http.Handler) http.Handler {
+	rules := m.limits[group]
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, req *http.Request) {
+			var tightest *Result
+
+			for _, rule := range rules {
+				id := m.keyOf(req, rule.Key)
+				if id == "" {
+					continue
+				}
+
+				r, err := m.limiter.Allow(
+					req.Context(),
+					redisKey(group, rule, id),
+					rule.Limit,
+					rule.WindowDuration())
+				if err != nil {
+					log.Printf("Rate limit %s skipped: %s", group, err)
+					continue
+				}
+
+				if tightest == nil || !r.Allowed || r.Remaining < tightest.Remaining {
+					tightest = &r
+				}
+
+				if !r.Allowed {
+					break
+				}
+			}
+
+			if tightest == nil {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			setHeaders(w.Header(), *tightest)
+
+			if !tightest.Allowed {
+				w.Header().Set("Retry-After", w.Header().Get(HeaderReset))
+				_ = render.New(w).TooManyRequests("Too many requests. Please retry later.")
+				return
+			}
+
+			next.ServeHTTP(w, req)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+func setHeaders(h http.Header, r Result) {
+	h.Set(HeaderLimit, strconv.FormatInt(r.Limit, 10))
+	h.Set(HeaderRemaining, strconv.FormatInt(r.Remaining, 10))
+	h.Set(HeaderReset, strconv.FormatInt(int64(math.Ceil(r.Reset.Seconds())), 10))
+}
+
+// keyOf finds the value a rule counts on. Empty if the
+// request does not have it.
+func (m Middleware) keyOf(req *http.Request, k config.RateLimitKey) string {
+	switch k {
+	case config.RateLimitByToken:
+		token, err := xhttp.GetAccessToken(req)
+		if err != nil {
+			return ""
+		}
+		// Do not keep tokens in Redis.
+		return hashKey(token)
+
+	case config.RateLimitByUser:
+		// Only trust ids set by session guard. Headers could
+		// be forged to spread requests over many keys.
+		if u, ok := ids.UserIDsFromContext(req.Context()); ok {
+			return u.GetCompoundID()
+		}
+		return m.clientIP(req)
+
+	case config.RateLimitByIP:
+		return m.clientIP(req)
+
+	case config.RateLimitByCredential:
+		c := credentialOf(req)
+		if c == "" {
+			return ""
+		}
+		// Do not keep emails or mobiles in Redis.
+		return hashKey(c)
+	}
+
+	return ""
+}
+
+// clientIP uses the peer address of the connection. The ip
+// forwarded in headers is used only if the peer is a trusted
+// proxy; otherwise any client could claim any ip.
+func (m Middleware) clientIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return host
+	}
+
+	for _, n := range m.proxies {
+		if n.Contains(ip) {
+			if fwd := footprint.NewClient(req).UserIP.String; fwd != "" {
+				return fwd
+			}
+			break
+		}
+	}
+
+	return host
+}
+
+// maxCredentialBody limits how much of a request body is read
+// to find the credential. Login bodies are tiny.
+const maxCredentialBody = 8 << 10
+
+// credentialOf finds the account a request is trying to
+// authenticate as from its JSON body: an email, a mobile,
+// or a two-factor challenge. The body is restored for
+// handlers.
+func credentialOf(req *http.Request) string {
+	if req.Body == nil || req.Body == http.NoBody {
+		return ""
+	}
+
+	b, err := io.ReadAll(io.LimitReader(req.Body, maxCredentialBody))
+	if err != nil {
+		return ""
+	}
+	req.Body = io.NopCloser(io.MultiReader(bytes.NewReader(b), req.Body))
+
+	var c struct {
+		Email     string `json:"email"`
+		Mobile    string `json:"mobile"`
+		Challenge string `json:"challenge"`
+	}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return ""
+	}
+
+	switch {
+	case c.Email != "":
+		return "email:" + strings.ToLower(strings.TrimSpace(c.Email))
+	case c.Mobile != "":
+		return "mobile:" + strings.TrimSpace(c.Mobile)
+	case c.Challenge != "":
+		return "challenge:" + strings.TrimSpace(c.Challenge)
+	}
+
+	return ""
+}
+
+func hashKey(s string) string {
+	h := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(h[:8])
+}
+
+func redisKey(group string, rule config.RateLimitRule, id string) string {
+	return "ratelimit:" + group + ":" +
+		string(rule.Key) + ":" +
+		strconv.FormatInt(rule.Window, 10) + ":" +
+		id
+}
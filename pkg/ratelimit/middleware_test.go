@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FTChinese/subscription-api/pkg/config"
+	"github.com/FTChinese/subscription-api/pkg/ids"
+	"github.com/guregu/null"
+)
+
+func TestKeyOf(t *testing.T) {
+	_, proxy, _ := net.ParseCIDR("10.0.0.0/8")
+	m := Middleware{proxies: []*net.IPNet{proxy}}
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/email/login", nil)
+	req.RemoteAddr = "203.0.113.5:1234"
+	req.Header.Set("Authorization", "Bearer abc")
+	req.Header.Set("X-Client-Type", "web")
+	req.Header.Set("X-User-Ip", "198.51.100.1")
+	req.Header.Set("X-User-Id", "ftc-uuid")
+
+	if k := m.keyOf(req, config.RateLimitByIP); k != "203.0.113.5" {
+		t.Errorf("ip from untrusted peer = %s", k)
+	}
+
+	if k := m.keyOf(req, config.RateLimitByToken); k == "" || k == "abc" {
+		t.Errorf("token key = %s", k)
+	}
+
+	if k := m.keyOf(req, config.RateLimitByUser); k != "203.0.113.5" {
+		t.Errorf("user key should fall back to ip, got %s", k)
+	}
+
+	req.RemoteAddr = "10.1.2.3:1234"
+	if k := m.keyOf(req, config.RateLimitByIP); k != "198.51.100.1" {
+		t.Errorf("ip from trusted proxy = %s", k)
+	}
+
+	req = req.WithContext(ids.WithUserIDs(req.Context(), ids.UserIDs{FtcID: null.StringFrom("ftc-uuid")}))
+	if k := m.keyOf(req, config.RateLimitByUser); k != "ftc-uuid" {
+		t.Errorf("user key = %s", k)
+	}
+}
+
+func TestCredentialOf(t *testing.T) {
+	body := `{"email": " Foo@Example.org ", "password": "x"}`
+	req := httptest.NewRequest(http.MethodPost, "/auth/email/login", strings.NewReader(body))
+
+	if c := credentialOf(req); c != "email:foo@example.org" {
+		t.Errorf("credential = %s", c)
+	}
+
+	b, _ := io.ReadAll(req.Body)
+	if string(b) != body {
+		t.Errorf("body not restored: %s", b)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/auth/email/login/2fa", strings.NewReader(`{"challenge": "abc", "code": "123456"}`))
+	if c := credentialOf(req); c != "challenge:abc" {
+		t.Errorf("credential = %s", c)
+	}
+}
+
+func TestSetHeaders(t *testing.T) {
+	h := http.Header{}
+	setHeaders(h, Result{
+		Allowed:   false,
+		Limit:     20,
+		Remaining: 0,
+		Reset:     1500 * time.Millisecond,
+	})
+
+	if h.Get(HeaderLimit) != "20" || h.Get(HeaderRemaining) != "0" || h.Get(HeaderReset) != "2" {
+		t.Errorf("headers = %v", h)
+	}
+}
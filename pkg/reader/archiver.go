@@ -117,6 +117,16 @@ func (a Archiver) ActionReactivate() Archiver {
 	return a
 }
 
+func (a Archiver) ActionPause() Archiver {
+	a.action = "pause"
+	return a
+}
+
+func (a Archiver) ActionResume() Archiver {
+	a.action = "resume"
+	return a
+}
+
 func (a Archiver) ActionLink() Archiver {
 	a.action = "link"
 	return a
@@ -132,6 +142,16 @@ func (a Archiver) ActionDelete() Archiver {
 	return a
 }
 
+func (a Archiver) ActionRefund() Archiver {
+	a.action = "refund"
+	return a
+}
+
+func (a Archiver) ActionDispute() Archiver {
+	a.action = "dispute"
+	return a
+}
+
 func (a Archiver) ActionUpdate() Archiver {
 	a.action = "update"
 	return a
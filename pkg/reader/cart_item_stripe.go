@@ -1,6 +1,8 @@
 package reader
 
 import (
+	"time"
+
 	"github.com/FTChinese/go-rest/render"
 	"github.com/FTChinese/subscription-api/pkg/price"
 )
@@ -13,6 +15,8 @@ type CartItemStripe struct {
 	Recurring    price.StripePrice
 	Introductory price.StripePrice // This is optional.
 	Coupon       price.StripeCoupon
+	PromoCode    price.StripePromoCode // Resolved from the code user entered. Exclusive with Coupon.
+	TrialDays    int64                 // Free trial granted after eligibility check. Exclusive with Introductory.
 }
 
 // AnyFromStripe checks if there's any price coming from API
@@ -48,5 +52,50 @@ func (ci CartItemStripe) Validate() *render.ValidationError {
 }
 
 func (ci CartItemStripe) HasCoupon() bool {
-	return ci.Coupon.IsValid()
+	return ci.Coupon.IsValid() || !ci.PromoCode.IsZero()
+}
+
+// AppliedCoupon is the coupon redeemed, either attached to the
+// price by CMS, or via a promotion code.
+func (ci CartItemStripe) AppliedCoupon() price.StripeCoupon {
+	if !ci.PromoCode.IsZero() {
+		return ci.PromoCode.Coupon
+	}
+
+	return ci.Coupon
+}
+
+// ValidatePromoCode checks restrictions of the promotion code,
+// if any, against the price and the customer redeeming it.
+func (ci CartItemStripe) ValidatePromoCode(cusID string) *render.ValidationError {
+	if ci.PromoCode.IsZero() {
+		return nil
+	}
+
+	return ci.PromoCode.Validate(ci.Recurring, cusID)
+}
+
+// WithTrial grants the trial configured on the recurring
+// price to an eligible user.
+// Introductory price already uses a trial period to defer
+// the recurring charge, so they never stack.
+func (ci CartItemStripe) WithTrial(eligible bool) CartItemStripe {
+	if !eligible || !ci.Introductory.IsZero() {
+		ci.TrialDays = 0
+		return ci
+	}
+
+	ci.TrialDays = ci.Recurring.TrialDays
+	return ci
+}
+
+// TrialEnd calculates when the trial ends if it starts now.
+func (ci CartItemStripe) TrialEnd() time.Time {
+	return time.Now().AddDate(0, 0, int(ci.TrialDays))
+}
+
+// IsTrialOffered tells whether the recurring price has a trial
+// which might be granted to new subscribers.
+func (ci CartItemStripe) IsTrialOffered() bool {
+	return ci.Introductory.IsZero() && ci.Recurring.TrialDays > 0
 }
@@ -0,0 +1,58 @@
+package reader
+
+import (
+	"testing"
+
+	"github.com/FTChinese/subscription-api/pkg/price"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCartItemStripe_WithTrial(t *testing.T) {
+	recurring := price.MockRandomStripePrice()
+	recurring.TrialDays = 14
+
+	intro := price.MockRandomStripePrice()
+	intro.Kind = price.KindOneTime
+
+	tests := []struct {
+		name     string
+		item     CartItemStripe
+		eligible bool
+		want     int64
+	}{
+		{
+			name:     "Eligible",
+			item:     CartItemStripe{Recurring: recurring},
+			eligible: true,
+			want:     14,
+		},
+		{
+			name:     "Not eligible",
+			item:     CartItemStripe{Recurring: recurring},
+			eligible: false,
+			want:     0,
+		},
+		{
+			name: "Introductory price never stacks trial",
+			item: CartItemStripe{
+				Recurring:    recurring,
+				Introductory: intro,
+			},
+			eligible: true,
+			want:     0,
+		},
+		{
+			name:     "Price without trial",
+			item:     CartItemStripe{Recurring: price.MockRandomStripePrice()},
+			eligible: true,
+			want:     0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.item.WithTrial(tt.eligible)
+
+			assert.Equal(t, tt.want, got.TrialDays)
+		})
+	}
+}
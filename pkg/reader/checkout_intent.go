@@ -22,6 +22,15 @@ var unknownCheckout = CheckoutIntent{
 // NewCheckoutIntentStripe deduces what kind of action
 // when user is trying is subscribed via Stripe.
 func NewCheckoutIntentStripe(m Membership, item CartItemStripe) CheckoutIntent {
+	// Anyone who ever subscribed via Stripe, even if expired,
+	// has paid Stripe before.
+	if item.PromoCode.IsFirstTimeOnly() && m.PaymentMethod == enum.PayMethodStripe {
+		return CheckoutIntent{
+			Kind:  IntentForbidden,
+			Error: ErrPromoFirstTimeOnly,
+		}
+	}
+
 	if m.IsExpired() || m.IsInvalidStripe() {
 		return CheckoutIntent{
 			Kind:  IntentCreate,
@@ -66,12 +75,13 @@ func NewCheckoutIntentStripe(m Membership, item CartItemStripe) CheckoutIntent {
 		switch item.Recurring.Tier {
 		// Current standard to Premium
 		case enum.TierPremium:
-			//if m.IsTrialing() {
-			//	return CheckoutIntent{
-			//		Kind:  IntentForbidden,
-			//		Error: ErrTrialUpgradeForbidden,
-			//	}
-			//}
+			// Trial is only granted to the price subscribed.
+			if m.IsTrialing() {
+				return CheckoutIntent{
+					Kind:  IntentForbidden,
+					Error: ErrTrialUpgradeForbidden,
+				}
+			}
 			return CheckoutIntent{
 				Kind:  IntentUpgrade,
 				Error: nil,
@@ -0,0 +1,32 @@
+package reader
+
+import (
+	"testing"
+
+	"github.com/FTChinese/go-rest/enum"
+	"github.com/FTChinese/subscription-api/pkg/price"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCheckoutIntentStripe_trialing(t *testing.T) {
+	premium := price.MockRandomStripePrice()
+	premium.Tier = enum.TierPremium
+
+	item := CartItemStripe{Recurring: premium}
+
+	trialing := NewMockMemberBuilder().
+		WithStripe("").
+		WithSubsStatus(enum.SubsStatusTrialing).
+		Build()
+
+	got := NewCheckoutIntentStripe(trialing, item)
+	assert.Equal(t, IntentForbidden, got.Kind)
+	assert.Equal(t, ErrTrialUpgradeForbidden, got.Error)
+
+	active := NewMockMemberBuilder().
+		WithStripe("").
+		Build()
+
+	got = NewCheckoutIntentStripe(active, item)
+	assert.Equal(t, IntentUpgrade, got.Kind)
+}
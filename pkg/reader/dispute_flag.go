@@ -0,0 +1,68 @@
+package reader
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+
+	"github.com/FTChinese/go-rest/chrono"
+)
+
+// DisputeFlag marks a Stripe membership whose payment is
+// disputed by the card holder.
+// If Suspended, the membership expires on the day the
+// dispute is created until the dispute is won.
+type DisputeFlag struct {
+	DisputeID  string      `json:"disputeId"`
+	Suspended  bool        `json:"suspended"`
+	CreatedUTC chrono.Time `json:"createdUtc"`
+}
+
+func (f DisputeFlag) IsZero() bool {
+	return f.DisputeID == ""
+}
+
+// MarshalJSON outputs null for zero value.
+func (f DisputeFlag) MarshalJSON() ([]byte, error) {
+	if f.IsZero() {
+		return []byte("null"), nil
+	}
+
+	type alias DisputeFlag
+	return json.Marshal(alias(f))
+}
+
+// Value saves the flag as a JSON column, or NULL if it is zero.
+func (f DisputeFlag) Value() (driver.Value, error) {
+	if f.IsZero() {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
+}
+
+func (f *DisputeFlag) Scan(src interface{}) error {
+	if src == nil {
+		*f = DisputeFlag{}
+		return nil
+	}
+
+	switch s := src.(type) {
+	case []byte:
+		var tmp DisputeFlag
+		err := json.Unmarshal(s, &tmp)
+		if err != nil {
+			return err
+		}
+		*f = tmp
+		return nil
+
+	default:
+		return errors.New("incompatible type to scan to DisputeFlag")
+	}
+}
@@ -0,0 +1,56 @@
+package reader
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/FTChinese/go-rest/chrono"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisputeFlag_MarshalJSON(t *testing.T) {
+	b, err := json.Marshal(DisputeFlag{})
+	assert.NoError(t, err)
+	assert.Equal(t, "null", string(b))
+
+	v, err := DisputeFlag{}.Value()
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+
+	f := DisputeFlag{
+		DisputeID:  "dp_test",
+		Suspended:  true,
+		CreatedUTC: chrono.TimeNow(),
+	}
+
+	v, err = f.Value()
+	assert.NoError(t, err)
+
+	var got DisputeFlag
+	err = got.Scan([]byte(v.(string)))
+	assert.NoError(t, err)
+	assert.Equal(t, f.DisputeID, got.DisputeID)
+	assert.True(t, got.Suspended)
+}
+
+func TestMembership_WithDispute(t *testing.T) {
+	m := Membership{
+		ExpireDate: chrono.DateFrom(time.Now().AddDate(0, 1, 0)),
+	}
+
+	created := chrono.TimeNow()
+
+	flagged := m.WithDispute(DisputeFlag{
+		DisputeID:  "dp_test",
+		CreatedUTC: created,
+	})
+	assert.Equal(t, m.ExpireDate, flagged.ExpireDate, "not suspended")
+
+	suspended := m.WithDispute(DisputeFlag{
+		DisputeID:  "dp_test",
+		Suspended:  true,
+		CreatedUTC: created,
+	})
+	assert.Equal(t, chrono.DateFrom(created.Time), suspended.ExpireDate)
+}
@@ -16,6 +16,7 @@ var (
 	ErrAlreadyAppleSubs      = errors.New("already subscribed via apple")
 	ErrAlreadyB2BSubs        = errors.New("already subscribed via B2B")
 	ErrUnknownPaymentMethod  = errors.New("unknown payment for current subscription")
+	ErrPromoFirstTimeOnly    = errors.New("promotion code is only for first-time subscribers")
 )
 
 // Errors in CheckoutIntent for one-time purchase
@@ -64,6 +65,13 @@ func ConvertIntentError(err error) error {
 			Field:   "payment_method",
 			Code:    render.CodeInvalid,
 		}
+
+	case ErrPromoFirstTimeOnly:
+		return &render.ValidationError{
+			Message: err.Error(),
+			Field:   "promotionCode",
+			Code:    render.CodeInvalid,
+		}
 	}
 
 	return err
@@ -138,6 +138,13 @@ func (x SubsIntentKind) IsUpdating() bool {
 	return x == IntentUpgrade || x == IntentDowngrade || x == IntentSwitchInterval || x == IntentApplyCoupon
 }
 
+// IsScheduled tells whether a stripe subscription update
+// should take effect at current period end rather than
+// immediately with proration.
+func (x SubsIntentKind) IsScheduled() bool {
+	return x == IntentDowngrade || x == IntentSwitchInterval
+}
+
 func (x SubsIntentKind) IsSwitchToAutoRenew() bool {
 	return x == IntentOneTimeToAutoRenew
 }
@@ -62,6 +62,16 @@ type Membership struct {
 	Status       enum.SubsStatus `json:"status" db:"subs_status"`
 	AppleSubsID  null.String     `json:"appleSubsId" db:"apple_subs_id"`
 	B2BLicenceID null.String     `json:"b2bLicenceId" db:"b2b_licence_id"`
+	// Scheduled downgrade or interval switch of a Stripe subscription.
+	// Written separately from other columns since it is only
+	// modified by subscription schedules.
+	PendingChange PendingChange `json:"pendingChange" db:"pending_change"`
+	// Set while payment of the Stripe subscription is disputed.
+	// Written separately like PendingChange.
+	Dispute DisputeFlag `json:"dispute" db:"dispute"`
+	// Set while payment collection of the Stripe subscription
+	// is paused. Synced together with other columns.
+	Pause PauseWindow `json:"pause" db:"pause"`
 	addon.AddOn
 	VIP bool `json:"vip" db:"is_vip"`
 }
@@ -174,6 +184,10 @@ func (m Membership) IsModified(other Membership) bool {
 		return true
 	}
 
+	if !m.Pause.Equal(other.Pause) {
+		return true
+	}
+
 	return false
 }
 
@@ -249,6 +263,23 @@ func (m Membership) IsInvalidStripe() bool {
 	return m.IsStripe() && (m.Status == enum.SubsStatusIncompleteExpired || m.Status == enum.SubsStatusPastDue || m.Status == enum.SubsStatusCanceled || m.Status == enum.SubsStatusUnpaid)
 }
 
+// WithDispute flags a Stripe membership whose payment is
+// disputed. A suspended membership expires on the day the
+// dispute is created.
+func (m Membership) WithDispute(f DisputeFlag) Membership {
+	m.Dispute = f
+	if !f.Suspended {
+		return m
+	}
+
+	if f.CreatedUTC.Before(m.ExpireDate.Time) {
+		m.ExpireDate = chrono.DateFrom(f.CreatedUTC.Time)
+		m.LegacyExpire = null.IntFrom(f.CreatedUTC.Unix())
+	}
+
+	return m
+}
+
 func (m Membership) IsStripeSubsMatch(subsID string) bool {
 	if m.StripeSubsID.IsZero() {
 		return false
@@ -257,8 +288,16 @@ func (m Membership) IsStripeSubsMatch(subsID string) bool {
 	return m.StripeSubsID.String == subsID
 }
 
+// IsPaused tests whether payment collection of the Stripe
+// subscription is paused.
+func (m Membership) IsPaused() bool {
+	return m.IsStripe() && !m.Pause.IsZero()
+}
+
+// IsTrialing tests whether user is in the free trial of a
+// Stripe subscription, which is not paid yet.
 func (m Membership) IsTrialing() bool {
-	return m.Status == enum.SubsStatusTrialing
+	return m.IsStripe() && m.Status == enum.SubsStatusTrialing
 }
 
 // IsIAP tests whether this membership comes from Apple.
@@ -13,6 +13,7 @@ auto_renewal = :auto_renewal,
 sub_status = :subs_status,
 apple_subscription_id = :apple_subs_id,
 b2b_licence_id = :b2b_licence_id,
+pause = :pause,
 standard_addon = :standard_addon,
 premium_addon = :premium_addon
 `
@@ -27,6 +28,9 @@ IFNULL(auto_renewal, FALSE) AS auto_renewal,
 sub_status AS subs_status,
 apple_subscription_id AS apple_subs_id,
 b2b_licence_id,
+pending_change,
+dispute,
+pause,
 standard_addon,
 premium_addon
 `
@@ -118,3 +122,19 @@ SET vip_id = vip_id_alias,
 WHERE vip_id = :compound_id
 	AND vip_id_alias = :union_id
 LIMIT 1`
+
+// StmtSetPendingChange saves or clears the change scheduled
+// for a stripe subscription.
+const StmtSetPendingChange = `
+UPDATE premium.ftc_vip
+SET pending_change = ?
+WHERE stripe_subscription_id = ?
+LIMIT 1`
+
+// StmtSetDispute flags or clears the dispute of a stripe
+// subscription.
+const StmtSetDispute = `
+UPDATE premium.ftc_vip
+SET dispute = ?
+WHERE stripe_subscription_id = ?
+LIMIT 1`
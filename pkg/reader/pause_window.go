@@ -0,0 +1,74 @@
+package reader
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+
+	"github.com/FTChinese/go-rest/chrono"
+)
+
+// PauseWindow is set while payment collection of a Stripe
+// subscription is paused.
+// Renewals during the window are not charged, so access ends
+// at PaidThroughUTC and auto renewal is off until resumed.
+type PauseWindow struct {
+	Behavior       string      `json:"behavior"`       // keep_as_draft, mark_uncollectible or void.
+	PaidThroughUTC chrono.Time `json:"paidThroughUtc"` // End of the last period paid.
+	ResumesAtUTC   chrono.Time `json:"resumesAtUtc"`   // Zero if user must resume it manually.
+}
+
+func (w PauseWindow) IsZero() bool {
+	return w.Behavior == ""
+}
+
+func (w PauseWindow) Equal(other PauseWindow) bool {
+	return w.Behavior == other.Behavior &&
+		w.PaidThroughUTC.Equal(other.PaidThroughUTC.Time) &&
+		w.ResumesAtUTC.Equal(other.ResumesAtUTC.Time)
+}
+
+// MarshalJSON outputs null for zero value.
+func (w PauseWindow) MarshalJSON() ([]byte, error) {
+	if w.IsZero() {
+		return []byte("null"), nil
+	}
+
+	type alias PauseWindow
+	return json.Marshal(alias(w))
+}
+
+// Value saves the window as a JSON column, or NULL if it is zero.
+func (w PauseWindow) Value() (driver.Value, error) {
+	if w.IsZero() {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(w)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
+}
+
+func (w *PauseWindow) Scan(src interface{}) error {
+	if src == nil {
+		*w = PauseWindow{}
+		return nil
+	}
+
+	switch s := src.(type) {
+	case []byte:
+		var tmp PauseWindow
+		err := json.Unmarshal(s, &tmp)
+		if err != nil {
+			return err
+		}
+		*w = tmp
+		return nil
+
+	default:
+		return errors.New("incompatible type to scan to PauseWindow")
+	}
+}
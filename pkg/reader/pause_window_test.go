@@ -0,0 +1,34 @@
+package reader
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/FTChinese/go-rest/chrono"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPauseWindow_Value(t *testing.T) {
+	b, err := json.Marshal(PauseWindow{})
+	assert.NoError(t, err)
+	assert.Equal(t, "null", string(b))
+
+	v, err := PauseWindow{}.Value()
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+
+	w := PauseWindow{
+		Behavior:       "void",
+		PaidThroughUTC: chrono.TimeFrom(time.Now().Truncate(time.Second)),
+	}
+
+	v, err = w.Value()
+	assert.NoError(t, err)
+
+	var got PauseWindow
+	err = got.Scan([]byte(v.(string)))
+	assert.NoError(t, err)
+	assert.True(t, w.Equal(got))
+	assert.True(t, got.ResumesAtUTC.IsZero())
+}
@@ -0,0 +1,71 @@
+package reader
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+
+	"github.com/FTChinese/go-rest/chrono"
+	"github.com/FTChinese/subscription-api/pkg/price"
+)
+
+// PendingChange is a change of edition scheduled to take
+// effect at the end of current billing period, e.g., a
+// Stripe subscription downgraded from premium to standard.
+// User keeps current edition until EffectiveUTC.
+type PendingChange struct {
+	ScheduleID string `json:"scheduleId"`
+	price.Edition
+	PriceID      string      `json:"priceId"`
+	EffectiveUTC chrono.Time `json:"effectiveUtc"`
+}
+
+func (c PendingChange) IsZero() bool {
+	return c.ScheduleID == ""
+}
+
+// MarshalJSON outputs null for zero value so that client
+// could tell whether there is any pending change.
+func (c PendingChange) MarshalJSON() ([]byte, error) {
+	if c.IsZero() {
+		return []byte("null"), nil
+	}
+
+	type alias PendingChange
+	return json.Marshal(alias(c))
+}
+
+// Value saves the change as a JSON column, or NULL if it is zero.
+func (c PendingChange) Value() (driver.Value, error) {
+	if c.IsZero() {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
+}
+
+func (c *PendingChange) Scan(src interface{}) error {
+	if src == nil {
+		*c = PendingChange{}
+		return nil
+	}
+
+	switch s := src.(type) {
+	case []byte:
+		var tmp PendingChange
+		err := json.Unmarshal(s, &tmp)
+		if err != nil {
+			return err
+		}
+		*c = tmp
+		return nil
+
+	default:
+		return errors.New("incompatible type to scan to PendingChange")
+	}
+}
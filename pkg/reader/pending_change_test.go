@@ -0,0 +1,40 @@
+package reader
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/FTChinese/go-rest/chrono"
+	"github.com/FTChinese/go-rest/enum"
+	"github.com/FTChinese/subscription-api/pkg/price"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPendingChange_MarshalJSON(t *testing.T) {
+	b, err := json.Marshal(PendingChange{})
+	assert.NoError(t, err)
+	assert.Equal(t, "null", string(b))
+
+	c := PendingChange{
+		ScheduleID: "sub_sched_test",
+		Edition: price.Edition{
+			Tier:  enum.TierStandard,
+			Cycle: enum.CycleYear,
+		},
+		PriceID:      "price_test",
+		EffectiveUTC: chrono.TimeNow(),
+	}
+
+	b, err = json.Marshal(c)
+	assert.NoError(t, err)
+
+	var got PendingChange
+	err = json.Unmarshal(b, &got)
+	assert.NoError(t, err)
+	assert.Equal(t, c.ScheduleID, got.ScheduleID)
+	assert.Equal(t, c.Edition, got.Edition)
+
+	v, err := PendingChange{}.Value()
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+}
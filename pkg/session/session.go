@@ -0,0 +1,136 @@
+package session
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	gorest "github.com/FTChinese/go-rest"
+	"github.com/FTChinese/go-rest/chrono"
+	"github.com/FTChinese/go-rest/enum"
+	"github.com/FTChinese/subscription-api/pkg/footprint"
+	"github.com/FTChinese/subscription-api/pkg/ids"
+	"github.com/guregu/null"
+)
+
+// HashRefreshToken hashes a refresh token before saving or
+// looking up. Only the hash is persisted.
+func HashRefreshToken(t string) string {
+	h := sha256.Sum256([]byte(t))
+	return hex.EncodeToString(h[:])
+}
+
+// Session is a login session backing the short-lived access
+// tokens. It is persisted so that it could be revoked and
+// refreshed.
+type Session struct {
+	ID      string      `json:"id" db:"session_id"`
+	FtcID   null.String `json:"ftcId" db:"ftc_id"`
+	UnionID null.String `json:"unionId" db:"union_id"`
+	// Hash of the current refresh token. A new one is issued
+	// on every refresh.
+	RefreshHash string `json:"-" db:"refresh_hash"`
+	// Hash of the previous refresh token. Presenting it again
+	// indicates the token is stolen and the session is revoked.
+	PrevRefreshHash null.String          `json:"-" db:"prev_refresh_hash"`
+	AuthMethod      footprint.AuthMethod `json:"authMethod" db:"auth_method"`
+	Platform        enum.Platform        `json:"platform" db:"platform"`
+	UserIP          null.String          `json:"-" db:"user_ip"`
+	ExpiresUTC      chrono.Time          `json:"expiresUtc" db:"expires_utc"`
+	RevokedUTC      chrono.Time          `json:"revokedUtc" db:"revoked_utc"`
+	CreatedUTC      chrono.Time          `json:"createdUtc" db:"created_utc"`
+	RefreshedUTC    chrono.Time          `json:"refreshedUtc" db:"refreshed_utc"`
+}
+
+func (s Session) IsActive() bool {
+	return s.RevokedUTC.IsZero() && s.ExpiresUTC.After(time.Now())
+}
+
+func (s Session) UserIDs() ids.UserIDs {
+	return ids.UserIDs{
+		FtcID:   s.FtcID,
+		UnionID: s.UnionID,
+	}.MustNormalize()
+}
+
+// Credentials are sent to client after login or refresh.
+type Credentials struct {
+	AccessToken      string      `json:"accessToken"`
+	ExpiresIn        int64       `json:"expiresIn"`
+	RefreshToken     string      `json:"refreshToken"`
+	RefreshExpiresIn int64       `json:"refreshExpiresIn"`
+	CreatedUTC       chrono.Time `json:"createdUtc"`
+}
+
+// Issuer creates sessions and signs access tokens.
+type Issuer struct {
+	signer     Signer
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+func NewIssuer(signer Signer, accessTTL, refreshTTL time.Duration) Issuer {
+	return Issuer{
+		signer:     signer,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+	}
+}
+
+// Start creates a new session for a logged-in user.
+func (i Issuer) Start(userIDs ids.UserIDs, method footprint.AuthMethod, client footprint.Client) (Session, Credentials, error) {
+	now := time.Now()
+
+	s := Session{
+		ID:         ids.SessionID(),
+		FtcID:      userIDs.FtcID,
+		UnionID:    userIDs.UnionID,
+		AuthMethod: method,
+		Platform:   client.Platform,
+		UserIP:     client.UserIP,
+		ExpiresUTC: chrono.TimeFrom(now.Add(i.refreshTTL)),
+		CreatedUTC: chrono.TimeFrom(now),
+	}
+
+	return i.Refresh(s)
+}
+
+// Refresh rotates refresh token and signs a new access token.
+func (i Issuer) Refresh(s Session) (Session, Credentials, error) {
+	refresh, err := gorest.RandomHex(32)
+	if err != nil {
+		return Session{}, Credentials{}, err
+	}
+
+	now := time.Now()
+
+	token, err := i.signer.Sign(Claims{
+		SessionID: s.ID,
+		FtcID:     s.FtcID,
+		UnionID:   s.UnionID,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(i.accessTTL).Unix(),
+	})
+	if err != nil {
+		return Session{}, Credentials{}, err
+	}
+
+	if s.RefreshHash != "" {
+		s.PrevRefreshHash = null.StringFrom(s.RefreshHash)
+	}
+	s.RefreshHash = HashRefreshToken(refresh)
+	s.RefreshedUTC = chrono.TimeFrom(now)
+
+	return s, Credentials{
+		AccessToken:      token,
+		ExpiresIn:        int64(i.accessTTL.Seconds()),
+		RefreshToken:     refresh,
+		RefreshExpiresIn: int64(time.Until(s.ExpiresUTC.Time).Seconds()),
+		CreatedUTC:       chrono.TimeFrom(now),
+	}, nil
+}
+
+// Verify checks an access token.
+func (i Issuer) Verify(token string) (Claims, error) {
+	return i.signer.Verify(token)
+}
@@ -0,0 +1,79 @@
+package session
+
+const StmtInsertSession = `
+INSERT INTO user_db.login_session
+SET session_id = :session_id,
+	ftc_id = :ftc_id,
+	union_id = :union_id,
+	refresh_hash = :refresh_hash,
+	prev_refresh_hash = :prev_refresh_hash,
+	auth_method = :auth_method,
+	platform = :platform,
+	user_ip = INET6_ATON(:user_ip),
+	expires_utc = :expires_utc,
+	created_utc = :created_utc,
+	refreshed_utc = :refreshed_utc`
+
+const colsSession = `
+SELECT session_id,
+	ftc_id,
+	union_id,
+	refresh_hash,
+	prev_refresh_hash,
+	auth_method,
+	platform,
+	INET6_NTOA(user_ip) AS user_ip,
+	expires_utc,
+	revoked_utc,
+	created_utc,
+	refreshed_utc
+FROM user_db.login_session
+`
+
+const StmtRetrieveSession = colsSession + `
+WHERE session_id = ?
+LIMIT 1`
+
+// StmtSessionByRefresh finds a session by either current
+// or previous refresh token so that reuse could be detected.
+const StmtSessionByRefresh = colsSession + `
+WHERE refresh_hash = ?
+	OR prev_refresh_hash = ?
+LIMIT 1`
+
+// StmtRefreshSession rotates refresh token only if it is not
+// rotated by another request in the meantime.
+const StmtRefreshSession = `
+UPDATE user_db.login_session
+SET refresh_hash = :refresh_hash,
+	prev_refresh_hash = :prev_refresh_hash,
+	refreshed_utc = :refreshed_utc
+WHERE session_id = :session_id
+	AND refresh_hash = :prev_refresh_hash
+	AND revoked_utc IS NULL
+LIMIT 1`
+
+const StmtRevokeSession = `
+UPDATE user_db.login_session
+SET revoked_utc = UTC_TIMESTAMP()
+WHERE session_id = ?
+	AND revoked_utc IS NULL
+LIMIT 1`
+
+// StmtRevokeUserSessions revokes all sessions of a user,
+// e.g., after password changed, or of both sides of linked
+// accounts, matched by either ftc id or union id.
+const StmtRevokeUserSessions = `
+UPDATE user_db.login_session
+SET revoked_utc = UTC_TIMESTAMP()
+WHERE (ftc_id = ? OR union_id = ?)
+	AND revoked_utc IS NULL`
+
+// StmtRevokedUserSessionIDs finds revoked sessions of a user
+// not expired yet, which might still be cached.
+const StmtRevokedUserSessionIDs = `
+SELECT session_id
+FROM user_db.login_session
+WHERE (ftc_id = ? OR union_id = ?)
+	AND revoked_utc IS NOT NULL
+	AND expires_utc > UTC_TIMESTAMP()`
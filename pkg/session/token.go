@@ -0,0 +1,140 @@
+package session
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/FTChinese/subscription-api/pkg/config"
+	"github.com/FTChinese/subscription-api/pkg/ids"
+	"github.com/guregu/null"
+)
+
+var (
+	ErrMalformed = errors.New("malformed session token")
+	ErrInvalid   = errors.New("invalid session token")
+	ErrExpired   = errors.New("session token expired")
+)
+
+// Claims is the payload of a session token.
+type Claims struct {
+	SessionID string      `json:"sid"`
+	FtcID     null.String `json:"ftcId"`
+	UnionID   null.String `json:"unionId"`
+	IssuedAt  int64       `json:"iat"`
+	ExpiresAt int64       `json:"exp"`
+}
+
+func (c Claims) UserIDs() (ids.UserIDs, error) {
+	return ids.UserIDs{
+		FtcID:   c.FtcID,
+		UnionID: c.UnionID,
+	}.Normalize()
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+	Kid string `json:"kid"`
+}
+
+// Signer signs and verifies session tokens in the JWT
+// compact format with HS256.
+// Tokens are always signed with the active key while
+// any configured key is accepted for verification,
+// so that keys could be rotated by adding a new key,
+// making it active, and removing the old one after
+// the longest token lifetime.
+type Signer struct {
+	activeKey string
+	keys      map[string][]byte
+}
+
+func NewSigner(c config.SessionConfig) Signer {
+	keys := make(map[string][]byte, len(c.Keys))
+	for _, k := range c.Keys {
+		keys[k.ID] = []byte(k.Secret)
+	}
+
+	return Signer{
+		activeKey: c.ActiveKey,
+		keys:      keys,
+	}
+}
+
+func (s Signer) Sign(c Claims) (string, error) {
+	h, err := json.Marshal(header{
+		Alg: "HS256",
+		Typ: "JWT",
+		Kid: s.activeKey,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	p, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	signing := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(p)
+
+	return signing + "." + base64.RawURLEncoding.EncodeToString(sign(s.keys[s.activeKey], signing)), nil
+}
+
+// Verify checks signature and expiration.
+func (s Signer) Verify(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Claims{}, ErrMalformed
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return Claims{}, ErrMalformed
+	}
+
+	key, ok := s.keys[h.Kid]
+	if !ok || h.Alg != "HS256" {
+		return Claims{}, ErrInvalid
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, ErrMalformed
+	}
+
+	if !hmac.Equal(sig, sign(key, parts[0]+"."+parts[1])) {
+		return Claims{}, ErrInvalid
+	}
+
+	var c Claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return Claims{}, ErrMalformed
+	}
+
+	if time.Unix(c.ExpiresAt, 0).Before(time.Now()) {
+		return Claims{}, ErrExpired
+	}
+
+	return c, nil
+}
+
+func sign(key []byte, s string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(s))
+	return mac.Sum(nil)
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,81 @@
+package session
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FTChinese/subscription-api/pkg/config"
+	"github.com/FTChinese/subscription-api/pkg/footprint"
+	"github.com/FTChinese/subscription-api/pkg/ids"
+	"github.com/google/uuid"
+	"github.com/guregu/null"
+)
+
+var testKeys = []config.SigningKey{
+	{ID: "k1", Secret: strings.Repeat("a", 32)},
+	{ID: "k2", Secret: strings.Repeat("b", 32)},
+}
+
+func TestSigner_Rotation(t *testing.T) {
+	old := NewSigner(config.SessionConfig{ActiveKey: "k1", Keys: testKeys})
+	rotated := NewSigner(config.SessionConfig{ActiveKey: "k2", Keys: testKeys})
+	retired := NewSigner(config.SessionConfig{ActiveKey: "k2", Keys: testKeys[1:]})
+
+	token, err := old.Sign(Claims{
+		SessionID: "ses_test",
+		FtcID:     null.StringFrom(uuid.New().String()),
+		ExpiresAt: time.Now().Add(time.Minute).Unix(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := rotated.Verify(token); err != nil {
+		t.Errorf("token signed by previous key should be accepted: %v", err)
+	}
+
+	if _, err := retired.Verify(token); err != ErrInvalid {
+		t.Errorf("token signed by removed key should be rejected, got %v", err)
+	}
+
+	tampered := token[:len(token)-2] + "xx"
+	if _, err := rotated.Verify(tampered); err == nil {
+		t.Error("tampered token should be rejected")
+	}
+}
+
+func TestIssuer_Refresh(t *testing.T) {
+	issuer := NewIssuer(
+		NewSigner(config.SessionConfig{ActiveKey: "k1", Keys: testKeys}),
+		time.Minute,
+		24*time.Hour)
+
+	userIDs := ids.UserIDs{
+		FtcID: null.StringFrom(uuid.New().String()),
+	}.MustNormalize()
+
+	s, cred, err := issuer.Start(userIDs, footprint.AuthMethodEmail, footprint.Client{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := issuer.Verify(cred.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.SessionID != s.ID || c.FtcID != userIDs.FtcID {
+		t.Errorf("unexpected claims %+v", c)
+	}
+	if s.RefreshHash != HashRefreshToken(cred.RefreshToken) {
+		t.Error("refresh token hash mismatched")
+	}
+
+	s2, cred2, err := issuer.Refresh(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cred2.RefreshToken == cred.RefreshToken || s2.PrevRefreshHash.String != s.RefreshHash {
+		t.Error("refresh token should be rotated")
+	}
+}
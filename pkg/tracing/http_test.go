@@ -0,0 +1,69 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/FTChinese/subscription-api/pkg/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSetup_none(t *testing.T) {
+	shutdown, err := Setup(config.TracingConfig{Exporter: config.TracingExporterNone}, "test", "0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := Setup(config.TracingConfig{Exporter: "jaeger"}, "test", "0"); err == nil {
+		t.Error("expected error for unknown exporter")
+	}
+}
+
+func TestMiddleware_propagation(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var outgoing http.Header
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		outgoing = req.Header.Clone()
+	}))
+	defer upstream.Close()
+
+	client := &http.Client{Transport: Transport(nil)}
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r, _ := http.NewRequestWithContext(req.Context(), http.MethodGet, upstream.URL, nil)
+		resp, err := client.Do(r)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		_ = resp.Body.Close()
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exp.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans", len(spans))
+	}
+	for _, s := range spans {
+		if s.SpanContext.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("span %s not in caller's trace", s.Name)
+		}
+	}
+	if outgoing.Get("traceparent") == "" {
+		t.Error("trace context not injected into outbound request")
+	}
+}
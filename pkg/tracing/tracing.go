@@ -0,0 +1,75 @@
+// Package tracing sets up OpenTelemetry and creates spans
+// around HTTP handlers, SQL calls and outbound requests.
+// Spans are no-op unless an exporter is configured.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/FTChinese/subscription-api/pkg/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/FTChinese/subscription-api"
+
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Setup installs the global tracer provider. The returned
+// function flushes pending spans and should be called on exit.
+func Setup(c config.TracingConfig, service, version string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	switch c.Exporter {
+	case config.TracingExporterNone:
+		return func(context.Context) error { return nil }, nil
+
+	case config.TracingExporterOTLP:
+		opts := []otlptracegrpc.Option{
+			otlptracegrpc.WithEndpoint(c.Endpoint),
+		}
+		if c.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+
+		exp, err := otlptracegrpc.New(context.Background(), opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		tp := sdktrace.NewTracerProvider(
+			sdktrace.WithBatcher(exp),
+			sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(c.SampleRatio))),
+			sdktrace.WithResource(resource.NewSchemaless(
+				attribute.String("service.name", service),
+				attribute.String("service.version", version),
+			)),
+		)
+		otel.SetTracerProvider(tp)
+
+		return tp.Shutdown, nil
+	}
+
+	return nil, fmt.Errorf("unknown tracing exporter %s", c.Exporter)
+}
+
+// TraceID of the span in ctx, or empty if not sampled.
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+
+	return sc.TraceID().String()
+}
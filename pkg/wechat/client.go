@@ -2,6 +2,7 @@ package wechat
 
 import (
 	"errors"
+	"github.com/FTChinese/subscription-api/pkg/metrics"
 	"github.com/guregu/null"
 	"github.com/objcoding/wxpay"
 	"go.uber.org/zap"
@@ -46,7 +47,11 @@ func (c WxPayClient) VerifySignature(payload wxpay.Params) error {
 // CreateOrder at
 // https://pay.weixin.qq.com/wiki/doc/api/jsapi.php?chapter=9_1
 func (c WxPayClient) CreateOrder(o UnifiedOrderReq) (wxpay.Params, error) {
-	return c.sdk.UnifiedOrder(o.Marshal())
+	t := metrics.StartOutbound("wechat", "unified_order")
+	p, err := c.sdk.UnifiedOrder(o.Marshal())
+	t.Done(err)
+
+	return p, err
 }
 
 // QueryOrder at
@@ -72,7 +77,9 @@ func (c WxPayClient) QueryOrder(params OrderQueryParams) (wxpay.Params, error) {
 	// total_fee:25800
 	// trade_state:NOTPAY
 	// trade_state_desc:订单未支付
+	t := metrics.StartOutbound("wechat", "order_query")
 	payload, err := c.sdk.OrderQuery(params.Marshal())
+	t.Done(err)
 
 	sugar.Infof("wxpay query order payload: %v", payload)
 
@@ -7,6 +7,11 @@ const (
 	XUnionID   = "X-Union-Id"   // Wechat union id
 	XAppID     = "X-App-Id"     // Wechat app id
 	XStaffName = "X-Staff-Name" // Used only by the root path /cms section.
+	// XSessionToken carries the signed access token issued after login.
+	XSessionToken = "X-Session-Token"
+	// XRequestID is accepted from clients or generated, and
+	// echoed in response.
+	XRequestID = "X-Request-Id"
 )
 
 func GetFtcID(h http.Header) string {
@@ -24,3 +29,7 @@ func GetAppID(h http.Header) string {
 func GetStaffName(h http.Header) string {
 	return h.Get(XStaffName)
 }
+
+func GetSessionToken(h http.Header) string {
+	return h.Get(XSessionToken)
+}
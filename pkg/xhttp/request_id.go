@@ -0,0 +1,165 @@
+package xhttp
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type ctxKey int
+
+const (
+	keyRequestID ctxKey = iota
+	keyLogger
+)
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// RequestIDFrom returns the id set by RequestID middleware.
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(keyRequestID).(string)
+	return id
+}
+
+// RequestID uses the X-Request-Id header sent by client or
+// the upstream proxy, or generates one if missing or invalid.
+// The id is sent back in response header and added to JSON
+// bodies of error responses as the `requestId` field so that
+// a failed request reported by user could be found in logs.
+func RequestID(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		id := strings.TrimSpace(req.Header.Get(XRequestID))
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+
+		req.Header.Set(XRequestID, id)
+		w.Header().Set(XRequestID, id)
+
+		ctx := context.WithValue(req.Context(), keyRequestID, id)
+		// Let chi's logger print it.
+		ctx = context.WithValue(ctx, middleware.RequestIDKey, id)
+
+		ew := &errorBodyWriter{
+			ResponseWriter: w,
+			requestID:      id,
+		}
+		next.ServeHTTP(ew, req.WithContext(ctx))
+		ew.flush()
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// errorBodyWriter holds back JSON error responses to add
+// the request id. Other responses are written through.
+type errorBodyWriter struct {
+	http.ResponseWriter
+	requestID   string
+	wroteHeader bool
+	buffering   bool
+	status      int
+	body        bytes.Buffer
+}
+
+func (w *errorBodyWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	if code >= http.StatusBadRequest &&
+		strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		w.buffering = true
+		w.status = code
+		return
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *errorBodyWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.buffering {
+		return w.body.Write(b)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *errorBodyWriter) flush() {
+	if !w.buffering {
+		return
+	}
+
+	body := w.body.Bytes()
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err == nil {
+		if _, ok := fields["requestId"]; !ok {
+			fields["requestId"], _ = json.Marshal(w.requestID)
+			if b, err := json.Marshal(fields); err == nil {
+				body = b
+			}
+		}
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.ResponseWriter.WriteHeader(w.status)
+	_, _ = w.ResponseWriter.Write(body)
+}
+
+// RequestLogger attaches a logger carrying request id and
+// trace id to request context. It should be used after
+// RequestID and tracing middleware.
+func RequestLogger(base *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, req *http.Request) {
+			ctx := req.Context()
+			id := RequestIDFrom(ctx)
+
+			fields := []zap.Field{
+				zap.String("requestId", id),
+			}
+			span := trace.SpanFromContext(ctx)
+			if sc := span.SpanContext(); sc.HasTraceID() {
+				fields = append(fields, zap.String("traceId", sc.TraceID().String()))
+			}
+			span.SetAttributes(attribute.String("http.request_id", id))
+
+			ctx = context.WithValue(ctx, keyLogger, base.With(fields...))
+			next.ServeHTTP(w, req.WithContext(ctx))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// LoggerFrom returns the request-scoped logger, or fallback
+// outside of a request.
+func LoggerFrom(ctx context.Context, fallback *zap.Logger) *zap.Logger {
+	if l, ok := ctx.Value(keyLogger).(*zap.Logger); ok {
+		return l
+	}
+
+	return fallback
+}
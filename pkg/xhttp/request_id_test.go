@@ -0,0 +1,105 @@
+package xhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/FTChinese/go-rest/render"
+	"go.uber.org/zap"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{"honour client id", "abc-123.x_y", true},
+		{"generate if missing", "", false},
+		{"replace invalid", "bad id\n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			h := RequestID(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				seen = RequestIDFrom(req.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(XRequestID, tt.incoming)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			got := rec.Header().Get(XRequestID)
+			if got != seen {
+				t.Errorf("header %s, context %s", got, seen)
+			}
+			if (got == tt.incoming) != tt.keep {
+				t.Errorf("got %s from %q", got, tt.incoming)
+			}
+		})
+	}
+}
+
+func TestRequestID_errorBody(t *testing.T) {
+	h := RequestID(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_ = render.New(w).NotFound("")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(XRequestID, "req-1")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status %d", rec.Code)
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body["requestId"] != "req-1" {
+		t.Errorf("body %s", rec.Body.String())
+	}
+	if body["message"] == nil {
+		t.Errorf("original fields lost: %s", rec.Body.String())
+	}
+}
+
+func TestRequestID_successBody(t *testing.T) {
+	h := RequestID(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_ = render.New(w).OK(map[string]string{"id": "1"})
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := body["requestId"]; ok {
+		t.Errorf("body modified: %s", rec.Body.String())
+	}
+}
+
+func TestLoggerFrom(t *testing.T) {
+	fallback := zap.NewNop()
+	if LoggerFrom(httptest.NewRequest(http.MethodGet, "/", nil).Context(), fallback) != fallback {
+		t.Error("expected fallback outside of request")
+	}
+
+	var got *zap.Logger
+	h := RequestID(RequestLogger(fallback)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		got = LoggerFrom(req.Context(), fallback)
+	})))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got == nil || got == fallback {
+		t.Error("expected request-scoped logger")
+	}
+}